@@ -0,0 +1,84 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/batch"
+	"github.com/leogps/lunar/pkg/format"
+	"github.com/leogps/lunar/pkg/utils"
+	"github.com/spf13/cobra"
+	"log/slog"
+	"os"
+)
+
+func init() {
+	batchCmd.Flags().String("input", "", "YAML, JSON or CSV file of ESPP and RSU orders, keyed like the flags of the espp and rsu commands")
+	addOutputFlag(batchCmd)
+	rootCmd.AddCommand(batchCmd)
+}
+
+var batchCmd = &cobra.Command{
+	Use:   "batch",
+	Short: "calculate profit/loss on the ESPP and RSU orders of a file",
+	Long: `calculate profit/loss on the ESPP and RSU orders of a YAML, JSON or CSV file and their totals;
+an invalid order is reported and the rest of the batch is still calculated`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		silent, _ := cmd.Flags().GetBool("silent")
+		var level slog.Level
+		if silent {
+			level = slog.LevelInfo
+		} else {
+			level = slog.LevelDebug
+		}
+		utils.InitLogger(level)
+
+		outputFormat := getOutputFormat(cmd)
+		inputPath, _ := cmd.Flags().GetString("input")
+		handleBatch(inputPath, outputFormat)
+	},
+}
+
+func handleBatch(inputPath string, outputFormat format.Format) {
+	if inputPath == "" {
+		exitInvalidInput(fmt.Errorf("missing value for --input"))
+	}
+	records, err := batch.ReadFile(inputPath)
+	if err != nil {
+		exitInvalidInput(err)
+	}
+
+	defaults := batch.Record{}
+	for key, value := range profileDefaults {
+		defaults[key] = batch.Value(value)
+	}
+	results, totals := batch.Calculate(records, defaults)
+	if err = format.WriteBatch(os.Stdout, outputFormat, results, totals); err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	if totals.FailedOrders > 0 {
+		os.Exit(exitCodeInvalidInput)
+	}
+}
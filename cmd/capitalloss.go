@@ -0,0 +1,49 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package cmd
+
+import (
+	"github.com/leogps/lunar/pkg/types"
+	"github.com/leogps/lunar/pkg/utils"
+)
+
+// promptCapitalLoss asks whether to value a capital loss by the tax it saves and, if so, for the other capital gains
+// realized in the tax year and the loss carried forward from prior years.
+func promptCapitalLoss() (bool, types.Money, types.Money) {
+	considerCapitalLoss := promptFeatureOrFlag("Calculate the tax saved by the capital loss (offsets gains, then up to $3,000 of ordinary income)[Y/N]? ",
+		"capital-loss", "other-capital-gains", "carryforward")
+	if !considerCapitalLoss {
+		return false, 0, 0
+	}
+	otherRealizedCapitalGains := promptOrFlag[types.Money]("other-capital-gains", "What are your other capital gains realized this tax year ($)? ")
+	capitalLossCarryforward := promptOrFlag[types.Money]("carryforward", "What is the capital loss carried forward from prior years ($)? ")
+	return true, otherRealizedCapitalGains, capitalLossCarryforward
+}
+
+// logCapitalLoss logs the tax saved by a capital loss, reported as a negative capital gain tax amount.
+func logCapitalLoss(capitalLoss types.Money, capitalGainTaxAmount types.Money, capitalLossCarryforward types.Money) {
+	utils.LogInfo("Capital loss: $%s", capitalLoss)
+	utils.LogInfo("Capital gain tax amount: $%s (tax saved: $%s)", capitalGainTaxAmount, -capitalGainTaxAmount)
+	utils.LogInfo("Capital loss carryforward: $%s", capitalLossCarryforward)
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/tax"
+	"github.com/leogps/lunar/pkg/utils"
+	"github.com/spf13/cobra"
+	"log/slog"
+	"os"
+)
+
+func init() {
+	rootCmd.AddCommand(carryforwardCmd)
+}
+
+var carryforwardCmd = &cobra.Command{
+	Use:   "carryforward",
+	Short: "track a capital loss carryforward across tax years interactively",
+	Long:  `track a capital loss carryforward across tax years interactively`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		silent, _ := cmd.Flags().GetBool("silent")
+		var level slog.Level
+		if silent {
+			level = slog.LevelInfo
+		} else {
+			level = slog.LevelDebug
+		}
+		utils.InitLogger(level)
+
+		handleCarryforward()
+	},
+}
+
+func handleCarryforward() {
+	filingStatus := promptFilingStatus()
+
+	capitalLossCarryforward, err := PromptAndValidate[float64]("What is the capital loss carried into the first tax year ($)? ")
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+
+	numberOfYears, err := PromptAndValidate[int]("How many tax years to track? ")
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	var capitalLossYears []tax.CapitalLossYear
+	for index := 1; index <= numberOfYears; index++ {
+		taxYear, err := PromptAndValidate[int](fmt.Sprintf("Year %d: what is the tax year? ", index))
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		netCapitalGainOrLoss, err := PromptAndValidate[float64](fmt.Sprintf("Year %d: what is the net capital gain (or negative loss) realized ($)? ", index))
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		capitalLossYears = append(capitalLossYears, tax.CapitalLossYear{
+			TaxYear:              taxYear,
+			NetCapitalGainOrLoss: netCapitalGainOrLoss,
+		})
+	}
+
+	utils.LogInfo("%-8s %14s %14s %14s %14s %14s", "Year", "Gain/Loss", "Carried In", "Offset Gains", "Offset Income", "Carried Out")
+	for _, year := range tax.ProjectCapitalLossCarryforward(filingStatus, capitalLossCarryforward, capitalLossYears) {
+		utils.LogInfo("%-8d %14s %14s %14s %14s %14s", year.TaxYear,
+			fmt.Sprintf("$%.2f", year.NetCapitalGainOrLoss),
+			fmt.Sprintf("$%.2f", year.CarryforwardIn),
+			fmt.Sprintf("$%.2f", year.OffsetGains),
+			fmt.Sprintf("$%.2f", year.OffsetOrdinaryIncome),
+			fmt.Sprintf("$%.2f", year.Carryforward))
+	}
+}
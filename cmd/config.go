@@ -0,0 +1,151 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+package cmd
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/config"
+	"github.com/leogps/lunar/pkg/utils"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+)
+
+func init() {
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configUseCmd)
+	configCmd.AddCommand(configKeysCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "view and edit the config profiles",
+	Long: `view and edit the config profiles of default values for the plan discount, look-back, commission,
+tax rates and filing status; flags and LUNAR_* environment variables override them`,
+	// The profiles are edited rather than applied, so an unknown profile or an invalid LUNAR_* variable does not
+	// get in the way
+	PersistentPreRun: func(_ *cobra.Command, _ []string) {},
+	Run: func(_ *cobra.Command, _ []string) {
+		configPath, lunarConfig := loadConfig()
+		fmt.Printf("Config file: %s\n", configPath)
+		fmt.Printf("Profile: %s\n", lunarConfig.ProfileName(""))
+		for _, name := range lunarConfig.ProfileNames() {
+			fmt.Printf("\n[%s]\n", name)
+			profile := lunarConfig.Profiles[name]
+			for _, key := range config.ProfileKeys() {
+				if value, ok := profile[key]; ok {
+					fmt.Printf("  %s: %s\n", key, value)
+				}
+			}
+		}
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "set a default value of the profile",
+	Long:  `set a default value of the profile, see the keys command for the keys`,
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		configPath, lunarConfig := loadConfig()
+		profileName := configProfileName(cmd, lunarConfig)
+		if err := lunarConfig.Set(profileName, args[0], args[1]); err != nil {
+			exitInvalidInput(err)
+		}
+		saveConfig(configPath, lunarConfig)
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "remove a default value of the profile",
+	Long:  `remove a default value of the profile`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		configPath, lunarConfig := loadConfig()
+		profileName := configProfileName(cmd, lunarConfig)
+		if err := lunarConfig.Unset(profileName, args[0]); err != nil {
+			exitInvalidInput(err)
+		}
+		saveConfig(configPath, lunarConfig)
+	},
+}
+
+var configUseCmd = &cobra.Command{
+	Use:   "use <profile>",
+	Short: "use the profile by default",
+	Long:  `use the profile by default when neither --profile nor LUNAR_PROFILE picks one`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		configPath, lunarConfig := loadConfig()
+		if _, err := lunarConfig.Profile(args[0]); err != nil {
+			exitInvalidInput(err)
+		}
+		lunarConfig.DefaultProfile = args[0]
+		saveConfig(configPath, lunarConfig)
+	},
+}
+
+var configKeysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "list the keys a profile can set",
+	Long:  `list the keys a profile can set along with the environment variables overriding them`,
+	Run: func(_ *cobra.Command, _ []string) {
+		keyWidth := 0
+		for _, key := range config.ProfileKeys() {
+			keyWidth = max(keyWidth, len(key))
+		}
+		for _, key := range config.ProfileKeys() {
+			fmt.Printf("%-*s  %s (%s)\n", keyWidth, key, config.DescribeProfileKey(key), config.EnvName(key))
+		}
+	},
+}
+
+// loadConfig loads the config file. Exits with exitCodeInvalidInput when it is invalid.
+func loadConfig() (string, *config.Config) {
+	configPath, err := config.DefaultPath()
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	lunarConfig, err := config.Load(configPath)
+	if err != nil {
+		exitInvalidInput(err)
+	}
+	return configPath, lunarConfig
+}
+
+// configProfileName returns the profile being edited: the --profile flag, LUNAR_PROFILE or the default profile.
+func configProfileName(cmd *cobra.Command, lunarConfig *config.Config) string {
+	profileName, _ := cmd.Flags().GetString("profile")
+	return lunarConfig.ProfileName(strings.TrimSpace(profileName))
+}
+
+func saveConfig(configPath string, lunarConfig *config.Config) {
+	if err := lunarConfig.Save(configPath); err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+}
@@ -24,21 +24,32 @@
 package cmd
 
 import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/format"
+	"github.com/leogps/lunar/pkg/tax"
 	"github.com/leogps/lunar/pkg/types"
 	"github.com/leogps/lunar/pkg/utils"
 	"github.com/spf13/cobra"
 	"log/slog"
 	"os"
+	"strings"
+	"time"
 )
 
 func init() {
+	esppCmd.Flags().String("state", "", fmt.Sprintf("state to compute state tax for (%s)", strings.Join(tax.StateCodes(), ", ")))
+	esppCmd.Flags().AddFlagSet(esppInputFlags)
+	addOutputFlag(esppCmd)
 	rootCmd.AddCommand(esppCmd)
 }
 
+// esppInputFlags answer the prompts of the espp command; only the values missing from them are prompted for.
+var esppInputFlags = newEsppInputFlags()
+
 var esppCmd = &cobra.Command{
 	Use:   "espp",
-	Short: "calculate profit/loss on ESPP orders interactively",
-	Long:  `calculate profit/loss on ESPP orders interactively`,
+	Short: "calculate profit/loss on ESPP orders interactively or from flags",
+	Long:  `calculate profit/loss on ESPP orders interactively or from flags; only the values missing from the flags are prompted for`,
 	Run: func(cmd *cobra.Command, _ []string) {
 		silent, _ := cmd.Flags().GetBool("silent")
 		var level slog.Level
@@ -49,104 +60,239 @@ var esppCmd = &cobra.Command{
 		}
 		utils.InitLogger(level)
 
-		handleEspp()
+		inputFlags = esppInputFlags
+		applyLedgerLot(types.Espp)
+		outputFormat := getOutputFormat(cmd)
+		stateCode, _ := cmd.Flags().GetString("state")
+		esppOrder := handleEspp(stateCode)
+		writeEsppOrderReport(esppOrder, outputFormat)
 	},
 }
 
-func handleEspp() {
-	discountPercent, err := PromptAndValidate[float64]("What is the discounted (buying) price percent per share (%)? ")
+func handleEspp(stateCode string) *types.EsppOrder {
+	esppOrder := promptEsppCostPerShare()
+
+	sellingPrice := promptPositiveOrFlag[types.Money]("selling-price", "What is the selling price per share ($)? ")
+	esppOrder.SellingPricePerShare = sellingPrice
+
+	numberOfShares := promptPositiveOrFlag[types.Shares]("shares", "How many shares sold? ")
+	esppOrder.NumberOfSharesSold = numberOfShares
+
+	esppOrder.ConsiderTransactionCommission, esppOrder.CommissionPaidPerTransaction, esppOrder.NumberOfTransactions =
+		promptTransactionCommission("Deduct transaction commission[Y/N]? ")
+
+	considerDisposition := promptFeatureOrFlag("Classify as qualifying/disqualifying disposition[Y/N]? ", "disposition", "offering-date")
+	if considerDisposition {
+		promptEsppDisposition(esppOrder)
+		esppOrder.StateTaxModel = promptStateTaxModel(stateCode)
+	}
+
+	profitOrLoss := esppOrder.CalculateProfitOrLoss()
+	if profitOrLoss < 0 {
+		utils.LogInfo("Loss: $%s", profitOrLoss)
+		logWashSaleWarning()
+		handleEsppCapitalLoss(esppOrder, stateCode)
+		return esppOrder
+	} else if profitOrLoss == 0 {
+		utils.LogInfo("Broke even: $%s", profitOrLoss)
+		return esppOrder
+	}
+	if profitOrLoss > 0 {
+		utils.LogInfo("Profit: $%s", profitOrLoss)
+
+		deductCapitalGains := promptFeatureOrFlag("Do you want to calculate capital gain tax and deduct from the profit[Y/N]? ", capitalGainTaxFlags...)
+		if deductCapitalGains {
+			promptEsppCapitalGainTax(esppOrder, stateCode)
+			esppOrder.ConsiderNetInvestmentIncomeTax, esppOrder.ModifiedAdjustedGrossIncome = promptNetInvestmentIncomeTax()
+
+			// A disqualifying disposition can turn a profit into a capital loss once the ordinary income is taken out.
+			ordinaryIncomeAmount := esppOrder.CalculateOrdinaryIncomeAmount()
+			if considerDisposition && profitOrLoss-ordinaryIncomeAmount < 0 {
+				esppOrder.ConsiderCapitalLoss, esppOrder.OtherRealizedCapitalGains, esppOrder.CapitalLossCarryforward = promptCapitalLoss()
+			}
+		}
+
+		esppOrder.TaxProfile = promptTaxProfileIfNeeded(esppOrder.TaxModel, esppOrder.StateTaxModel)
+		if (esppOrder.ConsiderNetInvestmentIncomeTax || esppOrder.ConsiderCapitalLoss) && esppOrder.TaxModel == nil && esppOrder.StateTaxModel == nil {
+			esppOrder.TaxProfile.FilingStatus = promptFilingStatus()
+		}
+
+		esppOrderSummary, err := esppOrder.CalculateEsppOrderSummary()
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		if esppOrder.ConsiderDisposition {
+			utils.LogInfo("Ordinary Income Amount: $%s", esppOrderSummary.OrdinaryIncomeAmount)
+			utils.LogInfo("Ordinary Income Tax Amount: $%s", esppOrderSummary.OrdinaryIncomeTaxAmount)
+			if esppOrder.StateTaxModel != nil {
+				utils.LogInfo("  Federal: $%s", esppOrderSummary.FederalOrdinaryIncomeTaxAmount)
+				utils.LogInfo("  State: $%s", esppOrderSummary.StateOrdinaryIncomeTaxAmount)
+			}
+			utils.LogInfo("Capital Gain Amount: $%s", esppOrderSummary.CapitalGainAmount)
+		}
+		if deductCapitalGains {
+			utils.LogInfo("Capital Gain Tax Amount: $%s", esppOrderSummary.CapitalGainTaxAmount)
+			if esppOrder.StateTaxModel != nil {
+				utils.LogInfo("  Federal: $%s", esppOrderSummary.FederalCapitalGainTaxAmount)
+				utils.LogInfo("  State: $%s", esppOrderSummary.StateCapitalGainTaxAmount)
+			}
+			if esppOrder.ConsiderCapitalLoss {
+				utils.LogInfo("Capital loss carryforward: $%s", esppOrderSummary.CapitalLossCarryforwardAmount)
+			}
+		}
+		if esppOrder.ConsiderNetInvestmentIncomeTax {
+			utils.LogInfo("Net Investment Income Tax: $%s", esppOrderSummary.NetInvestmentIncomeTaxAmount)
+		}
+		utils.LogInfo("True profit: $%s", esppOrderSummary.TrueProfitOrLoss())
+	}
+	return esppOrder
+}
+
+// writeEsppOrderReport writes the summary of the ESPP order in the output format, other than text.
+func writeEsppOrderReport(esppOrder *types.EsppOrder, outputFormat format.Format) {
+	if outputFormat == format.Text {
+		return
+	}
+	esppOrderSummary, err := esppOrder.CalculateEsppOrderSummary()
 	if err != nil {
 		utils.LogError("error occurred", err)
 		os.Exit(1)
 	}
-	esppOrder := types.EsppOrder{
+	writeReport(outputFormat, format.NewEsppOrderReport(esppOrderSummary))
+}
+
+// promptEsppCostPerShare prompts for the discount and the (look-back) cost and logs the effective cost per share.
+func promptEsppCostPerShare() *types.EsppOrder {
+	discountPercent := promptPercentOrFlag("discount", "What is the discounted (buying) price percent per share (%)? ")
+	esppOrder := &types.EsppOrder{
 		DiscountPercent: discountPercent,
 	}
 
-	costPricePerShare, err := PromptAndValidate[float64]("What is the cost price per share (with/without look-back) ($)? ")
-	if err != nil {
-		utils.LogError("error occurred", err)
-		os.Exit(1)
+	considerLookBack := promptFeatureOrFlag("Does the plan have a look-back provision[Y/N]? ", "look-back")
+	esppOrder.ConsiderLookBack = considerLookBack
+
+	if considerLookBack {
+		offeringDateMarketValue := promptPositiveOrFlag[types.Money]("offering-fmv", "What is the (FMV) market price per share on the offering date ($)? ")
+		esppOrder.OfferingDateMarketValuePerShare = offeringDateMarketValue
+
+		purchaseDateMarketValue := promptPositiveOrFlag[types.Money]("purchase-fmv", "What is the (FMV) market price per share on the purchase date ($)? ")
+		esppOrder.PurchaseDateMarketValuePerShare = purchaseDateMarketValue
+		utils.LogInfo("Look-back cost per share: $%s", esppOrder.CalculateLookBackCostPerShare())
+	} else {
+		costPricePerShare := promptPositiveOrFlag[types.Money]("cost-per-share", "What is the cost price per share ($)? ")
+		esppOrder.CostPerShare = costPricePerShare
 	}
-	esppOrder.CostPerShare = costPricePerShare
 
 	discountAmount := esppOrder.CalculateDiscountAmount()
-	utils.LogInfo("Discount Amount: $%.2f", discountAmount)
+	utils.LogInfo("Discount Amount: $%s", discountAmount)
 	effectiveCostPerShare := esppOrder.CalculateEffectiveCostPerShare()
-	utils.LogInfo("Effective Cost per share: $%.2f", effectiveCostPerShare)
+	utils.LogInfo("Effective Cost per share: $%s", effectiveCostPerShare)
+	return esppOrder
+}
 
-	sellingPrice, err := PromptAndValidate[float64]("What is the selling price per share ($)? ")
-	if err != nil {
-		utils.LogError("error occurred", err)
-		os.Exit(1)
+// promptEsppCapitalGainTax prompts for the tax brackets or the flat rates the capital gain is taxed at.
+func promptEsppCapitalGainTax(esppOrder *types.EsppOrder, stateCode string) {
+	esppOrder.ConsiderCapitalGainTax = true
+	useTaxBrackets := promptFeatureOrFlag("Use progressive federal tax brackets instead of flat percentages[Y/N]? ", "tax-brackets")
+	if useTaxBrackets {
+		esppOrder.TaxModel = tax.Federal
+	}
+	if !esppOrder.ConsiderDisposition {
+		esppOrder.StateTaxModel = promptStateTaxModel(stateCode)
 	}
-	esppOrder.SellingPricePerShare = sellingPrice
 
-	numberOfShares, err := PromptAndValidate[int]("How many shares sold? ")
-	if err != nil {
-		utils.LogError("error occurred", err)
-		os.Exit(1)
+	considerHoldingPeriod := promptFeatureOrFlag("Determine short-term/long-term from the purchase and sale dates[Y/N]? ", holdingPeriodFlags...)
+	if considerHoldingPeriod {
+		promptEsppHoldingPeriod(esppOrder)
+	} else if esppOrder.TaxModel == nil {
+		capitalGainTaxPercent := promptPercentOrFlag("cgt-percent", "What is the capital gain tax percent (Short-Term: 10%-35%) (Long-Term: 0%-20%)? ")
+		esppOrder.CapitalGainTaxPercent = capitalGainTaxPercent
+	}
+}
+
+// handleEsppCapitalLoss reports the tax saved by selling at a loss, if the user wants it modeled.
+func handleEsppCapitalLoss(esppOrder *types.EsppOrder, stateCode string) {
+	esppOrder.ConsiderCapitalLoss, esppOrder.OtherRealizedCapitalGains, esppOrder.CapitalLossCarryforward = promptCapitalLoss()
+	if !esppOrder.ConsiderCapitalLoss {
+		return
+	}
+	promptEsppCapitalGainTax(esppOrder, stateCode)
+	if esppOrder.TaxModel == nil && !esppOrder.ConsiderDisposition {
+		ordinaryIncomeTaxPercent := promptPercentOrFlag("ordinary-income-percent", "What is the ordinary income tax percent? ")
+		esppOrder.OrdinaryIncomeTaxPercent = ordinaryIncomeTaxPercent
+	}
+	esppOrder.TaxProfile = promptTaxProfileIfNeeded(esppOrder.TaxModel, esppOrder.StateTaxModel)
+	if esppOrder.TaxModel == nil && esppOrder.StateTaxModel == nil {
+		esppOrder.TaxProfile.FilingStatus = promptFilingStatus()
 	}
-	esppOrder.NumberOfSharesSold = numberOfShares
 
-	considerTransactionCommission, err := PromptAndValidate[bool]("Deduct transaction commission[Y/N]? ")
+	esppOrderSummary, err := esppOrder.CalculateEsppOrderSummary()
 	if err != nil {
 		utils.LogError("error occurred", err)
 		os.Exit(1)
 	}
-	esppOrder.ConsiderTransactionCommission = considerTransactionCommission
+	logCapitalLoss(esppOrderSummary.CapitalGainAmount, esppOrderSummary.CapitalGainTaxAmount,
+		esppOrderSummary.CapitalLossCarryforwardAmount)
+	if esppOrder.StateTaxModel != nil {
+		utils.LogInfo("  Federal: $%s", esppOrderSummary.FederalCapitalGainTaxAmount)
+		utils.LogInfo("  State: $%s", esppOrderSummary.StateCapitalGainTaxAmount)
+	}
+	utils.LogInfo("True profit/loss: $%s", esppOrderSummary.TrueProfitOrLoss())
+}
 
-	if considerTransactionCommission {
-		commissionPaidPerTransaction, err := PromptAndValidate[float64]("What is the commission paid per transaction ($)? ")
-		if err != nil {
-			utils.LogError("error occurred", err)
-			os.Exit(1)
-		}
-		esppOrder.CommissionPaidPerTransaction = commissionPaidPerTransaction
+func promptEsppDisposition(esppOrder *types.EsppOrder) {
+	esppOrder.ConsiderDisposition = true
 
-		numberOfTransactions, err := PromptAndValidate[int]("Number of transactions? ")
-		if err != nil {
-			utils.LogError("error occurred", err)
-			os.Exit(1)
-		}
-		esppOrder.NumberOfTransactions = numberOfTransactions
-	}
+	offeringDate := promptOrFlag[time.Time]("offering-date", "What is the offering date (YYYY-MM-DD)? ")
+	esppOrder.OfferingDate = offeringDate
 
-	profitOrLoss := esppOrder.CalculateProfitOrLoss()
-	if profitOrLoss < 0 {
-		utils.LogInfo("Loss: $%.2f", profitOrLoss)
-		return
-	} else if profitOrLoss == 0 {
-		utils.LogInfo("Broke even: $%.2f", profitOrLoss)
-		return
+	purchaseDate := promptOrFlag[time.Time]("purchase-date", "What is the purchase date (YYYY-MM-DD)? ")
+	esppOrder.PurchaseDate = purchaseDate
+
+	saleDate := promptOrFlag[time.Time]("sale-date", "What is the sale date (YYYY-MM-DD)? ")
+	esppOrder.SaleDate = saleDate
+
+	if !esppOrder.ConsiderLookBack {
+		offeringDateMarketValue := promptPositiveOrFlag[types.Money]("offering-fmv", "What is the (FMV) market price per share on the offering date ($)? ")
+		esppOrder.OfferingDateMarketValuePerShare = offeringDateMarketValue
+
+		purchaseDateMarketValue := promptPositiveOrFlag[types.Money]("purchase-fmv", "What is the (FMV) market price per share on the purchase date ($)? ")
+		esppOrder.PurchaseDateMarketValuePerShare = purchaseDateMarketValue
 	}
-	if profitOrLoss > 0 {
-		utils.LogInfo("Profit: $%.2f", profitOrLoss)
 
-		deductCapitalGains, err := PromptAndValidate[bool]("Do you want to calculate capital gain tax and deduct from the profit[Y/N]? ")
-		if err != nil {
-			utils.LogError("error occurred", err)
-			os.Exit(1)
-		}
-		if deductCapitalGains {
-			esppOrder.ConsiderTransactionCommission = true
-			capitalGainTaxPercent, err := PromptAndValidate[float64]("What is the capital gain tax percent (Short-Term: 10%-35%) (Long-Term: 0%-20%)? ")
-			if err != nil {
-				utils.LogError("error occurred", err)
-				os.Exit(1)
-			}
-			esppOrder.CapitalGainTaxPercent = capitalGainTaxPercent
+	ordinaryIncomeTaxPercent := promptPercentOrFlag("ordinary-income-percent", "What is the ordinary income tax percent? ")
+	esppOrder.OrdinaryIncomeTaxPercent = ordinaryIncomeTaxPercent
 
-			capitalGainTaxAmount, err := esppOrder.CalculateCapitalGainTaxAmount(profitOrLoss)
-			if err != nil {
-				utils.LogError("error occurred", err)
-				os.Exit(1)
-			}
-			utils.LogInfo("Capital Gain Amount: $%.2f", capitalGainTaxAmount)
+	utils.LogInfo("Disposition: %s", esppOrder.CalculateDispositionType())
+	utils.LogInfo("Ordinary income per share: $%s", esppOrder.CalculateOrdinaryIncomePerShare())
+	utils.LogInfo("Adjusted cost basis per share: $%s", esppOrder.CalculateAdjustedCostBasisPerShare())
+}
 
-			effectiveProfit := profitOrLoss - capitalGainTaxAmount
-			utils.LogInfo("True profit: $%.2f", effectiveProfit)
-		}
+func promptEsppHoldingPeriod(esppOrder *types.EsppOrder) {
+	esppOrder.ConsiderHoldingPeriod = true
+
+	// Dates are already known when the disposition has been classified.
+	if !esppOrder.ConsiderDisposition {
+		purchaseDate := promptOrFlag[time.Time]("purchase-date", "What is the purchase date (YYYY-MM-DD)? ")
+		esppOrder.PurchaseDate = purchaseDate
 
+		saleDate := promptOrFlag[time.Time]("sale-date", "What is the sale date (YYYY-MM-DD)? ")
+		esppOrder.SaleDate = saleDate
 	}
+
+	utils.LogInfo("Holding period: %s (long-term from %s)",
+		esppOrder.CalculateHoldingPeriod(), types.CalculateLongTermDate(esppOrder.PurchaseDate).Format(types.DateLayout))
+	// Progressive brackets pick the short-term/long-term rates themselves.
+	if esppOrder.TaxModel != nil {
+		return
+	}
+
+	shortTermCapitalGainTaxPercent := promptPercentOrFlag("short-term-percent", "What is the short-term capital gain tax percent (10%-37%)? ")
+	esppOrder.ShortTermCapitalGainTaxPercent = shortTermCapitalGainTaxPercent
+
+	longTermCapitalGainTaxPercent := promptPercentOrFlag("long-term-percent", "What is the long-term capital gain tax percent (0%-20%)? ")
+	esppOrder.LongTermCapitalGainTaxPercent = longTermCapitalGainTaxPercent
+
+	utils.LogInfo("Capital gain tax percent: %.2f%%", esppOrder.CalculateEffectiveCapitalGainTaxPercent())
 }
@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/tax"
+	"github.com/leogps/lunar/pkg/types"
+	"github.com/leogps/lunar/pkg/utils"
+	"github.com/spf13/cobra"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+func init() {
+	esppSharesNeededCmd.Flags().String("state", "", fmt.Sprintf("state to compute state tax for (%s)", strings.Join(tax.StateCodes(), ", ")))
+	esppCmd.AddCommand(esppSharesNeededCmd)
+}
+
+var esppSharesNeededCmd = &cobra.Command{
+	Use:   "shares-needed",
+	Short: "solve for the number of ESPP shares to sell to raise an after-tax profit or net cash target interactively",
+	Long:  `solve for the number of ESPP shares to sell to raise an after-tax profit or net cash target interactively`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		silent, _ := cmd.Flags().GetBool("silent")
+		var level slog.Level
+		if silent {
+			level = slog.LevelInfo
+		} else {
+			level = slog.LevelDebug
+		}
+		utils.InitLogger(level)
+
+		stateCode, _ := cmd.Flags().GetString("state")
+		handleEsppSharesNeeded(stateCode)
+	},
+}
+
+func handleEsppSharesNeeded(stateCode string) {
+	esppOrder := promptEsppCostPerShare()
+
+	sellingPricePerShare, err := PromptAndValidate[types.Money]("What is the selling price per share ($)? ")
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	esppOrder.SellingPricePerShare = sellingPricePerShare
+	fractionalShares := promptFractionalShares()
+
+	esppOrder.ConsiderTransactionCommission, esppOrder.CommissionPaidPerTransaction, esppOrder.NumberOfTransactions =
+		promptTransactionCommission("Deduct transaction commission[Y/N]? ")
+	promptEsppTaxes(esppOrder, stateCode)
+
+	saleTarget, targetAmount := promptSaleTarget()
+	solverResult, err := esppOrder.SolveSharesSoldForTarget(saleTarget, targetAmount, fractionalShares)
+	if err != nil {
+		handleSolverError(err)
+		return
+	}
+	logSharesSoldSolution(saleTarget, targetAmount, solverResult)
+	esppOrder.NumberOfSharesSold = solverResult.Value
+	logEsppSaleOutcome(esppOrder)
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/tax"
+	"github.com/leogps/lunar/pkg/types"
+	"github.com/leogps/lunar/pkg/utils"
+	"github.com/spf13/cobra"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+func init() {
+	esppTargetCmd.Flags().String("state", "", fmt.Sprintf("state to compute state tax for (%s)", strings.Join(tax.StateCodes(), ", ")))
+	esppCmd.AddCommand(esppTargetCmd)
+}
+
+var esppTargetCmd = &cobra.Command{
+	Use:   "target",
+	Short: "solve for the ESPP selling price that reaches an after-tax profit or net cash target interactively",
+	Long:  `solve for the ESPP selling price that reaches an after-tax profit or net cash target interactively`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		silent, _ := cmd.Flags().GetBool("silent")
+		var level slog.Level
+		if silent {
+			level = slog.LevelInfo
+		} else {
+			level = slog.LevelDebug
+		}
+		utils.InitLogger(level)
+
+		stateCode, _ := cmd.Flags().GetString("state")
+		handleEsppTarget(stateCode)
+	},
+}
+
+func handleEsppTarget(stateCode string) {
+	esppOrder := promptEsppCostPerShare()
+
+	numberOfShares, err := PromptAndValidate[types.Shares]("How many shares sold? ")
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	esppOrder.NumberOfSharesSold = numberOfShares
+
+	esppOrder.ConsiderTransactionCommission, esppOrder.CommissionPaidPerTransaction, esppOrder.NumberOfTransactions =
+		promptTransactionCommission("Deduct transaction commission[Y/N]? ")
+	promptEsppTaxes(esppOrder, stateCode)
+
+	saleTarget, targetAmount := promptSaleTarget()
+	solverResult, err := esppOrder.SolveSellingPriceForTarget(saleTarget, targetAmount)
+	if err != nil {
+		handleSolverError(err)
+		return
+	}
+	logSellingPriceSolution(saleTarget, targetAmount, solverResult)
+	esppOrder.SellingPricePerShare = solverResult.Value
+	logEsppSaleOutcome(esppOrder)
+}
+
+// promptEsppTaxes prompts for every tax on the sale up front, since the selling price is not known yet.
+func promptEsppTaxes(esppOrder *types.EsppOrder, stateCode string) {
+	considerDisposition, err := PromptAndValidate[bool]("Classify as qualifying/disqualifying disposition[Y/N]? ")
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	if considerDisposition {
+		promptEsppDisposition(esppOrder)
+		esppOrder.StateTaxModel = promptStateTaxModel(stateCode)
+	}
+
+	deductCapitalGains, err := PromptAndValidate[bool]("Do you want to calculate capital gain tax and deduct from the profit[Y/N]? ")
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	if deductCapitalGains {
+		promptEsppCapitalGainTax(esppOrder, stateCode)
+		esppOrder.ConsiderNetInvestmentIncomeTax, esppOrder.ModifiedAdjustedGrossIncome = promptNetInvestmentIncomeTax()
+	}
+
+	esppOrder.TaxProfile = promptTaxProfileIfNeeded(esppOrder.TaxModel, esppOrder.StateTaxModel)
+	if esppOrder.ConsiderNetInvestmentIncomeTax && esppOrder.TaxModel == nil && esppOrder.StateTaxModel == nil {
+		esppOrder.TaxProfile.FilingStatus = promptFilingStatus()
+	}
+}
+
+// logEsppSaleOutcome logs the true profit and the net cash of the sale.
+func logEsppSaleOutcome(esppOrder *types.EsppOrder) {
+	esppOrderSummary, err := esppOrder.CalculateEsppOrderSummary()
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	utils.LogInfo("Total selling price: $%s", esppOrderSummary.TotalSellingPrice)
+	utils.LogInfo("True profit: $%s", esppOrderSummary.TrueProfitOrLoss())
+	utils.LogInfo("Net cash: $%s", esppOrderSummary.NetProceeds())
+}
@@ -0,0 +1,152 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+package cmd
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/importer"
+	"github.com/leogps/lunar/pkg/types"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+func init() {
+	var layoutNames []string
+	for _, layout := range importer.Layouts() {
+		layoutNames = append(layoutNames, layout.Name)
+	}
+	importCmd.Flags().String("input", "", "CSV export of the stock plan broker")
+	importCmd.Flags().String("layout", "", "layout of the export, detected from its header when not given: "+strings.Join(layoutNames, ", "))
+	importCmd.Flags().String("mapping", "", "YAML file mapping the columns of an export of another layout")
+	importCmd.Flags().String("discount", "", "ESPP discount percent of the rows without one, otherwise derived from the FMVs (%)")
+	importCmd.Flags().Bool("dry-run", false, "preview the import without changing the ledger")
+	rootCmd.AddCommand(importCmd)
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "import the ESPP purchases, RSU vests and sales of a broker CSV export into the ledger",
+	Long: `import the ESPP purchases, RSU vests and sales of a stock plan broker CSV export into the ledger, skipping the
+lots and sales it already has; an invalid row is reported and the rest of the export is still imported`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		initLedgerLogger(cmd)
+
+		inputPath, _ := cmd.Flags().GetString("input")
+		layoutName, _ := cmd.Flags().GetString("layout")
+		mappingPath, _ := cmd.Flags().GetString("mapping")
+		discount, _ := cmd.Flags().GetString("discount")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		handleImport(inputPath, layoutName, mappingPath, discount, dryRun)
+	},
+}
+
+func handleImport(inputPath string, layoutName string, mappingPath string, discount string, dryRun bool) {
+	if inputPath == "" {
+		exitInvalidInput(fmt.Errorf("missing value for --input"))
+	}
+	var layout *importer.Layout
+	var err error
+	switch {
+	case layoutName != "" && mappingPath != "":
+		exitInvalidInput(fmt.Errorf("--layout and --mapping are mutually exclusive"))
+	case layoutName != "":
+		layout, err = importer.LookupLayout(layoutName)
+	case mappingPath != "":
+		layout, err = importer.LoadMapping(mappingPath)
+	}
+	if err != nil {
+		exitInvalidInput(err)
+	}
+	options := importer.Options{}
+	if discount != "" {
+		if options.DiscountPercent, err = parseInput[float64](discount); err != nil {
+			exitInvalidInput(fmt.Errorf("invalid value for --discount: %w", err))
+		}
+	}
+
+	layout, entries, err := importer.ReadFile(inputPath, layout, options)
+	if err != nil {
+		exitInvalidInput(err)
+	}
+	ledgerPath, holdings := loadLedger()
+	results := importer.Import(holdings, entries)
+	writeImportResults(results)
+
+	counts := map[importer.Action]int{}
+	for _, result := range results {
+		counts[result.Action]++
+	}
+	lots := counts[importer.AddedLot] + counts[importer.AddedLotAndSale]
+	sales := counts[importer.AddedSale] + counts[importer.AddedLotAndSale]
+	duplicates := counts[importer.DuplicateLot] + counts[importer.DuplicateSale]
+	summary := fmt.Sprintf("%d lots (%d made from sales with no matching lot) and %d sales from %s (%s layout), "+
+		"skipping %d duplicates and %d invalid rows", lots, counts[importer.AddedLotAndSale], sales, inputPath,
+		layout.Name, duplicates, counts[importer.Failed])
+	if dryRun {
+		fmt.Printf("\nDry run, would import %s\n", summary)
+	} else {
+		if lots+sales > 0 {
+			saveLedger(ledgerPath, holdings)
+		}
+		fmt.Printf("\nImported %s\n", summary)
+	}
+	if counts[importer.Failed] > 0 {
+		os.Exit(exitCodeInvalidInput)
+	}
+}
+
+// writeImportResults previews what importing each row does to the ledger
+func writeImportResults(results []*importer.Result) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "Line\tAction\tType\tAcquired\tShares\tBasis/Share\tSold\tPrice/Share\tLedger")
+	for _, result := range results {
+		entry := result.Entry
+		if result.Action == importer.Failed {
+			message := strings.ReplaceAll(result.Err.Error(), "\n", "; ")
+			if entry.Lot == nil {
+				fmt.Fprintf(writer, "%d\t%s\t\t\t\t\t\t\t%s\n", entry.Line, result.Action, message)
+				continue
+			}
+			fmt.Fprintf(writer, "%d\t%s\t%s\t\t\t\t\t\t%s\n", entry.Line, result.Action, entry.Lot.Source, message)
+			continue
+		}
+		lot, sold, price := entry.Lot, "", ""
+		if entry.Sale != nil {
+			sold, price = entry.Sale.Date.Format(types.DateLayout), "$"+entry.Sale.PricePerShare.StringPerShare()
+		}
+		ids := fmt.Sprintf("lot %d", result.LotID)
+		if result.Action == importer.AddedLotAndSale {
+			// The lot is made from the sale row, as the ledger has no matching lot holding the shares sold
+			ids = fmt.Sprintf("new lot %d", result.LotID)
+		}
+		if result.SaleID != 0 {
+			ids += fmt.Sprintf(", sale %d", result.SaleID)
+		}
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\t%s\t$%s\t%s\t%s\t%s\n", entry.Line, result.Action, lot.Source,
+			lot.AcquisitionDate.Format(types.DateLayout), lot.Quantity, lot.BasisPerShare.StringPerShare(), sold, price, ids)
+	}
+	_ = writer.Flush()
+}
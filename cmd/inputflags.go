@@ -0,0 +1,235 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/tax"
+	"github.com/leogps/lunar/pkg/types"
+	"github.com/leogps/lunar/pkg/utils"
+	"github.com/spf13/pflag"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// exitCodeInvalidInput is the exit code when a value is missing or invalid, e.g. a malformed flag or no input left
+// to answer a prompt with.
+const exitCodeInvalidInput = 2
+
+// inputFlags answers the prompts of the running command from its flags; nil when the command only prompts.
+var inputFlags *pflag.FlagSet
+
+// capitalGainTaxFlags imply deducting capital gain tax in flag input mode.
+var capitalGainTaxFlags = []string{"cgt-percent", "tax-brackets", "holding-period", "short-term-percent", "long-term-percent"}
+
+// holdingPeriodFlags imply determining short-term/long-term from the dates in flag input mode.
+var holdingPeriodFlags = []string{"holding-period", "short-term-percent", "long-term-percent"}
+
+// newEsppInputFlags defines the flags that answer the prompts of the espp command.
+func newEsppInputFlags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("espp", pflag.ContinueOnError)
+	flags.String("discount", "", "discounted (buying) price percent per share (%)")
+	flags.Bool("look-back", false, "the plan has a look-back provision")
+	flags.String("cost-per-share", "", "cost price per share ($)")
+	flags.String("offering-fmv", "", "(FMV) market price per share on the offering date ($)")
+	flags.String("purchase-fmv", "", "(FMV) market price per share on the purchase date ($)")
+	flags.Bool("disposition", false, "classify as a qualifying/disqualifying disposition")
+	flags.String("offering-date", "", "offering date (YYYY-MM-DD); implies --disposition")
+	flags.String("purchase-date", "", "purchase date (YYYY-MM-DD)")
+	flags.String("lot", "", "ID of the ledger lot sold from, filling in its discount, cost, dates and shares held")
+	addOrderInputFlags(flags)
+	return flags
+}
+
+// newRsuInputFlags defines the flags that answer the prompts of the rsu command.
+func newRsuInputFlags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("rsu", pflag.ContinueOnError)
+	flags.String("fmv", "", "(FMV) market price on vested stock per share ($)")
+	flags.String("vest-date", "", "vest date (YYYY-MM-DD)")
+	flags.String("income-tax", "", "income tax paid on vested stock ($); implies deducting the income tax")
+	flags.String("vested", "", "number of stocks vested; implies deducting the income tax")
+	flags.Bool("sell-to-cover", false, "simulate sell-to-cover at vest instead of entering the income tax paid")
+	var withholdingPercents []string
+	for _, withholdingPercent := range tax.DefaultSupplementalWithholdingPercents() {
+		withholdingPercents = append(withholdingPercents, strconv.FormatFloat(withholdingPercent, 'f', -1, 64))
+	}
+	flags.String("withholding-rates", strings.Join(withholdingPercents, ","), "comma-separated withholding rates for sell-to-cover (%)")
+	flags.String("cover-price", "0", "price the covering shares were sold at, 0 to use the FMV ($)")
+	flags.Bool("fractional", false, "the broker sells fractional shares to cover")
+	flags.String("lot", "", "ID of the ledger lot sold from, filling in its FMV, vest date, income tax and shares held")
+	addOrderInputFlags(flags)
+	return flags
+}
+
+// addOrderInputFlags defines the flags shared by the espp and rsu commands.
+func addOrderInputFlags(flags *pflag.FlagSet) {
+	flags.String("selling-price", "", "selling price per share ($)")
+	flags.String("shares", "", "number of shares sold")
+	flags.String("commission", "", "commission paid per transaction ($); implies deducting the commission")
+	flags.String("transactions", "1", "number of transactions")
+	flags.String("cgt-percent", "", "flat capital gain tax percent; implies deducting capital gain tax")
+	flags.Bool("tax-brackets", false, "use progressive federal tax brackets instead of flat percentages")
+	flags.Bool("holding-period", false, "determine short-term/long-term from the acquisition and sale dates")
+	flags.String("sale-date", "", "sale date (YYYY-MM-DD)")
+	flags.String("short-term-percent", "", "short-term capital gain tax percent; implies --holding-period")
+	flags.String("long-term-percent", "", "long-term capital gain tax percent; implies --holding-period")
+	flags.String("ordinary-income-percent", "", "ordinary income tax percent")
+	flags.String("filing-status", "", "filing status (single/mfj/mfs/hoh)")
+	flags.String("tax-year", "", "tax year for progressive tax brackets")
+	flags.String("other-income", "", "other taxable income (salary etc. after deductions) ($)")
+	flags.String("magi", "", "estimated MAGI excluding this sale ($); implies the 3.8% NIIT")
+	flags.Bool("capital-loss", false, "calculate the tax saved by a capital loss")
+	flags.String("other-capital-gains", "0", "other capital gains realized this tax year ($)")
+	flags.String("carryforward", "0", "capital loss carried forward from prior years ($)")
+}
+
+// flagInputMode reports whether any input flag was passed. Yes/no questions are then answered by the flags instead
+// of prompting, and only the values still missing are prompted for.
+func flagInputMode() bool {
+	if inputFlags == nil {
+		return false
+	}
+	changed := false
+	// The command parses the flags into its own set, which only marks the shared flags as changed
+	inputFlags.VisitAll(func(flag *pflag.Flag) {
+		changed = changed || flag.Changed
+	})
+	return changed
+}
+
+// flagSupplied reports whether the flag was passed or, in flag input mode, has a default value of its own or from
+// the config profile.
+func flagSupplied(flagName string) bool {
+	if inputFlags == nil {
+		return false
+	}
+	flag := inputFlags.Lookup(flagName)
+	if flag == nil {
+		return false
+	}
+	_, hasProfileDefault := profileDefault(flagName)
+	return flag.Changed || (flagInputMode() && (flag.DefValue != "" || hasProfileDefault))
+}
+
+// promptOrFlag returns the value of the flag when supplied, otherwise prompts for it with the value of the config
+// profile as the default.
+// Exits with exitCodeInvalidInput when the flag is invalid or the prompt is left unanswered.
+func promptOrFlag[T any](flagName string, prompt string) T {
+	if flagSupplied(flagName) {
+		value, err := parseInput[T](inputFlags.Lookup(flagName).Value.String())
+		if err != nil {
+			exitInvalidInput(fmt.Errorf("invalid value for --%s: %w", flagName, err))
+		}
+		return value
+	}
+	defaultValue, _ := profileDefault(flagName)
+	value, err := PromptWithDefault[T](prompt, defaultValue)
+	if err != nil {
+		if inputFlags != nil && inputFlags.Lookup(flagName) != nil {
+			exitInvalidInput(fmt.Errorf("missing value for --%s: %w", flagName, err))
+		}
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	return value
+}
+
+// promptPositiveOrFlag prompts until an amount or number of shares greater than 0 is entered.
+// Exits with exitCodeInvalidInput when the flag is not greater than 0.
+func promptPositiveOrFlag[T types.Money | types.Shares](flagName string, prompt string) T {
+	for {
+		value := promptOrFlag[T](flagName, prompt)
+		if value > 0 {
+			return value
+		}
+		rejectFlag(flagName, fmt.Errorf("must be greater than 0"))
+	}
+}
+
+// promptPercentOrFlag prompts until a percent from 0 to 100 is entered.
+// Exits with exitCodeInvalidInput when the flag is out of range.
+func promptPercentOrFlag(flagName string, prompt string) float64 {
+	for {
+		value := promptOrFlag[float64](flagName, prompt)
+		if value >= 0 && value <= 100 {
+			return value
+		}
+		rejectFlag(flagName, fmt.Errorf("must be from 0 to 100"))
+	}
+}
+
+// promptFeatureOrFlag asks a yes/no question. In flag input mode it is answered by whether any of the flags was
+// passed or set by the config profile (a boolean flag has to be true) instead of prompting. Otherwise the profile
+// picks the default answer.
+func promptFeatureOrFlag(prompt string, flagNames ...string) bool {
+	if !flagInputMode() {
+		defaultAnswer := ""
+		for _, flagName := range flagNames {
+			value, ok := profileDefault(flagName)
+			if !ok {
+				continue
+			}
+			if enabled, err := parseInput[bool](value); err != nil || enabled {
+				defaultAnswer = "y"
+				break
+			}
+			defaultAnswer = "n"
+		}
+		value, err := PromptWithDefault[bool](prompt, defaultAnswer)
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		return value
+	}
+	for _, flagName := range flagNames {
+		flag := inputFlags.Lookup(flagName)
+		if flag == nil {
+			continue
+		}
+		if _, hasProfileDefault := profileDefault(flagName); !flag.Changed && !hasProfileDefault {
+			continue
+		}
+		if flag.Value.Type() != "bool" || flag.Value.String() == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+// rejectFlag exits with exitCodeInvalidInput when the flag was passed, e.g. with a value that failed validation;
+// otherwise it logs the problem so the prompt can be answered again.
+func rejectFlag(flagName string, err error) {
+	if flagSupplied(flagName) {
+		exitInvalidInput(fmt.Errorf("invalid value for --%s: %w", flagName, err))
+	}
+	utils.LogWarn(err.Error())
+}
+
+func exitInvalidInput(err error) {
+	// The error is passed as an argument, as it may quote a prompt with a % in it
+	utils.LogError("invalid input error: %v", nil, err)
+	os.Exit(exitCodeInvalidInput)
+}
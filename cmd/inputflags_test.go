@@ -0,0 +1,82 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package cmd
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// invalidInputCaseEnv runs a single case of TestHandleOrder_InvalidFlags in the test binary run by the test
+const invalidInputCaseEnv = "LUNAR_TEST_INVALID_INPUT_CASE"
+
+func TestHandleOrder_InvalidFlags(t *testing.T) {
+	esppArgs := []string{"--discount", "15", "--cost-per-share", "100", "--selling-price", "120", "--shares", "10", "--cgt-percent", "20"}
+	rsuArgs := []string{"--fmv", "100", "--selling-price", "120", "--shares", "10", "--cgt-percent", "20"}
+	tests := []struct {
+		name   string
+		rsu    bool
+		args   []string
+		flag   string
+		values []string
+	}{
+		{name: "negative shares", args: esppArgs, flag: "--shares", values: []string{"-10", "0"}},
+		{name: "negative selling price", args: esppArgs, flag: "--selling-price", values: []string{"-120"}},
+		{name: "zero cost", args: esppArgs, flag: "--cost-per-share", values: []string{"0"}},
+		{name: "discount over 100", args: esppArgs, flag: "--discount", values: []string{"150", "-5"}},
+		{name: "negative capital gain tax", args: esppArgs, flag: "--cgt-percent", values: []string{"-20", "120"}},
+		{name: "zero FMV", rsu: true, args: rsuArgs, flag: "--fmv", values: []string{"0"}},
+		{name: "zero shares vested", rsu: true, args: append(rsuArgs, "--income-tax", "300"), flag: "--vested", values: []string{"0"}},
+	}
+
+	if caseValue := os.Getenv(invalidInputCaseEnv); caseValue != "" {
+		parts := strings.SplitN(caseValue, ":", 2)
+		index, _ := strconv.Atoi(parts[0])
+		test := tests[index]
+		args := append(append([]string{}, test.args...), test.flag, parts[1])
+		if test.rsu {
+			parseInputFlags(t, newRsuInputFlags, args...)
+			handleRsu("")
+		} else {
+			parseInputFlags(t, newEsppInputFlags, args...)
+			handleEspp("")
+		}
+		return
+	}
+
+	for index, test := range tests {
+		for _, value := range test.values {
+			command := exec.Command(os.Args[0], "-test.run=^TestHandleOrder_InvalidFlags$")
+			command.Env = append(os.Environ(), invalidInputCaseEnv+"="+strconv.Itoa(index)+":"+value)
+			var exitErr *exec.ExitError
+			if err := command.Run(); !errors.As(err, &exitErr) || exitErr.ExitCode() != exitCodeInvalidInput {
+				t.Errorf("%s: expected %s %s to exit with status %d, got %v", test.name, test.flag, value, exitCodeInvalidInput, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,424 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+package cmd
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/ledger"
+	"github.com/leogps/lunar/pkg/types"
+	"github.com/leogps/lunar/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+func init() {
+	ledgerAddEsppCmd.Flags().AddFlagSet(ledgerAddEsppFlags)
+	ledgerAddRsuCmd.Flags().AddFlagSet(ledgerAddRsuFlags)
+	ledgerAddSaleCmd.Flags().AddFlagSet(ledgerAddSaleFlags)
+	for _, addCmd := range []*cobra.Command{ledgerAddEsppCmd, ledgerAddRsuCmd, ledgerAddSaleCmd} {
+		addCmd.Flags().String("label", "", "label to recognize the entry by, e.g. 2024-Q1 purchase")
+		ledgerAddCmd.AddCommand(addCmd)
+	}
+	ledgerCmd.AddCommand(ledgerAddCmd)
+	ledgerListCmd.Flags().Bool("held", false, "only list the lots with shares still held")
+	ledgerCmd.AddCommand(ledgerListCmd)
+	ledgerCmd.AddCommand(ledgerShowCmd)
+	ledgerCmd.AddCommand(ledgerRemoveCmd)
+	rootCmd.AddCommand(ledgerCmd)
+}
+
+// ledgerAddEsppFlags answer the prompts of the ledger add espp command.
+var ledgerAddEsppFlags = func() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("ledger add espp", pflag.ContinueOnError)
+	flags.String("purchase-date", "", "purchase date (YYYY-MM-DD)")
+	flags.String("shares", "", "number of shares purchased")
+	flags.String("purchase-price", "", "purchase price paid per share ($)")
+	flags.String("discount", "", "discounted (buying) price percent per share (%)")
+	flags.String("offering-date", "", "offering date (YYYY-MM-DD); implies recording the offering details")
+	flags.String("offering-fmv", "", "(FMV) market price per share on the offering date ($)")
+	flags.String("purchase-fmv", "", "(FMV) market price per share on the purchase date ($)")
+	return flags
+}()
+
+// ledgerAddRsuFlags answer the prompts of the ledger add rsu command.
+var ledgerAddRsuFlags = func() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("ledger add rsu", pflag.ContinueOnError)
+	flags.String("vest-date", "", "vest date (YYYY-MM-DD)")
+	flags.String("shares", "", "number of shares vested (after any sold to cover)")
+	flags.String("fmv", "", "(FMV) market price per share at vest ($)")
+	flags.String("income-tax", "", "income tax paid on the vest ($)")
+	return flags
+}()
+
+// ledgerAddSaleFlags answer the prompts of the ledger add sale command.
+var ledgerAddSaleFlags = func() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("ledger add sale", pflag.ContinueOnError)
+	flags.String("lot", "", "ID of the lot sold from")
+	flags.String("sale-date", "", "sale date (YYYY-MM-DD)")
+	flags.String("shares", "", "number of shares sold")
+	flags.String("selling-price", "", "selling price per share ($)")
+	flags.String("commission", "", "commission paid on the sale ($)")
+	return flags
+}()
+
+var ledgerCmd = &cobra.Command{
+	Use:   "ledger",
+	Short: "record the ESPP purchases, RSU vests and sales held",
+	Long: `record the ESPP purchases, RSU vests and sales held in a local ledger, so the espp, rsu and sell commands
+can sell from its lots instead of retyping them`,
+}
+
+var ledgerAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "add an ESPP purchase, RSU vest or sale to the ledger interactively or from flags",
+	Long:  `add an ESPP purchase, RSU vest or sale to the ledger interactively or from flags`,
+}
+
+var ledgerAddEsppCmd = &cobra.Command{
+	Use:   "espp",
+	Short: "add an ESPP purchase to the ledger interactively or from flags",
+	Long:  `add an ESPP purchase to the ledger interactively or from flags; only the values missing from the flags are prompted for`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		initLedgerLogger(cmd)
+		inputFlags = ledgerAddEsppFlags
+
+		lot := &ledger.Lot{}
+		lot.Source = types.Espp
+		lot.Label, _ = cmd.Flags().GetString("label")
+		lot.AcquisitionDate = promptOrFlag[time.Time]("purchase-date", "What is the purchase date (YYYY-MM-DD)? ")
+		lot.Quantity = promptOrFlag[types.Shares]("shares", "How many shares were purchased? ")
+		lot.BasisPerShare = promptOrFlag[types.Money]("purchase-price", "What is the purchase price paid per share ($)? ")
+		lot.DiscountPercent = promptOrFlag[float64]("discount", "What is the discounted (buying) price percent per share (%)? ")
+		if promptFeatureOrFlag("Record the offering date and market prices (for the disposition)[Y/N]? ",
+			"offering-date", "offering-fmv", "purchase-fmv") {
+			lot.OfferingDate = promptOrFlag[time.Time]("offering-date", "What is the offering date (YYYY-MM-DD)? ")
+			lot.OfferingDateMarketValuePerShare = promptOrFlag[types.Money]("offering-fmv", "What is the (FMV) market price per share on the offering date ($)? ")
+			lot.PurchaseDateMarketValuePerShare = promptOrFlag[types.Money]("purchase-fmv", "What is the (FMV) market price per share on the purchase date ($)? ")
+		}
+		addLedgerLot(lot)
+	},
+}
+
+var ledgerAddRsuCmd = &cobra.Command{
+	Use:   "rsu",
+	Short: "add an RSU vest to the ledger interactively or from flags",
+	Long:  `add an RSU vest to the ledger interactively or from flags; only the values missing from the flags are prompted for`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		initLedgerLogger(cmd)
+		inputFlags = ledgerAddRsuFlags
+
+		lot := &ledger.Lot{}
+		lot.Source = types.Rsu
+		lot.Label, _ = cmd.Flags().GetString("label")
+		lot.AcquisitionDate = promptOrFlag[time.Time]("vest-date", "What is the vest date (YYYY-MM-DD)? ")
+		lot.Quantity = promptOrFlag[types.Shares]("shares", "How many shares were vested (after any sold to cover)? ")
+		lot.BasisPerShare = promptOrFlag[types.Money]("fmv", "What is the (FMV) market price per share at vest ($)? ")
+		if promptFeatureOrFlag("Record the income tax paid on the vest[Y/N]? ", "income-tax") {
+			lot.IncomeTax = promptOrFlag[types.Money]("income-tax", "What is the income tax paid on the vest ($)? ")
+		}
+		addLedgerLot(lot)
+	},
+}
+
+var ledgerAddSaleCmd = &cobra.Command{
+	Use:   "sale",
+	Short: "add a sale from a lot of the ledger interactively or from flags",
+	Long:  `add a sale from a lot of the ledger interactively or from flags; only the values missing from the flags are prompted for`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		initLedgerLogger(cmd)
+		inputFlags = ledgerAddSaleFlags
+
+		ledgerPath, holdings := loadLedger()
+		sale := &ledger.Sale{}
+		sale.Label, _ = cmd.Flags().GetString("label")
+		sale.LotID = promptOrFlag[int]("lot", "What is the ID of the lot sold from? ")
+		sale.Date = promptOrFlag[time.Time]("sale-date", "What is the sale date (YYYY-MM-DD)? ")
+		sale.Quantity = promptOrFlag[types.Shares]("shares", "How many shares sold? ")
+		sale.PricePerShare = promptOrFlag[types.Money]("selling-price", "What is the selling price per share ($)? ")
+		if promptFeatureOrFlag("Record the commission paid on the sale[Y/N]? ", "commission") {
+			sale.Commission = promptOrFlag[types.Money]("commission", "What is the commission paid on the sale ($)? ")
+		}
+		if err := holdings.AddSale(sale); err != nil {
+			exitInvalidInput(err)
+		}
+		saveLedger(ledgerPath, holdings)
+		utils.LogInfo("Added sale %d of %s shares from lot %d", sale.ID, sale.Quantity, sale.LotID)
+	},
+}
+
+var ledgerListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the lots and sales of the ledger",
+	Long:  `list the lots of the ledger with the shares still held, followed by the sales`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		_, holdings := loadLedger()
+		held, _ := cmd.Flags().GetBool("held")
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "ID\tType\tAcquired\tShares\tHeld\tBasis/Share\tLabel")
+		for _, lot := range holdings.Lots {
+			remainingShares := holdings.RemainingShares(lot)
+			if held && remainingShares <= 0 {
+				continue
+			}
+			fmt.Fprintf(writer, "%d\t%s\t%s\t%s\t%s\t$%s\t%s\n", lot.ID, lot.Source,
+				lot.AcquisitionDate.Format(types.DateLayout), lot.Quantity, remainingShares,
+				lot.BasisPerShare.StringPerShare(), lot.Label)
+		}
+		if !held && len(holdings.Sales) > 0 {
+			fmt.Fprintln(writer, "\nID\tLot\tSold\tShares\tPrice/Share\tCommission\tLabel")
+			for _, sale := range holdings.Sales {
+				fmt.Fprintf(writer, "%d\t%d\t%s\t%s\t$%s\t$%s\t%s\n", sale.ID, sale.LotID,
+					sale.Date.Format(types.DateLayout), sale.Quantity, sale.PricePerShare.StringPerShare(),
+					sale.Commission, sale.Label)
+			}
+		}
+		_ = writer.Flush()
+	},
+}
+
+var ledgerShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "show a lot or sale of the ledger",
+	Long:  `show a lot of the ledger with its sales, or a sale with its lot`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		_, holdings := loadLedger()
+		id := parseLedgerID(args[0])
+		if lot, err := holdings.Lot(id); err == nil {
+			fmt.Print(formatLedgerLot(holdings, lot))
+			for _, sale := range holdings.SalesOf(lot) {
+				fmt.Print(formatLedgerSale(sale))
+			}
+			return
+		}
+		sale, err := holdings.Sale(id)
+		if err != nil {
+			exitInvalidInput(fmt.Errorf("no lot or sale with ID %d", id))
+		}
+		fmt.Print(formatLedgerSale(sale))
+		if lot, err := holdings.Lot(sale.LotID); err == nil {
+			fmt.Print(formatLedgerLot(holdings, lot))
+		}
+	},
+}
+
+var ledgerRemoveCmd = &cobra.Command{
+	Use:   "remove <id>",
+	Short: "remove a lot or sale from the ledger",
+	Long:  `remove a lot or sale from the ledger; a lot can only be removed once its sales are`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		ledgerPath, holdings := loadLedger()
+		if err := holdings.Remove(parseLedgerID(args[0])); err != nil {
+			exitInvalidInput(err)
+		}
+		saveLedger(ledgerPath, holdings)
+	},
+}
+
+func initLedgerLogger(cmd *cobra.Command) {
+	silent, _ := cmd.Flags().GetBool("silent")
+	var level slog.Level
+	if silent {
+		level = slog.LevelInfo
+	} else {
+		level = slog.LevelDebug
+	}
+	utils.InitLogger(level)
+}
+
+// loadLedger loads the ledger file. Exits with exitCodeInvalidInput when it is invalid.
+func loadLedger() (string, *ledger.Ledger) {
+	ledgerPath, err := ledger.DefaultPath()
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	holdings, err := ledger.Load(ledgerPath)
+	if err != nil {
+		exitInvalidInput(err)
+	}
+	return ledgerPath, holdings
+}
+
+func saveLedger(ledgerPath string, holdings *ledger.Ledger) {
+	if err := holdings.Save(ledgerPath); err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+}
+
+func addLedgerLot(lot *ledger.Lot) {
+	ledgerPath, holdings := loadLedger()
+	if err := holdings.AddLot(lot); err != nil {
+		exitInvalidInput(err)
+	}
+	saveLedger(ledgerPath, holdings)
+	utils.LogInfo("Added %s lot %d of %s shares", lot.Source, lot.ID, lot.Quantity)
+}
+
+func parseLedgerID(value string) int {
+	id, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		exitInvalidInput(fmt.Errorf("invalid ID: %s", value))
+	}
+	return id
+}
+
+func formatLedgerLot(holdings *ledger.Ledger, lot *ledger.Lot) string {
+	var sb strings.Builder
+	acquisition, basis := "Vest Date", "FMV at Vest"
+	if lot.Source == types.Espp {
+		acquisition, basis = "Purchase Date", "Purchase Price"
+	}
+	sb.WriteString(fmt.Sprintf("Lot %d (%s):\n", lot.ID, lot.Source))
+	if lot.Label != "" {
+		sb.WriteString(fmt.Sprintf("  Label:              %s\n", lot.Label))
+	}
+	sb.WriteString(fmt.Sprintf("  %-19s %s\n", acquisition+":", lot.AcquisitionDate.Format(types.DateLayout)))
+	sb.WriteString(fmt.Sprintf("  Shares:             %s\n", lot.Quantity))
+	sb.WriteString(fmt.Sprintf("  Shares Held:        %s\n", holdings.RemainingShares(lot)))
+	sb.WriteString(fmt.Sprintf("  %-19s $%s\n", basis+":", lot.BasisPerShare.StringPerShare()))
+	if lot.Source == types.Espp {
+		sb.WriteString(fmt.Sprintf("  Discount:           %.2f%%\n", lot.DiscountPercent))
+		if !lot.OfferingDate.IsZero() {
+			sb.WriteString(fmt.Sprintf("  Offering Date:      %s\n", lot.OfferingDate.Format(types.DateLayout)))
+			sb.WriteString(fmt.Sprintf("  Offering FMV:       $%s\n", lot.OfferingDateMarketValuePerShare.StringPerShare()))
+			sb.WriteString(fmt.Sprintf("  Purchase FMV:       $%s\n", lot.PurchaseDateMarketValuePerShare.StringPerShare()))
+		}
+	} else if lot.IncomeTax != 0 {
+		sb.WriteString(fmt.Sprintf("  Income Tax:         $%s\n", lot.IncomeTax))
+	}
+	sb.WriteString(fmt.Sprintf("  Long-Term From:     %s\n", types.CalculateLongTermDate(lot.AcquisitionDate).Format(types.DateLayout)))
+	return sb.String()
+}
+
+func formatLedgerSale(sale *ledger.Sale) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Sale %d (lot %d):\n", sale.ID, sale.LotID))
+	if sale.Label != "" {
+		sb.WriteString(fmt.Sprintf("  Label:              %s\n", sale.Label))
+	}
+	sb.WriteString(fmt.Sprintf("  Sale Date:          %s\n", sale.Date.Format(types.DateLayout)))
+	sb.WriteString(fmt.Sprintf("  Shares:             %s\n", sale.Quantity))
+	sb.WriteString(fmt.Sprintf("  Price/Share:        $%s\n", sale.PricePerShare.StringPerShare()))
+	sb.WriteString(fmt.Sprintf("  Commission:         $%s\n", sale.Commission))
+	return sb.String()
+}
+
+// applyLedgerLot fills in the flags of the espp or rsu command that describe the lot picked with --lot, unless they
+// were passed. The shares held are sold unless --shares says otherwise.
+// Exits with exitCodeInvalidInput when the lot is missing, of the other order type or holds fewer shares.
+func applyLedgerLot(orderType types.OrderType) {
+	lotFlag := inputFlags.Lookup("lot")
+	if lotFlag == nil || lotFlag.Value.String() == "" {
+		return
+	}
+	_, holdings := loadLedger()
+	lot, err := holdings.Lot(parseLedgerID(lotFlag.Value.String()))
+	if err != nil {
+		exitInvalidInput(err)
+	}
+	if lot.Source != orderType {
+		exitInvalidInput(fmt.Errorf("lot %d is an %s lot, not %s", lot.ID, lot.Source, orderType))
+	}
+	remainingShares := holdings.RemainingShares(lot)
+	if remainingShares <= 0 {
+		exitInvalidInput(fmt.Errorf("lot %d is sold out", lot.ID))
+	}
+	if sharesFlag := inputFlags.Lookup("shares"); sharesFlag.Changed {
+		if shares, err := types.ParseShares(sharesFlag.Value.String()); err == nil && shares > remainingShares {
+			exitInvalidInput(fmt.Errorf("lot %d holds %s shares, fewer than the %s sold", lot.ID, remainingShares, shares))
+		}
+	}
+
+	values := map[string]string{"shares": remainingShares.String()}
+	if orderType == types.Espp {
+		// The purchase price paid is known, so the look-back does not need to be applied again
+		values["look-back"] = "false"
+		values["discount"] = strconv.FormatFloat(lot.DiscountPercent, 'f', -1, 64)
+		values["cost-per-share"] = lot.CostPerShare().StringPerShare()
+		values["purchase-date"] = lot.AcquisitionDate.Format(types.DateLayout)
+		if !lot.OfferingDate.IsZero() {
+			values["offering-fmv"] = lot.OfferingDateMarketValuePerShare.StringPerShare()
+			values["purchase-fmv"] = lot.PurchaseDateMarketValuePerShare.StringPerShare()
+			// The offering date implies classifying the disposition, which is left to --disposition
+			if disposition, _ := inputFlags.GetBool("disposition"); disposition {
+				values["offering-date"] = lot.OfferingDate.Format(types.DateLayout)
+			}
+		}
+	} else {
+		values["fmv"] = lot.BasisPerShare.StringPerShare()
+		values["vest-date"] = lot.AcquisitionDate.Format(types.DateLayout)
+		if lot.IncomeTax != 0 {
+			values["income-tax"] = lot.IncomeTax.String()
+			values["vested"] = lot.Quantity.String()
+		}
+	}
+	for flagName, value := range values {
+		if inputFlags.Lookup(flagName).Changed {
+			continue
+		}
+		if err = inputFlags.Set(flagName, value); err != nil {
+			exitInvalidInput(err)
+		}
+	}
+}
+
+// addLedgerLotsFlag defines the --lots flag of the commands selling from several lots.
+func addLedgerLotsFlag(cmd *cobra.Command) {
+	cmd.Flags().String("lots", "", "comma-separated IDs of the ledger lots sold from, in order, or all for every lot held; "+
+		"instead of entering the lots")
+}
+
+// loadLedgerLots returns the shares still held of the ledger lots picked by --lots.
+// Exits with exitCodeInvalidInput when a lot is missing or sold out.
+func loadLedgerLots(ledgerLotIDs string) []*types.Lot {
+	_, holdings := loadLedger()
+	var ids []int
+	if !strings.EqualFold(strings.TrimSpace(ledgerLotIDs), "all") {
+		var err error
+		if ids, err = types.ParseLotNumbers(ledgerLotIDs); err != nil {
+			exitInvalidInput(fmt.Errorf("invalid value for --lots: %w", err))
+		}
+	}
+	lots, err := holdings.HeldLots(ids...)
+	if err != nil {
+		exitInvalidInput(err)
+	}
+	if len(lots) == 0 {
+		exitInvalidInput(fmt.Errorf("the ledger holds no shares"))
+	}
+	return lots
+}
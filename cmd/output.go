@@ -0,0 +1,66 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/format"
+	"github.com/leogps/lunar/pkg/utils"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+)
+
+// addOutputFlag registers the --output flag of a command that reports an order summary.
+func addOutputFlag(cmd *cobra.Command) {
+	formatNames := make([]string, 0, len(format.Formats()))
+	for _, outputFormat := range format.Formats() {
+		formatNames = append(formatNames, outputFormat.String())
+	}
+	cmd.Flags().String("output", format.Text.String(),
+		fmt.Sprintf("format of the order summary (%s); other than text, prompts and logs go to stderr", strings.Join(formatNames, ", ")))
+}
+
+// getOutputFormat reads the --output flag. Other than text, the prompts and logs are moved to stderr so that stdout
+// carries only the order summary.
+func getOutputFormat(cmd *cobra.Command) format.Format {
+	value, _ := cmd.Flags().GetString("output")
+	outputFormat, err := format.ParseFormat(value)
+	if err != nil {
+		exitInvalidInput(err)
+	}
+	if outputFormat != format.Text {
+		utils.SetLogOutput(os.Stderr)
+		promptOutput = os.Stderr
+	}
+	return outputFormat
+}
+
+// writeReport writes the report to stdout in the output format.
+func writeReport(outputFormat format.Format, report *format.Report) {
+	if err := format.Write(os.Stdout, outputFormat, report); err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package cmd
+
+import (
+	"github.com/leogps/lunar/pkg/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"os"
+	"strconv"
+)
+
+// profileDefaults are the values of the selected config profile, overridden by the LUNAR_* environment variables.
+// Flags override them in turn.
+var profileDefaults = config.Profile{}
+
+// loadProfileDefaults loads the selected profile of the config file and applies it to the flags of cmd that were
+// not passed. Exits with exitCodeInvalidInput when the config file, the profile or an environment variable is invalid.
+func loadProfileDefaults(cmd *cobra.Command) {
+	configPath, err := config.DefaultPath()
+	if err != nil {
+		exitInvalidInput(err)
+	}
+	lunarConfig, err := config.Load(configPath)
+	if err != nil {
+		exitInvalidInput(err)
+	}
+	profileName, _ := cmd.Flags().GetString("profile")
+	profile, err := lunarConfig.Profile(lunarConfig.ProfileName(profileName))
+	if err != nil {
+		exitInvalidInput(err)
+	}
+	if profileDefaults, err = profile.WithEnv(os.LookupEnv); err != nil {
+		exitInvalidInput(err)
+	}
+
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		value, ok := profileDefault(flag.Name)
+		if !ok || flag.Changed {
+			return
+		}
+		if flag.Value.Type() == "bool" {
+			// Profiles also accept yes/no, which boolean flags do not
+			enabled, _ := parseInput[bool](value)
+			value = strconv.FormatBool(enabled)
+		}
+		if err := flag.Value.Set(value); err != nil {
+			exitInvalidInput(err)
+		}
+	})
+}
+
+// profileDefault returns the value the selected profile sets for the flag.
+func profileDefault(flagName string) (string, bool) {
+	value, ok := profileDefaults[flagName]
+	return value, ok && value != ""
+}
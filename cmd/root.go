@@ -33,6 +33,9 @@ var rootCmd = &cobra.Command{
 	// Use:   "yeet-cli [command] [flags]",
 	Short: "lunar is a CLI tool to perform calculations for stocks.",
 	Long:  `lunar is a CLI tool to perform calculations for stocks.`,
+	PersistentPreRun: func(cmd *cobra.Command, _ []string) {
+		loadProfileDefaults(cmd)
+	},
 	Run: func(cmd *cobra.Command, _ []string) {
 		// This will be executed when no subcommand is specified
 		utils.LogInfo("lunar: please pass sub-command. See help for more details:")
@@ -40,6 +43,10 @@ var rootCmd = &cobra.Command{
 	},
 }
 
+func init() {
+	rootCmd.PersistentFlags().String("profile", "", "config profile to take default values from (see the config command)")
+}
+
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
@@ -24,21 +24,32 @@
 package cmd
 
 import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/format"
+	"github.com/leogps/lunar/pkg/tax"
 	"github.com/leogps/lunar/pkg/types"
 	"github.com/leogps/lunar/pkg/utils"
 	"github.com/spf13/cobra"
 	"log/slog"
 	"os"
+	"strings"
+	"time"
 )
 
 func init() {
+	rsuCmd.Flags().String("state", "", fmt.Sprintf("state to compute state tax for (%s)", strings.Join(tax.StateCodes(), ", ")))
+	rsuCmd.Flags().AddFlagSet(rsuInputFlags)
+	addOutputFlag(rsuCmd)
 	rootCmd.AddCommand(rsuCmd)
 }
 
+// rsuInputFlags answer the prompts of the rsu command; only the values missing from them are prompted for.
+var rsuInputFlags = newRsuInputFlags()
+
 var rsuCmd = &cobra.Command{
 	Use:   "rsu",
-	Short: "calculate profit/loss on RSU orders interactively",
-	Long:  `calculate profit/loss on RSU orders interactively`,
+	Short: "calculate profit/loss on RSU orders interactively or from flags",
+	Long:  `calculate profit/loss on RSU orders interactively or from flags; only the values missing from the flags are prompted for`,
 	Run: func(cmd *cobra.Command, _ []string) {
 		silent, _ := cmd.Flags().GetBool("silent")
 		var level slog.Level
@@ -49,137 +60,262 @@ var rsuCmd = &cobra.Command{
 		}
 		utils.InitLogger(level)
 
-		handleRsu()
+		inputFlags = rsuInputFlags
+		applyLedgerLot(types.Rsu)
+		outputFormat := getOutputFormat(cmd)
+		stateCode, _ := cmd.Flags().GetString("state")
+		rsuOrder := handleRsu(stateCode)
+		writeRsuOrderReport(rsuOrder, outputFormat)
 	},
 }
 
-func handleRsu() {
+func handleRsu(stateCode string) *types.RsuOrder {
 	rsuOrder := types.RsuOrder{}
 
-	sellingPrice, err := PromptAndValidate[float64]("What is the selling price per share ($)? ")
-	if err != nil {
-		utils.LogError("error occurred", err)
-		os.Exit(1)
-	}
+	sellingPrice := promptPositiveOrFlag[types.Money]("selling-price", "What is the selling price per share ($)? ")
 	rsuOrder.SellingPricePerShare = sellingPrice
 
-	numberOfShares, err := PromptAndValidate[int]("How many shares sold? ")
-	if err != nil {
-		utils.LogError("error occurred", err)
-		os.Exit(1)
-	}
+	numberOfShares := promptPositiveOrFlag[types.Shares]("shares", "How many shares sold? ")
 	rsuOrder.NumberOfSharesSold = numberOfShares
 
-	considerTransactionCommission, err := PromptAndValidate[bool]("Consider transaction commission[Y/N]? ")
-	if err != nil {
-		utils.LogError("error occurred", err)
-		os.Exit(1)
+	rsuOrder.ConsiderTransactionCommission, rsuOrder.CommissionPaidPerTransaction, rsuOrder.NumberOfTransactions =
+		promptTransactionCommission("Consider transaction commission[Y/N]? ")
+
+	profitOrLoss := rsuOrder.CalculateEffectiveProfitOrLoss()
+	if profitOrLoss > 0 {
+		utils.LogInfo("Profit: $%s", profitOrLoss)
+	} else if profitOrLoss < 0 {
+		utils.LogInfo("Loss: $%s", profitOrLoss)
+		logWashSaleWarning()
+	} else {
+		utils.LogInfo("Broke even: $%s", profitOrLoss)
 	}
-	rsuOrder.ConsiderTransactionCommission = considerTransactionCommission
 
-	if considerTransactionCommission {
-		commissionPaidPerTransaction, err := PromptAndValidate[float64]("What is the commission paid per transaction ($)? ")
-		if err != nil {
-			utils.LogError("error occurred", err)
-			os.Exit(1)
-		}
-		rsuOrder.CommissionPaidPerTransaction = commissionPaidPerTransaction
+	// A sale below the market value at vest is a capital loss whatever the proceeds
+	var capitalGainTaxAmount types.Money
+	deductCapitalGains := promptFeatureOrFlag("Do you want to calculate capital gain tax and deduct from the profit[Y/N]? ", capitalGainTaxFlags...)
+	if deductCapitalGains {
+		promptRsuCapitalGainTax(&rsuOrder, stateCode)
 
-		numberOfTransactions, err := PromptAndValidate[int]("Number of transactions? ")
-		if err != nil {
-			utils.LogError("error occurred", err)
-			os.Exit(1)
+		rsuOrder.ConsiderNetInvestmentIncomeTax, rsuOrder.ModifiedAdjustedGrossIncome = promptNetInvestmentIncomeTax()
+		if rsuOrder.ConsiderNetInvestmentIncomeTax && rsuOrder.TaxModel == nil && rsuOrder.StateTaxModel == nil {
+			rsuOrder.TaxProfile.FilingStatus = promptFilingStatus()
 		}
-		rsuOrder.NumberOfTransactions = numberOfTransactions
-	}
 
-	profitOrLoss := rsuOrder.CalculateEffectiveProfitOrLoss()
-	var capitalGainTaxAmount float64
-	if profitOrLoss > 0 {
-		utils.LogInfo("Profit: $%.2f", profitOrLoss)
+		marketPriceOnVestedStockPerShare := promptPositiveOrFlag[types.Money]("fmv", "What is the (FMV) market price on vested stock per share ($)? ")
+		rsuOrder.MarketValuePerShare = marketPriceOnVestedStockPerShare
 
-		deductCapitalGains, err := PromptAndValidate[bool]("Do you want to calculate capital gain tax and deduct from the profit[Y/N]? ")
-		if err != nil {
-			utils.LogError("error occurred", err)
-			os.Exit(1)
-		}
-		if deductCapitalGains {
-			rsuOrder.ConsiderTransactionCommission = true
-			capitalGainTaxPercent, err := PromptAndValidate[float64]("What is the capital gain tax percent (Short-Term: 10%-35%) (Long-Term: 0%-20%)? ")
+		capitalGainTaxableAmount := rsuOrder.CalculateProfitOrLossForCapitalGain()
+		if capitalGainTaxableAmount <= 0 {
+			utils.LogInfo("Sold at a loss ($%s). No Capital Gain.", capitalGainTaxableAmount)
+			if profitOrLoss > 0 {
+				logWashSaleWarning()
+			}
+			if capitalGainTaxableAmount < 0 {
+				handleRsuCapitalLoss(&rsuOrder, profitOrLoss)
+			}
+		} else {
+			federalCapitalGainTaxAmount, err := rsuOrder.CalculateFederalCapitalGainTaxAmount(capitalGainTaxableAmount)
 			if err != nil {
 				utils.LogError("error occurred", err)
 				os.Exit(1)
 			}
-			rsuOrder.CapitalGainTaxPercent = capitalGainTaxPercent
-
-			marketPriceOnVestedStockPerShare, err := PromptAndValidate[float64]("What is the (FMV) market price on vested stock per share ($)? ")
+			stateCapitalGainTaxAmount, err := rsuOrder.CalculateStateCapitalGainTaxAmount(capitalGainTaxableAmount)
 			if err != nil {
 				utils.LogError("error occurred", err)
 				os.Exit(1)
 			}
-			rsuOrder.MarketValuePerShare = marketPriceOnVestedStockPerShare
-
-			capitalGainTaxableAmount := rsuOrder.CalculateProfitOrLossForCapitalGain()
-			if capitalGainTaxableAmount <= 0 {
-				utils.LogInfo("Sold at a loss ($%.2f). No Capital Gain.", capitalGainTaxableAmount)
-			} else {
-				capitalGainTaxAmount, _ = rsuOrder.CalculateCapitalGainTaxAmount(capitalGainTaxableAmount)
-				utils.LogInfo("Capital Gain tax amount: $%.2f", capitalGainTaxAmount)
-				effectiveProfit := profitOrLoss - capitalGainTaxAmount
-				utils.LogInfo("Effective profit: $%.2f", effectiveProfit)
+			capitalGainTaxAmount = federalCapitalGainTaxAmount + stateCapitalGainTaxAmount
+			utils.LogInfo("Capital Gain tax amount: $%s", capitalGainTaxAmount)
+			if rsuOrder.StateTaxModel != nil {
+				utils.LogInfo("  Federal: $%s", federalCapitalGainTaxAmount)
+				utils.LogInfo("  State: $%s", stateCapitalGainTaxAmount)
 			}
-		} else if profitOrLoss < 0 {
-			utils.LogInfo("Loss: $%.2f", profitOrLoss)
-		} else {
-			utils.LogInfo("Broke even: $%.2f", profitOrLoss)
+			if rsuOrder.ConsiderNetInvestmentIncomeTax {
+				netInvestmentIncomeTaxAmount := rsuOrder.CalculateNetInvestmentIncomeTaxAmount(capitalGainTaxableAmount)
+				utils.LogInfo("Net Investment Income Tax: $%s", netInvestmentIncomeTaxAmount)
+				capitalGainTaxAmount += netInvestmentIncomeTaxAmount
+			}
+			effectiveProfit := profitOrLoss - capitalGainTaxAmount
+			utils.LogInfo("Effective profit: $%s", effectiveProfit)
 		}
+	}
 
-		considerIncomeTaxOnVestedStock, err := PromptAndValidate[bool]("Calculate and deduct income tax on vested stock[Y/N]? ")
-		if err != nil {
-			utils.LogError("error occurred", err)
-			os.Exit(1)
-		}
-		if !considerIncomeTaxOnVestedStock {
-			return
-		}
+	// The FMV at vest is the cost basis reported even when the capital gain tax is not calculated
+	if rsuOrder.MarketValuePerShare <= 0 && flagSupplied("fmv") {
+		rsuOrder.MarketValuePerShare = promptPositiveOrFlag[types.Money]("fmv", "What is the (FMV) market price on vested stock per share ($)? ")
+	}
 
-		rsuOrder.ConsiderIncomeTaxOnVestedStock = true
-		incomeTaxIncurredWhenStockVested, err := PromptAndValidate[float64]("What is the income tax paid on vested stock\n(no. of shares traded * income tax %) ($)? ")
-		if err != nil {
-			utils.LogError("error occurred", err)
-			os.Exit(1)
-		}
+	considerIncomeTaxOnVestedStock := promptFeatureOrFlag("Calculate and deduct income tax on vested stock[Y/N]? ", "income-tax", "vested", "sell-to-cover")
+	if !considerIncomeTaxOnVestedStock {
+		return &rsuOrder
+	}
+
+	promptRsuIncomeTax(&rsuOrder)
+
+	incomeTaxPerShare, _ := rsuOrder.CalculateIncomeTaxPerShare()
+	utils.LogInfo("Income tax per share: $%s", incomeTaxPerShare)
+
+	totalIncomeTaxIncurred, err := rsuOrder.CalculateTotalIncomeTaxAmount()
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	utils.LogInfo("Total Income Tax: $%s", totalIncomeTaxIncurred)
+
+	effectiveProfitOrLoss := profitOrLoss - totalIncomeTaxIncurred
+	if deductCapitalGains && capitalGainTaxAmount > 0 {
+		effectiveProfitOrLoss -= capitalGainTaxAmount
+	}
+	utils.LogInfo("True profit/loss: $%s", effectiveProfitOrLoss)
+	return &rsuOrder
+}
+
+// writeRsuOrderReport writes the summary of the RSU order in the output format, other than text.
+func writeRsuOrderReport(rsuOrder *types.RsuOrder, outputFormat format.Format) {
+	if outputFormat == format.Text {
+		return
+	}
+	rsuOrderSummary, err := rsuOrder.CalculateRsuOrderSummary()
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	writeReport(outputFormat, format.NewRsuOrderReport(rsuOrderSummary))
+}
+
+// promptRsuCapitalGainTax prompts for the tax brackets or the flat rates the capital gain is taxed at.
+func promptRsuCapitalGainTax(rsuOrder *types.RsuOrder, stateCode string) {
+	rsuOrder.ConsiderCapitalGainTax = true
+	useTaxBrackets := promptFeatureOrFlag("Use progressive federal tax brackets instead of flat percentages[Y/N]? ", "tax-brackets")
+	if useTaxBrackets {
+		rsuOrder.TaxModel = tax.Federal
+	}
+	rsuOrder.StateTaxModel = promptStateTaxModel(stateCode)
+	rsuOrder.TaxProfile = promptTaxProfileIfNeeded(rsuOrder.TaxModel, rsuOrder.StateTaxModel)
+
+	considerHoldingPeriod := promptFeatureOrFlag("Determine short-term/long-term from the vest and sale dates[Y/N]? ", holdingPeriodFlags...)
+	if considerHoldingPeriod {
+		promptRsuHoldingPeriod(rsuOrder)
+	} else if rsuOrder.TaxModel == nil {
+		capitalGainTaxPercent := promptPercentOrFlag("cgt-percent", "What is the capital gain tax percent (Short-Term: 10%-35%) (Long-Term: 0%-20%)? ")
+		rsuOrder.CapitalGainTaxPercent = capitalGainTaxPercent
+	}
+}
+
+// promptRsuIncomeTax prompts for the income tax incurred at vest, simulated with sell-to-cover or entered by hand.
+func promptRsuIncomeTax(rsuOrder *types.RsuOrder) {
+	rsuOrder.ConsiderIncomeTaxOnVestedStock = true
+	simulateSellToCover := promptFeatureOrFlag("Simulate sell-to-cover at vest instead of entering the income tax paid[Y/N]? ", "sell-to-cover")
+	if simulateSellToCover {
+		promptSellToCover(rsuOrder)
+	} else {
+		incomeTaxIncurredWhenStockVested := promptOrFlag[types.Money]("income-tax", "What is the income tax paid on vested stock\n(no. of shares traded * income tax %) ($)? ")
 		rsuOrder.IncomeTaxIncurredWhenStockVested = incomeTaxIncurredWhenStockVested
 
-		var noOfStocksVested int
-		for {
-			noOfStocksVested, err = PromptAndValidate[int]("Number of stocks vested? ")
-			if err != nil {
-				utils.LogError("error occurred", err)
-				os.Exit(1)
-			}
-			if noOfStocksVested <= 0 {
-				utils.LogWarn("Number of stocks vested must be greater than 0")
-			} else {
-				break
-			}
-		}
-		rsuOrder.NumberOfStocksVested = noOfStocksVested
+		rsuOrder.NumberOfStocksVested = promptSharesVested()
+	}
+}
 
-		incomeTaxPerShare, _ := rsuOrder.CalculateIncomeTaxPerShare()
-		utils.LogInfo("Income tax per share: $%.2f", incomeTaxPerShare)
+// handleRsuCapitalLoss reports the tax saved by selling below the market value at vest, if the user wants it modeled.
+func handleRsuCapitalLoss(rsuOrder *types.RsuOrder, profitOrLoss types.Money) {
+	rsuOrder.ConsiderCapitalLoss, rsuOrder.OtherRealizedCapitalGains, rsuOrder.CapitalLossCarryforward = promptCapitalLoss()
+	if !rsuOrder.ConsiderCapitalLoss {
+		return
+	}
+	if rsuOrder.TaxModel == nil {
+		ordinaryIncomeTaxPercent := promptPercentOrFlag("ordinary-income-percent", "What is the ordinary income tax percent? ")
+		rsuOrder.OrdinaryIncomeTaxPercent = ordinaryIncomeTaxPercent
+	}
+	if !rsuOrder.ConsiderNetInvestmentIncomeTax && rsuOrder.TaxModel == nil && rsuOrder.StateTaxModel == nil {
+		rsuOrder.TaxProfile.FilingStatus = promptFilingStatus()
+	}
 
-		totalIncomeTaxIncurred, err := rsuOrder.CalculateTotalIncomeTaxAmount()
-		if err != nil {
-			utils.LogError("error occurred", err)
-			os.Exit(1)
-		}
-		utils.LogInfo("Total Income Tax: $%.2f", totalIncomeTaxIncurred)
+	rsuOrderSummary, err := rsuOrder.CalculateRsuOrderSummary()
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	logCapitalLoss(rsuOrder.CalculateProfitOrLossForCapitalGain(), rsuOrderSummary.CapitalGainTaxAmount,
+		rsuOrderSummary.CapitalLossCarryforwardAmount)
+	if rsuOrder.StateTaxModel != nil {
+		utils.LogInfo("  Federal: $%s", rsuOrderSummary.FederalCapitalGainTaxAmount)
+		utils.LogInfo("  State: $%s", rsuOrderSummary.StateCapitalGainTaxAmount)
+	}
+	utils.LogInfo("Effective profit: $%s", profitOrLoss-rsuOrderSummary.CapitalGainTaxAmount)
+}
+
+func promptRsuHoldingPeriod(rsuOrder *types.RsuOrder) {
+	rsuOrder.ConsiderHoldingPeriod = true
+
+	vestDate := promptOrFlag[time.Time]("vest-date", "What is the vest date (YYYY-MM-DD)? ")
+	rsuOrder.VestDate = vestDate
+
+	saleDate := promptOrFlag[time.Time]("sale-date", "What is the sale date (YYYY-MM-DD)? ")
+	rsuOrder.SaleDate = saleDate
+
+	utils.LogInfo("Holding period: %s (long-term from %s)",
+		rsuOrder.CalculateHoldingPeriod(), types.CalculateLongTermDate(rsuOrder.VestDate).Format(types.DateLayout))
+	// Progressive brackets pick the short-term/long-term rates themselves.
+	if rsuOrder.TaxModel != nil {
+		return
+	}
+
+	shortTermCapitalGainTaxPercent := promptPercentOrFlag("short-term-percent", "What is the short-term capital gain tax percent (10%-37%)? ")
+	rsuOrder.ShortTermCapitalGainTaxPercent = shortTermCapitalGainTaxPercent
+
+	longTermCapitalGainTaxPercent := promptPercentOrFlag("long-term-percent", "What is the long-term capital gain tax percent (0%-20%)? ")
+	rsuOrder.LongTermCapitalGainTaxPercent = longTermCapitalGainTaxPercent
+
+	utils.LogInfo("Capital gain tax percent: %.2f%%", rsuOrder.CalculateEffectiveCapitalGainTaxPercent())
+}
+
+func promptSellToCover(rsuOrder *types.RsuOrder) {
+	sellToCover := types.SellToCover{}
+
+	sellToCover.SharesVested = promptSharesVested()
 
-		effectiveProfitOrLoss := profitOrLoss - totalIncomeTaxIncurred
-		if deductCapitalGains && capitalGainTaxAmount > 0 {
-			effectiveProfitOrLoss -= capitalGainTaxAmount
+	// The FMV at vest is already known when capital gains were calculated.
+	if rsuOrder.MarketValuePerShare <= 0 {
+		marketValuePerShare := promptPositiveOrFlag[types.Money]("fmv", "What is the (FMV) market price on vested stock per share ($)? ")
+		rsuOrder.MarketValuePerShare = marketValuePerShare
+	}
+	sellToCover.MarketValuePerShare = rsuOrder.MarketValuePerShare
+
+	for {
+		withholdingRates := promptOrFlag[string]("withholding-rates", "What are the withholding rates (comma-separated %, blank for 22,6.2,1.45)? ")
+		if withholdingRates == "" {
+			sellToCover.WithholdingRatePercents = tax.DefaultSupplementalWithholdingPercents()
+			break
+		}
+		var err error
+		sellToCover.WithholdingRatePercents, err = types.ParseWithholdingRatePercents(withholdingRates)
+		if err == nil {
+			break
 		}
-		utils.LogInfo("True profit/loss: $%.2f", effectiveProfitOrLoss)
+		rejectFlag("withholding-rates", err)
+	}
+
+	salePricePerShare := promptOrFlag[types.Money]("cover-price", "What price were the covering shares sold at (0 to use the FMV) ($)? ")
+	sellToCover.SalePricePerShare = salePricePerShare
+
+	fractionalShares := promptFeatureOrFlag("Does the broker sell fractional shares to cover[Y/N]? ", "fractional")
+	sellToCover.FractionalShares = fractionalShares
+
+	sellToCoverSummary, err := sellToCover.CalculateSellToCoverSummary()
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
 	}
+	utils.LogInfo("Tax withheld (%.2f%% of $%s): $%s",
+		sellToCoverSummary.WithholdingPercent, sellToCoverSummary.VestValue, sellToCoverSummary.TaxWithheld)
+	utils.LogInfo("Shares sold to cover: %s", sellToCoverSummary.SharesSold)
+	utils.LogInfo("Cash refunded: $%s", sellToCoverSummary.CashRefunded)
+	utils.LogInfo("Net shares deposited: %s", sellToCoverSummary.NetSharesDeposited)
+	sellToCoverSummary.ApplyTo(rsuOrder)
+}
+
+// promptSharesVested prompts until a number of stocks vested greater than 0 is entered.
+func promptSharesVested() types.Shares {
+	return promptPositiveOrFlag[types.Shares]("vested", "Number of stocks vested? ")
 }
@@ -0,0 +1,90 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package cmd
+
+import (
+	"github.com/leogps/lunar/pkg/types"
+	"github.com/leogps/lunar/pkg/utils"
+	"github.com/spf13/pflag"
+	"log/slog"
+	"testing"
+)
+
+// parseInputFlags answers the prompts of the command from the args, as passed on the command line
+func parseInputFlags(t *testing.T, flags func() *pflag.FlagSet, args ...string) {
+	t.Helper()
+	utils.InitLogger(slog.LevelInfo)
+	inputFlags = flags()
+	t.Cleanup(func() {
+		inputFlags = nil
+	})
+	if err := inputFlags.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHandleRsu_CapitalLoss(t *testing.T) {
+	parseInputFlags(t, newRsuInputFlags, "--fmv", "100", "--selling-price", "90", "--shares", "10",
+		"--cgt-percent", "20", "--capital-loss", "--ordinary-income-percent", "24", "--filing-status", "single")
+	rsuOrder := handleRsu("")
+	rsuOrderSummary, err := rsuOrder.CalculateRsuOrderSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The $100 loss below the FMV at vest offsets ordinary income taxed at 24%
+	if !rsuOrder.ConsiderCapitalLoss || rsuOrderSummary.CapitalGainTaxAmount != types.NewMoney(-24) {
+		t.Errorf("expected a $24.00 capital loss tax benefit, got $%s", rsuOrderSummary.CapitalGainTaxAmount)
+	}
+}
+
+func TestHandleRsu_CapitalLossBelowCommission(t *testing.T) {
+	// The proceeds do not cover the commission, which still reaches the capital loss
+	parseInputFlags(t, newRsuInputFlags, "--fmv", "100", "--selling-price", "1", "--shares", "10", "--commission", "20",
+		"--cgt-percent", "20", "--capital-loss", "--ordinary-income-percent", "24", "--filing-status", "single")
+	rsuOrder := handleRsu("")
+	rsuOrderSummary, err := rsuOrder.CalculateRsuOrderSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rsuOrder.ConsiderCapitalLoss || rsuOrderSummary.CapitalGainTaxAmount >= 0 {
+		t.Errorf("expected a capital loss tax benefit, got $%s", rsuOrderSummary.CapitalGainTaxAmount)
+	}
+}
+
+func TestHandleRsu_VestedImpliesIncomeTax(t *testing.T) {
+	parseInputFlags(t, newRsuInputFlags, "--fmv", "100", "--selling-price", "120", "--shares", "10", "--vested", "10",
+		"--income-tax", "300", "--cgt-percent", "20")
+	rsuOrder := handleRsu("")
+	if !rsuOrder.ConsiderIncomeTaxOnVestedStock || rsuOrder.NumberOfStocksVested != types.NewShares(10) {
+		t.Errorf("expected 10 shares vested with the income tax deducted, got %s", rsuOrder.NumberOfStocksVested)
+	}
+}
+
+func TestHandleRsu_FmvWithoutCapitalGainTax(t *testing.T) {
+	parseInputFlags(t, newRsuInputFlags, "--fmv", "100", "--selling-price", "120", "--shares", "10")
+	rsuOrder := handleRsu("")
+	if rsuOrder.MarketValuePerShare != types.NewMoney(100) {
+		t.Errorf("expected the FMV at vest to be $100.00, got $%s", rsuOrder.MarketValuePerShare)
+	}
+}
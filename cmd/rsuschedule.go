@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/types"
+	"github.com/leogps/lunar/pkg/utils"
+	"github.com/spf13/cobra"
+	"log/slog"
+	"os"
+	"time"
+)
+
+func init() {
+	rsuCmd.AddCommand(rsuScheduleCmd)
+}
+
+var rsuScheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "project the vest schedule of an RSU grant interactively",
+	Long:  `project the vest schedule of an RSU grant interactively`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		silent, _ := cmd.Flags().GetBool("silent")
+		var level slog.Level
+		if silent {
+			level = slog.LevelInfo
+		} else {
+			level = slog.LevelDebug
+		}
+		utils.InitLogger(level)
+
+		handleRsuSchedule()
+	},
+}
+
+func handleRsuSchedule() {
+	rsuGrant := types.RsuGrant{}
+
+	grantDate, err := PromptAndValidate[time.Time]("What is the grant date (YYYY-MM-DD)? ")
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	rsuGrant.GrantDate = grantDate
+
+	totalUnits, err := PromptAndValidate[int]("How many units were granted? ")
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	rsuGrant.TotalUnits = totalUnits
+
+	for {
+		cadenceValue, err := PromptAndValidate[string]("What is the vest cadence (monthly/quarterly/custom)? ")
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		rsuGrant.Cadence, err = types.ParseVestCadence(cadenceValue)
+		if err == nil {
+			break
+		}
+		utils.LogWarn(err.Error())
+	}
+
+	if rsuGrant.Cadence == types.Custom {
+		for {
+			tranchesValue, err := PromptAndValidate[string]("What are the tranches (months from grant:percent, e.g. 12:25,24:25,36:25,48:25)? ")
+			if err != nil {
+				utils.LogError("error occurred", err)
+				os.Exit(1)
+			}
+			rsuGrant.Tranches, err = types.ParseVestTranches(tranchesValue)
+			if err == nil {
+				break
+			}
+			utils.LogWarn(err.Error())
+		}
+	} else {
+		vestingMonths, err := PromptAndValidate[int]("What is the vesting period in months (e.g. 48)? ")
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		rsuGrant.VestingMonths = vestingMonths
+	}
+
+	cliffMonths, err := PromptAndValidate[int]("What is the cliff in months (0 for none)? ")
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	rsuGrant.CliffMonths = cliffMonths
+
+	pricePerShare, err := PromptAndValidate[types.Money]("What is the projected price per share ($)? ")
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+
+	vestEvents, err := rsuGrant.CalculateVestSchedule()
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+
+	utils.LogInfo("%-12s %10s %14s %10s %10s", "Vest Date", "Units", "Gross Value", "Vested", "Unvested")
+	var totalGrossValue types.Money
+	for _, vestEvent := range vestEvents {
+		grossValue := vestEvent.CalculateGrossValue(pricePerShare)
+		totalGrossValue += grossValue
+		utils.LogInfo("%-12s %10d %14s %10d %10d", vestEvent.Date.Format(types.DateLayout), vestEvent.Units,
+			fmt.Sprintf("$%s", grossValue), vestEvent.CumulativeVestedUnits, vestEvent.UnvestedUnits)
+	}
+	utils.LogInfo("Total projected gross value: $%s", totalGrossValue)
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/tax"
+	"github.com/leogps/lunar/pkg/types"
+	"github.com/leogps/lunar/pkg/utils"
+	"github.com/spf13/cobra"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+func init() {
+	rsuTargetCmd.Flags().String("state", "", fmt.Sprintf("state to compute state tax for (%s)", strings.Join(tax.StateCodes(), ", ")))
+	rsuCmd.AddCommand(rsuTargetCmd)
+}
+
+var rsuTargetCmd = &cobra.Command{
+	Use:   "target",
+	Short: "solve for the RSU selling price that reaches an after-tax profit or net cash target interactively",
+	Long:  `solve for the RSU selling price that reaches an after-tax profit or net cash target interactively`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		silent, _ := cmd.Flags().GetBool("silent")
+		var level slog.Level
+		if silent {
+			level = slog.LevelInfo
+		} else {
+			level = slog.LevelDebug
+		}
+		utils.InitLogger(level)
+
+		stateCode, _ := cmd.Flags().GetString("state")
+		handleRsuTarget(stateCode)
+	},
+}
+
+func handleRsuTarget(stateCode string) {
+	rsuOrder := &types.RsuOrder{}
+
+	numberOfShares, err := PromptAndValidate[types.Shares]("How many shares sold? ")
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	rsuOrder.NumberOfSharesSold = numberOfShares
+
+	rsuOrder.ConsiderTransactionCommission, rsuOrder.CommissionPaidPerTransaction, rsuOrder.NumberOfTransactions =
+		promptTransactionCommission("Consider transaction commission[Y/N]? ")
+	promptRsuTaxes(rsuOrder, stateCode)
+
+	saleTarget, targetAmount := promptSaleTarget()
+	solverResult, err := rsuOrder.SolveSellingPriceForTarget(saleTarget, targetAmount)
+	if err != nil {
+		handleSolverError(err)
+		return
+	}
+	logSellingPriceSolution(saleTarget, targetAmount, solverResult)
+	rsuOrder.SellingPricePerShare = solverResult.Value
+	logRsuSaleOutcome(rsuOrder)
+}
+
+// promptRsuTaxes prompts for every tax on the sale up front, since the selling price is not known yet.
+func promptRsuTaxes(rsuOrder *types.RsuOrder, stateCode string) {
+	marketPriceOnVestedStockPerShare, err := PromptAndValidate[types.Money]("What is the (FMV) market price on vested stock per share ($)? ")
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	rsuOrder.MarketValuePerShare = marketPriceOnVestedStockPerShare
+
+	deductCapitalGains, err := PromptAndValidate[bool]("Do you want to calculate capital gain tax and deduct from the profit[Y/N]? ")
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	if deductCapitalGains {
+		promptRsuCapitalGainTax(rsuOrder, stateCode)
+		rsuOrder.ConsiderNetInvestmentIncomeTax, rsuOrder.ModifiedAdjustedGrossIncome = promptNetInvestmentIncomeTax()
+		if rsuOrder.ConsiderNetInvestmentIncomeTax && rsuOrder.TaxModel == nil && rsuOrder.StateTaxModel == nil {
+			rsuOrder.TaxProfile.FilingStatus = promptFilingStatus()
+		}
+	}
+
+	considerIncomeTaxOnVestedStock, err := PromptAndValidate[bool]("Calculate and deduct income tax on vested stock[Y/N]? ")
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	if considerIncomeTaxOnVestedStock {
+		promptRsuIncomeTax(rsuOrder)
+	}
+}
+
+// logRsuSaleOutcome logs the true profit and the net cash of the sale.
+func logRsuSaleOutcome(rsuOrder *types.RsuOrder) {
+	rsuOrderSummary, err := rsuOrder.CalculateRsuOrderSummary()
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	utils.LogInfo("Total selling price: $%s", rsuOrderSummary.TotalSellingPrice)
+	utils.LogInfo("True profit: $%s", rsuOrderSummary.TrueProfitOrLoss())
+	utils.LogInfo("Net cash: $%s", rsuOrderSummary.NetProceeds())
+}
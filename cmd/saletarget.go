@@ -0,0 +1,87 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package cmd
+
+import (
+	"errors"
+	"github.com/leogps/lunar/pkg/types"
+	"github.com/leogps/lunar/pkg/utils"
+	"os"
+)
+
+// promptSaleTarget prompts for what to solve for and the amount to reach.
+func promptSaleTarget() (types.SaleTarget, types.Money) {
+	var saleTarget types.SaleTarget
+	for {
+		saleTargetValue, err := PromptAndValidate[string]("Solve for an after-tax profit or net cash proceeds (profit/cash)? ")
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		saleTarget, err = types.ParseSaleTarget(saleTargetValue)
+		if err == nil {
+			break
+		}
+		utils.LogWarn(err.Error())
+	}
+	targetAmount, err := PromptAndValidate[types.Money]("What is the target amount ($)? ")
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	return saleTarget, targetAmount
+}
+
+// handleSolverError warns when the target cannot be reached and exits on any other error.
+func handleSolverError(err error) {
+	var noSolutionError *types.NoSolutionError
+	if errors.As(err, &noSolutionError) {
+		utils.LogWarn("The target cannot be reached: %s", err.Error())
+		return
+	}
+	utils.LogError("error occurred", err)
+	os.Exit(1)
+}
+
+// logSellingPriceSolution logs the selling price found for the target and how the solver converged.
+func logSellingPriceSolution(saleTarget types.SaleTarget, targetAmount types.Money, solverResult *types.SolverResult[types.Money]) {
+	utils.LogInfo("Selling price per share for $%s %s: $%s", targetAmount, saleTarget, solverResult.Value.StringPerShare())
+	utils.LogInfo("Solved in %d iterations, $%s over the target", solverResult.Iterations, solverResult.Residual)
+}
+
+// logSharesSoldSolution logs the number of shares to sell found for the target and how the solver converged.
+func logSharesSoldSolution(saleTarget types.SaleTarget, targetAmount types.Money, solverResult *types.SolverResult[types.Shares]) {
+	utils.LogInfo("Shares to sell for $%s %s: %s", targetAmount, saleTarget, solverResult.Value)
+	utils.LogInfo("Solved in %d iterations, $%s over the target", solverResult.Iterations, solverResult.Residual)
+}
+
+// promptFractionalShares asks whether shares can be sold in fractions of a share.
+func promptFractionalShares() bool {
+	fractionalShares, err := PromptAndValidate[bool]("Does the broker sell fractional shares[Y/N]? ")
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	return fractionalShares
+}
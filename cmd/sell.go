@@ -0,0 +1,335 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/tax"
+	"github.com/leogps/lunar/pkg/types"
+	"github.com/leogps/lunar/pkg/utils"
+	"github.com/spf13/cobra"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+func init() {
+	sellCmd.Flags().String("state", "", fmt.Sprintf("state to compute state tax for (%s)", strings.Join(tax.StateCodes(), ", ")))
+	addLedgerLotsFlag(sellCmd)
+	rootCmd.AddCommand(sellCmd)
+}
+
+var sellCmd = &cobra.Command{
+	Use:   "sell",
+	Short: "calculate profit/loss on a sale of shares from several ESPP/RSU lots interactively",
+	Long:  `calculate profit/loss on a sale of shares from several ESPP/RSU lots interactively`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		silent, _ := cmd.Flags().GetBool("silent")
+		var level slog.Level
+		if silent {
+			level = slog.LevelInfo
+		} else {
+			level = slog.LevelDebug
+		}
+		utils.InitLogger(level)
+
+		stateCode, _ := cmd.Flags().GetString("state")
+		ledgerLotIDs, _ := cmd.Flags().GetString("lots")
+		handleSell(stateCode, ledgerLotIDs)
+	},
+}
+
+func handleSell(stateCode string, ledgerLotIDs string) {
+	lotSale := promptLotSale(stateCode, ledgerLotIDs)
+
+	summary, err := lotSale.CalculateLotSaleSummary()
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	logLotSaleSummary(summary)
+}
+
+// promptLotSale prompts for the sale, the lots it is sold from and the taxes on it.
+// The lots are taken from the ledger instead when ledgerLotIDs picks them, see addLedgerLotsFlag.
+func promptLotSale(stateCode string, ledgerLotIDs string) *types.LotSale {
+	lotSale := &types.LotSale{}
+	if ledgerLotIDs != "" {
+		lotSale.Lots = loadLedgerLots(ledgerLotIDs)
+	}
+
+	sellingPrice, err := PromptAndValidate[types.Money]("What is the selling price per share ($)? ")
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	lotSale.SellingPricePerShare = sellingPrice
+
+	numberOfShares, err := PromptAndValidate[types.Shares]("How many shares sold? ")
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	lotSale.NumberOfSharesSold = numberOfShares
+
+	saleDate, err := PromptAndValidate[time.Time]("What is the sale date (YYYY-MM-DD)? ")
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	lotSale.SaleDate = saleDate
+
+	if lotSale.Lots == nil {
+		lotSale.Lots = promptLots("How many lots are the shares sold from? ")
+	}
+
+	considerWashSale, err := PromptAndValidate[bool]("Check for wash sales against the lots not sold[Y/N]? ")
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	lotSale.ConsiderWashSale = considerWashSale
+
+	promptLotSaleTaxes(lotSale, stateCode)
+	return lotSale
+}
+
+// promptLots prompts for the number of lots with question and then for each lot, in the order they are consumed.
+func promptLots(question string) []*types.Lot {
+	numberOfLots, err := PromptAndValidate[int](question)
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+
+	var lots []*types.Lot
+	for index := 1; index <= numberOfLots; index++ {
+		lot := &types.Lot{}
+		for {
+			sourceValue, err := PromptAndValidate[string](fmt.Sprintf("Lot %d: is it an ESPP or RSU lot (espp/rsu)? ", index))
+			if err != nil {
+				utils.LogError("error occurred", err)
+				os.Exit(1)
+			}
+			lot.Source, err = types.ParseOrderType(sourceValue)
+			if err == nil {
+				break
+			}
+			utils.LogWarn(err.Error())
+		}
+
+		acquisitionDatePrompt := "Lot %d: what is the vest date (YYYY-MM-DD)? "
+		basisPrompt := "Lot %d: what is the (FMV) market price per share at vest ($)? "
+		if lot.Source == types.Espp {
+			acquisitionDatePrompt = "Lot %d: what is the purchase date (YYYY-MM-DD)? "
+			basisPrompt = "Lot %d: what is the purchase price paid per share ($)? "
+		}
+		acquisitionDate, err := PromptAndValidate[time.Time](fmt.Sprintf(acquisitionDatePrompt, index))
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		lot.AcquisitionDate = acquisitionDate
+
+		quantity, err := PromptAndValidate[types.Shares](fmt.Sprintf("Lot %d: how many shares are in the lot? ", index))
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		lot.Quantity = quantity
+
+		basisPerShare, err := PromptAndValidate[types.Money](fmt.Sprintf(basisPrompt, index))
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		lot.BasisPerShare = basisPerShare
+
+		lots = append(lots, lot)
+	}
+	return lots
+}
+
+func promptLotSaleTaxes(lotSale *types.LotSale, stateCode string) {
+	lotSale.ConsiderTransactionCommission, lotSale.CommissionPaidPerTransaction, lotSale.NumberOfTransactions =
+		promptTransactionCommission("Consider transaction commission[Y/N]? ")
+
+	deductTaxes, err := PromptAndValidate[bool]("Do you want to calculate taxes and deduct from the profit[Y/N]? ")
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	if !deductTaxes {
+		return
+	}
+	lotSale.ConsiderCapitalGainTax = true
+	// The holding period of each lot is known from its acquisition date.
+	lotSale.ConsiderHoldingPeriod = true
+
+	useTaxBrackets, err := PromptAndValidate[bool]("Use progressive federal tax brackets instead of flat percentages[Y/N]? ")
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	if useTaxBrackets {
+		lotSale.TaxModel = tax.Federal
+	} else {
+		shortTermCapitalGainTaxPercent, err := PromptAndValidate[float64]("What is the short-term capital gain tax percent (10%-37%)? ")
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		lotSale.ShortTermCapitalGainTaxPercent = shortTermCapitalGainTaxPercent
+
+		longTermCapitalGainTaxPercent, err := PromptAndValidate[float64]("What is the long-term capital gain tax percent (0%-20%)? ")
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		lotSale.LongTermCapitalGainTaxPercent = longTermCapitalGainTaxPercent
+	}
+	lotSale.StateTaxModel = promptStateTaxModel(stateCode)
+
+	if hasEsppLot(lotSale.Lots) {
+		considerDisposition, err := PromptAndValidate[bool]("Classify ESPP lots as qualifying/disqualifying dispositions[Y/N]? ")
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		if considerDisposition {
+			promptLotDispositions(lotSale)
+		}
+	}
+
+	lotSale.ConsiderNetInvestmentIncomeTax, lotSale.ModifiedAdjustedGrossIncome = promptNetInvestmentIncomeTax()
+	lotSale.TaxProfile = promptTaxProfileIfNeeded(lotSale.TaxModel, lotSale.StateTaxModel)
+	if lotSale.ConsiderNetInvestmentIncomeTax && lotSale.TaxModel == nil && lotSale.StateTaxModel == nil {
+		lotSale.TaxProfile.FilingStatus = promptFilingStatus()
+	}
+}
+
+func hasEsppLot(lots []*types.Lot) bool {
+	for _, lot := range lots {
+		if lot.Source == types.Espp {
+			return true
+		}
+	}
+	return false
+}
+
+func promptLotDispositions(lotSale *types.LotSale) {
+	lotSale.ConsiderDisposition = true
+	promptEsppLotOfferings(lotSale.Lots)
+
+	// Progressive brackets compute the ordinary income tax themselves.
+	if lotSale.TaxModel != nil {
+		return
+	}
+	ordinaryIncomeTaxPercent, err := PromptAndValidate[float64]("What is the ordinary income tax percent? ")
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	lotSale.OrdinaryIncomeTaxPercent = ordinaryIncomeTaxPercent
+}
+
+// promptEsppLotOfferings prompts for the offering details that classify the disposition of the ESPP lots
+func promptEsppLotOfferings(lots []*types.Lot) {
+	for index, lot := range lots {
+		// Ledger lots already hold their offering details
+		if lot.Source != types.Espp || !lot.OfferingDate.IsZero() {
+			continue
+		}
+		discountPercent, err := PromptAndValidate[float64](fmt.Sprintf("Lot %d: what is the ESPP discount percent (%%)? ", index+1))
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		lot.DiscountPercent = discountPercent
+
+		offeringDate, err := PromptAndValidate[time.Time](fmt.Sprintf("Lot %d: what is the offering date (YYYY-MM-DD)? ", index+1))
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		lot.OfferingDate = offeringDate
+
+		offeringDateMarketValue, err := PromptAndValidate[types.Money](fmt.Sprintf("Lot %d: what is the (FMV) market price per share on the offering date ($)? ", index+1))
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		lot.OfferingDateMarketValuePerShare = offeringDateMarketValue
+
+		purchaseDateMarketValue, err := PromptAndValidate[types.Money](fmt.Sprintf("Lot %d: what is the (FMV) market price per share on the purchase date ($)? ", index+1))
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		lot.PurchaseDateMarketValuePerShare = purchaseDateMarketValue
+	}
+}
+
+func logLotSaleSummary(summary *types.LotSaleSummary) {
+	for index, lotResult := range summary.LotResults {
+		utils.LogInfo("Lot %d (%s acquired %s, %s): %s shares", index+1, lotResult.Lot.Source,
+			lotResult.Lot.AcquisitionDate.Format(types.DateLayout), lotResult.HoldingPeriod, lotResult.SharesSold)
+		utils.LogInfo("  Proceeds: $%s, Cost basis: $%s, Commission: $%s",
+			lotResult.Proceeds, lotResult.CostBasis, lotResult.Commission)
+		if lotResult.EsppOrderSummary != nil && summary.LotSale.ConsiderDisposition {
+			utils.LogInfo("  %s disposition, Ordinary income: $%s, Ordinary income tax: $%s",
+				lotResult.EsppOrderSummary.DispositionType, lotResult.OrdinaryIncomeAmount, lotResult.OrdinaryIncomeTaxAmount)
+		}
+		utils.LogInfo("  Capital gain: $%s, Capital gain tax: $%s", lotResult.CapitalGainAmount, lotResult.CapitalGainTaxAmount)
+		if summary.LotSale.ConsiderNetInvestmentIncomeTax {
+			utils.LogInfo("  Net investment income tax: $%s", lotResult.NetInvestmentIncomeTaxAmount)
+		}
+		if lotResult.WashSaleDisallowedLoss != 0 {
+			utils.LogInfo("  Wash sale disallowed loss: $%s", lotResult.WashSaleDisallowedLoss)
+		}
+		utils.LogInfo("  Profit/Loss: $%s", lotResult.ProfitOrLoss())
+	}
+	for _, washSale := range summary.WashSales {
+		utils.LogWarn("Wash sale: $%s loss carried into %s shares of lot %d (adjusted basis $%s/share)",
+			washSale.DisallowedLoss, washSale.ReplacementShares, lotNumber(summary.LotSale.Lots, washSale.ReplacementLot),
+			washSale.AdjustedBasisPerShare)
+	}
+	if len(summary.WashSales) > 0 {
+		for _, heldShares := range summary.HeldShares {
+			utils.LogInfo("Held: %s shares of lot %d at $%s/share", heldShares.Quantity,
+				lotNumber(summary.LotSale.Lots, heldShares.Lot), heldShares.BasisPerShare)
+		}
+	}
+	utils.LogInfo("Total proceeds: $%s", summary.TotalProceeds)
+	utils.LogInfo("Total cost basis: $%s", summary.TotalCostBasis)
+	utils.LogInfo("Total commission: $%s", summary.TotalCommission)
+	utils.LogInfo("Total ordinary income: $%s", summary.TotalOrdinaryIncomeAmount)
+	utils.LogInfo("Total capital gain: $%s", summary.TotalCapitalGainAmount)
+	if summary.LotSale.ConsiderWashSale {
+		utils.LogInfo("Total wash sale disallowed loss: $%s", summary.TotalWashSaleDisallowedLoss)
+	}
+	utils.LogInfo("Total tax: $%s", summary.TotalTaxAmount())
+	utils.LogInfo("True profit/loss: $%s", summary.TrueProfitOrLoss())
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/tax"
+	"github.com/leogps/lunar/pkg/types"
+	"github.com/leogps/lunar/pkg/utils"
+	"github.com/spf13/cobra"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	sellPlanCmd.Flags().String("state", "", fmt.Sprintf("state to compute state tax for (%s)", strings.Join(tax.StateCodes(), ", ")))
+	addLedgerLotsFlag(sellPlanCmd)
+	sellCmd.AddCommand(sellPlanCmd)
+}
+
+var sellPlanCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "compare the after-tax outcome of lot selection strategies (FIFO, LIFO, HIFO, lowest tax, specific ID) interactively",
+	Long:  `compare the after-tax outcome of lot selection strategies (FIFO, LIFO, HIFO, lowest tax, specific ID) interactively`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		silent, _ := cmd.Flags().GetBool("silent")
+		var level slog.Level
+		if silent {
+			level = slog.LevelInfo
+		} else {
+			level = slog.LevelDebug
+		}
+		utils.InitLogger(level)
+
+		stateCode, _ := cmd.Flags().GetString("state")
+		ledgerLotIDs, _ := cmd.Flags().GetString("lots")
+		handleSellPlan(stateCode, ledgerLotIDs)
+	},
+}
+
+func handleSellPlan(stateCode string, ledgerLotIDs string) {
+	lotSale := promptLotSale(stateCode, ledgerLotIDs)
+
+	var lotSalePlans []*types.LotSalePlan
+	for {
+		specificLotNumbers, err := promptSpecificLotNumbers()
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		lotSalePlans, err = lotSale.CalculateLotSalePlans(specificLotNumbers)
+		if err == nil {
+			break
+		}
+		utils.LogWarn(err.Error())
+	}
+	logLotSalePlans(lotSale.Lots, lotSalePlans)
+}
+
+// promptSpecificLotNumbers prompts for the lots to sell by specific identification; nil when skipped.
+func promptSpecificLotNumbers() ([]int, error) {
+	for {
+		lotNumbersValue, err := PromptAndValidate[string]("Which lots to sell for specific identification, in order (e.g. 3,1; leave blank to skip)? ")
+		if err != nil {
+			return nil, err
+		}
+		if lotNumbersValue == "" {
+			return nil, nil
+		}
+		lotNumbers, err := types.ParseLotNumbers(lotNumbersValue)
+		if err == nil {
+			return lotNumbers, nil
+		}
+		utils.LogWarn(err.Error())
+	}
+}
+
+// logLotSalePlans compares the plans side by side, numbering the lots as they were entered.
+func logLotSalePlans(lots []*types.Lot, lotSalePlans []*types.LotSalePlan) {
+	utils.LogInfo("%-12s %-12s %14s %14s %14s %14s", "Strategy", "Lots", "Capital Gain", "Tax", "Net Proceeds", "Profit/Loss")
+	for _, lotSalePlan := range lotSalePlans {
+		summary := lotSalePlan.Summary
+		var lotNumbers []string
+		for _, lotResult := range summary.LotResults {
+			lotNumbers = append(lotNumbers, strconv.Itoa(lotNumber(lots, lotResult.Lot)))
+		}
+		utils.LogInfo("%-12s %-12s %14s %14s %14s %14s", lotSalePlan.Strategy, strings.Join(lotNumbers, ","),
+			fmt.Sprintf("$%s", summary.TotalCapitalGainAmount),
+			fmt.Sprintf("$%s", summary.TotalTaxAmount()),
+			fmt.Sprintf("$%s", summary.NetProceeds()),
+			fmt.Sprintf("$%s", summary.TrueProfitOrLoss()))
+	}
+	if best := types.BestLotSalePlan(lotSalePlans); best != nil {
+		utils.LogInfo("Best strategy: %s (net proceeds $%s)", best.Strategy, best.Summary.NetProceeds())
+	}
+}
+
+func lotNumber(lots []*types.Lot, lot *types.Lot) int {
+	for index, candidate := range lots {
+		if candidate == lot {
+			return index + 1
+		}
+	}
+	return 0
+}
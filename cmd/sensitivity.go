@@ -0,0 +1,116 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/types"
+	"github.com/leogps/lunar/pkg/utils"
+	"os"
+	"strings"
+)
+
+// promptSellingPriceRange prompts for the selling prices on the rows of a sensitivity grid.
+func promptSellingPriceRange() []types.Money {
+	for {
+		start, err := PromptAndValidate[types.Money]("What is the lowest selling price per share ($)? ")
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		end, err := PromptAndValidate[types.Money]("What is the highest selling price per share ($)? ")
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		step, err := PromptAndValidate[types.Money]("What is the selling price step ($)? ")
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		sellingPrices, err := types.SensitivityRange(start, end, step)
+		if err == nil {
+			return sellingPrices
+		}
+		utils.LogWarn(err.Error())
+	}
+}
+
+// promptSensitivityColumns prompts for the variable on the columns of a sensitivity grid and its values.
+func promptSensitivityColumns(question string) (types.SensitivityVariable, []float64) {
+	var variable types.SensitivityVariable
+	for {
+		variableValue, err := PromptAndValidate[string](question)
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		variable, err = types.ParseSensitivityVariable(variableValue)
+		if err == nil {
+			break
+		}
+		utils.LogWarn(err.Error())
+	}
+	for {
+		start, err := PromptAndValidate[float64](fmt.Sprintf("What is the lowest %s? ", variable))
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		end, err := PromptAndValidate[float64](fmt.Sprintf("What is the highest %s? ", variable))
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		step, err := PromptAndValidate[float64](fmt.Sprintf("What is the %s step? ", variable))
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		values, err := types.SensitivityRange(start, end, step)
+		if err == nil {
+			return variable, values
+		}
+		utils.LogWarn(err.Error())
+	}
+}
+
+// logSensitivityGrid logs the true profit or loss with a row per selling price and a column per value.
+func logSensitivityGrid(sensitivityGrid *types.SensitivityGrid) {
+	utils.LogInfo("True profit/loss by selling price (rows) and %s (columns)", sensitivityGrid.Variable)
+	var header strings.Builder
+	header.WriteString(fmt.Sprintf("%-14s", "Price"))
+	for _, value := range sensitivityGrid.Values {
+		header.WriteString(fmt.Sprintf(" %14s", sensitivityGrid.Variable.Format(value)))
+	}
+	utils.LogInfo("%s", header.String())
+	for row, sellingPrice := range sensitivityGrid.SellingPrices {
+		var line strings.Builder
+		line.WriteString(fmt.Sprintf("%-14s", fmt.Sprintf("$%s", sellingPrice)))
+		for _, trueProfitOrLoss := range sensitivityGrid.TrueProfitOrLoss[row] {
+			line.WriteString(fmt.Sprintf(" %14s", fmt.Sprintf("$%s", trueProfitOrLoss)))
+		}
+		utils.LogInfo("%s", line.String())
+	}
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/tax"
+	"github.com/leogps/lunar/pkg/types"
+	"github.com/leogps/lunar/pkg/utils"
+	"os"
+	"strings"
+)
+
+// promptStateTaxModel returns the tax model for stateCode, prompting for the state when stateCode is empty.
+// Returns nil when no state is chosen.
+func promptStateTaxModel(stateCode string) tax.Model {
+	for {
+		if stateCode == "" {
+			// Flags leave out the state unless --state is passed
+			if flagInputMode() {
+				return nil
+			}
+			var err error
+			stateCode, err = PromptAndValidate[string](fmt.Sprintf("Which state do you file taxes in (%s; leave blank to skip)? ",
+				strings.Join(tax.StateCodes(), "/")))
+			if err != nil {
+				utils.LogError("error occurred", err)
+				os.Exit(1)
+			}
+			if stateCode == "" {
+				return nil
+			}
+		}
+		stateTaxModel, err := tax.LookupState(stateCode)
+		if err == nil {
+			return stateTaxModel
+		}
+		utils.LogWarn(err.Error())
+		stateCode = ""
+	}
+}
+
+// promptTaxProfile prompts for the filing status, tax year and other income used by progressive tax brackets.
+// The tax year is validated against each of the given models.
+func promptTaxProfile(models ...tax.Model) tax.Profile {
+	filingStatus := promptFilingStatus()
+
+	var taxYear int
+	for {
+		taxYear = promptOrFlag[int]("tax-year", "What is the tax year? ")
+		err := validateTaxProfile(tax.Profile{FilingStatus: filingStatus, TaxYear: taxYear}, models...)
+		if err == nil {
+			break
+		}
+		rejectFlag("tax-year", err)
+	}
+
+	otherIncome := promptOrFlag[float64]("other-income", "What is your other taxable income (salary etc. after deductions) ($)? ")
+
+	return tax.Profile{
+		FilingStatus: filingStatus,
+		TaxYear:      taxYear,
+		OtherIncome:  otherIncome,
+	}
+}
+
+// promptFilingStatus prompts until a valid filing status is entered.
+func promptFilingStatus() tax.FilingStatus {
+	for {
+		filingStatusValue := promptOrFlag[string]("filing-status", "What is your filing status (single/mfj/mfs/hoh)? ")
+		filingStatus, err := tax.ParseFilingStatus(filingStatusValue)
+		if err == nil {
+			return filingStatus
+		}
+		rejectFlag("filing-status", err)
+	}
+}
+
+// promptNetInvestmentIncomeTax asks whether the 3.8% NIIT applies and, if so, for the MAGI estimate.
+func promptNetInvestmentIncomeTax() (bool, types.Money) {
+	considerNetInvestmentIncomeTax := promptFeatureOrFlag("Apply the 3.8% Net Investment Income Tax (NIIT) on the capital gain[Y/N]? ", "magi")
+	if !considerNetInvestmentIncomeTax {
+		return false, 0
+	}
+	modifiedAdjustedGrossIncome := promptOrFlag[types.Money]("magi", "What is your estimated MAGI excluding this sale ($)? ")
+	return true, modifiedAdjustedGrossIncome
+}
+
+// promptTaxProfileIfNeeded prompts for the tax profile only when one of the models is set.
+func promptTaxProfileIfNeeded(models ...tax.Model) tax.Profile {
+	var selectedModels []tax.Model
+	for _, model := range models {
+		if model != nil {
+			selectedModels = append(selectedModels, model)
+		}
+	}
+	if len(selectedModels) == 0 {
+		return tax.Profile{}
+	}
+	return promptTaxProfile(selectedModels...)
+}
+
+// validateTaxProfile checks that each model supports the profile's tax year and filing status.
+func validateTaxProfile(profile tax.Profile, models ...tax.Model) error {
+	for _, model := range models {
+		for _, incomeType := range []tax.IncomeType{tax.OrdinaryIncome, tax.LongTermCapitalGain} {
+			if _, err := model.CalculateTax(profile, incomeType, 1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -48,6 +48,6 @@ var uiCmd = &cobra.Command{
 		}
 		utils.InitLogger(level)
 
-		_ = ui.StartApp()
+		_ = ui.StartApp(profileDefaults)
 	},
 }
@@ -25,59 +25,125 @@ package cmd
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"github.com/leogps/lunar/pkg/types"
+	"io"
 	"os"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// stdinReader is shared by every prompt so input piped ahead of the prompts is not lost to a discarded buffer.
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// promptOutput is where the prompts are written, stdout unless the results are written there instead.
+var promptOutput io.Writer = os.Stdout
+
 // PromptAndValidate prompts the user for input and validates it based on the type.
+// It returns an error once the input is exhausted, e.g. when stdin is closed or redirected from an empty file.
 func PromptAndValidate[T any](prompt string) (T, error) {
+	return PromptWithDefault[T](prompt, "")
+}
+
+// PromptWithDefault prompts like PromptAndValidate, showing defaultValue in brackets and using it when the input
+// is left blank. An empty defaultValue means there is no default.
+func PromptWithDefault[T any](prompt string, defaultValue string) (T, error) {
 	var zero T // zero value for T, to return on error
-	reader := bufio.NewReader(os.Stdin)
 
+	if defaultValue != "" {
+		prompt = fmt.Sprintf("%s [%s] ", strings.TrimRight(prompt, " "), defaultValue)
+	}
 	for {
-		fmt.Print(prompt)
-		input, _ := reader.ReadString('\n')
+		fmt.Fprint(promptOutput, prompt)
+		input, err := stdinReader.ReadString('\n')
+		if err != nil && input == "" {
+			fmt.Fprintln(promptOutput)
+			return zero, fmt.Errorf("no input left to answer %q: %w", strings.TrimSpace(prompt), err)
+		}
+
 		input = strings.TrimSpace(input)
+		if input == "" && defaultValue != "" {
+			input = defaultValue
+		}
+		value, err := parseInput[T](input)
+		var invalidInputError *invalidInputError
+		if errors.As(err, &invalidInputError) {
+			fmt.Fprintf(promptOutput, "Invalid input. Expected %s\n", invalidInputError.expected)
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(promptOutput, "Unsupported type: %s\n", reflect.TypeOf(zero).Kind())
+			return zero, err
+		}
+		return value, nil
+	}
+}
+
+// invalidInputError describes the input that was expected instead.
+type invalidInputError struct {
+	expected string
+}
 
-		// Determine the type of T and parse accordingly
-		switch any(zero).(type) {
-		case int:
-			value, err := strconv.Atoi(input)
-			if err != nil {
-				fmt.Println("Invalid input. Expected an int value")
-				continue
-			}
-			return any(value).(T), nil
-
-		case float64:
-			value, err := strconv.ParseFloat(input, 64)
-			if err != nil {
-				fmt.Println("Invalid input. Expected dollar amount")
-				continue
-			}
-			return any(value).(T), nil
-
-		case string:
-			return any(input).(T), nil
-
-		case bool:
-			inputNormalized := strings.ToLower(input)
-			// Check for acceptable boolean values
-			if inputNormalized == "true" || inputNormalized == "yes" || inputNormalized == "y" {
-				return any(true).(T), nil
-			} else if inputNormalized == "false" || inputNormalized == "no" || inputNormalized == "n" {
-				return any(false).(T), nil
-			} else {
-				fmt.Println("Invalid input. Expected one of: 'true', 'false', 'yes', 'no', 'y', 'n')")
-				continue
-			}
-
-		default:
-			fmt.Printf("Unsupported type: %s\n", reflect.TypeOf(zero).Kind())
-			return zero, fmt.Errorf("unsupported type")
+func (i *invalidInputError) Error() string {
+	return fmt.Sprintf("expected %s", i.expected)
+}
+
+// parseInput parses the input, typed at a prompt or passed as a flag, based on the type.
+func parseInput[T any](input string) (T, error) {
+	var zero T
+	switch any(zero).(type) {
+	case int:
+		value, err := strconv.Atoi(input)
+		if err != nil {
+			return zero, &invalidInputError{expected: "an int value"}
+		}
+		return any(value).(T), nil
+
+	case float64:
+		value, err := strconv.ParseFloat(input, 64)
+		if err != nil {
+			return zero, &invalidInputError{expected: "a number"}
+		}
+		return any(value).(T), nil
+
+	case types.Money:
+		value, err := types.ParseMoney(input)
+		if err != nil {
+			return zero, &invalidInputError{expected: "dollar amount"}
+		}
+		return any(value).(T), nil
+
+	case types.Shares:
+		value, err := types.ParseShares(input)
+		if err != nil {
+			return zero, &invalidInputError{expected: "a number of shares"}
+		}
+		return any(value).(T), nil
+
+	case string:
+		return any(input).(T), nil
+
+	case time.Time:
+		value, err := time.Parse(types.DateLayout, input)
+		if err != nil {
+			return zero, &invalidInputError{expected: "a date in YYYY-MM-DD format"}
 		}
+		return any(value).(T), nil
+
+	case bool:
+		inputNormalized := strings.ToLower(input)
+		// Check for acceptable boolean values
+		if inputNormalized == "true" || inputNormalized == "yes" || inputNormalized == "y" {
+			return any(true).(T), nil
+		} else if inputNormalized == "false" || inputNormalized == "no" || inputNormalized == "n" {
+			return any(false).(T), nil
+		}
+		return zero, &invalidInputError{expected: "one of: 'true', 'false', 'yes', 'no', 'y', 'n')"}
+
+	default:
+		return zero, fmt.Errorf("unsupported type")
 	}
 }
@@ -0,0 +1,139 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/types"
+	"github.com/leogps/lunar/pkg/utils"
+	"github.com/spf13/cobra"
+	"log/slog"
+	"os"
+	"time"
+)
+
+func init() {
+	rootCmd.AddCommand(washSaleCmd)
+}
+
+var washSaleCmd = &cobra.Command{
+	Use:   "washsale",
+	Short: "report wash sales over a history of ESPP purchases, RSU vests and sales interactively",
+	Long:  `report wash sales over a history of ESPP purchases, RSU vests and sales interactively`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		silent, _ := cmd.Flags().GetBool("silent")
+		var level slog.Level
+		if silent {
+			level = slog.LevelInfo
+		} else {
+			level = slog.LevelDebug
+		}
+		utils.InitLogger(level)
+
+		handleWashSale()
+	},
+}
+
+func handleWashSale() {
+	transactionHistory := &types.TransactionHistory{}
+	transactionHistory.Lots = promptLots("How many lots (ESPP purchases and RSU vests) were acquired? ")
+	if hasEsppLot(transactionHistory.Lots) {
+		considerDisposition, err := PromptAndValidate[bool]("Add the ordinary income of ESPP dispositions to the basis of the shares sold[Y/N]? ")
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		if considerDisposition {
+			transactionHistory.ConsiderDisposition = true
+			promptEsppLotOfferings(transactionHistory.Lots)
+		}
+	}
+
+	numberOfSales, err := PromptAndValidate[int]("How many sales were made? ")
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	for index := 1; index <= numberOfSales; index++ {
+		sale := &types.Sale{}
+		saleDate, err := PromptAndValidate[time.Time](fmt.Sprintf("Sale %d: what is the sale date (YYYY-MM-DD)? ", index))
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		sale.Date = saleDate
+
+		quantity, err := PromptAndValidate[types.Shares](fmt.Sprintf("Sale %d: how many shares were sold? ", index))
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		sale.Quantity = quantity
+
+		pricePerShare, err := PromptAndValidate[types.Money](fmt.Sprintf("Sale %d: what is the selling price per share ($)? ", index))
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		sale.PricePerShare = pricePerShare
+
+		transactionHistory.Sales = append(transactionHistory.Sales, sale)
+	}
+
+	washSaleReport, err := transactionHistory.CalculateWashSaleReport()
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	logWashSaleReport(transactionHistory.Lots, washSaleReport)
+}
+
+func logWashSaleReport(lots []*types.Lot, washSaleReport *types.WashSaleReport) {
+	utils.LogInfo("%-12s %5s %8s %12s %14s %14s", "Sale Date", "Lot", "Shares", "Basis", "Gain/Loss", "Disallowed")
+	for _, disposition := range washSaleReport.Dispositions {
+		utils.LogInfo("%-12s %5d %8s %12s %14s %14s", disposition.Sale.Date.Format(types.DateLayout),
+			lotNumber(lots, disposition.Lot), disposition.SharesSold,
+			fmt.Sprintf("$%s", disposition.BasisPerShare),
+			fmt.Sprintf("$%s", disposition.GainOrLoss),
+			fmt.Sprintf("$%s", disposition.DisallowedLoss))
+	}
+	for _, washSale := range washSaleReport.WashSales {
+		utils.LogWarn("Wash sale on %s: $%s loss carried into %s shares of lot %d (adjusted basis $%s/share)",
+			washSale.Disposition.Sale.Date.Format(types.DateLayout), washSale.DisallowedLoss, washSale.ReplacementShares,
+			lotNumber(lots, washSale.ReplacementLot), washSale.AdjustedBasisPerShare)
+	}
+	for _, heldShares := range washSaleReport.HeldShares {
+		utils.LogInfo("Held: %s shares of lot %d at $%s/share", heldShares.Quantity, lotNumber(lots, heldShares.Lot),
+			heldShares.BasisPerShare)
+	}
+	utils.LogInfo("Total gain/loss: $%s", washSaleReport.TotalGainOrLoss)
+	utils.LogInfo("Total disallowed loss: $%s", washSaleReport.TotalDisallowedLoss)
+	utils.LogInfo("Allowed gain/loss: $%s", washSaleReport.AllowedGainOrLoss())
+}
+
+// logWashSaleWarning warns that a loss may not be deductible when shares were acquired around the sale.
+func logWashSaleWarning() {
+	utils.LogWarn("The loss is disallowed under the wash sale rule if shares were acquired (vest or ESPP purchase) " +
+		"within 30 days before or after the sale. Run `lunar washsale` to check.")
+}
@@ -0,0 +1,149 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/tax"
+	"github.com/leogps/lunar/pkg/types"
+	"github.com/leogps/lunar/pkg/utils"
+	"github.com/spf13/cobra"
+	"log/slog"
+	"os"
+	"time"
+)
+
+func init() {
+	rootCmd.AddCommand(withholdingCmd)
+}
+
+var withholdingCmd = &cobra.Command{
+	Use:   "withholding",
+	Short: "estimate the tax withholding shortfall on RSU and ESPP income interactively",
+	Long:  `estimate the tax withholding shortfall on RSU and ESPP income interactively`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		silent, _ := cmd.Flags().GetBool("silent")
+		var level slog.Level
+		if silent {
+			level = slog.LevelInfo
+		} else {
+			level = slog.LevelDebug
+		}
+		utils.InitLogger(level)
+
+		handleWithholding()
+	},
+}
+
+func handleWithholding() {
+	withholdingEstimate := types.WithholdingEstimate{}
+
+	useTaxBrackets, err := PromptAndValidate[bool]("Use progressive federal tax brackets instead of a flat marginal rate[Y/N]? ")
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	if useTaxBrackets {
+		withholdingEstimate.TaxModel = tax.Federal
+		withholdingEstimate.TaxProfile = promptTaxProfile(tax.Federal)
+		withholdingEstimate.Salary = types.NewMoney(withholdingEstimate.TaxProfile.OtherIncome)
+	} else {
+		marginalTaxPercent, err := PromptAndValidate[float64]("What is your marginal federal tax percent (10%-37%)? ")
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		withholdingEstimate.MarginalTaxPercent = marginalTaxPercent
+	}
+
+	numberOfVests, err := PromptAndValidate[int]("How many RSU vests this year? ")
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	for index := 1; index <= numberOfVests; index++ {
+		rsuVest := &types.RsuOrder{}
+
+		vestDate, err := PromptAndValidate[time.Time](fmt.Sprintf("Vest %d: what is the vest date (YYYY-MM-DD)? ", index))
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		rsuVest.VestDate = vestDate
+
+		noOfStocksVested, err := PromptAndValidate[types.Shares](fmt.Sprintf("Vest %d: number of stocks vested? ", index))
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		rsuVest.NumberOfStocksVested = noOfStocksVested
+
+		marketValuePerShare, err := PromptAndValidate[types.Money](fmt.Sprintf("Vest %d: what is the (FMV) market price per share at vest ($)? ", index))
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		rsuVest.MarketValuePerShare = marketValuePerShare
+
+		taxWithheld, err := PromptWithDefault[types.Money](fmt.Sprintf("Vest %d: federal income tax withheld on the vest, 0 to estimate it at the supplemental rate ($)? ", index), "0")
+		if err != nil {
+			utils.LogError("error occurred", err)
+			os.Exit(1)
+		}
+		rsuVest.ConsiderIncomeTaxOnVestedStock = taxWithheld != 0
+		rsuVest.IncomeTaxIncurredWhenStockVested = taxWithheld
+
+		withholdingEstimate.RsuVests = append(withholdingEstimate.RsuVests, rsuVest)
+	}
+
+	esppDisqualifyingIncome, err := PromptAndValidate[types.Money]("What is the ordinary income from disqualifying ESPP sales this year ($)? ")
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	withholdingEstimate.EsppDisqualifyingIncome = esppDisqualifyingIncome
+
+	summary, err := withholdingEstimate.CalculateWithholdingEstimateSummary()
+	if err != nil {
+		utils.LogError("error occurred", err)
+		os.Exit(1)
+	}
+	for _, vestWithholding := range summary.VestWithholdings {
+		estimated := ""
+		if vestWithholding.Estimated {
+			estimated = " (estimated)"
+		}
+		utils.LogInfo("Vest %s: $%s withheld on $%s%s", vestWithholding.VestDate.Format(types.DateLayout),
+			vestWithholding.TaxWithheld, vestWithholding.Income, estimated)
+	}
+	utils.LogInfo("Supplemental income (RSU $%s + ESPP $%s): $%s",
+		summary.RsuIncome, summary.EsppDisqualifyingIncome, summary.SupplementalIncome)
+	utils.LogInfo("Federal tax withheld: $%s (%.2f%%)", summary.TaxWithheld, summary.EffectiveWithholdingPercent())
+	utils.LogInfo("Federal tax owed: $%s (marginal rate %.2f%%)", summary.TaxOwed, summary.MarginalTaxPercent)
+	if shortfall := summary.Shortfall(); shortfall > 0 {
+		utils.LogInfo("Projected shortfall: $%s", shortfall)
+	} else {
+		utils.LogInfo("Projected over-withholding: $%s", -shortfall)
+	}
+}
@@ -0,0 +1,179 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package batch
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/types"
+	"strings"
+)
+
+// Result is the outcome of one record of a batch: the summary of its order, or the error that kept it from
+// being calculated.
+type Result struct {
+	// Number is the position of the record in the batch, from 1.
+	Number    int
+	Label     string
+	OrderType types.OrderType
+
+	EsppOrderSummary *types.EsppOrderSummary
+	RsuOrderSummary  *types.RsuOrderSummary
+	Err              error
+}
+
+// SharesSold returns the number of shares sold by the order.
+func (r *Result) SharesSold() types.Shares {
+	if r.EsppOrderSummary != nil {
+		return r.EsppOrderSummary.EsppOrder.NumberOfSharesSold
+	}
+	return r.RsuOrderSummary.RsuOrder.NumberOfSharesSold
+}
+
+// SellingPricePerShare returns the selling price per share of the order.
+func (r *Result) SellingPricePerShare() types.Money {
+	if r.EsppOrderSummary != nil {
+		return r.EsppOrderSummary.EsppOrder.SellingPricePerShare
+	}
+	return r.RsuOrderSummary.RsuOrder.SellingPricePerShare
+}
+
+// TotalSellingPrice returns the total selling price of the order.
+func (r *Result) TotalSellingPrice() types.Money {
+	if r.EsppOrderSummary != nil {
+		return r.EsppOrderSummary.TotalSellingPrice
+	}
+	return r.RsuOrderSummary.TotalSellingPrice
+}
+
+// EffectiveCommission returns the commission paid on the order.
+func (r *Result) EffectiveCommission() types.Money {
+	if r.EsppOrderSummary != nil {
+		return r.EsppOrderSummary.EffectiveCommission
+	}
+	return r.RsuOrderSummary.EffectiveCommission
+}
+
+// NetResult returns the profit/loss of the order before taxes.
+func (r *Result) NetResult() types.Money {
+	if r.EsppOrderSummary != nil {
+		return r.EsppOrderSummary.NetResult
+	}
+	return r.RsuOrderSummary.NetResult
+}
+
+// TrueProfitOrLoss returns the profit/loss of the order after commission and taxes.
+func (r *Result) TrueProfitOrLoss() types.Money {
+	if r.EsppOrderSummary != nil {
+		return r.EsppOrderSummary.TrueProfitOrLoss()
+	}
+	return r.RsuOrderSummary.TrueProfitOrLoss()
+}
+
+// TaxesDeducted returns the taxes deducted from the net result to get the true profit/loss.
+func (r *Result) TaxesDeducted() types.Money {
+	return r.NetResult() - r.TrueProfitOrLoss()
+}
+
+// NetProceeds returns the cash left from the sale after commission and taxes.
+func (r *Result) NetProceeds() types.Money {
+	if r.EsppOrderSummary != nil {
+		return r.EsppOrderSummary.NetProceeds()
+	}
+	return r.RsuOrderSummary.NetProceeds()
+}
+
+// ProfitOrLossMargin returns the true profit/loss as a percent of what the order cost.
+func (r *Result) ProfitOrLossMargin() float64 {
+	if r.EsppOrderSummary != nil {
+		return r.EsppOrderSummary.ProfitOrLossMargin()
+	}
+	return r.RsuOrderSummary.ProfitOrLossMargin()
+}
+
+// Totals aggregates the orders of a batch that were calculated.
+type Totals struct {
+	Orders       int
+	FailedOrders int
+
+	TotalSellingPrice   types.Money
+	EffectiveCommission types.Money
+	NetResult           types.Money
+	TaxesDeducted       types.Money
+	TrueProfitOrLoss    types.Money
+	NetProceeds         types.Money
+}
+
+// Calculate calculates the summary of every record. A record that is invalid or fails to calculate is reported
+// in its Result and left out of the Totals; the rest of the batch is still calculated. The defaults, e.g. those of a
+// config profile, apply to the keys a record leaves out that are valid for its order type.
+func Calculate(records []Record, defaults Record) ([]Result, Totals) {
+	results := make([]Result, 0, len(records))
+	var totals Totals
+	for index, record := range records {
+		result := calculateRecord(index+1, record, defaults)
+		results = append(results, result)
+		if result.Err != nil {
+			totals.FailedOrders++
+			continue
+		}
+		totals.Orders++
+		totals.TotalSellingPrice += result.TotalSellingPrice()
+		totals.EffectiveCommission += result.EffectiveCommission()
+		totals.NetResult += result.NetResult()
+		totals.TaxesDeducted += result.TaxesDeducted()
+		totals.TrueProfitOrLoss += result.TrueProfitOrLoss()
+		totals.NetProceeds += result.NetProceeds()
+	}
+	return results, totals
+}
+
+func calculateRecord(number int, record Record, defaults Record) Result {
+	result := Result{
+		Number: number,
+		Label:  strings.TrimSpace(string(record["label"])),
+	}
+	orderType, err := types.ParseOrderType(string(record["type"]))
+	if err != nil {
+		result.Err = fmt.Errorf("type: %w", err)
+		return result
+	}
+	result.OrderType = orderType
+
+	if orderType == types.Rsu {
+		rsuOrder, err := record.withDefaults(defaults, commonKeys, rsuKeys).RsuOrder()
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		result.RsuOrderSummary, result.Err = rsuOrder.CalculateRsuOrderSummary()
+		return result
+	}
+	esppOrder, err := record.withDefaults(defaults, commonKeys, esppKeys).EsppOrder()
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.EsppOrderSummary, result.Err = esppOrder.CalculateEsppOrderSummary()
+	return result
+}
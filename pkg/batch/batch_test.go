@@ -0,0 +1,227 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package batch
+
+import (
+	"github.com/leogps/lunar/pkg/types"
+	"strings"
+	"testing"
+)
+
+const ordersYAML = `
+- type: espp
+  label: q1-espp
+  discount: 15
+  cost-per-share: 100
+  selling-price: 150
+  shares: 10
+  commission: 5
+  cgt-percent: 24
+- type: rsu
+  label: q1-rsu
+  selling-price: 150
+  shares: 100
+  fmv: 100
+  cgt-percent: 24
+  income-tax: 2200
+  vested: 100
+- type: rsu
+  label: invalid
+  selling-price: abc
+  fmv: 100
+  sellingprice: 150
+- type: bond
+  shares: 1
+`
+
+func TestCalculate(t *testing.T) {
+	records, err := Read(strings.NewReader(ordersYAML), YAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, totals := Calculate(records, nil)
+	if len(results) != 4 {
+		t.Fatalf("expected a result per record, got %d", len(results))
+	}
+
+	if results[0].Err != nil || results[0].OrderType != types.Espp || results[0].TrueProfitOrLoss() != types.NewMoney(490.20) {
+		t.Errorf("expected an ESPP true profit of $490.20, got %+v", results[0])
+	}
+	if results[1].Err != nil || results[1].OrderType != types.Rsu || results[1].TrueProfitOrLoss() != types.NewMoney(11600) {
+		t.Errorf("expected an RSU true profit of $11600.00, got %+v", results[1])
+	}
+
+	invalidErr := results[2].Err
+	if invalidErr == nil {
+		t.Fatal("expected the invalid record to fail")
+	}
+	for _, expected := range []string{"sellingprice: unknown key", "selling-price: invalid dollar amount: abc", "shares: missing value"} {
+		if !strings.Contains(invalidErr.Error(), expected) {
+			t.Errorf("expected %q in %q", expected, invalidErr)
+		}
+	}
+	if results[3].Err == nil || !strings.HasPrefix(results[3].Err.Error(), "type: ") {
+		t.Errorf("expected an invalid type, got %v", results[3].Err)
+	}
+
+	if totals.Orders != 2 || totals.FailedOrders != 2 {
+		t.Errorf("expected 2 orders calculated and 2 failed, got %+v", totals)
+	}
+	if totals.TrueProfitOrLoss != types.NewMoney(12090.20) || totals.NetResult-totals.TaxesDeducted != totals.TrueProfitOrLoss {
+		t.Errorf("expected a total true profit of $12090.20 reconciling with the net result, got %+v", totals)
+	}
+}
+
+func TestCalculate_Defaults(t *testing.T) {
+	records := []Record{
+		{"type": "espp", "cost-per-share": "100", "selling-price": "150", "shares": "10"},
+		{"type": "espp", "discount": "10", "cost-per-share": "100", "selling-price": "150", "shares": "10"},
+		{"type": "rsu", "selling-price": "150", "shares": "10", "fmv": "100"},
+	}
+	results, totals := Calculate(records, Record{"discount": "15", "cgt-percent": "20"})
+	if totals.FailedOrders != 0 {
+		t.Fatalf("expected the ESPP discount to be left out of the RSU order, got %+v", results)
+	}
+	if results[0].EsppOrderSummary.EsppOrder.DiscountPercent != 15 || results[1].EsppOrderSummary.EsppOrder.DiscountPercent != 10 {
+		t.Errorf("expected the default discount only where the record leaves it out, got %+v", results)
+	}
+	if !results[2].RsuOrderSummary.RsuOrder.ConsiderCapitalGainTax || results[2].RsuOrderSummary.RsuOrder.CapitalGainTaxPercent != 20 {
+		t.Errorf("expected the default capital gain tax percent, got %+v", results[2].RsuOrderSummary.RsuOrder)
+	}
+	if records[0]["discount"] != "" {
+		t.Errorf("expected the records to be left as read, got %v", records[0])
+	}
+}
+
+func TestRead(t *testing.T) {
+	fromJSON, err := Read(strings.NewReader(`[{"type": "espp", "selling-price": 150.10, "look-back": true, "label": null}]`), JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fromJSON[0]["selling-price"] != "150.10" || fromJSON[0]["look-back"] != "true" || fromJSON[0]["label"] != "" {
+		t.Errorf("expected the JSON values as written, got %v", fromJSON[0])
+	}
+
+	fromCSV, err := Read(strings.NewReader("type,selling-price,shares\nrsu,150.10,\nespp,1,2,3\n"), CSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fromCSV) != 2 || fromCSV[0]["selling-price"] != "150.10" {
+		t.Fatalf("expected a record per row, got %v", fromCSV)
+	}
+	if _, ok := fromCSV[0]["shares"]; ok {
+		t.Errorf("expected the empty cell to be left out, got %v", fromCSV[0])
+	}
+	if fromCSV[1]["column 4"] != "3" {
+		t.Errorf("expected the extra cell to be kept for validation, got %v", fromCSV[1])
+	}
+
+	if _, err = InputFormatFromPath("orders.txt"); err == nil {
+		t.Error("expected an error for a .txt file")
+	}
+}
+
+func TestRecord_EsppOrder(t *testing.T) {
+	record := Record{
+		"type":                    "espp",
+		"discount":                "15",
+		"cost-per-share":          "100",
+		"selling-price":           "150",
+		"shares":                  "10",
+		"offering-date":           "2023-01-01",
+		"purchase-date":           "2023-06-30",
+		"sale-date":               "2024-07-15",
+		"offering-fmv":            "110",
+		"purchase-fmv":            "120",
+		"ordinary-income-percent": "32",
+		"holding-period":          "yes",
+		"short-term-percent":      "32",
+		"long-term-percent":       "15",
+	}
+	esppOrder, err := record.EsppOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !esppOrder.ConsiderDisposition || !esppOrder.ConsiderCapitalGainTax || !esppOrder.ConsiderHoldingPeriod {
+		t.Errorf("expected the disposition and holding period to be considered, got %+v", esppOrder)
+	}
+	if esppOrder.ConsiderTransactionCommission || esppOrder.ConsiderNetInvestmentIncomeTax {
+		t.Errorf("expected no commission or NIIT without their keys, got %+v", esppOrder)
+	}
+
+	delete(record, "purchase-date")
+	record["tax-brackets"] = "true"
+	if _, err = record.EsppOrder(); err == nil || strings.Count(err.Error(), "purchase-date: missing value") != 1 ||
+		!strings.Contains(err.Error(), "tax-year: missing value") {
+		t.Errorf("expected the purchase date and tax year to be reported missing once, got %v", err)
+	}
+}
+
+func TestRecord_FalseBooleans(t *testing.T) {
+	esppRecord := Record{
+		"type":           "espp",
+		"discount":       "15",
+		"cost-per-share": "85",
+		"selling-price":  "100",
+		"shares":         "10",
+		"tax-brackets":   "false",
+		"disposition":    "false",
+		"offering-date":  "2023-01-01",
+	}
+	esppOrder, err := esppRecord.EsppOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if esppOrder.ConsiderCapitalGainTax || esppOrder.ConsiderDisposition {
+		t.Errorf("expected no capital gain tax or disposition when turned off, got %+v", esppOrder)
+	}
+
+	rsuRecord := Record{
+		"type":           "rsu",
+		"selling-price":  "150",
+		"shares":         "10",
+		"fmv":            "100",
+		"holding-period": "no",
+		"capital-loss":   "false",
+		"carryforward":   "1000",
+	}
+	rsuOrder, err := rsuRecord.RsuOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rsuOrder.ConsiderCapitalGainTax || rsuOrder.ConsiderHoldingPeriod || rsuOrder.ConsiderCapitalLoss {
+		t.Errorf("expected no capital gain tax, holding period or capital loss when turned off, got %+v", rsuOrder)
+	}
+
+	results, totals := Calculate([]Record{{"type": "rsu", "selling-price": "150", "shares": "10", "fmv": "100"}},
+		Record{"holding-period": "no"})
+	if totals.FailedOrders != 0 || results[0].RsuOrderSummary.RsuOrder.ConsiderCapitalGainTax {
+		t.Errorf("expected a profile turning the holding period off to leave the capital gain tax out, got %+v", results[0])
+	}
+
+	rsuRecord["holding-period"] = "maybe"
+	if _, err = rsuRecord.RsuOrder(); err == nil || strings.Count(err.Error(), "holding-period: invalid boolean") != 1 {
+		t.Errorf("expected the invalid boolean to be reported once, got %v", err)
+	}
+}
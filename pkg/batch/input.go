@@ -0,0 +1,188 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package batch
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// InputFormat is the file format a batch of orders is read from.
+type InputFormat int
+
+const (
+	YAML InputFormat = iota
+	JSON
+	CSV
+)
+
+func (i InputFormat) String() string {
+	switch i {
+	case YAML:
+		return "yaml"
+	case JSON:
+		return "json"
+	case CSV:
+		return "csv"
+	default:
+		return "unknown"
+	}
+}
+
+// InputFormatFromPath picks the input format from the file extension.
+func InputFormatFromPath(path string) (InputFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return YAML, nil
+	case ".json":
+		return JSON, nil
+	case ".csv":
+		return CSV, nil
+	default:
+		return 0, fmt.Errorf("unsupported input file: %s, valid extensions: .yaml, .yml, .json, .csv", path)
+	}
+}
+
+// Value is a value of a Record kept as the text it was written as, so that amounts are parsed exactly and a
+// malformed value fails only its own record.
+type Value string
+
+// UnmarshalJSON keeps numbers, booleans, objects and arrays as their JSON text, the latter to fail as invalid values
+// of their record. null is treated as a missing value.
+func (v *Value) UnmarshalJSON(data []byte) error {
+	if strings.HasPrefix(string(data), `"`) {
+		var value string
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		*v = Value(value)
+		return nil
+	}
+	if string(data) == "null" {
+		*v = ""
+		return nil
+	}
+	*v = Value(data)
+	return nil
+}
+
+// UnmarshalYAML keeps scalars as written and mappings and sequences as their YAML text, the latter to fail as
+// invalid values of their record. null is treated as a missing value.
+func (v *Value) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		if node.ShortTag() == "!!null" {
+			*v = ""
+			return nil
+		}
+		*v = Value(node.Value)
+		return nil
+	}
+	node.Style = yaml.FlowStyle
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		return err
+	}
+	*v = Value(strings.TrimSpace(string(data)))
+	return nil
+}
+
+// Record is one order of a batch, keyed like the flags of the espp and rsu commands, e.g. selling-price.
+// The type key tags the order as espp or rsu.
+type Record map[string]Value
+
+// ReadFile reads the records of a YAML, JSON or CSV file, picking the format from the file extension.
+func ReadFile(path string) ([]Record, error) {
+	inputFormat, err := InputFormatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	return Read(file, inputFormat)
+}
+
+// Read reads the records from r. YAML and JSON hold a list of records; CSV holds a header row of keys and a
+// record per row, with empty cells left out.
+func Read(r io.Reader, inputFormat InputFormat) ([]Record, error) {
+	var records []Record
+	switch inputFormat {
+	case YAML:
+		if err := yaml.NewDecoder(r).Decode(&records); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("invalid YAML: %w", err)
+		}
+	case JSON:
+		if err := json.NewDecoder(r).Decode(&records); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+	case CSV:
+		return readCSV(r)
+	default:
+		return nil, fmt.Errorf("unsupported input format: %d", inputFormat)
+	}
+	return records, nil
+}
+
+func readCSV(r io.Reader) ([]Record, error) {
+	csvReader := csv.NewReader(r)
+	// A row with too many cells fails its own record rather than the whole file
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+	rows, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	header := rows[0]
+	records := make([]Record, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		record := Record{}
+		for column, cell := range row {
+			cell = strings.TrimSpace(cell)
+			if cell == "" {
+				continue
+			}
+			key := fmt.Sprintf("column %d", column+1)
+			if column < len(header) {
+				key = strings.TrimSpace(header[column])
+			}
+			record[key] = Value(cell)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
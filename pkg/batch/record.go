@@ -0,0 +1,397 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package batch
+
+import (
+	"errors"
+	"fmt"
+	"github.com/leogps/lunar/pkg/tax"
+	"github.com/leogps/lunar/pkg/types"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// commonKeys are the keys of both ESPP and RSU records
+var commonKeys = []string{"type", "label", "selling-price", "shares", "commission", "transactions", "cgt-percent",
+	"tax-brackets", "holding-period", "sale-date", "short-term-percent", "long-term-percent", "ordinary-income-percent",
+	"state", "filing-status", "tax-year", "other-income", "magi", "capital-loss", "other-capital-gains", "carryforward"}
+
+var esppKeys = []string{"discount", "look-back", "cost-per-share", "offering-fmv", "purchase-fmv", "disposition",
+	"offering-date", "purchase-date"}
+
+var rsuKeys = []string{"fmv", "vest-date", "income-tax", "vested"}
+
+// capitalGainTaxKeys imply deducting the capital gain tax; tax-brackets and holding-period only when true
+var capitalGainTaxKeys = []string{"cgt-percent", "tax-brackets", "holding-period", "short-term-percent", "long-term-percent"}
+
+// withDefaults returns the record with the defaults for the valid keys it leaves out, e.g. those of a config profile.
+func (r Record) withDefaults(defaults Record, validKeys ...[]string) Record {
+	record := Record{}
+	for key, value := range r {
+		record[key] = value
+	}
+	for _, keys := range validKeys {
+		for _, key := range keys {
+			if record[key] == "" && defaults[key] != "" {
+				record[key] = defaults[key]
+			}
+		}
+	}
+	return record
+}
+
+// recordParser parses the values of a record, collecting an error per invalid or missing value
+type recordParser struct {
+	record  Record
+	errs    []error
+	missing map[string]bool
+}
+
+func (p *recordParser) has(key string) bool {
+	return p.record[key] != ""
+}
+
+func (p *recordParser) hasAny(keys ...string) bool {
+	for _, key := range keys {
+		if p.has(key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *recordParser) fail(key string, err error) {
+	err = fmt.Errorf("%s: %w", key, err)
+	for _, recorded := range p.errs {
+		if recorded.Error() == err.Error() {
+			return
+		}
+	}
+	p.errs = append(p.errs, err)
+}
+
+// require records an error when the key is missing.
+func (p *recordParser) require(key string) {
+	if !p.has(key) && !p.missing[key] {
+		p.missing[key] = true
+		p.errs = append(p.errs, fmt.Errorf("%s: missing value", key))
+	}
+}
+
+// checkKeys records an error for each key that is not one of the valid keys, e.g. a misspelled one.
+func (p *recordParser) checkKeys(validKeys ...[]string) {
+	valid := map[string]bool{}
+	for _, keys := range validKeys {
+		for _, key := range keys {
+			valid[key] = true
+		}
+	}
+	var unknownKeys []string
+	for key := range p.record {
+		if !valid[key] {
+			unknownKeys = append(unknownKeys, key)
+		}
+	}
+	sort.Strings(unknownKeys)
+	for _, key := range unknownKeys {
+		p.errs = append(p.errs, fmt.Errorf("%s: unknown key", key))
+	}
+}
+
+func (p *recordParser) err() error {
+	return errors.Join(p.errs...)
+}
+
+func parseValue[T any](p *recordParser, key string, parse func(string) (T, error)) T {
+	value, _ := parseOptional(p, key, parse)
+	return value
+}
+
+// parseOptional parses the value of key, returning false when it is missing or invalid.
+func parseOptional[T any](p *recordParser, key string, parse func(string) (T, error)) (T, bool) {
+	var zero T
+	if !p.has(key) {
+		return zero, false
+	}
+	value, err := parse(strings.TrimSpace(string(p.record[key])))
+	if err != nil {
+		p.fail(key, err)
+		return zero, false
+	}
+	return value, true
+}
+
+func (p *recordParser) money(key string) types.Money {
+	return parseValue(p, key, types.ParseMoney)
+}
+
+// positiveMoney requires a dollar amount greater than zero.
+func (p *recordParser) positiveMoney(key string) types.Money {
+	p.require(key)
+	value, ok := parseOptional(p, key, types.ParseMoney)
+	if ok && value <= 0 {
+		p.fail(key, fmt.Errorf("must be greater than zero"))
+	}
+	return value
+}
+
+// positiveShares requires a number of shares greater than zero.
+func (p *recordParser) positiveShares(key string) types.Shares {
+	p.require(key)
+	value, ok := parseOptional(p, key, types.ParseShares)
+	if ok && value <= 0 {
+		p.fail(key, fmt.Errorf("must be greater than zero"))
+	}
+	return value
+}
+
+func (p *recordParser) percent(key string) float64 {
+	return parseValue(p, key, func(value string) (float64, error) {
+		return strconv.ParseFloat(value, 64)
+	})
+}
+
+func (p *recordParser) int(key string) int {
+	return parseValue(p, key, strconv.Atoi)
+}
+
+func (p *recordParser) date(key string) time.Time {
+	return parseValue(p, key, func(value string) (time.Time, error) {
+		return time.Parse(types.DateLayout, value)
+	})
+}
+
+func (p *recordParser) bool(key string) bool {
+	return parseValue(p, key, func(value string) (bool, error) {
+		switch strings.ToLower(value) {
+		case "true", "yes", "y":
+			return true, nil
+		case "false", "no", "n":
+			return false, nil
+		}
+		return false, fmt.Errorf("invalid boolean: %s, valid values: true, false, yes, no", value)
+	})
+}
+
+// feature tells whether a feature applies: the boolean key when given, so that false turns it off, otherwise any of the
+// keys it implies.
+func (p *recordParser) feature(booleanKey string, impliedBy ...string) bool {
+	if p.has(booleanKey) {
+		return p.bool(booleanKey)
+	}
+	return p.hasAny(impliedBy...)
+}
+
+// considerCapitalGainTax tells whether the capital gain tax is deducted: a rate is given, or the tax brackets or the
+// holding period are turned on.
+func (p *recordParser) considerCapitalGainTax() bool {
+	return p.bool("tax-brackets") || p.feature("holding-period", "short-term-percent", "long-term-percent") ||
+		p.has("cgt-percent")
+}
+
+// transactionCommission reads the commission, paid over one transaction unless transactions says otherwise.
+func (p *recordParser) transactionCommission() (bool, types.Money, int) {
+	if !p.has("commission") {
+		return false, 0, 0
+	}
+	numberOfTransactions := 1
+	if p.has("transactions") {
+		numberOfTransactions = p.int("transactions")
+	}
+	return true, p.money("commission"), numberOfTransactions
+}
+
+// taxModels reads the progressive federal brackets and the state tax model.
+func (p *recordParser) taxModels() (tax.Model, tax.Model) {
+	var taxModel, stateTaxModel tax.Model
+	if p.bool("tax-brackets") {
+		taxModel = tax.Federal
+	}
+	if p.has("state") {
+		var err error
+		if stateTaxModel, err = tax.LookupState(string(p.record["state"])); err != nil {
+			p.fail("state", err)
+		}
+	}
+	return taxModel, stateTaxModel
+}
+
+// taxProfile reads the tax profile, required by the tax models, the NIIT and a capital loss.
+func (p *recordParser) taxProfile(taxModel tax.Model, stateTaxModel tax.Model, needsFilingStatus bool) tax.Profile {
+	var taxProfile tax.Profile
+	if taxModel != nil || stateTaxModel != nil {
+		p.require("tax-year")
+		taxProfile.TaxYear = p.int("tax-year")
+		taxProfile.OtherIncome = p.money("other-income").Float64()
+		needsFilingStatus = true
+	}
+	if needsFilingStatus {
+		p.require("filing-status")
+		taxProfile.FilingStatus = parseValue(p, "filing-status", tax.ParseFilingStatus)
+	}
+	return taxProfile
+}
+
+// capitalGainTax reads the flat capital gain tax rate or, with the holding period, the short-term and long-term rates.
+// The progressive brackets need neither.
+func (p *recordParser) capitalGainTax(taxModel tax.Model) (considerHoldingPeriod bool, capitalGainTaxPercent float64,
+	shortTermCapitalGainTaxPercent float64, longTermCapitalGainTaxPercent float64) {
+	considerHoldingPeriod = p.feature("holding-period", "short-term-percent", "long-term-percent")
+	if considerHoldingPeriod {
+		p.require("sale-date")
+		if taxModel == nil {
+			p.require("short-term-percent")
+			p.require("long-term-percent")
+		}
+		return true, 0, p.percent("short-term-percent"), p.percent("long-term-percent")
+	}
+	if taxModel == nil {
+		p.require("cgt-percent")
+	}
+	return false, p.percent("cgt-percent"), 0, 0
+}
+
+// EsppOrder builds the ESPP order of the record, or returns every invalid or missing value.
+func (r Record) EsppOrder() (*types.EsppOrder, error) {
+	p := &recordParser{record: r, missing: map[string]bool{}}
+	p.checkKeys(commonKeys, esppKeys)
+
+	p.require("discount")
+	esppOrder := &types.EsppOrder{
+		DiscountPercent:      p.percent("discount"),
+		SellingPricePerShare: p.positiveMoney("selling-price"),
+		NumberOfSharesSold:   p.positiveShares("shares"),
+		ConsiderLookBack:     p.bool("look-back"),
+	}
+	if esppOrder.ConsiderLookBack {
+		esppOrder.OfferingDateMarketValuePerShare = p.positiveMoney("offering-fmv")
+		esppOrder.PurchaseDateMarketValuePerShare = p.positiveMoney("purchase-fmv")
+	} else {
+		esppOrder.CostPerShare = p.positiveMoney("cost-per-share")
+	}
+	esppOrder.ConsiderTransactionCommission, esppOrder.CommissionPaidPerTransaction, esppOrder.NumberOfTransactions =
+		p.transactionCommission()
+	esppOrder.TaxModel, esppOrder.StateTaxModel = p.taxModels()
+
+	esppOrder.ConsiderDisposition = p.feature("disposition", "offering-date")
+	if esppOrder.ConsiderDisposition {
+		p.require("offering-date")
+		p.require("purchase-date")
+		p.require("sale-date")
+		if !esppOrder.ConsiderLookBack {
+			esppOrder.OfferingDateMarketValuePerShare = p.positiveMoney("offering-fmv")
+			esppOrder.PurchaseDateMarketValuePerShare = p.positiveMoney("purchase-fmv")
+		}
+		if esppOrder.TaxModel == nil {
+			p.require("ordinary-income-percent")
+		}
+	}
+	esppOrder.OfferingDate = p.date("offering-date")
+	esppOrder.PurchaseDate = p.date("purchase-date")
+	esppOrder.SaleDate = p.date("sale-date")
+	esppOrder.OrdinaryIncomeTaxPercent = p.percent("ordinary-income-percent")
+
+	esppOrder.ConsiderCapitalGainTax = p.considerCapitalGainTax()
+	if esppOrder.ConsiderCapitalGainTax {
+		esppOrder.ConsiderHoldingPeriod, esppOrder.CapitalGainTaxPercent, esppOrder.ShortTermCapitalGainTaxPercent,
+			esppOrder.LongTermCapitalGainTaxPercent = p.capitalGainTax(esppOrder.TaxModel)
+		if esppOrder.ConsiderHoldingPeriod {
+			p.require("purchase-date")
+		}
+	}
+
+	esppOrder.ConsiderNetInvestmentIncomeTax = p.has("magi")
+	esppOrder.ModifiedAdjustedGrossIncome = p.money("magi")
+	esppOrder.ConsiderCapitalLoss, esppOrder.OtherRealizedCapitalGains, esppOrder.CapitalLossCarryforward = p.capitalLoss(
+		esppOrder.ConsiderCapitalGainTax, esppOrder.TaxModel)
+	esppOrder.TaxProfile = p.taxProfile(esppOrder.TaxModel, esppOrder.StateTaxModel,
+		esppOrder.ConsiderNetInvestmentIncomeTax || esppOrder.ConsiderCapitalLoss)
+
+	if err := p.err(); err != nil {
+		return nil, err
+	}
+	return esppOrder, nil
+}
+
+// RsuOrder builds the RSU order of the record, or returns every invalid or missing value.
+func (r Record) RsuOrder() (*types.RsuOrder, error) {
+	p := &recordParser{record: r, missing: map[string]bool{}}
+	p.checkKeys(commonKeys, rsuKeys)
+
+	rsuOrder := &types.RsuOrder{
+		SellingPricePerShare: p.positiveMoney("selling-price"),
+		NumberOfSharesSold:   p.positiveShares("shares"),
+		MarketValuePerShare:  p.positiveMoney("fmv"),
+		VestDate:             p.date("vest-date"),
+		SaleDate:             p.date("sale-date"),
+	}
+	rsuOrder.ConsiderTransactionCommission, rsuOrder.CommissionPaidPerTransaction, rsuOrder.NumberOfTransactions =
+		p.transactionCommission()
+	rsuOrder.TaxModel, rsuOrder.StateTaxModel = p.taxModels()
+
+	rsuOrder.ConsiderIncomeTaxOnVestedStock = p.has("income-tax")
+	if rsuOrder.ConsiderIncomeTaxOnVestedStock {
+		rsuOrder.IncomeTaxIncurredWhenStockVested = p.money("income-tax")
+		rsuOrder.NumberOfStocksVested = p.positiveShares("vested")
+	}
+
+	rsuOrder.ConsiderCapitalGainTax = p.considerCapitalGainTax()
+	if rsuOrder.ConsiderCapitalGainTax {
+		rsuOrder.ConsiderHoldingPeriod, rsuOrder.CapitalGainTaxPercent, rsuOrder.ShortTermCapitalGainTaxPercent,
+			rsuOrder.LongTermCapitalGainTaxPercent = p.capitalGainTax(rsuOrder.TaxModel)
+		if rsuOrder.ConsiderHoldingPeriod {
+			p.require("vest-date")
+		}
+	}
+
+	rsuOrder.ConsiderNetInvestmentIncomeTax = p.has("magi")
+	rsuOrder.ModifiedAdjustedGrossIncome = p.money("magi")
+	rsuOrder.ConsiderCapitalLoss, rsuOrder.OtherRealizedCapitalGains, rsuOrder.CapitalLossCarryforward = p.capitalLoss(
+		rsuOrder.ConsiderCapitalGainTax, rsuOrder.TaxModel)
+	rsuOrder.OrdinaryIncomeTaxPercent = p.percent("ordinary-income-percent")
+	rsuOrder.TaxProfile = p.taxProfile(rsuOrder.TaxModel, rsuOrder.StateTaxModel,
+		rsuOrder.ConsiderNetInvestmentIncomeTax || rsuOrder.ConsiderCapitalLoss)
+
+	if err := p.err(); err != nil {
+		return nil, err
+	}
+	return rsuOrder, nil
+}
+
+// capitalLoss reads the capital loss, valued at the capital gain tax rates, and at the ordinary income tax rate for
+// the part that offsets ordinary income.
+func (p *recordParser) capitalLoss(considerCapitalGainTax bool, taxModel tax.Model) (bool, types.Money, types.Money) {
+	if !p.feature("capital-loss", "other-capital-gains", "carryforward") {
+		return false, 0, 0
+	}
+	if !considerCapitalGainTax {
+		p.fail("capital-loss", fmt.Errorf("requires the capital gain tax (%s)", strings.Join(capitalGainTaxKeys, ", ")))
+	}
+	if taxModel == nil {
+		p.require("ordinary-income-percent")
+	}
+	return true, p.money("other-capital-gains"), p.money("carryforward")
+}
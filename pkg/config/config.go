@@ -0,0 +1,306 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/leogps/lunar/pkg/tax"
+	"github.com/leogps/lunar/pkg/types"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultProfileName is the profile used when neither the command line, the environment nor the config file picks one.
+const DefaultProfileName = "default"
+
+// ConfigEnv overrides the path of the config file.
+const ConfigEnv = "LUNAR_CONFIG"
+
+// ProfileEnv picks the profile, overriding the default profile of the config file.
+const ProfileEnv = "LUNAR_PROFILE"
+
+// profileKey is a key of a profile along with how its value is validated.
+type profileKey struct {
+	name        string
+	description string
+	validate    func(value string) error
+}
+
+func validateNumber(value string) error {
+	_, err := strconv.ParseFloat(value, 64)
+	return err
+}
+
+func validateInt(value string) error {
+	_, err := strconv.Atoi(value)
+	return err
+}
+
+func validateMoney(value string) error {
+	_, err := types.ParseMoney(value)
+	return err
+}
+
+func validateBool(value string) error {
+	switch strings.ToLower(value) {
+	case "true", "false", "yes", "no", "y", "n":
+		return nil
+	}
+	return fmt.Errorf("invalid boolean: %s, valid values: true, false, yes, no", value)
+}
+
+func validateFilingStatus(value string) error {
+	_, err := tax.ParseFilingStatus(value)
+	return err
+}
+
+func validateState(value string) error {
+	_, err := tax.LookupState(value)
+	return err
+}
+
+// profileKeys are the plan terms, commission and tax settings a profile can default, named like the flags of the
+// espp and rsu commands.
+var profileKeys = []profileKey{
+	{"discount", "discounted (buying) price percent per share (%)", validateNumber},
+	{"look-back", "the ESPP plan has a look-back provision", validateBool},
+	{"commission", "commission paid per transaction ($)", validateMoney},
+	{"transactions", "number of transactions", validateInt},
+	{"cgt-percent", "flat capital gain tax percent", validateNumber},
+	{"holding-period", "determine short-term/long-term from the acquisition and sale dates", validateBool},
+	{"short-term-percent", "short-term capital gain tax percent", validateNumber},
+	{"long-term-percent", "long-term capital gain tax percent", validateNumber},
+	{"ordinary-income-percent", "ordinary income tax percent", validateNumber},
+	{"tax-brackets", "use progressive federal tax brackets instead of flat percentages", validateBool},
+	{"state", "state to compute state tax for", validateState},
+	{"filing-status", "filing status (single/mfj/mfs/hoh)", validateFilingStatus},
+	{"tax-year", "tax year for progressive tax brackets", validateInt},
+	{"other-income", "other taxable income (salary etc. after deductions) ($)", validateNumber},
+	{"magi", "estimated MAGI excluding the sale ($); implies the 3.8% NIIT", validateMoney},
+}
+
+// ProfileKeys returns the keys a profile can set, in the order they are listed.
+func ProfileKeys() []string {
+	keys := make([]string, 0, len(profileKeys))
+	for _, key := range profileKeys {
+		keys = append(keys, key.name)
+	}
+	return keys
+}
+
+// DescribeProfileKey returns the description of a profile key.
+func DescribeProfileKey(key string) string {
+	for _, profileKey := range profileKeys {
+		if profileKey.name == key {
+			return profileKey.description
+		}
+	}
+	return ""
+}
+
+// ValidateProfileValue checks that key is a profile key and value is valid for it.
+func ValidateProfileValue(key string, value string) error {
+	for _, profileKey := range profileKeys {
+		if profileKey.name == key {
+			if err := profileKey.validate(strings.TrimSpace(value)); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown key: %s, valid keys: %s", key, strings.Join(ProfileKeys(), ", "))
+}
+
+// EnvName returns the environment variable overriding a profile key, e.g. LUNAR_CGT_PERCENT for cgt-percent.
+func EnvName(key string) string {
+	return "LUNAR_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+// Profile holds the default values of a named profile, keyed like the flags of the espp and rsu commands.
+type Profile map[string]string
+
+// Validate checks every key and value of the profile.
+func (p Profile) Validate() error {
+	var errs []error
+	for _, key := range p.sortedKeys() {
+		if err := ValidateProfileValue(key, p[key]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WithEnv returns the profile overridden by the LUNAR_* environment variables that are set, see EnvName.
+func (p Profile) WithEnv(lookupEnv func(key string) (string, bool)) (Profile, error) {
+	profile := Profile{}
+	for key, value := range p {
+		profile[key] = value
+	}
+	for _, key := range ProfileKeys() {
+		value, ok := lookupEnv(EnvName(key))
+		if !ok || strings.TrimSpace(value) == "" {
+			continue
+		}
+		if err := ValidateProfileValue(key, value); err != nil {
+			return nil, fmt.Errorf("%s: %w", EnvName(key), err)
+		}
+		profile[key] = strings.TrimSpace(value)
+	}
+	return profile, nil
+}
+
+func (p Profile) sortedKeys() []string {
+	keys := make([]string, 0, len(p))
+	for key := range p {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Config is the user configuration file: named profiles of default values and the profile used by default.
+type Config struct {
+	DefaultProfile string             `yaml:"default-profile,omitempty"`
+	Profiles       map[string]Profile `yaml:"profiles,omitempty"`
+}
+
+// DefaultPath returns the path of the config file: $LUNAR_CONFIG, otherwise lunar/config.yaml in the user config
+// directory, i.e. $XDG_CONFIG_HOME (~/.config) on Linux.
+func DefaultPath() (string, error) {
+	if path := os.Getenv(ConfigEnv); path != "" {
+		return path, nil
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "lunar", "config.yaml"), nil
+}
+
+// Load reads and validates the config file. A missing file is an empty config.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+	if err = yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	for _, name := range config.ProfileNames() {
+		if err = config.Profiles[name].Validate(); err != nil {
+			return nil, fmt.Errorf("invalid profile %s in %s: %w", name, path, err)
+		}
+	}
+	return config, nil
+}
+
+// Save writes the config file, creating its directory when needed.
+func (c *Config) Save(path string) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// ProfileNames returns the names of the profiles in alphabetical order.
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ProfileName resolves the profile to use: name when given, otherwise $LUNAR_PROFILE, the default profile of the
+// config file or DefaultProfileName.
+func (c *Config) ProfileName(name string) string {
+	if name != "" {
+		return name
+	}
+	if name = os.Getenv(ProfileEnv); name != "" {
+		return name
+	}
+	if c.DefaultProfile != "" {
+		return c.DefaultProfile
+	}
+	return DefaultProfileName
+}
+
+// Profile returns the named profile. Only DefaultProfileName may be missing, as an empty profile.
+func (c *Config) Profile(name string) (Profile, error) {
+	profile, ok := c.Profiles[name]
+	if ok {
+		return profile, nil
+	}
+	if name == DefaultProfileName {
+		return Profile{}, nil
+	}
+	return nil, fmt.Errorf("unknown profile: %s, profiles: %s", name, strings.Join(c.ProfileNames(), ", "))
+}
+
+// Set sets a key of the named profile, creating the profile when needed.
+func (c *Config) Set(name string, key string, value string) error {
+	if err := ValidateProfileValue(key, value); err != nil {
+		return err
+	}
+	if c.Profiles == nil {
+		c.Profiles = map[string]Profile{}
+	}
+	if c.Profiles[name] == nil {
+		c.Profiles[name] = Profile{}
+	}
+	c.Profiles[name][key] = strings.TrimSpace(value)
+	return nil
+}
+
+// Unset removes a key of the named profile, and the profile once it is empty.
+func (c *Config) Unset(name string, key string) error {
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown profile: %s", name)
+	}
+	if _, ok = profile[key]; !ok {
+		return fmt.Errorf("profile %s does not set %s", name, key)
+	}
+	delete(profile, key)
+	if len(profile) == 0 {
+		delete(c.Profiles, name)
+	}
+	return nil
+}
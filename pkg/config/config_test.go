@@ -0,0 +1,141 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfig_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lunar", "config.yaml")
+	config, err := Load(path)
+	if err != nil || len(config.Profiles) != 0 {
+		t.Fatalf("expected a missing file to be an empty config, got %+v, %v", config, err)
+	}
+
+	for key, value := range map[string]string{"discount": "15", "look-back": "yes", "state": "CA", "filing-status": "mfj"} {
+		if err = config.Set("work", key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config.DefaultProfile = "work"
+	if err = config.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile, err := loaded.Profile(loaded.ProfileName(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile["discount"] != "15" || profile["filing-status"] != "mfj" || len(profile) != 4 {
+		t.Errorf("expected the saved work profile, got %v", profile)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("profiles:\n  work:\n    discount: abc\n    dicsount: 15\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Load(path)
+	if err == nil {
+		t.Fatal("expected the invalid profile to fail")
+	}
+	for _, expected := range []string{"discount: strconv.ParseFloat", "unknown key: dicsount"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in %q", expected, err)
+		}
+	}
+}
+
+func TestConfig_Profile(t *testing.T) {
+	config := &Config{DefaultProfile: "work", Profiles: map[string]Profile{"work": {"discount": "15"}}}
+	if name := config.ProfileName("home"); name != "home" {
+		t.Errorf("expected the given profile, got %s", name)
+	}
+	t.Setenv(ProfileEnv, "home")
+	if name := config.ProfileName(""); name != "home" {
+		t.Errorf("expected the profile of %s, got %s", ProfileEnv, name)
+	}
+	t.Setenv(ProfileEnv, "")
+	if name := config.ProfileName(""); name != "work" {
+		t.Errorf("expected the default profile of the config, got %s", name)
+	}
+
+	if _, err := config.Profile("home"); err == nil {
+		t.Error("expected an unknown profile to fail")
+	}
+	if profile, err := config.Profile(DefaultProfileName); err != nil || len(profile) != 0 {
+		t.Errorf("expected a missing default profile to be empty, got %v, %v", profile, err)
+	}
+}
+
+func TestProfile_WithEnv(t *testing.T) {
+	profile := Profile{"discount": "15", "cgt-percent": "24"}
+	env := map[string]string{"LUNAR_CGT_PERCENT": "32", "LUNAR_TAX_YEAR": " 2024 ", "LUNAR_MAGI": ""}
+	lookupEnv := func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+	overridden, err := profile.WithEnv(lookupEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if overridden["discount"] != "15" || overridden["cgt-percent"] != "32" || overridden["tax-year"] != "2024" {
+		t.Errorf("expected the environment to override the profile, got %v", overridden)
+	}
+	if _, ok := overridden["magi"]; ok || profile["cgt-percent"] != "24" {
+		t.Errorf("expected an empty variable to be ignored and the profile to be left as is, got %v, %v", overridden, profile)
+	}
+
+	env["LUNAR_DISCOUNT"] = "abc"
+	if _, err = profile.WithEnv(lookupEnv); err == nil || !strings.HasPrefix(err.Error(), "LUNAR_DISCOUNT: ") {
+		t.Errorf("expected the invalid variable to be reported, got %v", err)
+	}
+}
+
+func TestConfig_Unset(t *testing.T) {
+	config := &Config{}
+	if err := config.Set("work", "transactions", "two"); err == nil {
+		t.Error("expected an invalid value to fail")
+	}
+	if err := config.Set("work", "transactions", "2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Unset("work", "commission"); err == nil {
+		t.Error("expected unsetting a key that is not set to fail")
+	}
+	if err := config.Unset("work", "transactions"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := config.Profiles["work"]; ok {
+		t.Errorf("expected the empty profile to be removed, got %v", config.Profiles)
+	}
+}
@@ -0,0 +1,302 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package format
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/leogps/lunar/pkg/batch"
+	"io"
+	"strings"
+)
+
+// batchColumns are the fields of the results table of a batch. A record that failed fills in only its record
+// number, label and error.
+var batchColumns = []Field{
+	{Key: "record", Label: "Record", kind: countKind},
+	{Key: "label", Label: "Label", kind: textKind},
+	{Key: "type", Label: "Type", kind: textKind},
+	{Key: "shares_sold", Label: "Shares", kind: sharesKind},
+	{Key: "selling_price_per_share", Label: "Selling Price", kind: perShareKind},
+	{Key: "total_selling_price", Label: "Total Selling Price", kind: amountKind},
+	{Key: "effective_commission", Label: "Commission", kind: amountKind},
+	{Key: "net_result", Label: "Net Result", kind: amountKind},
+	{Key: "taxes_deducted", Label: "Taxes Deducted", kind: amountKind},
+	{Key: "true_profit_or_loss", Label: "True Profit/Loss", kind: amountKind},
+	{Key: "net_proceeds", Label: "Net Proceeds", kind: amountKind},
+	{Key: "profit_or_loss_margin", Label: "Margin", kind: percentKind},
+	{Key: "error", Label: "Error", kind: textKind},
+}
+
+func batchRow(result *batch.Result) []Field {
+	values := []any{result.Number, result.Label}
+	if result.Err != nil {
+		values = append(values, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, strings.Join(errorMessages(result), "; "))
+	} else {
+		values = append(values, result.OrderType.String(), result.SharesSold(), result.SellingPricePerShare(),
+			result.TotalSellingPrice(), result.EffectiveCommission(), result.NetResult(), result.TaxesDeducted(),
+			result.TrueProfitOrLoss(), result.NetProceeds(), result.ProfitOrLossMargin(), nil)
+	}
+
+	row := make([]Field, len(batchColumns))
+	for index, column := range batchColumns {
+		row[index] = column
+		row[index].value = values[index]
+	}
+	return row
+}
+
+func totalsFields(totals batch.Totals) []Field {
+	return []Field{
+		count("orders", "Orders", totals.Orders),
+		count("failed_orders", "Failed Orders", totals.FailedOrders),
+		amount("total_selling_price", "Total Selling Price", totals.TotalSellingPrice),
+		amount("effective_commission", "Commission", totals.EffectiveCommission),
+		amount("net_result", "Net Result", totals.NetResult),
+		amount("taxes_deducted", "Taxes Deducted", totals.TaxesDeducted),
+		amount("true_profit_or_loss", "True Profit/Loss", totals.TrueProfitOrLoss),
+		amount("net_proceeds", "Net Proceeds", totals.NetProceeds),
+	}
+}
+
+// WriteBatch writes the result of each record of a batch and the totals to w in the format. JSON holds the full
+// report of each order calculated; the other formats hold a table row per record.
+func WriteBatch(w io.Writer, format Format, results []batch.Result, totals batch.Totals) error {
+	switch format {
+	case JSON:
+		return writeBatchJSON(w, results, totals)
+	case CSV:
+		return writeBatchCSV(w, results, totals)
+	case Markdown:
+		return writeBatchMarkdown(w, results, totals)
+	default:
+		return writeBatchText(w, results, totals)
+	}
+}
+
+// writeBatchJSON writes {"orders": [...], "totals": {...}}. An order has its record number, label and errors
+// followed by the type and sections of its report, left out when it failed.
+func writeBatchJSON(w io.Writer, results []batch.Result, totals batch.Totals) error {
+	var buffer bytes.Buffer
+	buffer.WriteString(`{"orders":[`)
+	for index := range results {
+		result := &results[index]
+		if index > 0 {
+			buffer.WriteString(",")
+		}
+		errorsJSON, err := json.Marshal(errorMessages(result))
+		if err != nil {
+			return err
+		}
+		buffer.WriteString("{")
+		writeJSONFields(&buffer, []Field{count("record", "Record", result.Number), text("label", "Label", result.Label)})
+		buffer.WriteString(fmt.Sprintf(",%q:", "errors"))
+		buffer.Write(errorsJSON)
+		if report := orderReport(result); report != nil {
+			buffer.WriteString(fmt.Sprintf(",%q:%q", "type", report.Type))
+			writeJSONSections(&buffer, report.Sections)
+		}
+		buffer.WriteString("}")
+	}
+	buffer.WriteString(`],"totals":{`)
+	writeJSONFields(&buffer, totalsFields(totals))
+	buffer.WriteString("}}")
+	return writeIndentedJSON(w, buffer.Bytes())
+}
+
+func orderReport(result *batch.Result) *Report {
+	if result.EsppOrderSummary != nil {
+		return NewEsppOrderReport(result.EsppOrderSummary)
+	}
+	if result.RsuOrderSummary != nil {
+		return NewRsuOrderReport(result.RsuOrderSummary)
+	}
+	return nil
+}
+
+// writeBatchCSV writes a header of the column keys, a row per record and a last row of totals with the record "total".
+func writeBatchCSV(w io.Writer, results []batch.Result, totals batch.Totals) error {
+	csvWriter := csv.NewWriter(w)
+	header := make([]string, 0, len(batchColumns))
+	for _, column := range batchColumns {
+		header = append(header, column.Key)
+	}
+	if err := csvWriter.Write(header); err != nil {
+		return err
+	}
+	for index := range results {
+		if err := csvWriter.Write(rawValues(batchRow(&results[index]))); err != nil {
+			return err
+		}
+	}
+
+	totalsRow := make([]string, len(batchColumns))
+	totalsRow[0] = "total"
+	for _, field := range totalsFields(totals) {
+		for index, column := range batchColumns {
+			if column.Key == field.Key {
+				totalsRow[index], _ = field.Raw()
+			}
+		}
+	}
+	if err := csvWriter.Write(totalsRow); err != nil {
+		return err
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+func rawValues(fields []Field) []string {
+	values := make([]string, 0, len(fields))
+	for _, field := range fields {
+		raw, _ := field.Raw()
+		values = append(values, raw)
+	}
+	return values
+}
+
+// tableColumns are the columns of the text and Markdown tables; the errors are listed below the table.
+func tableColumns() []Field {
+	return batchColumns[:len(batchColumns)-1]
+}
+
+func writeBatchMarkdown(w io.Writer, results []batch.Result, totals batch.Totals) error {
+	var sb strings.Builder
+	sb.WriteString("## Batch Results\n\n")
+	var labels, alignments []string
+	for _, column := range tableColumns() {
+		labels = append(labels, column.Label)
+		if column.kind == textKind {
+			alignments = append(alignments, "---")
+		} else {
+			alignments = append(alignments, "---:")
+		}
+	}
+	sb.WriteString(fmt.Sprintf("| %s |\n", strings.Join(labels, " | ")))
+	sb.WriteString(fmt.Sprintf("| %s |\n", strings.Join(alignments, " | ")))
+	for index := range results {
+		row := batchRow(&results[index])
+		cells := make([]string, 0, len(row))
+		for _, field := range row[:len(row)-1] {
+			cells = append(cells, escapeMarkdown(field.Display()))
+		}
+		sb.WriteString(fmt.Sprintf("| %s |\n", strings.Join(cells, " | ")))
+	}
+
+	if errorLines := batchErrors(results); len(errorLines) > 0 {
+		sb.WriteString("\n### Errors\n\n")
+		for _, errorLine := range errorLines {
+			sb.WriteString(fmt.Sprintf("- %s\n", escapeMarkdown(errorLine)))
+		}
+	}
+
+	sb.WriteString("\n### Totals\n\n")
+	sb.WriteString("| Field | Value |\n")
+	sb.WriteString("| --- | ---: |\n")
+	for _, field := range totalsFields(totals) {
+		sb.WriteString(fmt.Sprintf("| %s | %s |\n", field.Label, field.Display()))
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeBatchText(w io.Writer, results []batch.Result, totals batch.Totals) error {
+	columns := tableColumns()
+	rows := [][]string{make([]string, len(columns))}
+	for index, column := range columns {
+		rows[0][index] = column.Label
+	}
+	for index := range results {
+		row := batchRow(&results[index])
+		cells := make([]string, len(columns))
+		for column := range columns {
+			cells[column] = row[column].Display()
+		}
+		rows = append(rows, cells)
+	}
+
+	widths := make([]int, len(columns))
+	for _, row := range rows {
+		for index, cell := range row {
+			widths[index] = max(widths[index], len(cell))
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Batch Results:\n")
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for index, cell := range row {
+			// Text columns read left to right, numbers line up on the right
+			if columns[index].kind == textKind {
+				cells[index] = fmt.Sprintf("%-*s", widths[index], cell)
+			} else {
+				cells[index] = fmt.Sprintf("%*s", widths[index], cell)
+			}
+		}
+		sb.WriteString(fmt.Sprintf("  %s\n", strings.TrimRight(strings.Join(cells, "  "), " ")))
+	}
+
+	if errorLines := batchErrors(results); len(errorLines) > 0 {
+		sb.WriteString("Errors:\n")
+		for _, errorLine := range errorLines {
+			sb.WriteString(fmt.Sprintf("  %s\n", errorLine))
+		}
+	}
+
+	totalsFields := totalsFields(totals)
+	sb.WriteString("Totals:\n")
+	writeTextFields(&sb, "  ", maxLabelWidth(totalsFields), totalsFields)
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// batchErrors returns a line per error of the records that failed, e.g. "record 2: shares: missing value".
+func batchErrors(results []batch.Result) []string {
+	var errorLines []string
+	for index := range results {
+		result := &results[index]
+		if result.Err == nil {
+			continue
+		}
+		record := fmt.Sprintf("record %d", result.Number)
+		if result.Label != "" {
+			record = fmt.Sprintf("record %d (%s)", result.Number, result.Label)
+		}
+		for _, errorLine := range errorMessages(result) {
+			errorLines = append(errorLines, fmt.Sprintf("%s: %s", record, errorLine))
+		}
+	}
+	return errorLines
+}
+
+// errorMessages returns a message per invalid or missing value of a record that failed, none when it succeeded.
+func errorMessages(result *batch.Result) []string {
+	if result.Err == nil {
+		return []string{}
+	}
+	return strings.Split(result.Err.Error(), "\n")
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package format
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/leogps/lunar/pkg/batch"
+	"strings"
+	"testing"
+)
+
+func batchResults(t *testing.T) ([]batch.Result, batch.Totals) {
+	records, err := batch.Read(strings.NewReader(`[
+		{"type": "espp", "discount": 15, "cost-per-share": 100, "selling-price": 150, "shares": 10, "commission": 5, "cgt-percent": 24},
+		{"type": "rsu", "label": "no shares", "selling-price": 150, "fmv": 100}
+	]`), batch.JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, totals := batch.Calculate(records, nil)
+	return results, totals
+}
+
+func TestWriteBatch_JSON(t *testing.T) {
+	results, totals := batchResults(t)
+	var output bytes.Buffer
+	if err := WriteBatch(&output, JSON, results, totals); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded struct {
+		Orders []map[string]any `json:"orders"`
+		Totals map[string]any   `json:"totals"`
+	}
+	if err := json.Unmarshal(output.Bytes(), &decoded); err != nil {
+		t.Fatalf("%v in %s", err, output.String())
+	}
+	if len(decoded.Orders) != 2 || decoded.Orders[0]["type"] != "ESPP" || decoded.Orders[0]["computed"] == nil {
+		t.Fatalf("expected the full report of the ESPP order, got %v", decoded.Orders)
+	}
+	if _, ok := decoded.Orders[1]["inputs"]; ok || len(decoded.Orders[1]["errors"].([]any)) != 1 {
+		t.Errorf("expected only the error of the failed order, got %v", decoded.Orders[1])
+	}
+	if decoded.Totals["true_profit_or_loss"] != 490.2 || decoded.Totals["failed_orders"] != 1.0 {
+		t.Errorf("expected the totals of the ESPP order, got %v", decoded.Totals)
+	}
+}
+
+func TestWriteBatch_CSV(t *testing.T) {
+	results, totals := batchResults(t)
+	var output bytes.Buffer
+	if err := WriteBatch(&output, CSV, results, totals); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&output).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("expected a header, a row per order and the totals, got %v", records)
+	}
+	if records[2][len(records[2])-1] != "shares: missing value" {
+		t.Errorf("expected the error of the failed order, got %v", records[2])
+	}
+	if records[3][0] != "total" || records[3][9] != "490.20" {
+		t.Errorf("expected the total true profit, got %v", records[3])
+	}
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package format
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Format is the layout a report is written in.
+type Format int
+
+const (
+	// Text is an aligned, human-readable listing.
+	Text Format = iota
+	// JSON is a stable object keyed by section and field, for scripts.
+	JSON
+	// CSV is a header row of section.field keys followed by a row of values, for spreadsheets.
+	CSV
+	// Markdown is a table per section, for pasting into documents.
+	Markdown
+)
+
+func (f Format) String() string {
+	switch f {
+	case Text:
+		return "text"
+	case JSON:
+		return "json"
+	case CSV:
+		return "csv"
+	case Markdown:
+		return "markdown"
+	default:
+		return "unknown"
+	}
+}
+
+// Formats lists the formats in the order they are offered.
+func Formats() []Format {
+	return []Format{Text, JSON, CSV, Markdown}
+}
+
+func ParseFormat(value string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "text", "":
+		return Text, nil
+	case "json":
+		return JSON, nil
+	case "csv":
+		return CSV, nil
+	case "markdown", "md":
+		return Markdown, nil
+	default:
+		return 0, fmt.Errorf("invalid output format: %s, valid values: text, json, csv, markdown", value)
+	}
+}
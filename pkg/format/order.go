@@ -0,0 +1,204 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package format
+
+import (
+	"github.com/leogps/lunar/pkg/tax"
+	"github.com/leogps/lunar/pkg/types"
+)
+
+// NewEsppOrderReport reports the inputs of the ESPP order, the amounts derived from them and the profit/loss
+// computed from those.
+func NewEsppOrderReport(esppOrderSummary *types.EsppOrderSummary) *Report {
+	esppOrder := esppOrderSummary.EsppOrder
+	inputs := []Field{
+		percent("discount_percent", "Discount Percent", esppOrder.DiscountPercent),
+		perShare("cost_per_share", "Cost Per Share", esppOrder.CostPerShare),
+		perShare("selling_price_per_share", "Selling Price Per Share", esppOrder.SellingPricePerShare),
+		shares("number_of_shares_sold", "Number Of Shares Sold", esppOrder.NumberOfSharesSold),
+		flag("consider_look_back", "Look-Back", esppOrder.ConsiderLookBack),
+		perShare("offering_date_market_value_per_share", "Offering Date FMV Per Share", esppOrder.OfferingDateMarketValuePerShare),
+		perShare("purchase_date_market_value_per_share", "Purchase Date FMV Per Share", esppOrder.PurchaseDateMarketValuePerShare),
+		date("offering_date", "Offering Date", esppOrder.OfferingDate),
+		date("purchase_date", "Purchase Date", esppOrder.PurchaseDate),
+		date("sale_date", "Sale Date", esppOrder.SaleDate),
+		flag("consider_disposition", "Classify Disposition", esppOrder.ConsiderDisposition),
+		percent("ordinary_income_tax_percent", "Ordinary Income Tax Percent", esppOrder.OrdinaryIncomeTaxPercent),
+	}
+	inputs = append(inputs, commissionInputs(esppOrder.ConsiderTransactionCommission, esppOrder.CommissionPaidPerTransaction,
+		esppOrder.NumberOfTransactions)...)
+	inputs = append(inputs,
+		flag("consider_capital_gain_tax", "Deduct Capital Gain Tax", esppOrder.ConsiderCapitalGainTax),
+		percent("capital_gain_tax_percent", "Capital Gain Tax Percent", esppOrder.CapitalGainTaxPercent),
+		flag("consider_holding_period", "Consider Holding Period", esppOrder.ConsiderHoldingPeriod),
+		percent("short_term_capital_gain_tax_percent", "Short-Term Capital Gain Tax Percent", esppOrder.ShortTermCapitalGainTaxPercent),
+		percent("long_term_capital_gain_tax_percent", "Long-Term Capital Gain Tax Percent", esppOrder.LongTermCapitalGainTaxPercent),
+	)
+	inputs = append(inputs, taxProfileInputs(esppOrder.TaxModel, esppOrder.StateTaxModel, esppOrder.TaxProfile)...)
+	inputs = append(inputs, netInvestmentIncomeTaxInputs(esppOrder.ConsiderNetInvestmentIncomeTax, esppOrder.ModifiedAdjustedGrossIncome)...)
+	inputs = append(inputs, capitalLossInputs(esppOrder.ConsiderCapitalLoss, esppOrder.OtherRealizedCapitalGains,
+		esppOrder.CapitalLossCarryforward)...)
+
+	derived := []Field{
+		perShare("base_cost_per_share", "Base Cost Per Share", esppOrderSummary.BaseCostPerShare),
+		perShare("effective_cost_per_share", "Effective Cost Per Share", esppOrderSummary.EffectiveCostPerShare),
+		amount("total_selling_price", "Total Selling Price", esppOrderSummary.TotalSellingPrice),
+		amount("total_cost", "Total Cost", esppOrderSummary.TotalCost),
+		amount("effective_commission", "Effective Commission", esppOrderSummary.EffectiveCommission),
+		amount("net_result", "Net Result", esppOrderSummary.NetResult),
+		classification("disposition_type", "Disposition", esppOrderSummary.DispositionType.String(), esppOrder.ConsiderDisposition),
+		perShare("ordinary_income_per_share", "Ordinary Income Per Share", esppOrderSummary.OrdinaryIncomePerShare),
+		amount("ordinary_income_amount", "Ordinary Income Amount", esppOrderSummary.OrdinaryIncomeAmount),
+		amount("ordinary_income_tax_amount", "Ordinary Income Tax Amount", esppOrderSummary.OrdinaryIncomeTaxAmount),
+		amount("federal_ordinary_income_tax_amount", "Federal Ordinary Income Tax Amount", esppOrderSummary.FederalOrdinaryIncomeTaxAmount),
+		amount("state_ordinary_income_tax_amount", "State Ordinary Income Tax Amount", esppOrderSummary.StateOrdinaryIncomeTaxAmount),
+		perShare("adjusted_cost_basis_per_share", "Adjusted Cost Basis Per Share", esppOrderSummary.AdjustedCostBasisPerShare),
+		amount("capital_gain_amount", "Capital Gain Amount", esppOrderSummary.CapitalGainAmount),
+		classification("holding_period", "Holding Period", esppOrderSummary.HoldingPeriod.String(), esppOrder.ConsiderHoldingPeriod),
+		date("long_term_date", "Long-Term From", esppOrderSummary.LongTermDate),
+		percent("effective_capital_gain_tax_percent", "Effective Capital Gain Tax Percent", esppOrderSummary.EffectiveCapitalGainTaxPercent),
+		amount("capital_gain_tax_amount", "Capital Gain Tax Amount", esppOrderSummary.CapitalGainTaxAmount),
+		amount("federal_capital_gain_tax_amount", "Federal Capital Gain Tax Amount", esppOrderSummary.FederalCapitalGainTaxAmount),
+		amount("state_capital_gain_tax_amount", "State Capital Gain Tax Amount", esppOrderSummary.StateCapitalGainTaxAmount),
+		amount("net_investment_income_tax_amount", "Net Investment Income Tax Amount", esppOrderSummary.NetInvestmentIncomeTaxAmount),
+		amount("capital_loss_carryforward_amount", "Capital Loss Carryforward Amount", esppOrderSummary.CapitalLossCarryforwardAmount),
+	}
+
+	computed := []Field{
+		amount("profit_or_loss_after_capital_gains_tax", "Profit/Loss After Capital Gains Tax", esppOrderSummary.ProfitOrLossAfterCapitalGainsTax()),
+		amount("true_profit_or_loss", "True Profit/Loss", esppOrderSummary.TrueProfitOrLoss()),
+		amount("net_proceeds", "Net Proceeds", esppOrderSummary.NetProceeds()),
+		percent("profit_or_loss_margin", "Profit/Loss Margin", esppOrderSummary.ProfitOrLossMargin()),
+		flag("is_profitable", "Is Profitable", esppOrderSummary.IsProfitable()),
+	}
+
+	return &Report{
+		Type:     types.Espp.String(),
+		Title:    "ESPP Order Summary",
+		Sections: orderSections(inputs, derived, computed),
+	}
+}
+
+// NewRsuOrderReport reports the inputs of the RSU order, the amounts derived from them and the profit/loss
+// computed from those.
+func NewRsuOrderReport(rsuOrderSummary *types.RsuOrderSummary) *Report {
+	rsuOrder := rsuOrderSummary.RsuOrder
+	inputs := []Field{
+		perShare("selling_price_per_share", "Selling Price Per Share", rsuOrder.SellingPricePerShare),
+		shares("number_of_shares_sold", "Number Of Shares Sold", rsuOrder.NumberOfSharesSold),
+		perShare("market_value_per_share", "FMV At Vest Per Share", rsuOrder.MarketValuePerShare),
+		date("vest_date", "Vest Date", rsuOrder.VestDate),
+		date("sale_date", "Sale Date", rsuOrder.SaleDate),
+		flag("consider_income_tax_on_vested_stock", "Deduct Income Tax At Vest", rsuOrder.ConsiderIncomeTaxOnVestedStock),
+		amount("income_tax_incurred_when_stock_vested", "Income Tax Incurred At Vest", rsuOrder.IncomeTaxIncurredWhenStockVested),
+		shares("number_of_stocks_vested", "Number Of Stocks Vested", rsuOrder.NumberOfStocksVested),
+		percent("ordinary_income_tax_percent", "Ordinary Income Tax Percent", rsuOrder.OrdinaryIncomeTaxPercent),
+	}
+	inputs = append(inputs, commissionInputs(rsuOrder.ConsiderTransactionCommission, rsuOrder.CommissionPaidPerTransaction,
+		rsuOrder.NumberOfTransactions)...)
+	inputs = append(inputs,
+		flag("consider_capital_gain_tax", "Deduct Capital Gain Tax", rsuOrder.ConsiderCapitalGainTax),
+		percent("capital_gain_tax_percent", "Capital Gain Tax Percent", rsuOrder.CapitalGainTaxPercent),
+		flag("consider_holding_period", "Consider Holding Period", rsuOrder.ConsiderHoldingPeriod),
+		percent("short_term_capital_gain_tax_percent", "Short-Term Capital Gain Tax Percent", rsuOrder.ShortTermCapitalGainTaxPercent),
+		percent("long_term_capital_gain_tax_percent", "Long-Term Capital Gain Tax Percent", rsuOrder.LongTermCapitalGainTaxPercent),
+	)
+	inputs = append(inputs, taxProfileInputs(rsuOrder.TaxModel, rsuOrder.StateTaxModel, rsuOrder.TaxProfile)...)
+	inputs = append(inputs, netInvestmentIncomeTaxInputs(rsuOrder.ConsiderNetInvestmentIncomeTax, rsuOrder.ModifiedAdjustedGrossIncome)...)
+	inputs = append(inputs, capitalLossInputs(rsuOrder.ConsiderCapitalLoss, rsuOrder.OtherRealizedCapitalGains,
+		rsuOrder.CapitalLossCarryforward)...)
+
+	derived := []Field{
+		amount("total_selling_price", "Total Selling Price", rsuOrderSummary.TotalSellingPrice),
+		amount("effective_commission", "Effective Commission", rsuOrderSummary.EffectiveCommission),
+		amount("net_result", "Net Result", rsuOrderSummary.NetResult),
+		amount("total_income_tax_incurred", "Total Income Tax Incurred", rsuOrderSummary.TotalIncomeTaxIncurred),
+		classification("holding_period", "Holding Period", rsuOrderSummary.HoldingPeriod.String(), rsuOrder.ConsiderHoldingPeriod),
+		date("long_term_date", "Long-Term From", rsuOrderSummary.LongTermDate),
+		percent("effective_capital_gain_tax_percent", "Effective Capital Gain Tax Percent", rsuOrderSummary.EffectiveCapitalGainTaxPercent),
+		amount("capital_gain_tax_amount", "Capital Gain Tax Amount", rsuOrderSummary.CapitalGainTaxAmount),
+		amount("federal_capital_gain_tax_amount", "Federal Capital Gain Tax Amount", rsuOrderSummary.FederalCapitalGainTaxAmount),
+		amount("state_capital_gain_tax_amount", "State Capital Gain Tax Amount", rsuOrderSummary.StateCapitalGainTaxAmount),
+		amount("net_investment_income_tax_amount", "Net Investment Income Tax Amount", rsuOrderSummary.NetInvestmentIncomeTaxAmount),
+		amount("capital_loss_carryforward_amount", "Capital Loss Carryforward Amount", rsuOrderSummary.CapitalLossCarryforwardAmount),
+	}
+
+	computed := []Field{
+		amount("profit_or_loss_after_income_tax", "Profit/Loss After Income Tax", rsuOrderSummary.ProfitOrLossAfterIncomeTax()),
+		amount("profit_or_loss_after_capital_gains_tax", "Profit/Loss After Capital Gains Tax", rsuOrderSummary.ProfitOrLossAfterCapitalGainsTax()),
+		amount("true_profit_or_loss", "True Profit/Loss", rsuOrderSummary.TrueProfitOrLoss()),
+		amount("net_proceeds", "Net Proceeds", rsuOrderSummary.NetProceeds()),
+		percent("profit_or_loss_margin", "Profit/Loss Margin", rsuOrderSummary.ProfitOrLossMargin()),
+		flag("is_profitable", "Is Profitable", rsuOrderSummary.IsProfitable()),
+	}
+
+	return &Report{
+		Type:     types.Rsu.String(),
+		Title:    "RSU Order Summary",
+		Sections: orderSections(inputs, derived, computed),
+	}
+}
+
+func orderSections(inputs []Field, derived []Field, computed []Field) []Section {
+	return []Section{
+		{Key: "inputs", Title: "Inputs", Fields: inputs},
+		{Key: "derived", Title: "Derived", Fields: derived},
+		{Key: "computed", Title: "Computed", Fields: computed},
+	}
+}
+
+func commissionInputs(considerTransactionCommission bool, commissionPaidPerTransaction types.Money, numberOfTransactions int) []Field {
+	return []Field{
+		flag("consider_transaction_commission", "Deduct Transaction Commission", considerTransactionCommission),
+		amount("commission_paid_per_transaction", "Commission Per Transaction", commissionPaidPerTransaction),
+		count("number_of_transactions", "Number Of Transactions", numberOfTransactions),
+	}
+}
+
+// taxProfileInputs reports whether the progressive federal brackets and a state tax were applied, and the tax
+// profile they were applied for.
+func taxProfileInputs(taxModel tax.Model, stateTaxModel tax.Model, taxProfile tax.Profile) []Field {
+	return []Field{
+		flag("tax_brackets", "Progressive Federal Tax Brackets", taxModel != nil),
+		flag("state_tax", "State Tax", stateTaxModel != nil),
+		count("tax_year", "Tax Year", taxProfile.TaxYear),
+		text("filing_status", "Filing Status", taxProfile.FilingStatus.String()),
+		amount("other_income", "Other Income", types.NewMoney(taxProfile.OtherIncome)),
+	}
+}
+
+func netInvestmentIncomeTaxInputs(considerNetInvestmentIncomeTax bool, modifiedAdjustedGrossIncome types.Money) []Field {
+	return []Field{
+		flag("consider_net_investment_income_tax", "Net Investment Income Tax", considerNetInvestmentIncomeTax),
+		amount("modified_adjusted_gross_income", "Modified Adjusted Gross Income", modifiedAdjustedGrossIncome),
+	}
+}
+
+func capitalLossInputs(considerCapitalLoss bool, otherRealizedCapitalGains types.Money, capitalLossCarryforward types.Money) []Field {
+	return []Field{
+		flag("consider_capital_loss", "Capital Loss", considerCapitalLoss),
+		amount("other_realized_capital_gains", "Other Realized Capital Gains", otherRealizedCapitalGains),
+		amount("capital_loss_carryforward", "Capital Loss Carryforward", capitalLossCarryforward),
+	}
+}
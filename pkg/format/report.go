@@ -0,0 +1,334 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package format
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/leogps/lunar/pkg/types"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fieldKind decides how a field value is written
+type fieldKind int
+
+const (
+	amountKind fieldKind = iota
+	perShareKind
+	sharesKind
+	percentKind
+	dateKind
+	boolKind
+	countKind
+	textKind
+)
+
+// Field is a single named value of a report. Key is the stable machine-readable name, Label the human-readable one.
+type Field struct {
+	Key   string
+	Label string
+	kind  fieldKind
+	value any
+}
+
+// Section groups the fields of a report, e.g. the inputs or the derived amounts
+type Section struct {
+	Key    string
+	Title  string
+	Fields []Field
+}
+
+// Report is the formattable result of a calculation. Every report of the same Type has the same sections and fields
+// in the same order, whatever was considered, so the output is stable for scripts.
+type Report struct {
+	Type     string
+	Title    string
+	Sections []Section
+}
+
+func amount(key string, label string, value types.Money) Field {
+	return Field{Key: key, Label: label, kind: amountKind, value: value}
+}
+
+func perShare(key string, label string, value types.Money) Field {
+	return Field{Key: key, Label: label, kind: perShareKind, value: value}
+}
+
+func shares(key string, label string, value types.Shares) Field {
+	return Field{Key: key, Label: label, kind: sharesKind, value: value}
+}
+
+func percent(key string, label string, value float64) Field {
+	return Field{Key: key, Label: label, kind: percentKind, value: value}
+}
+
+func date(key string, label string, value time.Time) Field {
+	return Field{Key: key, Label: label, kind: dateKind, value: value}
+}
+
+func flag(key string, label string, value bool) Field {
+	return Field{Key: key, Label: label, kind: boolKind, value: value}
+}
+
+func count(key string, label string, value int) Field {
+	return Field{Key: key, Label: label, kind: countKind, value: value}
+}
+
+func text(key string, label string, value string) Field {
+	return Field{Key: key, Label: label, kind: textKind, value: value}
+}
+
+// classification is a text field with no value unless it was considered, so a default classification is not
+// mistaken for a computed one
+func classification(key string, label string, value string, considered bool) Field {
+	if !considered {
+		return Field{Key: key, Label: label, kind: textKind}
+	}
+	return text(key, label, value)
+}
+
+// Raw returns the value as written to JSON and CSV: dollar amounts rounded to the cent, per-share amounts with
+// 4 decimal places, dates as YYYY-MM-DD and percents as plain numbers. ok is false when there is no value,
+// i.e. an unset date, a percent that is not a finite number, a classification that was not considered or an order
+// that was not calculated.
+func (f Field) Raw() (raw string, ok bool) {
+	if f.value == nil {
+		return "", false
+	}
+	switch f.kind {
+	case amountKind:
+		return f.value.(types.Money).String(), true
+	case perShareKind:
+		return f.value.(types.Money).StringPerShare(), true
+	case sharesKind:
+		return f.value.(types.Shares).String(), true
+	case percentKind:
+		value := f.value.(float64)
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return "", false
+		}
+		return strconv.FormatFloat(value, 'f', -1, 64), true
+	case dateKind:
+		value := f.value.(time.Time)
+		if value.IsZero() {
+			return "", false
+		}
+		return value.Format(types.DateLayout), true
+	case boolKind:
+		return strconv.FormatBool(f.value.(bool)), true
+	case countKind:
+		return strconv.Itoa(f.value.(int)), true
+	default:
+		return f.value.(string), true
+	}
+}
+
+// Display returns the value as written to text and Markdown, with $ and % signs.
+func (f Field) Display() string {
+	raw, ok := f.Raw()
+	if !ok {
+		return "-"
+	}
+	switch f.kind {
+	case amountKind, perShareKind:
+		return "$" + raw
+	case percentKind:
+		return fmt.Sprintf("%.2f%%", f.value.(float64))
+	default:
+		return raw
+	}
+}
+
+// MarshalJSON writes the amounts, shares and percents as JSON numbers and a missing value as null.
+func (f Field) MarshalJSON() ([]byte, error) {
+	raw, ok := f.Raw()
+	if !ok {
+		return []byte("null"), nil
+	}
+	switch f.kind {
+	case amountKind, perShareKind, sharesKind, percentKind, boolKind, countKind:
+		return []byte(raw), nil
+	default:
+		return json.Marshal(raw)
+	}
+}
+
+// MarshalJSON writes the report as {"type": ..., "<section>": {"<field>": ...}} keeping the field order.
+func (r *Report) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString(`{"type":`)
+	reportType, err := json.Marshal(r.Type)
+	if err != nil {
+		return nil, err
+	}
+	buffer.Write(reportType)
+	writeJSONSections(&buffer, r.Sections)
+	buffer.WriteString("}")
+	return buffer.Bytes(), nil
+}
+
+// writeJSONSections writes each section as a ,"<section>": {...} member of an object.
+func writeJSONSections(buffer *bytes.Buffer, sections []Section) {
+	for _, section := range sections {
+		buffer.WriteString(fmt.Sprintf(",%q:{", section.Key))
+		writeJSONFields(buffer, section.Fields)
+		buffer.WriteString("}")
+	}
+}
+
+// writeJSONFields writes the fields as comma-separated "<field>": value members of an object.
+func writeJSONFields(buffer *bytes.Buffer, fields []Field) {
+	for index, field := range fields {
+		if index > 0 {
+			buffer.WriteString(",")
+		}
+		// Field values always marshal
+		value, _ := field.MarshalJSON()
+		buffer.WriteString(fmt.Sprintf("%q:", field.Key))
+		buffer.Write(value)
+	}
+}
+
+// Keys returns the section.field keys of the report in order, the CSV header.
+func (r *Report) Keys() []string {
+	keys := []string{"type"}
+	for _, section := range r.Sections {
+		for _, field := range section.Fields {
+			keys = append(keys, section.Key+"."+field.Key)
+		}
+	}
+	return keys
+}
+
+// Values returns the raw values of the report in the order of Keys, a CSV row.
+func (r *Report) Values() []string {
+	values := []string{r.Type}
+	for _, section := range r.Sections {
+		for _, field := range section.Fields {
+			raw, _ := field.Raw()
+			values = append(values, raw)
+		}
+	}
+	return values
+}
+
+// Write writes the report to w in the format.
+func Write(w io.Writer, format Format, report *Report) error {
+	switch format {
+	case JSON:
+		return writeJSON(w, report)
+	case CSV:
+		return writeCSV(w, report)
+	case Markdown:
+		return writeMarkdown(w, report)
+	default:
+		return writeText(w, report)
+	}
+}
+
+func writeJSON(w io.Writer, report *Report) error {
+	compact, err := report.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	return writeIndentedJSON(w, compact)
+}
+
+func writeIndentedJSON(w io.Writer, compact []byte) error {
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, compact, "", "  "); err != nil {
+		return err
+	}
+	indented.WriteString("\n")
+	_, err := indented.WriteTo(w)
+	return err
+}
+
+func writeCSV(w io.Writer, report *Report) error {
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write(report.Keys()); err != nil {
+		return err
+	}
+	if err := csvWriter.Write(report.Values()); err != nil {
+		return err
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+func writeMarkdown(w io.Writer, report *Report) error {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("## %s\n", report.Title))
+	for _, section := range report.Sections {
+		sb.WriteString(fmt.Sprintf("\n### %s\n\n", section.Title))
+		sb.WriteString("| Field | Value |\n")
+		sb.WriteString("| --- | ---: |\n")
+		for _, field := range section.Fields {
+			sb.WriteString(fmt.Sprintf("| %s | %s |\n", escapeMarkdown(field.Label), escapeMarkdown(field.Display())))
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func escapeMarkdown(value string) string {
+	return strings.ReplaceAll(value, "|", `\|`)
+}
+
+func writeText(w io.Writer, report *Report) error {
+	labelWidth := 0
+	for _, section := range report.Sections {
+		labelWidth = max(labelWidth, maxLabelWidth(section.Fields))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s:\n", report.Title))
+	for _, section := range report.Sections {
+		sb.WriteString(fmt.Sprintf("  %s:\n", section.Title))
+		writeTextFields(&sb, "    ", labelWidth, section.Fields)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func maxLabelWidth(fields []Field) int {
+	labelWidth := 0
+	for _, field := range fields {
+		labelWidth = max(labelWidth, len(field.Label))
+	}
+	return labelWidth
+}
+
+// writeTextFields writes a "Label: value" line per field with the values lined up after labelWidth.
+func writeTextFields(sb *strings.Builder, indent string, labelWidth int, fields []Field) {
+	for _, field := range fields {
+		sb.WriteString(fmt.Sprintf("%s%-*s %s\n", indent, labelWidth+1, field.Label+":", field.Display()))
+	}
+}
@@ -0,0 +1,183 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package format
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/leogps/lunar/pkg/types"
+	"strings"
+	"testing"
+)
+
+func esppOrderReport(t *testing.T) *Report {
+	esppOrder := &types.EsppOrder{
+		DiscountPercent:               15,
+		CostPerShare:                  types.NewMoney(100),
+		SellingPricePerShare:          types.NewMoney(150),
+		NumberOfSharesSold:            types.NewShares(10),
+		ConsiderTransactionCommission: true,
+		CommissionPaidPerTransaction:  types.NewMoney(5),
+		NumberOfTransactions:          1,
+		ConsiderCapitalGainTax:        true,
+		CapitalGainTaxPercent:         24,
+	}
+	esppOrderSummary, err := esppOrder.CalculateEsppOrderSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewEsppOrderReport(esppOrderSummary)
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, format := range Formats() {
+		parsed, err := ParseFormat(format.String())
+		if err != nil || parsed != format {
+			t.Errorf("expected %s to parse, got %s, %v", format, parsed, err)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("expected an error for xml")
+	}
+}
+
+func TestWrite_JSON(t *testing.T) {
+	var output bytes.Buffer
+	if err := Write(&output, JSON, esppOrderReport(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	var report map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(output.Bytes()))
+	decoder.UseNumber()
+	if err := decoder.Decode(&report); err != nil {
+		t.Fatalf("%v in %s", err, output.String())
+	}
+	decoded := map[string]map[string]any{}
+	for _, section := range []string{"inputs", "derived", "computed"} {
+		decoded[section] = report[section].(map[string]any)
+	}
+	if decoded["inputs"]["cost_per_share"] != json.Number("100.0000") {
+		t.Errorf("expected the cost per share 100.0000, got %v", decoded["inputs"]["cost_per_share"])
+	}
+	if decoded["derived"]["effective_cost_per_share"] != json.Number("85.0000") {
+		t.Errorf("expected the effective cost per share 85.0000, got %v", decoded["derived"]["effective_cost_per_share"])
+	}
+	if decoded["computed"]["true_profit_or_loss"] != json.Number("490.20") {
+		t.Errorf("expected the true profit 490.20, got %v", decoded["computed"]["true_profit_or_loss"])
+	}
+	if decoded["computed"]["is_profitable"] != true {
+		t.Errorf("expected the order to be profitable, got %v", decoded["computed"]["is_profitable"])
+	}
+	if decoded["inputs"]["sale_date"] != nil {
+		t.Errorf("expected no sale date, got %v", decoded["inputs"]["sale_date"])
+	}
+
+	if !strings.HasPrefix(output.String(), "{\n  \"type\": \"ESPP\",\n  \"inputs\": {\n    \"discount_percent\": 15,") {
+		t.Errorf("expected the sections and fields in order, got %s", output.String())
+	}
+}
+
+func TestWrite_JSONClassificationNotConsidered(t *testing.T) {
+	var output bytes.Buffer
+	if err := Write(&output, JSON, esppOrderReport(t)); err != nil {
+		t.Fatal(err)
+	}
+	for _, golden := range []string{
+		"    \"net_result\": 645.00,\n    \"disposition_type\": null,\n",
+		"    \"capital_gain_amount\": 645.00,\n    \"holding_period\": null,\n",
+	} {
+		if !strings.Contains(output.String(), golden) {
+			t.Errorf("expected %q, got %s", golden, output.String())
+		}
+	}
+}
+
+func TestWrite_JSONWithoutMargin(t *testing.T) {
+	rsuOrder := &types.RsuOrder{
+		SellingPricePerShare: types.NewMoney(150),
+		NumberOfSharesSold:   types.NewShares(10),
+		MarketValuePerShare:  types.NewMoney(100),
+	}
+	rsuOrderSummary, err := rsuOrder.CalculateRsuOrderSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+	if err = Write(&output, JSON, NewRsuOrderReport(rsuOrderSummary)); err != nil {
+		t.Fatal(err)
+	}
+	if !json.Valid(output.Bytes()) {
+		t.Fatalf("expected valid JSON, got %s", output.String())
+	}
+	if !strings.Contains(output.String(), `"profit_or_loss_margin": null`) {
+		t.Errorf("expected a null margin without income tax, got %s", output.String())
+	}
+}
+
+func TestWrite_CSV(t *testing.T) {
+	report := esppOrderReport(t)
+	var output bytes.Buffer
+	if err := Write(&output, CSV, report); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&output).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || len(records[0]) != len(records[1]) {
+		t.Fatalf("expected a header and a row of the same length, got %v", records)
+	}
+	for index, key := range records[0] {
+		if key == "computed.true_profit_or_loss" && records[1][index] != "490.20" {
+			t.Errorf("expected the true profit 490.20, got %s", records[1][index])
+		}
+	}
+}
+
+func TestWrite_Text(t *testing.T) {
+	var output bytes.Buffer
+	if err := Write(&output, Text, esppOrderReport(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	valueColumn := -1
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		if !strings.HasPrefix(line, "    ") {
+			continue
+		}
+		column := strings.LastIndex(line, " ") + 1
+		if valueColumn == -1 {
+			valueColumn = column
+		} else if column != valueColumn {
+			t.Errorf("expected the values to line up at column %d, got %q", valueColumn, line)
+		}
+	}
+	if !strings.Contains(output.String(), "True Profit/Loss:") || !strings.Contains(output.String(), "$490.20") {
+		t.Errorf("expected the true profit, got %s", output.String())
+	}
+}
@@ -0,0 +1,359 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+// Package importer reads the lots and sales of the CSV exports of stock plan brokers into the ledger.
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/leogps/lunar/pkg/ledger"
+	"github.com/leogps/lunar/pkg/types"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxHeaderRows is how many leading rows are searched for the header, e.g. past the title rows of an export.
+const maxHeaderRows = 10
+
+// Entry is a lot read from a row of an export along with the sale from it, when the row is a sale.
+type Entry struct {
+	// Line is the line of the row in the file.
+	Line int
+	Lot  *ledger.Lot
+	// Sale is nil for a purchase or vest; its LotID is left for Import to set.
+	Sale *ledger.Sale
+	// Err is why the row could not be read, leaving Lot and Sale nil.
+	Err error
+}
+
+// Options tune how the rows are read.
+type Options struct {
+	// DiscountPercent is the ESPP discount of the rows without one. When zero, it is derived from the purchase price
+	// and the lower of the offering and purchase date FMVs, as with a look-back.
+	DiscountPercent float64
+}
+
+// ReadFile reads the entries of an export file, see Read.
+func ReadFile(path string, layout *Layout, options Options) (*Layout, []*Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	return Read(file, layout, options)
+}
+
+// Read reads the entries of an export. The header is the first of the leading rows that holds the key columns of
+// the layout, which is detected from the built-in layouts when nil. Rows filtered out by the layout, blank rows and,
+// without a filter, rows without an acquisition date such as totals are skipped.
+// Returns the layout read with; an error when no header is found.
+func Read(r io.Reader, layout *Layout, options Options) (*Layout, []*Entry, error) {
+	csvReader := csv.NewReader(r)
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+
+	var header map[string]int
+	for rowNumber := 0; header == nil; rowNumber++ {
+		row, err := csvReader.Read()
+		if err == io.EOF || rowNumber == maxHeaderRows {
+			if layout != nil {
+				return nil, nil, fmt.Errorf("no header with the columns %s of the %s layout",
+					strings.Join(layout.keyColumns(), ", "), layout.Name)
+			}
+			return nil, nil, fmt.Errorf("no header matching a layout, valid layouts: %s", strings.Join(layoutNames(), ", "))
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		candidate := map[string]int{}
+		for column, name := range row {
+			if _, ok := candidate[normalizeColumn(name)]; !ok {
+				candidate[normalizeColumn(name)] = column
+			}
+		}
+		if layout == nil {
+			layout = detectLayout(candidate)
+			if layout != nil {
+				header = candidate
+			}
+		} else if layout.matches(candidate) {
+			header = candidate
+		}
+	}
+
+	var entries []*Entry
+	for {
+		row, err := csvReader.Read()
+		if err == io.EOF {
+			return layout, entries, nil
+		}
+		line, _ := csvReader.FieldPos(0)
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				line = parseErr.Line
+			}
+			entries = append(entries, &Entry{Line: line, Err: err})
+			continue
+		}
+		parser := &rowParser{layout: layout, header: header, row: row, options: options}
+		if parser.skip() {
+			continue
+		}
+		entry := &Entry{Line: line}
+		entry.Lot, entry.Sale = parser.parse()
+		if err = parser.err(); err != nil {
+			entry.Lot, entry.Sale, entry.Err = nil, nil, err
+		}
+		entries = append(entries, entry)
+	}
+}
+
+func layoutNames() []string {
+	var names []string
+	for _, layout := range layouts {
+		names = append(names, layout.Name)
+	}
+	return names
+}
+
+// rowParser parses the cells of a row, collecting an error per invalid or missing value
+type rowParser struct {
+	layout  *Layout
+	header  map[string]int
+	row     []string
+	options Options
+	errs    []error
+}
+
+// cell returns the trimmed cell of the column mapped to the field, empty when the field or column is missing or
+// holds a placeholder such as --
+func (p *rowParser) cell(field string) string {
+	column, ok := p.layout.Columns[field]
+	if !ok {
+		return ""
+	}
+	return p.column(column)
+}
+
+func (p *rowParser) column(name string) string {
+	index, ok := p.header[normalizeColumn(name)]
+	if !ok || index >= len(p.row) {
+		return ""
+	}
+	cell := strings.TrimSpace(p.row[index])
+	if cell == "--" || strings.EqualFold(cell, "n/a") {
+		return ""
+	}
+	return cell
+}
+
+// first returns the first of the fields with a value, empty when none has
+func (p *rowParser) first(fields ...string) string {
+	for _, field := range fields {
+		if p.cell(field) != "" {
+			return field
+		}
+	}
+	return ""
+}
+
+func (p *rowParser) skip() bool {
+	blank := true
+	for _, cell := range p.row {
+		blank = blank && strings.TrimSpace(cell) == ""
+	}
+	if blank {
+		return true
+	}
+	if p.layout.FilterColumn == "" {
+		return p.first("acquisition-date", "purchase-date", "vest-date") == ""
+	}
+	value := p.column(p.layout.FilterColumn)
+	for _, filterValue := range p.layout.FilterValues {
+		if strings.EqualFold(value, strings.TrimSpace(filterValue)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *rowParser) fail(field string, err error) {
+	p.errs = append(p.errs, fmt.Errorf("%s (%s): %w", field, p.layout.Columns[field], err))
+}
+
+func (p *rowParser) err() error {
+	return errors.Join(p.errs...)
+}
+
+// require records an error when none of the fields has a value
+func (p *rowParser) require(fields ...string) string {
+	field := p.first(fields...)
+	if field == "" {
+		p.errs = append(p.errs, fmt.Errorf("%s: missing value", strings.Join(fields, " or ")))
+	}
+	return field
+}
+
+func (p *rowParser) money(field string) types.Money {
+	cell := p.cell(field)
+	if cell == "" {
+		return 0
+	}
+	// Accountants write negative amounts in parentheses, e.g. ($12.50)
+	if strings.HasPrefix(cell, "(") && strings.HasSuffix(cell, ")") {
+		cell = "-" + strings.TrimSuffix(strings.TrimPrefix(cell, "("), ")")
+	}
+	value, err := types.ParseMoney(cell)
+	if err != nil {
+		p.fail(field, err)
+	}
+	return value
+}
+
+func (p *rowParser) shares(field string) types.Shares {
+	cell := strings.ReplaceAll(p.cell(field), ",", "")
+	if cell == "" {
+		return 0
+	}
+	value, err := types.ParseShares(cell)
+	if err != nil {
+		p.fail(field, err)
+	}
+	return value
+}
+
+func (p *rowParser) percent(field string) float64 {
+	cell := strings.TrimSuffix(p.cell(field), "%")
+	if cell == "" {
+		return 0
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(cell), 64)
+	if err != nil {
+		p.fail(field, fmt.Errorf("invalid percent: %s", p.cell(field)))
+	}
+	return value
+}
+
+func (p *rowParser) date(field string) time.Time {
+	cell := p.cell(field)
+	if cell == "" {
+		return time.Time{}
+	}
+	for _, dateLayout := range p.layout.DateLayouts {
+		if date, err := time.Parse(dateLayout, cell); err == nil {
+			return date
+		}
+	}
+	p.fail(field, fmt.Errorf("invalid date: %s", cell))
+	return time.Time{}
+}
+
+func (p *rowParser) orderType() (types.OrderType, bool) {
+	value := p.cell("type")
+	if value == "" {
+		if p.layout.DefaultType == nil {
+			p.require("type")
+			return 0, false
+		}
+		return *p.layout.DefaultType, true
+	}
+	orderType, ok := p.layout.Types[strings.ToLower(value)]
+	if !ok {
+		p.fail("type", fmt.Errorf("unknown plan type: %s", value))
+	}
+	return orderType, ok
+}
+
+// parse reads the lot of the row and the sale from it
+func (p *rowParser) parse() (*ledger.Lot, *ledger.Sale) {
+	orderType, ok := p.orderType()
+	if !ok {
+		return nil, nil
+	}
+	lot := &ledger.Lot{Label: p.cell("symbol")}
+	lot.Source = orderType
+	lot.Quantity = p.shares(p.require("shares"))
+
+	dateField, basisField := "vest-date", "vest-fmv"
+	if orderType == types.Espp {
+		dateField, basisField = "purchase-date", "purchase-price"
+	}
+	lot.AcquisitionDate = p.date(p.require(dateField, "acquisition-date"))
+	if field := p.first(basisField, "basis-per-share"); field != "" {
+		lot.BasisPerShare = p.money(field)
+	} else if p.require(basisField, "basis-per-share", "cost-basis") != "" && lot.Quantity > 0 {
+		lot.BasisPerShare = p.money("cost-basis").DivShares(lot.Quantity)
+	}
+
+	if orderType == types.Espp {
+		lot.OfferingDate = p.date("offering-date")
+		lot.OfferingDateMarketValuePerShare = p.money("offering-fmv")
+		lot.PurchaseDateMarketValuePerShare = p.money("purchase-fmv")
+		lot.DiscountPercent = p.discountPercent(&lot.Lot)
+	} else {
+		lot.IncomeTax = p.money("income-tax")
+	}
+
+	if p.cell("sale-date") == "" {
+		return lot, nil
+	}
+	sale := &ledger.Sale{Label: lot.Label, Quantity: lot.Quantity}
+	sale.Date = p.date("sale-date")
+	if !sale.Date.IsZero() && !lot.AcquisitionDate.IsZero() && sale.Date.Before(lot.AcquisitionDate) {
+		p.fail("sale-date", fmt.Errorf("must not be before the acquisition date"))
+	}
+	if p.cell("selling-price") != "" {
+		sale.PricePerShare = p.money("selling-price")
+	} else if p.require("selling-price", "proceeds") != "" && sale.Quantity > 0 {
+		sale.PricePerShare = p.money("proceeds").DivShares(sale.Quantity)
+	}
+	sale.Commission = p.money("commission")
+	return lot, sale
+}
+
+// discountPercent is the discount of the row, otherwise of the options, otherwise derived from the FMVs, rounded
+// to 2 decimal places
+func (p *rowParser) discountPercent(lot *types.Lot) float64 {
+	if p.cell("discount") != "" {
+		return p.percent("discount")
+	}
+	if p.options.DiscountPercent > 0 {
+		return p.options.DiscountPercent
+	}
+	marketValue := lot.PurchaseDateMarketValuePerShare
+	if lot.OfferingDateMarketValuePerShare > 0 && (marketValue <= 0 || lot.OfferingDateMarketValuePerShare < marketValue) {
+		marketValue = lot.OfferingDateMarketValuePerShare
+	}
+	if marketValue <= 0 || lot.BasisPerShare <= 0 || lot.BasisPerShare > marketValue {
+		return 0
+	}
+	return math.Round((1-lot.BasisPerShare.Ratio(marketValue))*10000) / 100
+}
@@ -0,0 +1,260 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+package importer
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/ledger"
+	"github.com/leogps/lunar/pkg/types"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(value string) time.Time {
+	parsed, _ := time.Parse(types.DateLayout, value)
+	return parsed
+}
+
+func readFixture(t *testing.T, name string, layout *Layout, options Options) (*Layout, []*Entry) {
+	layout, entries, err := ReadFile(filepath.Join("testdata", name), layout, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Err != nil {
+			t.Fatalf("line %d: %v", entry.Line, entry.Err)
+		}
+	}
+	return layout, entries
+}
+
+func TestRead_DetectsLayouts(t *testing.T) {
+	for _, name := range []string{"etrade-gains-losses", "etrade-benefit-history", "schwab-realized-gains"} {
+		layout, _ := readFixture(t, name+".csv", nil, Options{})
+		if layout.Name != name {
+			t.Errorf("expected %s to be read with its own layout, got %s", name, layout.Name)
+		}
+	}
+	if _, _, err := Read(strings.NewReader("Date,Amount\n2024-01-01,1\n"), nil, Options{}); err == nil {
+		t.Error("expected an unknown layout to fail")
+	}
+}
+
+func TestRead_GainsLosses(t *testing.T) {
+	_, entries := readFixture(t, "etrade-gains-losses.csv", nil, Options{})
+	if len(entries) != 3 {
+		t.Fatalf("expected the 3 sales without the summary, got %d entries", len(entries))
+	}
+
+	espp := entries[0]
+	if espp.Line != 3 || espp.Lot.Source != types.Espp || espp.Lot.Label != "ACME" {
+		t.Errorf("unexpected ESPP entry: line %d, %s %s", espp.Line, espp.Lot.Source, espp.Lot.Label)
+	}
+	if !espp.Lot.AcquisitionDate.Equal(date("2024-01-31")) || espp.Lot.BasisPerShare != types.NewMoney(85) ||
+		espp.Lot.Quantity != types.NewShares(20) {
+		t.Errorf("unexpected ESPP lot: %+v", espp.Lot.Lot)
+	}
+	if !espp.Lot.OfferingDate.Equal(date("2023-08-01")) || espp.Lot.OfferingDateMarketValuePerShare != types.NewMoney(100) ||
+		espp.Lot.PurchaseDateMarketValuePerShare != types.NewMoney(110) || espp.Lot.DiscountPercent != 15 {
+		t.Errorf("unexpected ESPP offering: %+v", espp.Lot.Lot)
+	}
+	if !espp.Sale.Date.Equal(date("2024-06-14")) || espp.Sale.PricePerShare != types.NewMoney(120) {
+		t.Errorf("unexpected ESPP sale: %+v", espp.Sale)
+	}
+
+	rsu := entries[1]
+	if rsu.Lot.Source != types.Rsu || !rsu.Lot.AcquisitionDate.Equal(date("2024-03-15")) ||
+		rsu.Lot.BasisPerShare != types.NewMoney(100) || !rsu.Lot.OfferingDate.IsZero() {
+		t.Errorf("unexpected RSU lot: %+v", rsu.Lot.Lot)
+	}
+
+	// Without a price per share it is the proceeds over the shares sold
+	if price := entries[2].Sale.PricePerShare; price != types.NewMoney(136.1905) {
+		t.Errorf("expected a selling price of $136.1905, got %s", price.StringPerShare())
+	}
+	if !entries[2].Lot.AcquisitionDate.Equal(date("2023-09-15")) {
+		t.Errorf("expected the single-digit date to parse, got %s", entries[2].Lot.AcquisitionDate)
+	}
+}
+
+func TestRead_DiscountOption(t *testing.T) {
+	_, entries := readFixture(t, "etrade-gains-losses.csv", nil, Options{DiscountPercent: 10})
+	if discount := entries[0].Lot.DiscountPercent; discount != 10 {
+		t.Errorf("expected the discount of the options, got %v", discount)
+	}
+}
+
+func TestRead_Schwab(t *testing.T) {
+	_, entries := readFixture(t, "schwab-realized-gains.csv", nil, Options{})
+	if len(entries) != 2 {
+		t.Fatalf("expected the 2 sales without the title and total, got %d entries", len(entries))
+	}
+	if entries[0].Line != 3 || entries[0].Sale.Commission != types.NewMoney(4.95) || entries[0].Lot.DiscountPercent != 15 {
+		t.Errorf("unexpected ESPP entry: line %d, %+v %+v", entries[0].Line, entries[0].Lot.Lot, entries[0].Sale)
+	}
+	if entries[1].Lot.Source != types.Rsu || entries[1].Sale.PricePerShare != types.NewMoney(95) {
+		t.Errorf("unexpected RSU entry: %+v %+v", entries[1].Lot.Lot, entries[1].Sale)
+	}
+}
+
+func TestRead_InvalidRows(t *testing.T) {
+	layout, err := LookupLayout("schwab-realized-gains")
+	if err != nil {
+		t.Fatal(err)
+	}
+	csv := "Symbol,Type,Quantity,Date Acquired,Date Sold,Cost Basis Per Share,Sale Price\n" +
+		"ACME,ESPP,ten,2024-13-01,06/14/2024,$85.00,\n" +
+		"ACME,PSU,10,01/31/2024,06/14/2024,$85.00,$120.00\n"
+	_, entries, err := Read(strings.NewReader(csv), layout, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	for _, expected := range []string{"shares (Quantity)", "acquisition-date (Date Acquired): invalid date",
+		"selling-price or proceeds: missing value"} {
+		if entries[0].Err == nil || !strings.Contains(entries[0].Err.Error(), expected) {
+			t.Errorf("expected %q in %v", expected, entries[0].Err)
+		}
+	}
+	if entries[1].Err == nil || !strings.Contains(entries[1].Err.Error(), "unknown plan type: PSU") {
+		t.Errorf("expected an unknown plan type, got %v", entries[1].Err)
+	}
+}
+
+func TestLoadMapping(t *testing.T) {
+	layout, err := LoadMapping(filepath.Join("testdata", "mapping.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, entries := readFixture(t, "custom.csv", layout, Options{})
+	if len(entries) != 2 {
+		t.Fatalf("expected the 2 closed rows, got %d entries", len(entries))
+	}
+	espp := entries[0]
+	if espp.Lot.Source != types.Espp || !espp.Lot.AcquisitionDate.Equal(date("2024-01-31")) ||
+		espp.Lot.BasisPerShare != types.NewMoney(85) || espp.Sale.PricePerShare != types.NewMoney(120) {
+		t.Errorf("unexpected entry: %+v %+v", espp.Lot.Lot, espp.Sale)
+	}
+
+	mapping := &mappingFile{Columns: map[string]string{"shares": "Shares", "acquired": "Acquired"}}
+	if _, err = mapping.toLayout(); err == nil || !strings.Contains(err.Error(), "unknown field: acquired") {
+		t.Errorf("expected an unknown field, got %v", err)
+	}
+	mapping = &mappingFile{Columns: map[string]string{"shares": "Shares", "acquisition-date": "Acquired"}}
+	if _, err = mapping.toLayout(); err == nil || !strings.Contains(err.Error(), "default type") {
+		t.Errorf("expected a missing type, got %v", err)
+	}
+}
+
+func TestImport(t *testing.T) {
+	holdings := &ledger.Ledger{}
+	_, acquisitions := readFixture(t, "etrade-benefit-history.csv", nil, Options{})
+	results := Import(holdings, acquisitions)
+	expectActions(t, results, AddedLot, AddedLot, AddedLot)
+	rsuLot, _ := holdings.Lot(2)
+	if rsuLot.IncomeTax != types.NewMoney(1850) {
+		t.Errorf("expected the taxes withheld as income tax, got %s", rsuLot.IncomeTax)
+	}
+
+	// The sales of the lots imported from the purchases and vests are added to them
+	_, sales := readFixture(t, "etrade-gains-losses.csv", nil, Options{})
+	results = Import(holdings, sales)
+	expectActions(t, results, AddedSale, AddedSale, AddedLotAndSale)
+	if results[0].LotID != 1 || results[1].LotID != 2 {
+		t.Errorf("expected the sales from lots 1 and 2, got %d and %d", results[0].LotID, results[1].LotID)
+	}
+	if len(holdings.Lots) != 4 || len(holdings.Sales) != 3 {
+		t.Errorf("expected 4 lots and 3 sales, got %d and %d", len(holdings.Lots), len(holdings.Sales))
+	}
+
+	// Importing again finds the duplicates
+	expectActions(t, Import(holdings, acquisitions), DuplicateLot, DuplicateLot, DuplicateLot)
+	results = Import(holdings, sales)
+	expectActions(t, results, DuplicateSale, DuplicateSale, DuplicateSale)
+	if results[2].LotID != 6 || results[2].SaleID != 7 {
+		t.Errorf("expected the duplicate of sale 7 from lot 6, got sale %d from lot %d", results[2].SaleID, results[2].LotID)
+	}
+	if len(holdings.Lots) != 4 || len(holdings.Sales) != 3 {
+		t.Errorf("expected no lots or sales added, got %d and %d", len(holdings.Lots), len(holdings.Sales))
+	}
+
+	failed := Import(holdings, []*Entry{{Line: 9, Err: fmt.Errorf("invalid row")}})
+	expectActions(t, failed, Failed)
+}
+
+func TestImport_SplitOrder(t *testing.T) {
+	holdings := &ledger.Ledger{}
+	_, fills := readFixture(t, "etrade-gains-losses-split.csv", nil, Options{})
+	expectActions(t, Import(holdings, fills), AddedLotAndSale, AddedLotAndSale)
+	if len(holdings.Sales) != 2 {
+		t.Errorf("expected both fills to be added, got %d sales", len(holdings.Sales))
+	}
+
+	results := Import(holdings, fills)
+	expectActions(t, results, DuplicateSale, DuplicateSale)
+	if results[0].SaleID == results[1].SaleID {
+		t.Errorf("expected each fill to duplicate its own sale, got sale %d twice", results[0].SaleID)
+	}
+
+	// A fill of the same lot on the same day at the same price not imported before is added
+	results = Import(holdings, append(fills, fills[0]))
+	expectActions(t, results, DuplicateSale, DuplicateSale, AddedLotAndSale)
+
+	lot := *fills[0].Lot
+	results = Import(&ledger.Ledger{}, []*Entry{{Line: 2, Lot: &lot}, {Line: 3, Lot: &lot}})
+	expectActions(t, results, AddedLot, AddedLot)
+}
+
+func TestImport_Shortfall(t *testing.T) {
+	holdings := &ledger.Ledger{}
+	_, sales := readFixture(t, "etrade-gains-losses.csv", nil, Options{})
+	lot := *sales[0].Lot
+	lot.Quantity = sales[0].Sale.Quantity - types.NewShares(1)
+	if err := holdings.AddLot(&lot); err != nil {
+		t.Fatal(err)
+	}
+
+	// The matching lot holds a share fewer than sold, which is not made up with a new lot
+	results := Import(holdings, sales[:1])
+	expectActions(t, results, Failed)
+	if results[0].Err == nil || len(holdings.Lots) != 1 || len(holdings.Sales) != 0 {
+		t.Errorf("expected the shortfall to leave the ledger unchanged, got %d lots and %d sales: %v",
+			len(holdings.Lots), len(holdings.Sales), results[0].Err)
+	}
+}
+
+func expectActions(t *testing.T, results []*Result, actions ...Action) {
+	t.Helper()
+	if len(results) != len(actions) {
+		t.Fatalf("expected %d results, got %d", len(actions), len(results))
+	}
+	for index, result := range results {
+		if result.Action != actions[index] {
+			t.Errorf("entry %d: expected %s, got %s (%v)", index+1, actions[index], result.Action, result.Err)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+package importer
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/types"
+	"sort"
+	"strings"
+)
+
+// Fields are the values an import reads from the columns of a row. An ESPP lot is acquired on its purchase date
+// at its purchase price and an RSU lot on its vest date at the FMV at vest; acquisition-date and basis-per-share
+// (or the total cost-basis) stand in for them when a layout does not tell them apart.
+var Fields = []string{"type", "symbol", "shares", "acquisition-date", "purchase-date", "vest-date", "basis-per-share",
+	"cost-basis", "purchase-price", "vest-fmv", "discount", "offering-date", "offering-fmv", "purchase-fmv",
+	"income-tax", "sale-date", "selling-price", "proceeds", "commission"}
+
+// Layout maps the columns of a broker's CSV export to the Fields.
+type Layout struct {
+	Name        string
+	Description string
+	// Columns holds the header of the column of each field the layout has.
+	Columns map[string]string
+	// Types maps the values of the type column, in lower case, to the order type.
+	Types map[string]types.OrderType
+	// DefaultType is the order type of the rows without a type column or value, e.g. an export of RSU sales only.
+	DefaultType *types.OrderType
+	// DateLayouts are the time layouts the dates are parsed with, tried in order.
+	DateLayouts []string
+	// Only the rows whose FilterColumn holds one of the FilterValues are imported, e.g. the sales but not the
+	// summary rows. Every row is imported without a FilterColumn.
+	FilterColumn string
+	FilterValues []string
+}
+
+// keyColumns are the columns a header must have to be read with the layout: the shares, type, sale date and filter
+// columns the layout has.
+func (l *Layout) keyColumns() []string {
+	var keyColumns []string
+	for _, field := range []string{"shares", "type", "sale-date"} {
+		if column, ok := l.Columns[field]; ok {
+			keyColumns = append(keyColumns, column)
+		}
+	}
+	if l.FilterColumn != "" {
+		keyColumns = append(keyColumns, l.FilterColumn)
+	}
+	return keyColumns
+}
+
+// matches reports whether the header holds the key columns of the layout and one of its acquisition dates.
+func (l *Layout) matches(header map[string]int) bool {
+	for _, column := range l.keyColumns() {
+		if _, ok := header[normalizeColumn(column)]; !ok {
+			return false
+		}
+	}
+	for _, field := range []string{"acquisition-date", "purchase-date", "vest-date"} {
+		if _, ok := header[normalizeColumn(l.Columns[field])]; ok && l.Columns[field] != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that the layout maps known fields, the shares and an acquisition date, and has an order type
+// for every row.
+func (l *Layout) Validate() error {
+	for field := range l.Columns {
+		if !isField(field) {
+			return fmt.Errorf("unknown field: %s, valid fields: %s", field, strings.Join(Fields, ", "))
+		}
+	}
+	if l.Columns["shares"] == "" {
+		return fmt.Errorf("the shares column is required")
+	}
+	if l.Columns["acquisition-date"] == "" && l.Columns["purchase-date"] == "" && l.Columns["vest-date"] == "" {
+		return fmt.Errorf("an acquisition-date, purchase-date or vest-date column is required")
+	}
+	if l.Columns["type"] == "" && l.DefaultType == nil {
+		return fmt.Errorf("a type column or a default type is required")
+	}
+	if len(l.DateLayouts) == 0 {
+		return fmt.Errorf("a date format is required")
+	}
+	return nil
+}
+
+func isField(field string) bool {
+	for _, name := range Fields {
+		if name == field {
+			return true
+		}
+	}
+	return false
+}
+
+// brokerTypes are the plan types of the broker exports
+var brokerTypes = map[string]types.OrderType{
+	"espp": types.Espp,
+	"rs":   types.Rsu,
+	"rsu":  types.Rsu,
+}
+
+// brokerDateLayouts are the date formats of the broker exports, e.g. 03/15/2024 or 3/15/2024
+var brokerDateLayouts = []string{"1/2/2006", types.DateLayout}
+
+var layouts = []*Layout{
+	{
+		Name:        "etrade-gains-losses",
+		Description: "E*TRADE/Morgan Stanley Gains & Losses (expanded) download: the sales with the lots sold",
+		Columns: map[string]string{
+			"type":             "Plan Type",
+			"symbol":           "Symbol",
+			"shares":           "Quantity",
+			"acquisition-date": "Date Acquired",
+			"purchase-date":    "Purchase Date",
+			"vest-date":        "Vest Date",
+			"purchase-price":   "Acquisition Cost Per Share",
+			"vest-fmv":         "Vest Date FMV",
+			"offering-date":    "Grant Date",
+			"offering-fmv":     "Grant Date FMV",
+			"purchase-fmv":     "Purchase Date Fair Mkt. Value",
+			"sale-date":        "Date Sold",
+			"selling-price":    "Proceeds Per Share",
+			"proceeds":         "Total Proceeds",
+		},
+		Types:        brokerTypes,
+		DateLayouts:  brokerDateLayouts,
+		FilterColumn: "Record Type",
+		FilterValues: []string{"Sell"},
+	},
+	{
+		Name:        "etrade-benefit-history",
+		Description: "E*TRADE/Morgan Stanley Benefit History download: the ESPP purchases and RSU vests",
+		Columns: map[string]string{
+			"type":           "Plan Type",
+			"symbol":         "Symbol",
+			"shares":         "Quantity",
+			"purchase-date":  "Purchase Date",
+			"vest-date":      "Vest Date",
+			"purchase-price": "Purchase Price",
+			"vest-fmv":       "Vest Date FMV",
+			"offering-date":  "Grant Date",
+			"offering-fmv":   "Grant Date FMV",
+			"purchase-fmv":   "Purchase Date FMV",
+			"income-tax":     "Taxes Withheld",
+		},
+		Types:        brokerTypes,
+		DateLayouts:  brokerDateLayouts,
+		FilterColumn: "Record Type",
+		FilterValues: []string{"Purchase", "Vest"},
+	},
+	{
+		Name:        "schwab-realized-gains",
+		Description: "Schwab Equity Award Center realized gain/loss download: the sales with the lots sold",
+		Columns: map[string]string{
+			"type":             "Type",
+			"symbol":           "Symbol",
+			"shares":           "Quantity",
+			"acquisition-date": "Date Acquired",
+			"basis-per-share":  "Cost Basis Per Share",
+			"cost-basis":       "Cost Basis",
+			"offering-date":    "Offering Date",
+			"offering-fmv":     "Offering Date FMV",
+			"purchase-fmv":     "Purchase Date FMV",
+			"sale-date":        "Date Sold",
+			"selling-price":    "Sale Price",
+			"proceeds":         "Proceeds",
+			"commission":       "Fees & Commissions",
+		},
+		Types:       brokerTypes,
+		DateLayouts: brokerDateLayouts,
+	},
+}
+
+// Layouts returns the built-in broker layouts.
+func Layouts() []*Layout {
+	return layouts
+}
+
+// LookupLayout returns the built-in layout with the name.
+func LookupLayout(name string) (*Layout, error) {
+	var names []string
+	for _, layout := range layouts {
+		if layout.Name == strings.ToLower(strings.TrimSpace(name)) {
+			return layout, nil
+		}
+		names = append(names, layout.Name)
+	}
+	return nil, fmt.Errorf("unknown layout: %s, valid values: %s", name, strings.Join(names, ", "))
+}
+
+// detectLayout returns the built-in layout the header matches, preferring the one with the most key columns, e.g.
+// the Gains & Losses layout over the Benefit History one whose columns it also has.
+func detectLayout(header map[string]int) *Layout {
+	var candidates []*Layout
+	for _, layout := range layouts {
+		if layout.matches(header) {
+			candidates = append(candidates, layout)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return len(candidates[i].keyColumns()) > len(candidates[j].keyColumns())
+	})
+	return candidates[0]
+}
+
+func normalizeColumn(column string) string {
+	return strings.ToLower(strings.TrimSpace(column))
+}
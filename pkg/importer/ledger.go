@@ -0,0 +1,193 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+package importer
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/ledger"
+	"github.com/leogps/lunar/pkg/types"
+)
+
+// Action is what importing an entry did to the ledger.
+type Action int
+
+const (
+	// AddedLot added the lot of a purchase or vest.
+	AddedLot Action = iota
+	// AddedLotAndSale added the sale along with a new lot of the shares sold, made from the lot data of the entry, as
+	// the ledger has no matching lot or only matching lots sold out.
+	AddedLotAndSale
+	// AddedSale added the sale to a matching lot of the ledger, e.g. one imported from the purchases and vests.
+	AddedSale
+	// DuplicateLot skipped a purchase or vest the ledger already has.
+	DuplicateLot
+	// DuplicateSale skipped a sale the ledger already has.
+	DuplicateSale
+	// Failed skipped an entry that could not be read or added.
+	Failed
+)
+
+func (a Action) String() string {
+	switch a {
+	case AddedLot:
+		return "add lot"
+	case AddedLotAndSale:
+		return "add lot and sale"
+	case AddedSale:
+		return "add sale"
+	case DuplicateLot:
+		return "duplicate lot"
+	case DuplicateSale:
+		return "duplicate sale"
+	default:
+		return "error"
+	}
+}
+
+// Result is the outcome of importing an entry.
+type Result struct {
+	Entry  *Entry
+	Action Action
+	// LotID is the lot added, sold from or duplicated, SaleID the sale added or duplicated.
+	LotID  int
+	SaleID int
+	Err    error
+}
+
+// Import adds the entries to the ledger in order, skipping the duplicates of the lots and sales it already had
+// before the import. Lots match on their type, acquisition date and basis per share; a purchase or vest is a
+// duplicate of a matching lot of the same number of shares, and a sale of a sale from a matching lot on the same date
+// of the same number of shares at the same price. Each lot and sale of the ledger is the duplicate of one entry at
+// most, so identical rows of a file, e.g. the fills of a split order, are all added the first time and all skipped
+// when imported again.
+// A sale is added to the first matching lot still holding the shares sold. When the matching lots hold fewer shares
+// than sold, the shortfall fails the entry rather than adding shares the ledger does not have; otherwise, e.g. when
+// the matching lots are sold out by the other fills of a split order, the sale is added along with a new lot of the
+// shares sold, made from the acquisition date and basis of the entry. The ledger is only changed in memory, so a dry run can preview the import by not saving it.
+func Import(holdings *ledger.Ledger, entries []*Entry) []*Result {
+	ledgerImport := &ledgerImport{holdings: holdings, lastID: holdings.LastID, duplicated: map[int]bool{}}
+	results := make([]*Result, 0, len(entries))
+	for _, entry := range entries {
+		results = append(results, ledgerImport.importEntry(entry))
+	}
+	return results
+}
+
+// ledgerImport tracks the lots and sales the ledger had before the import, which entries can duplicate, and those
+// already duplicated by an entry
+type ledgerImport struct {
+	holdings   *ledger.Ledger
+	lastID     int
+	duplicated map[int]bool
+}
+
+// duplicate tells whether the lot or sale of the ID is left to duplicate, and marks it duplicated if so
+func (i *ledgerImport) duplicate(id int) bool {
+	if id > i.lastID || i.duplicated[id] {
+		return false
+	}
+	i.duplicated[id] = true
+	return true
+}
+
+func (i *ledgerImport) importEntry(entry *Entry) *Result {
+	holdings := i.holdings
+	result := &Result{Entry: entry}
+	if entry.Err != nil {
+		result.Action, result.Err = Failed, entry.Err
+		return result
+	}
+
+	if entry.Sale == nil {
+		for _, lot := range matchingLots(holdings, entry.Lot) {
+			if lot.Quantity == entry.Lot.Quantity && i.duplicate(lot.ID) {
+				result.Action, result.LotID = DuplicateLot, lot.ID
+				return result
+			}
+		}
+		lot := *entry.Lot
+		if err := holdings.AddLot(&lot); err != nil {
+			result.Action, result.Err = Failed, err
+			return result
+		}
+		result.Action, result.LotID = AddedLot, lot.ID
+		return result
+	}
+
+	lots := matchingLots(holdings, entry.Lot)
+	for _, lot := range lots {
+		for _, sale := range holdings.SalesOf(lot) {
+			if sale.Date.Equal(entry.Sale.Date) && sale.Quantity == entry.Sale.Quantity &&
+				sale.PricePerShare == entry.Sale.PricePerShare && i.duplicate(sale.ID) {
+				result.Action, result.LotID, result.SaleID = DuplicateSale, lot.ID, sale.ID
+				return result
+			}
+		}
+	}
+	sale := *entry.Sale
+	var remainingShares types.Shares
+	for _, lot := range lots {
+		remainingShares += holdings.RemainingShares(lot)
+		if holdings.RemainingShares(lot) >= sale.Quantity {
+			sale.LotID = lot.ID
+			if err := holdings.AddSale(&sale); err != nil {
+				result.Action, result.Err = Failed, err
+				return result
+			}
+			result.Action, result.LotID, result.SaleID = AddedSale, lot.ID, sale.ID
+			return result
+		}
+	}
+
+	if remainingShares > 0 {
+		result.Action = Failed
+		result.Err = fmt.Errorf("%s shares sold, but the matching lots only hold %s", sale.Quantity, remainingShares)
+		return result
+	}
+	lot := *entry.Lot
+	lot.Quantity = sale.Quantity
+	if err := holdings.AddLot(&lot); err != nil {
+		result.Action, result.Err = Failed, err
+		return result
+	}
+	sale.LotID = lot.ID
+	if err := holdings.AddSale(&sale); err != nil {
+		_ = holdings.Remove(lot.ID)
+		result.Action, result.Err = Failed, err
+		return result
+	}
+	result.Action, result.LotID, result.SaleID = AddedLotAndSale, lot.ID, sale.ID
+	return result
+}
+
+// matchingLots returns the lots of the ledger of the same type, acquisition date and basis per share as the lot
+func matchingLots(holdings *ledger.Ledger, lot *ledger.Lot) []*ledger.Lot {
+	var lots []*ledger.Lot
+	for _, candidate := range holdings.Lots {
+		if candidate.Source == lot.Source && candidate.AcquisitionDate.Equal(lot.AcquisitionDate) &&
+			candidate.BasisPerShare == lot.BasisPerShare {
+			lots = append(lots, candidate)
+		}
+	}
+	return lots
+}
@@ -0,0 +1,125 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+package importer
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/types"
+	"gopkg.in/yaml.v3"
+	"os"
+	"strings"
+)
+
+// mappingFile is a user-defined layout, e.g.
+//
+//	columns:
+//	  type: Award Type
+//	  acquisition-date: Acquired
+//	  shares: Shares
+//	  basis-per-share: Cost Per Share
+//	  sale-date: Sold
+//	  selling-price: Price
+//	types:
+//	  Employee Stock Purchase: espp
+//	  Restricted Stock: rsu
+//	date-format: MM/DD/YYYY
+type mappingFile struct {
+	Name        string            `yaml:"name"`
+	Columns     map[string]string `yaml:"columns"`
+	Types       map[string]string `yaml:"types"`
+	DefaultType string            `yaml:"default-type"`
+	DateFormat  string            `yaml:"date-format"`
+	Filter      struct {
+		Column string   `yaml:"column"`
+		Values []string `yaml:"values"`
+	} `yaml:"filter"`
+}
+
+// dateFormatReplacer turns a date format such as MM/DD/YYYY into a time layout, longest tokens first. Months and
+// days take one or two digits.
+var dateFormatReplacer = strings.NewReplacer("YYYY", "2006", "YY", "06", "MMM", "Jan", "MM", "1", "DD", "2")
+
+// LoadMapping reads a user-defined layout from a YAML file mapping the Fields to the columns of the export.
+// The plan types default to those of the broker exports (ESPP, RS, RSU) and the dates to MM/DD/YYYY or YYYY-MM-DD.
+func LoadMapping(path string) (*Layout, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var mapping mappingFile
+	if err = yaml.Unmarshal(data, &mapping); err != nil {
+		return nil, fmt.Errorf("invalid mapping file %s: %w", path, err)
+	}
+	layout, err := mapping.toLayout()
+	if err != nil {
+		return nil, fmt.Errorf("invalid mapping file %s: %w", path, err)
+	}
+	return layout, nil
+}
+
+func (m *mappingFile) toLayout() (*Layout, error) {
+	layout := &Layout{
+		Name:         m.Name,
+		Description:  "user-defined column mapping",
+		Columns:      map[string]string{},
+		Types:        brokerTypes,
+		DateLayouts:  brokerDateLayouts,
+		FilterColumn: strings.TrimSpace(m.Filter.Column),
+		FilterValues: m.Filter.Values,
+	}
+	if layout.Name == "" {
+		layout.Name = "custom"
+	}
+	for field, column := range m.Columns {
+		if strings.TrimSpace(column) != "" {
+			layout.Columns[field] = strings.TrimSpace(column)
+		}
+	}
+	if len(m.Types) > 0 {
+		layout.Types = map[string]types.OrderType{}
+		for value, orderType := range m.Types {
+			parsedType, err := types.ParseOrderType(orderType)
+			if err != nil {
+				return nil, fmt.Errorf("types: %s: %w", value, err)
+			}
+			layout.Types[normalizeColumn(value)] = parsedType
+		}
+	}
+	if m.DefaultType != "" {
+		defaultType, err := types.ParseOrderType(m.DefaultType)
+		if err != nil {
+			return nil, fmt.Errorf("default-type: %w", err)
+		}
+		layout.DefaultType = &defaultType
+	}
+	if m.DateFormat != "" {
+		layout.DateLayouts = []string{dateFormatReplacer.Replace(strings.TrimSpace(m.DateFormat))}
+	}
+	if layout.FilterColumn != "" && len(layout.FilterValues) == 0 {
+		return nil, fmt.Errorf("filter: values are required with a column")
+	}
+	if err := layout.Validate(); err != nil {
+		return nil, err
+	}
+	return layout, nil
+}
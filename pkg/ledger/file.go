@@ -0,0 +1,238 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"github.com/leogps/lunar/pkg/types"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ledgerFile is the ledger as written to the file, with amounts, shares and dates as text so it stays exact and
+// readable
+type ledgerFile struct {
+	LastID int          `yaml:"last-id"`
+	Lots   []lotRecord  `yaml:"lots,omitempty"`
+	Sales  []saleRecord `yaml:"sales,omitempty"`
+}
+
+type lotRecord struct {
+	ID              int    `yaml:"id"`
+	Type            string `yaml:"type"`
+	Label           string `yaml:"label,omitempty"`
+	AcquisitionDate string `yaml:"acquisition-date"`
+	Shares          string `yaml:"shares"`
+	BasisPerShare   string `yaml:"basis-per-share"`
+	Discount        string `yaml:"discount,omitempty"`
+	OfferingDate    string `yaml:"offering-date,omitempty"`
+	OfferingFmv     string `yaml:"offering-fmv,omitempty"`
+	PurchaseFmv     string `yaml:"purchase-fmv,omitempty"`
+	IncomeTax       string `yaml:"income-tax,omitempty"`
+}
+
+type saleRecord struct {
+	ID            int    `yaml:"id"`
+	Lot           int    `yaml:"lot"`
+	Label         string `yaml:"label,omitempty"`
+	SaleDate      string `yaml:"sale-date"`
+	Shares        string `yaml:"shares"`
+	PricePerShare string `yaml:"price-per-share"`
+	Commission    string `yaml:"commission,omitempty"`
+}
+
+func newLedgerFile(ledger *Ledger) ledgerFile {
+	ledgerFile := ledgerFile{LastID: ledger.LastID}
+	for _, lot := range ledger.Lots {
+		record := lotRecord{
+			ID:              lot.ID,
+			Type:            strings.ToLower(lot.Source.String()),
+			Label:           lot.Label,
+			AcquisitionDate: formatDate(lot.AcquisitionDate),
+			Shares:          lot.Quantity.String(),
+			BasisPerShare:   lot.BasisPerShare.StringPerShare(),
+			OfferingDate:    formatDate(lot.OfferingDate),
+		}
+		if lot.DiscountPercent != 0 {
+			record.Discount = strconv.FormatFloat(lot.DiscountPercent, 'f', -1, 64)
+		}
+		if lot.OfferingDateMarketValuePerShare != 0 {
+			record.OfferingFmv = lot.OfferingDateMarketValuePerShare.StringPerShare()
+		}
+		if lot.PurchaseDateMarketValuePerShare != 0 {
+			record.PurchaseFmv = lot.PurchaseDateMarketValuePerShare.StringPerShare()
+		}
+		if lot.IncomeTax != 0 {
+			record.IncomeTax = lot.IncomeTax.String()
+		}
+		ledgerFile.Lots = append(ledgerFile.Lots, record)
+	}
+	for _, sale := range ledger.Sales {
+		record := saleRecord{
+			ID:            sale.ID,
+			Lot:           sale.LotID,
+			Label:         sale.Label,
+			SaleDate:      formatDate(sale.Date),
+			Shares:        sale.Quantity.String(),
+			PricePerShare: sale.PricePerShare.StringPerShare(),
+		}
+		if sale.Commission != 0 {
+			record.Commission = sale.Commission.String()
+		}
+		ledgerFile.Sales = append(ledgerFile.Sales, record)
+	}
+	return ledgerFile
+}
+
+// toLedger parses and validates the records, adding them as they were added to the ledger
+func (f ledgerFile) toLedger() (*Ledger, error) {
+	ledger := &Ledger{}
+	var errs []error
+	for _, record := range f.Lots {
+		lot, err := record.toLot()
+		if err == nil {
+			err = ledger.AddLot(lot)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("lot %d: %w", record.ID, err))
+			continue
+		}
+		lot.ID = record.ID
+	}
+	for _, record := range f.Sales {
+		sale, err := record.toSale()
+		if err == nil {
+			err = ledger.AddSale(sale)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("sale %d: %w", record.ID, err))
+			continue
+		}
+		sale.ID = record.ID
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	seenIDs := map[int]bool{}
+	for _, id := range append(lotIDs(f.Lots), saleIDs(f.Sales)...) {
+		if id <= 0 || id > f.LastID || seenIDs[id] {
+			return nil, fmt.Errorf("invalid or duplicate ID %d (last ID %d)", id, f.LastID)
+		}
+		seenIDs[id] = true
+	}
+	ledger.LastID = f.LastID
+	return ledger, nil
+}
+
+func (r lotRecord) toLot() (*Lot, error) {
+	var p fieldParser
+	lot := &Lot{Label: r.Label}
+	source, err := types.ParseOrderType(r.Type)
+	if err != nil {
+		p.errs = append(p.errs, fmt.Errorf("type: %w", err))
+	}
+	lot.Source = source
+	p.parse("acquisition-date", r.AcquisitionDate, parseDate(&lot.AcquisitionDate))
+	p.parse("shares", r.Shares, func(value string) (err error) {
+		lot.Quantity, err = types.ParseShares(value)
+		return err
+	})
+	p.parse("basis-per-share", r.BasisPerShare, parseMoney(&lot.BasisPerShare))
+	p.parse("discount", r.Discount, func(value string) (err error) {
+		lot.DiscountPercent, err = strconv.ParseFloat(value, 64)
+		return err
+	})
+	p.parse("offering-date", r.OfferingDate, parseDate(&lot.OfferingDate))
+	p.parse("offering-fmv", r.OfferingFmv, parseMoney(&lot.OfferingDateMarketValuePerShare))
+	p.parse("purchase-fmv", r.PurchaseFmv, parseMoney(&lot.PurchaseDateMarketValuePerShare))
+	p.parse("income-tax", r.IncomeTax, parseMoney(&lot.IncomeTax))
+	return lot, errors.Join(p.errs...)
+}
+
+func (r saleRecord) toSale() (*Sale, error) {
+	var p fieldParser
+	sale := &Sale{LotID: r.Lot, Label: r.Label}
+	p.parse("sale-date", r.SaleDate, parseDate(&sale.Date))
+	p.parse("shares", r.Shares, func(value string) (err error) {
+		sale.Quantity, err = types.ParseShares(value)
+		return err
+	})
+	p.parse("price-per-share", r.PricePerShare, parseMoney(&sale.PricePerShare))
+	p.parse("commission", r.Commission, parseMoney(&sale.Commission))
+	return sale, errors.Join(p.errs...)
+}
+
+// fieldParser parses the fields of a record, collecting an error per invalid value
+type fieldParser struct {
+	errs []error
+}
+
+// parse parses the value of the named field unless it is empty
+func (p *fieldParser) parse(name string, value string, parse func(string) error) {
+	if value == "" {
+		return
+	}
+	if err := parse(value); err != nil {
+		p.errs = append(p.errs, fmt.Errorf("%s: %w", name, err))
+	}
+}
+
+func parseDate(date *time.Time) func(string) error {
+	return func(value string) (err error) {
+		*date, err = time.Parse(types.DateLayout, value)
+		return err
+	}
+}
+
+func parseMoney(money *types.Money) func(string) error {
+	return func(value string) (err error) {
+		*money, err = types.ParseMoney(value)
+		return err
+	}
+}
+
+func formatDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format(types.DateLayout)
+}
+
+func lotIDs(records []lotRecord) []int {
+	var ids []int
+	for _, record := range records {
+		ids = append(ids, record.ID)
+	}
+	return ids
+}
+
+func saleIDs(records []saleRecord) []int {
+	var ids []int
+	for _, record := range records {
+		ids = append(ids, record.ID)
+	}
+	return ids
+}
@@ -0,0 +1,321 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"github.com/leogps/lunar/pkg/types"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LedgerEnv overrides the path of the ledger file.
+const LedgerEnv = "LUNAR_LEDGER"
+
+// Lot is an ESPP purchase or RSU vest recorded in the ledger. Quantity is the number of shares acquired; the shares
+// still held are reduced by the sales of the lot.
+type Lot struct {
+	types.Lot
+	ID    int
+	Label string
+	// IncomeTax is the income tax paid on an RSU vest.
+	IncomeTax types.Money
+}
+
+// Sale is a sale of shares from a lot of the ledger.
+type Sale struct {
+	ID            int
+	LotID         int
+	Label         string
+	Date          time.Time
+	Quantity      types.Shares
+	PricePerShare types.Money
+	Commission    types.Money
+}
+
+// Ledger holds the lots acquired and the sales from them. Each lot and sale has an ID that is never reused.
+type Ledger struct {
+	Lots  []*Lot
+	Sales []*Sale
+	// LastID is the ID of the last lot or sale added.
+	LastID int
+}
+
+// DefaultPath returns the path of the ledger file: $LUNAR_LEDGER, otherwise lunar/ledger.yaml in the user data
+// directory, i.e. $XDG_DATA_HOME (~/.local/share) on Linux.
+func DefaultPath() (string, error) {
+	if path := os.Getenv(LedgerEnv); path != "" {
+		return path, nil
+	}
+	dataDir, err := userDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "lunar", "ledger.yaml"), nil
+}
+
+// userDataDir returns $XDG_DATA_HOME or ~/.local/share on Unix, and the user config directory on macOS and
+// Windows, which keep data alongside the config.
+func userDataDir() (string, error) {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		return os.UserConfigDir()
+	}
+	if dataDir := os.Getenv("XDG_DATA_HOME"); dataDir != "" {
+		return dataDir, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".local", "share"), nil
+}
+
+// Load reads the ledger file. A missing file is an empty ledger.
+func Load(path string) (*Ledger, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Ledger{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ledgerFile ledgerFile
+	if err = yaml.Unmarshal(data, &ledgerFile); err != nil {
+		return nil, fmt.Errorf("invalid ledger file %s: %w", path, err)
+	}
+	ledger, err := ledgerFile.toLedger()
+	if err != nil {
+		return nil, fmt.Errorf("invalid ledger file %s: %w", path, err)
+	}
+	return ledger, nil
+}
+
+// Save writes the ledger file, creating its directory when needed. The ledger is written to a temporary file in the
+// same directory and renamed over the ledger file, so an interrupted save leaves the previous ledger intact.
+func (l *Ledger) Save(path string) error {
+	data, err := yaml.Marshal(newLedgerFile(l))
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if err = writeLedgerFile(file, data); err != nil {
+		_ = os.Remove(file.Name())
+		return err
+	}
+	if err = os.Rename(file.Name(), path); err != nil {
+		_ = os.Remove(file.Name())
+		return err
+	}
+	return nil
+}
+
+// writeLedgerFile writes the data to the file with the permissions of a ledger file and closes it.
+func writeLedgerFile(file *os.File, data []byte) error {
+	_, err := file.Write(data)
+	if err == nil {
+		err = file.Chmod(0o644)
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// AddLot validates the lot and adds it with the next ID.
+func (l *Ledger) AddLot(lot *Lot) error {
+	if err := validateLot(lot); err != nil {
+		return err
+	}
+	l.LastID++
+	lot.ID = l.LastID
+	l.Lots = append(l.Lots, lot)
+	return nil
+}
+
+func validateLot(lot *Lot) error {
+	var errs []error
+	if lot.AcquisitionDate.IsZero() {
+		errs = append(errs, fmt.Errorf("acquisition date is required"))
+	}
+	if lot.Quantity <= 0 {
+		errs = append(errs, fmt.Errorf("number of shares must be greater than zero"))
+	}
+	if lot.BasisPerShare <= 0 {
+		errs = append(errs, fmt.Errorf("basis per share must be greater than zero"))
+	}
+	if lot.DiscountPercent < 0 || lot.DiscountPercent >= 100 {
+		errs = append(errs, fmt.Errorf("discount percent must be at least 0 and less than 100"))
+	}
+	if !lot.OfferingDate.IsZero() && lot.OfferingDate.After(lot.AcquisitionDate) {
+		errs = append(errs, fmt.Errorf("offering date must not be after the purchase date"))
+	}
+	if lot.IncomeTax < 0 {
+		errs = append(errs, fmt.Errorf("income tax must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// AddSale validates the sale against its lot and adds it with the next ID.
+func (l *Ledger) AddSale(sale *Sale) error {
+	lot, err := l.Lot(sale.LotID)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	if sale.Date.IsZero() {
+		errs = append(errs, fmt.Errorf("sale date is required"))
+	} else if sale.Date.Before(lot.AcquisitionDate) {
+		errs = append(errs, fmt.Errorf("sale date must not be before the acquisition date %s of lot %d",
+			lot.AcquisitionDate.Format(types.DateLayout), lot.ID))
+	}
+	if sale.Quantity <= 0 {
+		errs = append(errs, fmt.Errorf("number of shares sold must be greater than zero"))
+	} else if remainingShares := l.RemainingShares(lot); sale.Quantity > remainingShares {
+		errs = append(errs, fmt.Errorf("lot %d holds %s shares, fewer than the %s sold", lot.ID, remainingShares, sale.Quantity))
+	}
+	if sale.PricePerShare <= 0 {
+		errs = append(errs, fmt.Errorf("selling price per share must be greater than zero"))
+	}
+	if sale.Commission < 0 {
+		errs = append(errs, fmt.Errorf("commission must not be negative"))
+	}
+	if err = errors.Join(errs...); err != nil {
+		return err
+	}
+	l.LastID++
+	sale.ID = l.LastID
+	l.Sales = append(l.Sales, sale)
+	return nil
+}
+
+// Lot returns the lot with the ID.
+func (l *Ledger) Lot(id int) (*Lot, error) {
+	for _, lot := range l.Lots {
+		if lot.ID == id {
+			return lot, nil
+		}
+	}
+	return nil, fmt.Errorf("no lot with ID %d", id)
+}
+
+// Sale returns the sale with the ID.
+func (l *Ledger) Sale(id int) (*Sale, error) {
+	for _, sale := range l.Sales {
+		if sale.ID == id {
+			return sale, nil
+		}
+	}
+	return nil, fmt.Errorf("no sale with ID %d", id)
+}
+
+// SalesOf returns the sales from the lot in the order they were added.
+func (l *Ledger) SalesOf(lot *Lot) []*Sale {
+	var sales []*Sale
+	for _, sale := range l.Sales {
+		if sale.LotID == lot.ID {
+			sales = append(sales, sale)
+		}
+	}
+	return sales
+}
+
+// RemainingShares is the number of shares of the lot not sold yet.
+func (l *Ledger) RemainingShares(lot *Lot) types.Shares {
+	remainingShares := lot.Quantity
+	for _, sale := range l.SalesOf(lot) {
+		remainingShares -= sale.Quantity
+	}
+	return remainingShares
+}
+
+// HeldLots returns the lots in the order they were added, as types.Lot holding the shares not sold yet, e.g. to sell
+// from with a types.LotSale. IDs picks the lots, in that order; all the lots still held when there are none.
+func (l *Ledger) HeldLots(ids ...int) ([]*types.Lot, error) {
+	var lots []*Lot
+	if len(ids) == 0 {
+		lots = l.Lots
+	}
+	for _, id := range ids {
+		lot, err := l.Lot(id)
+		if err != nil {
+			return nil, err
+		}
+		lots = append(lots, lot)
+	}
+
+	var heldLots []*types.Lot
+	for _, lot := range lots {
+		remainingShares := l.RemainingShares(lot)
+		if remainingShares <= 0 {
+			if len(ids) > 0 {
+				return nil, fmt.Errorf("lot %d is sold out", lot.ID)
+			}
+			continue
+		}
+		heldLot := lot.Lot
+		heldLot.Quantity = remainingShares
+		heldLots = append(heldLots, &heldLot)
+	}
+	return heldLots, nil
+}
+
+// Remove removes the lot or sale with the ID. A lot can only be removed once its sales are.
+func (l *Ledger) Remove(id int) error {
+	for index, lot := range l.Lots {
+		if lot.ID != id {
+			continue
+		}
+		if sales := l.SalesOf(lot); len(sales) > 0 {
+			var saleIDs []string
+			for _, sale := range sales {
+				saleIDs = append(saleIDs, strconv.Itoa(sale.ID))
+			}
+			return fmt.Errorf("lot %d has sales %s, remove them first", id, strings.Join(saleIDs, ", "))
+		}
+		l.Lots = append(l.Lots[:index], l.Lots[index+1:]...)
+		return nil
+	}
+	for index, sale := range l.Sales {
+		if sale.ID == id {
+			l.Sales = append(l.Sales[:index], l.Sales[index+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("no lot or sale with ID %d", id)
+}
@@ -0,0 +1,245 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+package ledger
+
+import (
+	"github.com/leogps/lunar/pkg/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(value string) time.Time {
+	parsed, _ := time.Parse(types.DateLayout, value)
+	return parsed
+}
+
+func newTestLedger(t *testing.T) *Ledger {
+	ledger := &Ledger{}
+	esppLot := &Lot{Label: "2024-H1"}
+	esppLot.Source = types.Espp
+	esppLot.AcquisitionDate = date("2024-06-28")
+	esppLot.Quantity = types.NewShares(50)
+	esppLot.BasisPerShare = types.NewMoney(85)
+	esppLot.DiscountPercent = 15
+	esppLot.OfferingDate = date("2024-01-02")
+	esppLot.OfferingDateMarketValuePerShare = types.NewMoney(110)
+	esppLot.PurchaseDateMarketValuePerShare = types.NewMoney(100)
+
+	rsuLot := &Lot{IncomeTax: types.NewMoney(5000)}
+	rsuLot.Source = types.Rsu
+	rsuLot.AcquisitionDate = date("2024-03-15")
+	rsuLot.Quantity = types.NewShares(100.5)
+	rsuLot.BasisPerShare = types.NewMoney(150.1234)
+
+	for _, lot := range []*Lot{esppLot, rsuLot} {
+		if err := ledger.AddLot(lot); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sale := &Sale{LotID: 2, Date: date("2024-05-01"), Quantity: types.NewShares(40), PricePerShare: types.NewMoney(160),
+		Commission: types.NewMoney(4.95)}
+	if err := ledger.AddSale(sale); err != nil {
+		t.Fatal(err)
+	}
+	return ledger
+}
+
+func TestLedger_AddSale(t *testing.T) {
+	ledger := newTestLedger(t)
+	rsuLot, err := ledger.Lot(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remainingShares := ledger.RemainingShares(rsuLot); remainingShares != types.NewShares(60.5) {
+		t.Errorf("expected 60.5 shares held, got %s", remainingShares)
+	}
+
+	err = ledger.AddSale(&Sale{LotID: 2, Date: date("2024-01-01"), Quantity: types.NewShares(61), PricePerShare: types.NewMoney(160)})
+	if err == nil {
+		t.Fatal("expected the sale to fail")
+	}
+	for _, expected := range []string{"before the acquisition date", "holds 60.5 shares"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in %q", expected, err)
+		}
+	}
+	if err = ledger.AddSale(&Sale{LotID: 9, Date: date("2024-05-01"), Quantity: types.NewShares(1), PricePerShare: types.NewMoney(1)}); err == nil {
+		t.Error("expected a sale from a missing lot to fail")
+	}
+	if len(ledger.Sales) != 1 || ledger.LastID != 3 {
+		t.Errorf("expected the failed sales to be left out, got %d sales and last ID %d", len(ledger.Sales), ledger.LastID)
+	}
+}
+
+func TestLedger_AddLot(t *testing.T) {
+	ledger := &Ledger{}
+	lot := &Lot{}
+	lot.DiscountPercent = 100
+	err := ledger.AddLot(lot)
+	if err == nil {
+		t.Fatal("expected the lot to fail")
+	}
+	for _, expected := range []string{"acquisition date is required", "number of shares", "basis per share", "discount percent"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in %q", expected, err)
+		}
+	}
+}
+
+func TestLedger_HeldLots(t *testing.T) {
+	ledger := newTestLedger(t)
+	heldLots, err := ledger.HeldLots(2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(heldLots) != 2 || heldLots[0].Source != types.Rsu || heldLots[0].Quantity != types.NewShares(60.5) {
+		t.Errorf("expected the RSU lot first with the shares held, got %+v", heldLots)
+	}
+	if heldLots[1].CostPerShare() != types.NewMoney(100) {
+		t.Errorf("expected the ESPP cost per share before the discount, got %s", heldLots[1].CostPerShare())
+	}
+	lot, _ := ledger.Lot(2)
+	if lot.Quantity != types.NewShares(100.5) {
+		t.Errorf("expected the ledger lot to be left as is, got %s", lot.Quantity)
+	}
+
+	if err = ledger.AddSale(&Sale{LotID: 1, Date: date("2024-07-01"), Quantity: types.NewShares(50), PricePerShare: types.NewMoney(90)}); err != nil {
+		t.Fatal(err)
+	}
+	if heldLots, err = ledger.HeldLots(); err != nil || len(heldLots) != 1 {
+		t.Errorf("expected the sold out lot to be left out, got %+v, %v", heldLots, err)
+	}
+	if _, err = ledger.HeldLots(1); err == nil {
+		t.Error("expected picking the sold out lot to fail")
+	}
+}
+
+func TestLedger_Remove(t *testing.T) {
+	ledger := newTestLedger(t)
+	if err := ledger.Remove(2); err == nil || !strings.Contains(err.Error(), "has sales 3") {
+		t.Errorf("expected the lot with sales to be kept, got %v", err)
+	}
+	for _, id := range []int{3, 2} {
+		if err := ledger.Remove(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ledger.Remove(2); err == nil {
+		t.Error("expected removing a missing ID to fail")
+	}
+
+	lot := &Lot{}
+	lot.Source = types.Rsu
+	lot.AcquisitionDate = date("2024-06-15")
+	lot.Quantity = types.NewShares(10)
+	lot.BasisPerShare = types.NewMoney(100)
+	if err := ledger.AddLot(lot); err != nil || lot.ID != 4 {
+		t.Errorf("expected the removed IDs not to be reused, got ID %d, %v", lot.ID, err)
+	}
+}
+
+func TestLedger_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lunar", "ledger.yaml")
+	if ledger, err := Load(path); err != nil || len(ledger.Lots) != 0 {
+		t.Fatalf("expected a missing file to be an empty ledger, got %+v, %v", ledger, err)
+	}
+
+	ledger := newTestLedger(t)
+	if err := ledger.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.LastID != 3 || len(loaded.Lots) != 2 || len(loaded.Sales) != 1 {
+		t.Fatalf("expected the saved ledger, got %+v", loaded)
+	}
+	if *loaded.Lots[0] != *ledger.Lots[0] || *loaded.Lots[1] != *ledger.Lots[1] || *loaded.Sales[0] != *ledger.Sales[0] {
+		t.Errorf("expected the lots and sales to round trip exactly, got %+v, %+v, %+v", loaded.Lots[0], loaded.Lots[1], loaded.Sales[0])
+	}
+}
+
+func TestLedger_SaveReplaces(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ledger.yaml")
+	ledger := newTestLedger(t)
+	if err := ledger.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	ledger.Sales = nil
+	if err := ledger.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, err := Load(path); err != nil || len(loaded.Sales) != 0 {
+		t.Fatalf("expected the ledger to be replaced, got %+v, %v", loaded, err)
+	}
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 1 {
+		t.Errorf("expected only the ledger file to be left, got %v, %v", entries, err)
+	}
+
+	// A ledger path that cannot be replaced fails without leaving the temporary file behind
+	blocked := filepath.Join(dir, "blocked")
+	if err := os.MkdirAll(filepath.Join(blocked, "ledger"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ledger.Save(blocked); err == nil {
+		t.Error("expected an error saving over a directory")
+	}
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 2 {
+		t.Errorf("expected the temporary file to be removed, got %v, %v", entries, err)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.yaml")
+	data := `last-id: 2
+lots:
+  - id: 1
+    type: bond
+    acquisition-date: 2024-13-01
+    shares: 10
+    basis-per-share: 100
+sales:
+  - id: 2
+    lot: 1
+    sale-date: 2024-05-01
+    shares: 1
+    price-per-share: 1
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Load(path)
+	if err == nil {
+		t.Fatal("expected the invalid ledger to fail")
+	}
+	for _, expected := range []string{"lot 1: type: unknown order type: bond", "acquisition-date:", "sale 2: no lot with ID 1"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in %q", expected, err)
+		}
+	}
+}
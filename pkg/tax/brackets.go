@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package tax
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Bracket taxes the income up to UpperBound at RatePercent. The top bracket uses math.Inf(1) as its UpperBound.
+type Bracket struct {
+	UpperBound  float64
+	RatePercent float64
+}
+
+// BracketTable holds the brackets for each tax year and filing status
+type BracketTable map[int]map[FilingStatus][]Bracket
+
+// Brackets returns the brackets for the given tax year and filing status
+func (b BracketTable) Brackets(taxYear int, filingStatus FilingStatus) ([]Bracket, error) {
+	byFilingStatus, ok := b[taxYear]
+	if !ok {
+		return nil, fmt.Errorf("tax year %d is not supported (supported: %v)", taxYear, b.TaxYears())
+	}
+	brackets, ok := byFilingStatus[filingStatus]
+	if !ok {
+		return nil, fmt.Errorf("filing status %s is not supported for tax year %d", filingStatus, taxYear)
+	}
+	return brackets, nil
+}
+
+// TaxYears returns the supported tax years in ascending order
+func (b BracketTable) TaxYears() []int {
+	taxYears := make([]int, 0, len(b))
+	for taxYear := range b {
+		taxYears = append(taxYears, taxYear)
+	}
+	sort.Ints(taxYears)
+	return taxYears
+}
+
+// CalculateTaxOnIncome returns the total tax on the taxable income
+func CalculateTaxOnIncome(brackets []Bracket, taxableIncome float64) float64 {
+	var totalTax float64
+	lowerBound := 0.0
+	for _, bracket := range brackets {
+		if taxableIncome <= lowerBound {
+			break
+		}
+		taxedInBracket := math.Min(taxableIncome, bracket.UpperBound) - lowerBound
+		totalTax += taxedInBracket * bracket.RatePercent / 100
+		lowerBound = bracket.UpperBound
+	}
+	return totalTax
+}
+
+// CalculateIncrementalTax returns the tax on income stacked on top of otherIncome
+func CalculateIncrementalTax(brackets []Bracket, otherIncome float64, income float64) float64 {
+	otherIncome = math.Max(otherIncome, 0)
+	return CalculateTaxOnIncome(brackets, otherIncome+income) - CalculateTaxOnIncome(brackets, otherIncome)
+}
+
+// CalculateMarginalRatePercent returns the rate of the bracket the taxable income falls in
+func CalculateMarginalRatePercent(brackets []Bracket, taxableIncome float64) float64 {
+	for _, bracket := range brackets {
+		if taxableIncome < bracket.UpperBound {
+			return bracket.RatePercent
+		}
+	}
+	if len(brackets) == 0 {
+		return 0
+	}
+	return brackets[len(brackets)-1].RatePercent
+}
+
+// ProgressiveModel taxes ordinary income and long-term capital gains with separate bracket tables
+type ProgressiveModel struct {
+	OrdinaryIncomeBrackets      BracketTable
+	LongTermCapitalGainBrackets BracketTable
+}
+
+func (p ProgressiveModel) CalculateTax(profile Profile, incomeType IncomeType, income float64) (float64, error) {
+	if income <= 0 {
+		return 0, nil
+	}
+	table := p.OrdinaryIncomeBrackets
+	if incomeType == LongTermCapitalGain {
+		table = p.LongTermCapitalGainBrackets
+	}
+	brackets, err := table.Brackets(profile.TaxYear, profile.FilingStatus)
+	if err != nil {
+		return 0, err
+	}
+	return CalculateIncrementalTax(brackets, profile.OtherIncome, income), nil
+}
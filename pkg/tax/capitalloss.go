@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package tax
+
+import "math"
+
+// CapitalLossOrdinaryIncomeLimit is how much of a net capital loss may offset ordinary income each year
+const CapitalLossOrdinaryIncomeLimit = 3000
+
+// CapitalLossOrdinaryIncomeLimitFor returns the yearly ordinary income offset limit, halved when married filing separately
+func CapitalLossOrdinaryIncomeLimitFor(filingStatus FilingStatus) float64 {
+	if filingStatus == MarriedFilingSeparately {
+		return CapitalLossOrdinaryIncomeLimit / 2
+	}
+	return CapitalLossOrdinaryIncomeLimit
+}
+
+// CapitalLossApplication is how a capital loss and the carryforward from prior years are used up in a tax year
+type CapitalLossApplication struct {
+	OffsetGains          float64
+	OffsetOrdinaryIncome float64
+	// Carryforward is the loss left over for the next tax year
+	Carryforward float64
+}
+
+// ApplyCapitalLoss applies loss plus the carryforward against the other realized gains first,
+// then against ordinary income up to the limit, carrying the rest forward. loss is a positive amount.
+func ApplyCapitalLoss(filingStatus FilingStatus, loss float64, otherRealizedGains float64, carryforward float64) CapitalLossApplication {
+	totalLoss := math.Max(loss, 0) + math.Max(carryforward, 0)
+	offsetGains := math.Min(totalLoss, math.Max(otherRealizedGains, 0))
+	offsetOrdinaryIncome := math.Min(totalLoss-offsetGains, CapitalLossOrdinaryIncomeLimitFor(filingStatus))
+	return CapitalLossApplication{
+		OffsetGains:          offsetGains,
+		OffsetOrdinaryIncome: offsetOrdinaryIncome,
+		Carryforward:         totalLoss - offsetGains - offsetOrdinaryIncome,
+	}
+}
+
+// CapitalLossYear is the net capital gain (positive) or loss (negative) realized in a tax year
+type CapitalLossYear struct {
+	TaxYear              int
+	NetCapitalGainOrLoss float64
+}
+
+// CapitalLossCarryforwardYear is how the carryforward into a tax year was used and what is left for the next one
+type CapitalLossCarryforwardYear struct {
+	CapitalLossYear
+	CarryforwardIn float64
+	CapitalLossApplication
+}
+
+// ProjectCapitalLossCarryforward carries the loss forward through the years in order, starting from carryforward
+func ProjectCapitalLossCarryforward(filingStatus FilingStatus, carryforward float64, years []CapitalLossYear) []CapitalLossCarryforwardYear {
+	var projection []CapitalLossCarryforwardYear
+	for _, year := range years {
+		loss := math.Max(-year.NetCapitalGainOrLoss, 0)
+		gains := math.Max(year.NetCapitalGainOrLoss, 0)
+		application := ApplyCapitalLoss(filingStatus, loss, gains, carryforward)
+		projection = append(projection, CapitalLossCarryforwardYear{
+			CapitalLossYear:        year,
+			CarryforwardIn:         carryforward,
+			CapitalLossApplication: application,
+		})
+		carryforward = application.Carryforward
+	}
+	return projection
+}
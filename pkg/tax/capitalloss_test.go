@@ -0,0 +1,69 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package tax
+
+import (
+	"math"
+	"testing"
+)
+
+func TestApplyCapitalLoss(t *testing.T) {
+	tests := []struct {
+		name               string
+		filingStatus       FilingStatus
+		loss               float64
+		otherRealizedGains float64
+		carryforward       float64
+		expected           CapitalLossApplication
+	}{
+		{"offsets gains first", Single, 2000, 5000, 0, CapitalLossApplication{OffsetGains: 2000}},
+		{"then ordinary income up to the limit", Single, 10000, 4000, 0, CapitalLossApplication{OffsetGains: 4000, OffsetOrdinaryIncome: 3000, Carryforward: 3000}},
+		{"carryforward is pooled with the loss", MarriedFilingJointly, 1000, 0, 2500, CapitalLossApplication{OffsetOrdinaryIncome: 3000, Carryforward: 500}},
+		{"married filing separately limit is halved", MarriedFilingSeparately, 2000, 0, 0, CapitalLossApplication{OffsetOrdinaryIncome: 1500, Carryforward: 500}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := ApplyCapitalLoss(test.filingStatus, test.loss, test.otherRealizedGains, test.carryforward)
+			if math.Abs(actual.OffsetGains-test.expected.OffsetGains) > 0.0001 ||
+				math.Abs(actual.OffsetOrdinaryIncome-test.expected.OffsetOrdinaryIncome) > 0.0001 ||
+				math.Abs(actual.Carryforward-test.expected.Carryforward) > 0.0001 {
+				t.Errorf("expected %+v, got %+v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestProjectCapitalLossCarryforward(t *testing.T) {
+	projection := ProjectCapitalLossCarryforward(Single, 0, []CapitalLossYear{
+		{TaxYear: 2024, NetCapitalGainOrLoss: -10000},
+		{TaxYear: 2025, NetCapitalGainOrLoss: 2000},
+		{TaxYear: 2026, NetCapitalGainOrLoss: 0},
+	})
+	expectedCarryforwards := []float64{7000, 2000, 0}
+	for index, year := range projection {
+		if math.Abs(year.Carryforward-expectedCarryforwards[index]) > 0.0001 {
+			t.Errorf("%d: expected $%.2f carried forward, got $%.2f", year.TaxYear, expectedCarryforwards[index], year.Carryforward)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package tax
+
+import "math"
+
+var federalOrdinaryIncomeRates = []float64{10, 12, 22, 24, 32, 35, 37}
+
+var federalLongTermCapitalGainRates = []float64{0, 15, 20}
+
+// FederalOrdinaryIncomeBrackets are the US federal income tax brackets on taxable income
+var FederalOrdinaryIncomeBrackets = BracketTable{
+	2023: {
+		Single:                  newBrackets(federalOrdinaryIncomeRates, 11000, 44725, 95375, 182100, 231250, 578125),
+		MarriedFilingJointly:    newBrackets(federalOrdinaryIncomeRates, 22000, 89450, 190750, 364200, 462500, 693750),
+		MarriedFilingSeparately: newBrackets(federalOrdinaryIncomeRates, 11000, 44725, 95375, 182100, 231250, 346875),
+		HeadOfHousehold:         newBrackets(federalOrdinaryIncomeRates, 15700, 59850, 95350, 182100, 231250, 578100),
+	},
+	2024: {
+		Single:                  newBrackets(federalOrdinaryIncomeRates, 11600, 47150, 100525, 191950, 243725, 609350),
+		MarriedFilingJointly:    newBrackets(federalOrdinaryIncomeRates, 23200, 94300, 201050, 383900, 487450, 731200),
+		MarriedFilingSeparately: newBrackets(federalOrdinaryIncomeRates, 11600, 47150, 100525, 191950, 243725, 365600),
+		HeadOfHousehold:         newBrackets(federalOrdinaryIncomeRates, 16550, 63100, 100500, 191950, 243700, 609350),
+	},
+	2025: {
+		Single:                  newBrackets(federalOrdinaryIncomeRates, 11925, 48475, 103350, 197300, 250525, 626350),
+		MarriedFilingJointly:    newBrackets(federalOrdinaryIncomeRates, 23850, 96950, 206700, 394600, 501050, 751600),
+		MarriedFilingSeparately: newBrackets(federalOrdinaryIncomeRates, 11925, 48475, 103350, 197300, 250525, 375800),
+		HeadOfHousehold:         newBrackets(federalOrdinaryIncomeRates, 17000, 64850, 103350, 197300, 250500, 626350),
+	},
+}
+
+// FederalLongTermCapitalGainBrackets are the US federal long-term capital gains brackets on taxable income
+var FederalLongTermCapitalGainBrackets = BracketTable{
+	2023: {
+		Single:                  newBrackets(federalLongTermCapitalGainRates, 44625, 492300),
+		MarriedFilingJointly:    newBrackets(federalLongTermCapitalGainRates, 89250, 553850),
+		MarriedFilingSeparately: newBrackets(federalLongTermCapitalGainRates, 44625, 276900),
+		HeadOfHousehold:         newBrackets(federalLongTermCapitalGainRates, 59750, 523050),
+	},
+	2024: {
+		Single:                  newBrackets(federalLongTermCapitalGainRates, 47025, 518900),
+		MarriedFilingJointly:    newBrackets(federalLongTermCapitalGainRates, 94050, 583750),
+		MarriedFilingSeparately: newBrackets(federalLongTermCapitalGainRates, 47025, 291850),
+		HeadOfHousehold:         newBrackets(federalLongTermCapitalGainRates, 63000, 551350),
+	},
+	2025: {
+		Single:                  newBrackets(federalLongTermCapitalGainRates, 48350, 533400),
+		MarriedFilingJointly:    newBrackets(federalLongTermCapitalGainRates, 96700, 600050),
+		MarriedFilingSeparately: newBrackets(federalLongTermCapitalGainRates, 48350, 300000),
+		HeadOfHousehold:         newBrackets(federalLongTermCapitalGainRates, 64750, 566700),
+	},
+}
+
+// Federal is the US federal income tax model
+var Federal = ProgressiveModel{
+	OrdinaryIncomeBrackets:      FederalOrdinaryIncomeBrackets,
+	LongTermCapitalGainBrackets: FederalLongTermCapitalGainBrackets,
+}
+
+// newBrackets pairs each rate with its upper bound; the last rate applies to all income above the last bound.
+func newBrackets(ratePercents []float64, upperBounds ...float64) []Bracket {
+	brackets := make([]Bracket, len(ratePercents))
+	for i, ratePercent := range ratePercents {
+		upperBound := math.Inf(1)
+		if i < len(upperBounds) {
+			upperBound = upperBounds[i]
+		}
+		brackets[i] = Bracket{UpperBound: upperBound, RatePercent: ratePercent}
+	}
+	return brackets
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package tax
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFederal_CalculateTaxOrdinaryIncome(t *testing.T) {
+	profile := Profile{
+		FilingStatus: Single,
+		TaxYear:      2024,
+		OtherIncome:  180000,
+	}
+
+	// $50,000 vest spans the 24% ($11,950) and 32% ($38,050) brackets.
+	incomeTax, err := Federal.CalculateTax(profile, OrdinaryIncome, 50000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedIncomeTax := 11950*0.24 + 38050*0.32
+	if math.Abs(incomeTax-expectedIncomeTax) > 0.0001 {
+		t.Errorf("expected income tax of $%.2f, got $%.2f", expectedIncomeTax, incomeTax)
+	}
+}
+
+func TestFederal_CalculateTaxLongTermCapitalGain(t *testing.T) {
+	profile := Profile{
+		FilingStatus: MarriedFilingJointly,
+		TaxYear:      2024,
+		OtherIncome:  84050,
+	}
+
+	// The first $10,000 of gains fall in the 0% bracket, the remaining $10,000 in the 15% bracket.
+	capitalGainTax, err := Federal.CalculateTax(profile, LongTermCapitalGain, 20000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(capitalGainTax-1500) > 0.0001 {
+		t.Errorf("expected capital gain tax of $1500.00, got $%.2f", capitalGainTax)
+	}
+}
+
+func TestFederal_CalculateTaxUnsupportedYear(t *testing.T) {
+	_, err := Federal.CalculateTax(Profile{TaxYear: 1999}, OrdinaryIncome, 1000)
+	if err == nil {
+		t.Error("expected error for unsupported tax year")
+	}
+}
+
+func TestParseFilingStatus(t *testing.T) {
+	for value, expected := range map[string]FilingStatus{
+		"single":                 Single,
+		"MFJ":                    MarriedFilingJointly,
+		"married-filing-jointly": MarriedFilingJointly,
+		"hoh":                    HeadOfHousehold,
+	} {
+		filingStatus, err := ParseFilingStatus(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if filingStatus != expected {
+			t.Errorf("expected %s for %q, got %s", expected, value, filingStatus)
+		}
+	}
+	if _, err := ParseFilingStatus("widowed"); err == nil {
+		t.Error("expected error for unknown filing status")
+	}
+}
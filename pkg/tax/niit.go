@@ -0,0 +1,56 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package tax
+
+import "math"
+
+// NetInvestmentIncomeTaxPercent is the Net Investment Income Tax (NIIT) surcharge rate
+const NetInvestmentIncomeTaxPercent = 3.8
+
+// netInvestmentIncomeTaxThresholds are the MAGI thresholds above which NIIT applies; they are not indexed for inflation
+var netInvestmentIncomeTaxThresholds = map[FilingStatus]float64{
+	Single:                  200000,
+	MarriedFilingJointly:    250000,
+	MarriedFilingSeparately: 125000,
+	HeadOfHousehold:         200000,
+}
+
+// NetInvestmentIncomeTaxThreshold returns the MAGI threshold for the filing status
+func NetInvestmentIncomeTaxThreshold(filingStatus FilingStatus) float64 {
+	return netInvestmentIncomeTaxThresholds[filingStatus]
+}
+
+// CalculateNetInvestmentIncomeTax returns the NIIT owed on investmentIncome.
+// modifiedAdjustedGrossIncome is the MAGI estimate excluding the investment income itself;
+// the tax applies to the lesser of the investment income and the MAGI in excess of the threshold.
+func CalculateNetInvestmentIncomeTax(filingStatus FilingStatus, modifiedAdjustedGrossIncome float64, investmentIncome float64) float64 {
+	if investmentIncome <= 0 {
+		return 0
+	}
+	excessIncome := modifiedAdjustedGrossIncome + investmentIncome - NetInvestmentIncomeTaxThreshold(filingStatus)
+	if excessIncome <= 0 {
+		return 0
+	}
+	return math.Min(investmentIncome, excessIncome) * NetInvestmentIncomeTaxPercent / 100
+}
@@ -0,0 +1,51 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package tax
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculateNetInvestmentIncomeTax(t *testing.T) {
+	tests := []struct {
+		name             string
+		filingStatus     FilingStatus
+		magi             float64
+		investmentIncome float64
+		expected         float64
+	}{
+		{"below threshold", Single, 150000, 20000, 0},
+		{"partially above threshold", Single, 190000, 20000, 10000 * 0.038},
+		{"fully above threshold", MarriedFilingJointly, 300000, 20000, 20000 * 0.038},
+		{"married filing separately threshold", MarriedFilingSeparately, 125000, 5000, 5000 * 0.038},
+		{"loss", Single, 500000, -1000, 0},
+	}
+	for _, test := range tests {
+		actual := CalculateNetInvestmentIncomeTax(test.filingStatus, test.magi, test.investmentIncome)
+		if math.Abs(actual-test.expected) > 0.0001 {
+			t.Errorf("%s: expected $%.2f, got $%.2f", test.name, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package tax
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+var californiaRates = []float64{1, 2, 4, 6, 8, 9.3, 10.3, 11.3, 12.3}
+
+var newYorkRates = []float64{4, 4.5, 5.25, 5.5, 6, 6.85, 9.65, 10.3, 10.9}
+
+// CaliforniaBrackets are the California income tax brackets. California taxes capital gains as ordinary income.
+// The 1% Mental Health Services Tax on income above $1,000,000 is included as its own bracket.
+var CaliforniaBrackets = BracketTable{
+	2023: {
+		Single:                  withCaliforniaMentalHealthTax(newBrackets(californiaRates, 10412, 24684, 38959, 54081, 68350, 349137, 418961, 698271)),
+		MarriedFilingJointly:    withCaliforniaMentalHealthTax(newBrackets(californiaRates, 20824, 49368, 77918, 108162, 136700, 698274, 837922, 1396542)),
+		MarriedFilingSeparately: withCaliforniaMentalHealthTax(newBrackets(californiaRates, 10412, 24684, 38959, 54081, 68350, 349137, 418961, 698271)),
+		HeadOfHousehold:         withCaliforniaMentalHealthTax(newBrackets(californiaRates, 20839, 49371, 63644, 78765, 93037, 474824, 569790, 949649)),
+	},
+	2024: {
+		Single:                  withCaliforniaMentalHealthTax(newBrackets(californiaRates, 10756, 25499, 40245, 55866, 70606, 360659, 432787, 721314)),
+		MarriedFilingJointly:    withCaliforniaMentalHealthTax(newBrackets(californiaRates, 21512, 50998, 80490, 111732, 141212, 721318, 865574, 1442628)),
+		MarriedFilingSeparately: withCaliforniaMentalHealthTax(newBrackets(californiaRates, 10756, 25499, 40245, 55866, 70606, 360659, 432787, 721314)),
+		HeadOfHousehold:         withCaliforniaMentalHealthTax(newBrackets(californiaRates, 21527, 51000, 65744, 81364, 96107, 490493, 588593, 980987)),
+	},
+	2025: {
+		Single:                  withCaliforniaMentalHealthTax(newBrackets(californiaRates, 11079, 26264, 41452, 57542, 72724, 371479, 445771, 742953)),
+		MarriedFilingJointly:    withCaliforniaMentalHealthTax(newBrackets(californiaRates, 22158, 52528, 82904, 115084, 145448, 742958, 891542, 1485906)),
+		MarriedFilingSeparately: withCaliforniaMentalHealthTax(newBrackets(californiaRates, 11079, 26264, 41452, 57542, 72724, 371479, 445771, 742953)),
+		HeadOfHousehold:         withCaliforniaMentalHealthTax(newBrackets(californiaRates, 22173, 52530, 67716, 83805, 98990, 505208, 606251, 1010417)),
+	},
+}
+
+// NewYorkBrackets are the New York State income tax brackets. New York taxes capital gains as ordinary income.
+// The supplemental tax (benefit recapture) on high incomes and New York City tax are not included.
+var NewYorkBrackets = BracketTable{
+	2023: newYorkBracketsByFilingStatus(),
+	2024: newYorkBracketsByFilingStatus(),
+	2025: newYorkBracketsByFilingStatus(),
+}
+
+// California is the California income tax model
+var California = ProgressiveModel{
+	OrdinaryIncomeBrackets:      CaliforniaBrackets,
+	LongTermCapitalGainBrackets: CaliforniaBrackets,
+}
+
+// NewYork is the New York State income tax model
+var NewYork = ProgressiveModel{
+	OrdinaryIncomeBrackets:      NewYorkBrackets,
+	LongTermCapitalGainBrackets: NewYorkBrackets,
+}
+
+// NoIncomeTaxModel is used for states without a personal income tax
+type NoIncomeTaxModel struct{}
+
+func (n NoIncomeTaxModel) CalculateTax(_ Profile, _ IncomeType, _ float64) (float64, error) {
+	return 0, nil
+}
+
+// WashingtonModel has no tax on ordinary income and taxes long-term capital gains above a standard deduction.
+// The deduction applies to the gain itself, so other income does not affect the tax; other long-term gains
+// realized in the same year are not considered.
+type WashingtonModel struct {
+	// CapitalGainBrackets are the rates on long-term gains, the first bracket being the standard deduction.
+	CapitalGainBrackets map[int][]Bracket
+}
+
+func (w WashingtonModel) CalculateTax(profile Profile, incomeType IncomeType, income float64) (float64, error) {
+	if incomeType != LongTermCapitalGain || income <= 0 {
+		return 0, nil
+	}
+	brackets, ok := w.CapitalGainBrackets[profile.TaxYear]
+	if !ok {
+		return 0, fmt.Errorf("tax year %d is not supported for WA", profile.TaxYear)
+	}
+	return CalculateTaxOnIncome(brackets, income), nil
+}
+
+// Washington is the Washington capital gains tax model
+var Washington = WashingtonModel{
+	CapitalGainBrackets: map[int][]Bracket{
+		2023: {{UpperBound: 262000, RatePercent: 0}, {UpperBound: math.Inf(1), RatePercent: 7}},
+		2024: {{UpperBound: 270000, RatePercent: 0}, {UpperBound: math.Inf(1), RatePercent: 7}},
+		2025: {{UpperBound: 278000, RatePercent: 0}, {UpperBound: 1000000, RatePercent: 7}, {UpperBound: math.Inf(1), RatePercent: 9.9}},
+	},
+}
+
+var stateModels = map[string]Model{
+	"AK": NoIncomeTaxModel{},
+	"CA": California,
+	"FL": NoIncomeTaxModel{},
+	"NV": NoIncomeTaxModel{},
+	"NY": NewYork,
+	"SD": NoIncomeTaxModel{},
+	"TN": NoIncomeTaxModel{},
+	"TX": NoIncomeTaxModel{},
+	"WA": Washington,
+	"WY": NoIncomeTaxModel{},
+}
+
+// StateCodes returns the supported two-letter state codes in alphabetical order
+func StateCodes() []string {
+	stateCodes := make([]string, 0, len(stateModels))
+	for stateCode := range stateModels {
+		stateCodes = append(stateCodes, stateCode)
+	}
+	sort.Strings(stateCodes)
+	return stateCodes
+}
+
+// LookupState returns the tax model for a two-letter state code (e.g. "CA")
+func LookupState(stateCode string) (Model, error) {
+	model, ok := stateModels[strings.ToUpper(strings.TrimSpace(stateCode))]
+	if !ok {
+		return nil, fmt.Errorf("state %s is not supported (supported: %s)", stateCode, strings.Join(StateCodes(), ", "))
+	}
+	return model, nil
+}
+
+func withCaliforniaMentalHealthTax(brackets []Bracket) []Bracket {
+	const mentalHealthTaxThreshold = 1000000
+	var result []Bracket
+	for _, bracket := range brackets {
+		if bracket.UpperBound > mentalHealthTaxThreshold {
+			if len(result) == 0 || result[len(result)-1].UpperBound < mentalHealthTaxThreshold {
+				result = append(result, Bracket{UpperBound: mentalHealthTaxThreshold, RatePercent: bracket.RatePercent})
+			}
+			result = append(result, Bracket{UpperBound: bracket.UpperBound, RatePercent: bracket.RatePercent + 1})
+			continue
+		}
+		result = append(result, bracket)
+	}
+	return result
+}
+
+func newYorkBracketsByFilingStatus() map[FilingStatus][]Bracket {
+	return map[FilingStatus][]Bracket{
+		Single:                  newBrackets(newYorkRates, 8500, 11700, 13900, 80650, 215400, 1077550, 5000000, 25000000),
+		MarriedFilingJointly:    newBrackets(newYorkRates, 17150, 23600, 27900, 161550, 323200, 2155350, 5000000, 25000000),
+		MarriedFilingSeparately: newBrackets(newYorkRates, 8500, 11700, 13900, 80650, 215400, 1077550, 5000000, 25000000),
+		HeadOfHousehold:         newBrackets(newYorkRates, 12800, 17650, 20900, 107650, 269300, 1616450, 5000000, 25000000),
+	}
+}
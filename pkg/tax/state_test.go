@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package tax
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalifornia_CalculateTax(t *testing.T) {
+	profile := Profile{
+		FilingStatus: Single,
+		TaxYear:      2024,
+		OtherIncome:  100000,
+	}
+
+	// California taxes long-term gains as ordinary income.
+	capitalGainTax, err := California.CalculateTax(profile, LongTermCapitalGain, 10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(capitalGainTax-930) > 0.0001 {
+		t.Errorf("expected capital gain tax of $930.00, got $%.2f", capitalGainTax)
+	}
+
+	// Income above $1,000,000 includes the 1% Mental Health Services Tax.
+	profile.OtherIncome = 1000000
+	incomeTax, err := California.CalculateTax(profile, OrdinaryIncome, 10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(incomeTax-1330) > 0.0001 {
+		t.Errorf("expected income tax of $1330.00, got $%.2f", incomeTax)
+	}
+
+	// The 2025 brackets are supported as for the federal, NY and WA taxes.
+	profile.TaxYear, profile.OtherIncome = 2025, 60000
+	incomeTax, err = California.CalculateTax(profile, OrdinaryIncome, 20000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(incomeTax-1694.588) > 0.0001 {
+		t.Errorf("expected income tax of $1694.59, got $%.2f", incomeTax)
+	}
+	if _, err = California.CalculateTax(Profile{FilingStatus: Single, TaxYear: 2022}, OrdinaryIncome, 10000); err == nil {
+		t.Error("expected an error for an unsupported tax year")
+	}
+}
+
+func TestWashington_CalculateTax(t *testing.T) {
+	profile := Profile{
+		FilingStatus: Single,
+		TaxYear:      2024,
+		OtherIncome:  500000,
+	}
+
+	capitalGainTax, err := Washington.CalculateTax(profile, LongTermCapitalGain, 300000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(capitalGainTax-2100) > 0.0001 {
+		t.Errorf("expected capital gain tax of $2100.00, got $%.2f", capitalGainTax)
+	}
+
+	incomeTax, err := Washington.CalculateTax(profile, OrdinaryIncome, 300000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if incomeTax != 0 {
+		t.Errorf("expected no income tax, got $%.2f", incomeTax)
+	}
+}
+
+func TestLookupState(t *testing.T) {
+	model, err := LookupState("tx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	incomeTax, _ := model.CalculateTax(Profile{TaxYear: 2024}, OrdinaryIncome, 100000)
+	if incomeTax != 0 {
+		t.Errorf("expected no income tax in TX, got $%.2f", incomeTax)
+	}
+	if _, err = LookupState("ZZ"); err == nil {
+		t.Error("expected error for unknown state")
+	}
+}
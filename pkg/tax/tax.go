@@ -0,0 +1,123 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package tax
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FilingStatus is the filing status used to pick a bracket table
+type FilingStatus int
+
+const (
+	Single FilingStatus = iota
+	MarriedFilingJointly
+	MarriedFilingSeparately
+	HeadOfHousehold
+)
+
+var filingStatusNames = map[FilingStatus]string{
+	Single:                  "single",
+	MarriedFilingJointly:    "married-filing-jointly",
+	MarriedFilingSeparately: "married-filing-separately",
+	HeadOfHousehold:         "head-of-household",
+}
+
+var filingStatusAliases = map[string]FilingStatus{
+	"s":   Single,
+	"mfj": MarriedFilingJointly,
+	"mfs": MarriedFilingSeparately,
+	"hoh": HeadOfHousehold,
+}
+
+func (f FilingStatus) String() string {
+	return filingStatusNames[f]
+}
+
+// FilingStatuses returns all filing statuses in declaration order
+func FilingStatuses() []FilingStatus {
+	return []FilingStatus{Single, MarriedFilingJointly, MarriedFilingSeparately, HeadOfHousehold}
+}
+
+// ParseFilingStatus parses a filing status name (e.g. "single", "married-filing-jointly") or its abbreviation (e.g. "mfj").
+func ParseFilingStatus(value string) (FilingStatus, error) {
+	normalized := strings.ToLower(strings.TrimSpace(value))
+	if filingStatus, ok := filingStatusAliases[normalized]; ok {
+		return filingStatus, nil
+	}
+	for filingStatus, name := range filingStatusNames {
+		if name == normalized {
+			return filingStatus, nil
+		}
+	}
+	return Single, fmt.Errorf("unknown filing status: %s (expected one of: single, mfj, mfs, hoh)", value)
+}
+
+// IncomeType classifies the income being taxed
+type IncomeType int
+
+const (
+	// OrdinaryIncome covers wages, RSU/ESPP compensation income and short-term capital gains.
+	OrdinaryIncome IncomeType = iota
+	// LongTermCapitalGain covers capital gains on shares held for more than one year.
+	LongTermCapitalGain
+)
+
+func (i IncomeType) String() string {
+	if i == LongTermCapitalGain {
+		return "Long-Term Capital Gain"
+	}
+	return "Ordinary Income"
+}
+
+// Profile describes the taxpayer the incremental income is added on top of
+type Profile struct {
+	FilingStatus FilingStatus
+	TaxYear      int
+	// OtherIncome is the taxable income (after deductions) earned besides the income being taxed.
+	OtherIncome float64
+}
+
+// Model computes the tax owed on incremental income
+type Model interface {
+	// CalculateTax returns the tax owed on income when it is added on top of the profile's other income.
+	CalculateTax(profile Profile, incomeType IncomeType, income float64) (float64, error)
+}
+
+// FlatModel taxes income at a flat percentage per income type
+type FlatModel struct {
+	OrdinaryIncomePercent      float64
+	LongTermCapitalGainPercent float64
+}
+
+func (f FlatModel) CalculateTax(_ Profile, incomeType IncomeType, income float64) (float64, error) {
+	if income <= 0 {
+		return 0, nil
+	}
+	if incomeType == LongTermCapitalGain {
+		return income * f.LongTermCapitalGainPercent / 100, nil
+	}
+	return income * f.OrdinaryIncomePercent / 100, nil
+}
@@ -0,0 +1,55 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package tax
+
+import "math"
+
+const (
+	// FederalSupplementalWithholdingPercent is the flat federal withholding rate on supplemental wages such as RSU vests
+	FederalSupplementalWithholdingPercent = 22.0
+	// FederalSupplementalWithholdingOverMillionPercent applies to supplemental wages above $1 million in a year
+	FederalSupplementalWithholdingOverMillionPercent = 37.0
+	SocialSecurityWithholdingPercent                 = 6.2
+	MedicareWithholdingPercent                       = 1.45
+)
+
+// DefaultSupplementalWithholdingPercents are the rates usually withheld on an RSU vest: federal, Social Security and Medicare
+func DefaultSupplementalWithholdingPercents() []float64 {
+	return []float64{FederalSupplementalWithholdingPercent, SocialSecurityWithholdingPercent, MedicareWithholdingPercent}
+}
+
+// FederalSupplementalWithholdingThreshold is the yearly supplemental wages above which the higher rate is mandatory
+const FederalSupplementalWithholdingThreshold = 1000000.0
+
+// CalculateFederalSupplementalWithholding returns the federal withholding on supplementalWages, given the
+// supplemental wages already paid in the year: 22% up to $1 million and 37% on the excess.
+func CalculateFederalSupplementalWithholding(priorSupplementalWages float64, supplementalWages float64) float64 {
+	if supplementalWages <= 0 {
+		return 0
+	}
+	wagesBelowThreshold := math.Max(math.Min(supplementalWages, FederalSupplementalWithholdingThreshold-priorSupplementalWages), 0)
+	wagesAboveThreshold := supplementalWages - wagesBelowThreshold
+	return wagesBelowThreshold*FederalSupplementalWithholdingPercent/100 +
+		wagesAboveThreshold*FederalSupplementalWithholdingOverMillionPercent/100
+}
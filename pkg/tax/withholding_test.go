@@ -0,0 +1,41 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package tax
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculateFederalSupplementalWithholding(t *testing.T) {
+	// $100,000 straddles the $1 million threshold: $50,000 at 22% and $50,000 at 37%.
+	actual := CalculateFederalSupplementalWithholding(950000, 100000)
+	expected := 50000*0.22 + 50000*0.37
+	if math.Abs(actual-expected) > 0.0001 {
+		t.Errorf("expected $%.2f, got $%.2f", expected, actual)
+	}
+	if actual = CalculateFederalSupplementalWithholding(0, 10000); math.Abs(actual-2200) > 0.0001 {
+		t.Errorf("expected $2200.00, got $%.2f", actual)
+	}
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package types
+
+import (
+	"github.com/leogps/lunar/pkg/tax"
+)
+
+// capitalLossApplication is tax.CapitalLossApplication in Money, each amount rounded to the cent
+type capitalLossApplication struct {
+	OffsetGains          Money
+	OffsetOrdinaryIncome Money
+	Carryforward         Money
+}
+
+// applyCapitalLoss applies tax.ApplyCapitalLoss to Money amounts
+func applyCapitalLoss(filingStatus tax.FilingStatus, loss Money, otherRealizedGains Money, carryforward Money) capitalLossApplication {
+	application := tax.ApplyCapitalLoss(filingStatus, loss.Float64(), otherRealizedGains.Float64(), carryforward.Float64())
+	return capitalLossApplication{
+		OffsetGains:          NewMoney(application.OffsetGains).RoundToCents(),
+		OffsetOrdinaryIncome: NewMoney(application.OffsetOrdinaryIncome).RoundToCents(),
+		Carryforward:         NewMoney(application.Carryforward).RoundToCents(),
+	}
+}
+
+// calculateCapitalLossTaxBenefit values a capital loss as the tax saved on the gains and ordinary income it offsets,
+// beyond what the carryforward from prior years offsets on its own. The result is a positive amount.
+func calculateCapitalLossTaxBenefit(filingStatus tax.FilingStatus, loss Money, otherRealizedGains Money, carryforward Money,
+	capitalGainTaxAmount func(Money) (Money, error), ordinaryIncomeTaxAmount func(Money) (Money, error)) (Money, error) {
+	taxSaved := func(application capitalLossApplication) (Money, error) {
+		capitalGainTaxSaved, err := capitalGainTaxAmount(application.OffsetGains)
+		if err != nil {
+			return 0, err
+		}
+		ordinaryIncomeTaxSaved, err := ordinaryIncomeTaxAmount(application.OffsetOrdinaryIncome)
+		if err != nil {
+			return 0, err
+		}
+		return capitalGainTaxSaved + ordinaryIncomeTaxSaved, nil
+	}
+
+	taxSavedWithLoss, err := taxSaved(applyCapitalLoss(filingStatus, loss, otherRealizedGains, carryforward))
+	if err != nil {
+		return 0, err
+	}
+	taxSavedByCarryforward, err := taxSaved(applyCapitalLoss(filingStatus, 0, otherRealizedGains, carryforward))
+	if err != nil {
+		return 0, err
+	}
+	return taxSavedWithLoss - taxSavedByCarryforward, nil
+}
+
+// calculateOffsetOrdinaryIncomeTax is the tax the model no longer charges on the top of the profile's other income
+// once ordinaryIncome of it is offset by a capital loss.
+func calculateOffsetOrdinaryIncomeTax(model tax.Model, profile tax.Profile, ordinaryIncome Money) (Money, error) {
+	offsetIncome := MinMoney(ordinaryIncome, MaxMoney(NewMoney(profile.OtherIncome), 0))
+	profile.OtherIncome -= offsetIncome.Float64()
+	return calculateTax(model, profile, tax.OrdinaryIncome, offsetIncome)
+}
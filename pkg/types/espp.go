@@ -25,68 +25,201 @@ package types
 
 import (
 	"fmt"
-	"math"
+	"github.com/leogps/lunar/pkg/tax"
 	"strings"
+	"time"
 )
 
+// DispositionType classifies an ESPP sale under Section 423.
+type DispositionType int
+
+const (
+	Disqualifying DispositionType = iota
+	Qualifying
+)
+
+func (d DispositionType) String() string {
+	if d == Qualifying {
+		return "Qualifying"
+	}
+	return "Disqualifying"
+}
+
 type EsppOrder struct {
 	DiscountPercent      float64
-	CostPerShare         float64
-	SellingPricePerShare float64
-	NumberOfSharesSold   int
+	CostPerShare         Money
+	SellingPricePerShare Money
+	NumberOfSharesSold   Shares
+
+	// ConsiderLookBack derives the cost per share from the lower of the offering-date and
+	// purchase-date market values instead of CostPerShare.
+	ConsiderLookBack                bool
+	OfferingDateMarketValuePerShare Money
+	PurchaseDateMarketValuePerShare Money
+
+	// PurchasePricePerShare, when greater than zero, is the price paid per share, e.g. as recorded for a lot, used
+	// as the effective cost per share as is instead of applying the discount to the cost per share.
+	// DiscountPercent still sets the ordinary income of a qualifying disposition.
+	PurchasePricePerShare Money
+
+	OfferingDate time.Time
+	PurchaseDate time.Time
+	SaleDate     time.Time
+
+	// ConsiderDisposition splits the gain into ordinary income and capital gain based on
+	// whether the sale is a qualifying disposition.
+	ConsiderDisposition      bool
+	OrdinaryIncomeTaxPercent float64
 
 	ConsiderTransactionCommission bool
-	CommissionPaidPerTransaction  float64
+	CommissionPaidPerTransaction  Money
 	NumberOfTransactions          int
 
 	ConsiderCapitalGainTax bool
 	CapitalGainTaxPercent  float64
+
+	// ConsiderHoldingPeriod picks the short-term or long-term rate from the purchase and sale dates
+	// instead of CapitalGainTaxPercent.
+	ConsiderHoldingPeriod          bool
+	ShortTermCapitalGainTaxPercent float64
+	LongTermCapitalGainTaxPercent  float64
+
+	// TaxModel, when set, computes the capital gain and ordinary income tax progressively for the
+	// TaxProfile instead of using the flat percentages.
+	TaxModel   tax.Model
+	TaxProfile tax.Profile
+	// StateTaxModel, when set, adds state tax on the capital gain and ordinary income for the TaxProfile.
+	StateTaxModel tax.Model
+
+	// ConsiderNetInvestmentIncomeTax adds the 3.8% NIIT on the capital gain above the MAGI threshold
+	// for the filing status of the TaxProfile. ModifiedAdjustedGrossIncome excludes this sale.
+	ConsiderNetInvestmentIncomeTax bool
+	ModifiedAdjustedGrossIncome    Money
+
+	// ConsiderCapitalLoss values a capital loss by the tax it saves, reported as a negative capital gain tax.
+	// The loss offsets OtherRealizedCapitalGains of the tax year first, then up to $3,000 of ordinary income,
+	// and the rest is carried forward along with the CapitalLossCarryforward from prior years.
+	ConsiderCapitalLoss       bool
+	OtherRealizedCapitalGains Money
+	CapitalLossCarryforward   Money
 }
 
 type EsppOrderSummary struct {
 	EsppOrder             *EsppOrder
-	EffectiveCostPerShare float64
-	TotalSellingPrice     float64
-	TotalCost             float64
-	EffectiveCommission   float64
-	NetResult             float64
-	CapitalGainTaxAmount  float64
+	BaseCostPerShare      Money
+	EffectiveCostPerShare Money
+	TotalSellingPrice     Money
+	TotalCost             Money
+	EffectiveCommission   Money
+	NetResult             Money
+	CapitalGainTaxAmount  Money
+
+	DispositionType           DispositionType
+	OrdinaryIncomePerShare    Money
+	OrdinaryIncomeAmount      Money
+	OrdinaryIncomeTaxAmount   Money
+	AdjustedCostBasisPerShare Money
+	CapitalGainAmount         Money
+
+	HoldingPeriod                  HoldingPeriod
+	LongTermDate                   time.Time
+	EffectiveCapitalGainTaxPercent float64
+
+	FederalCapitalGainTaxAmount    Money
+	StateCapitalGainTaxAmount      Money
+	FederalOrdinaryIncomeTaxAmount Money
+	StateOrdinaryIncomeTaxAmount   Money
+
+	NetInvestmentIncomeTaxAmount Money
+
+	// CapitalLossCarryforwardAmount is the capital loss left over for the next tax year.
+	CapitalLossCarryforwardAmount Money
 }
 
 func (e *EsppOrderSummary) IsProfitable() bool {
 	return e.TrueProfitOrLoss() > 0
 }
 
-func (e *EsppOrderSummary) ProfitOrLossAfterCapitalGainsTax() float64 {
-	return e.NetResult - e.CapitalGainTaxAmount
+// ProfitOrLossAfterCapitalGainsTax deducts the capital gain tax and the NIIT on the capital gain.
+func (e *EsppOrderSummary) ProfitOrLossAfterCapitalGainsTax() Money {
+	return e.NetResult - e.CapitalGainTaxAmount - e.NetInvestmentIncomeTaxAmount
 }
 
-func (e *EsppOrderSummary) TrueProfitOrLoss() float64 {
+func (e *EsppOrderSummary) TrueProfitOrLoss() Money {
 	trueProfitOrLoss := e.NetResult
 	if e.EsppOrder.ConsiderCapitalGainTax {
 		trueProfitOrLoss -= e.CapitalGainTaxAmount
 	}
+	if e.EsppOrder.ConsiderDisposition {
+		trueProfitOrLoss -= e.OrdinaryIncomeTaxAmount
+	}
+	if e.EsppOrder.ConsiderNetInvestmentIncomeTax {
+		trueProfitOrLoss -= e.NetInvestmentIncomeTaxAmount
+	}
 	return trueProfitOrLoss
 }
 
+// NetProceeds is the cash left from the sale after commission and taxes.
+func (e *EsppOrderSummary) NetProceeds() Money {
+	return e.TrueProfitOrLoss() + e.TotalCost
+}
+
+// SaleOutcome returns the outcome of the sale a SaleTarget aims for.
+func (e *EsppOrderSummary) SaleOutcome(saleTarget SaleTarget) Money {
+	if saleTarget == NetProceedsTarget {
+		return e.NetProceeds()
+	}
+	return e.TrueProfitOrLoss()
+}
+
 func (e *EsppOrderSummary) ProfitOrLossMargin() float64 {
 	effectiveProfit := e.TrueProfitOrLoss()
-	return (effectiveProfit / (e.TotalCost + e.EffectiveCommission + e.CapitalGainTaxAmount)) * 100
+	return effectiveProfit.Float64() / SumMoney(e.TotalCost, e.EffectiveCommission, e.CapitalGainTaxAmount, e.OrdinaryIncomeTaxAmount,
+		e.NetInvestmentIncomeTaxAmount).Float64() * 100
 }
 
 func (e *EsppOrderSummary) ToString() string {
 	var sb strings.Builder
 
 	sb.WriteString("ESPP Order Summary:\n")
-	sb.WriteString(fmt.Sprintf("  Effective Cost Per Share:      $%.2f\n", e.EffectiveCostPerShare))
-	sb.WriteString(fmt.Sprintf("  Total Selling Price:           $%.2f\n", e.TotalSellingPrice))
-	sb.WriteString(fmt.Sprintf("  Total Cost:                    $%.2f\n", e.TotalCost))
-	sb.WriteString(fmt.Sprintf("  Effective Commission:          $%.2f\n", e.EffectiveCommission))
-	sb.WriteString(fmt.Sprintf("  Net Result: 					$%.2f\n", e.NetResult))
-	sb.WriteString(fmt.Sprintf("  Capital Gain Tax Amount:       $%.2f\n", e.CapitalGainTaxAmount))
-	sb.WriteString(fmt.Sprintf("  Profit After Capital Gain Tax: $%.2f\n", e.ProfitOrLossAfterCapitalGainsTax()))
-	sb.WriteString(fmt.Sprintf("  Profit/Loss Margin: 			%.2f%%\n", e.ProfitOrLossMargin()))
-	sb.WriteString(fmt.Sprintf("  Net Result:                    $%.2f\n", e.NetResult))
+	sb.WriteString(fmt.Sprintf("  Base Cost Per Share:           $%s\n", e.BaseCostPerShare.StringPerShare()))
+	sb.WriteString(fmt.Sprintf("  Effective Cost Per Share:      $%s\n", e.EffectiveCostPerShare.StringPerShare()))
+	sb.WriteString(fmt.Sprintf("  Total Selling Price:           $%s\n", e.TotalSellingPrice))
+	sb.WriteString(fmt.Sprintf("  Total Cost:                    $%s\n", e.TotalCost))
+	sb.WriteString(fmt.Sprintf("  Effective Commission:          $%s\n", e.EffectiveCommission))
+	sb.WriteString(fmt.Sprintf("  Net Result:                    $%s\n", e.NetResult))
+	if e.EsppOrder.ConsiderDisposition {
+		sb.WriteString(fmt.Sprintf("  Disposition:                   %s\n", e.DispositionType))
+		sb.WriteString(fmt.Sprintf("  Ordinary Income Per Share:     $%s\n", e.OrdinaryIncomePerShare.StringPerShare()))
+		sb.WriteString(fmt.Sprintf("  Ordinary Income Amount:        $%s\n", e.OrdinaryIncomeAmount))
+		sb.WriteString(fmt.Sprintf("  Ordinary Income Tax Amount:    $%s\n", e.OrdinaryIncomeTaxAmount))
+		if e.EsppOrder.StateTaxModel != nil {
+			sb.WriteString(fmt.Sprintf("    Federal:                     $%s\n", e.FederalOrdinaryIncomeTaxAmount))
+			sb.WriteString(fmt.Sprintf("    State:                       $%s\n", e.StateOrdinaryIncomeTaxAmount))
+		}
+		sb.WriteString(fmt.Sprintf("  Adjusted Cost Basis Per Share: $%s\n", e.AdjustedCostBasisPerShare.StringPerShare()))
+	}
+	sb.WriteString(fmt.Sprintf("  Capital Gain Amount:           $%s\n", e.CapitalGainAmount))
+	if e.EsppOrder.ConsiderHoldingPeriod {
+		sb.WriteString(fmt.Sprintf("  Holding Period:                %s\n", e.HoldingPeriod))
+		sb.WriteString(fmt.Sprintf("  Long-Term From:                %s\n", e.LongTermDate.Format(DateLayout)))
+	}
+	sb.WriteString(fmt.Sprintf("  Capital Gain Tax Percent:      %.2f%%\n", e.EffectiveCapitalGainTaxPercent))
+	sb.WriteString(fmt.Sprintf("  Capital Gain Tax Amount:       $%s\n", e.CapitalGainTaxAmount))
+	if e.EsppOrder.StateTaxModel != nil {
+		sb.WriteString(fmt.Sprintf("    Federal:                     $%s\n", e.FederalCapitalGainTaxAmount))
+		sb.WriteString(fmt.Sprintf("    State:                       $%s\n", e.StateCapitalGainTaxAmount))
+	}
+	if e.EsppOrder.ConsiderNetInvestmentIncomeTax {
+		sb.WriteString(fmt.Sprintf("  Net Investment Income Tax:     $%s\n", e.NetInvestmentIncomeTaxAmount))
+	}
+	if e.EsppOrder.ConsiderCapitalLoss {
+		sb.WriteString(fmt.Sprintf("  Capital Loss Carryforward:     $%s\n", e.CapitalLossCarryforwardAmount))
+	}
+	sb.WriteString(fmt.Sprintf("  Profit After Capital Gain Tax: $%s\n", e.ProfitOrLossAfterCapitalGainsTax()))
+	sb.WriteString(fmt.Sprintf("  True Profit/Loss:              $%s\n", e.TrueProfitOrLoss()))
+	sb.WriteString(fmt.Sprintf("  Profit/Loss Margin:            %.2f%%\n", e.ProfitOrLossMargin()))
+	sb.WriteString(fmt.Sprintf("  Net Result:                    $%s\n", e.NetResult))
 	sb.WriteString(fmt.Sprintf("  Is Profitable:                 %t\n", e.IsProfitable()))
 
 	return sb.String()
@@ -95,110 +228,402 @@ func (e *EsppOrderSummary) ToString() string {
 // Clone creates a deep copy of the EsppOrder
 func (e *EsppOrder) Clone() *EsppOrder {
 	return &EsppOrder{
-		DiscountPercent:               e.DiscountPercent,
-		CostPerShare:                  e.CostPerShare,
-		SellingPricePerShare:          e.SellingPricePerShare,
-		NumberOfSharesSold:            e.NumberOfSharesSold,
-		ConsiderTransactionCommission: e.ConsiderTransactionCommission,
-		CommissionPaidPerTransaction:  e.CommissionPaidPerTransaction,
-		NumberOfTransactions:          e.NumberOfTransactions,
-		ConsiderCapitalGainTax:        e.ConsiderCapitalGainTax,
-		CapitalGainTaxPercent:         e.CapitalGainTaxPercent,
+		DiscountPercent:                 e.DiscountPercent,
+		CostPerShare:                    e.CostPerShare,
+		SellingPricePerShare:            e.SellingPricePerShare,
+		NumberOfSharesSold:              e.NumberOfSharesSold,
+		ConsiderLookBack:                e.ConsiderLookBack,
+		OfferingDateMarketValuePerShare: e.OfferingDateMarketValuePerShare,
+		PurchaseDateMarketValuePerShare: e.PurchaseDateMarketValuePerShare,
+		PurchasePricePerShare:           e.PurchasePricePerShare,
+		ConsiderTransactionCommission:   e.ConsiderTransactionCommission,
+		CommissionPaidPerTransaction:    e.CommissionPaidPerTransaction,
+		NumberOfTransactions:            e.NumberOfTransactions,
+		ConsiderCapitalGainTax:          e.ConsiderCapitalGainTax,
+		CapitalGainTaxPercent:           e.CapitalGainTaxPercent,
+		OfferingDate:                    e.OfferingDate,
+		PurchaseDate:                    e.PurchaseDate,
+		SaleDate:                        e.SaleDate,
+		ConsiderDisposition:             e.ConsiderDisposition,
+		OrdinaryIncomeTaxPercent:        e.OrdinaryIncomeTaxPercent,
+		ConsiderHoldingPeriod:           e.ConsiderHoldingPeriod,
+		ShortTermCapitalGainTaxPercent:  e.ShortTermCapitalGainTaxPercent,
+		LongTermCapitalGainTaxPercent:   e.LongTermCapitalGainTaxPercent,
+		TaxModel:                        e.TaxModel,
+		TaxProfile:                      e.TaxProfile,
+		StateTaxModel:                   e.StateTaxModel,
+		ConsiderNetInvestmentIncomeTax:  e.ConsiderNetInvestmentIncomeTax,
+		ModifiedAdjustedGrossIncome:     e.ModifiedAdjustedGrossIncome,
+		ConsiderCapitalLoss:             e.ConsiderCapitalLoss,
+		OtherRealizedCapitalGains:       e.OtherRealizedCapitalGains,
+		CapitalLossCarryforward:         e.CapitalLossCarryforward,
+	}
+}
+
+// CalculateLookBackCostPerShare returns the lower of the offering-date and purchase-date market values.
+func (e *EsppOrder) CalculateLookBackCostPerShare() Money {
+	return MinMoney(e.OfferingDateMarketValuePerShare, e.PurchaseDateMarketValuePerShare)
+}
+
+// CalculateBaseCostPerShare returns the cost per share before the plan discount is applied.
+func (e *EsppOrder) CalculateBaseCostPerShare() Money {
+	if e.ConsiderLookBack {
+		return e.CalculateLookBackCostPerShare()
 	}
+	return e.CostPerShare
 }
 
-func (e *EsppOrder) CalculateDiscountAmount() float64 {
-	return (e.CostPerShare * e.DiscountPercent) / 100
+func (e *EsppOrder) CalculateDiscountAmount() Money {
+	if e.PurchasePricePerShare > 0 {
+		return e.CalculateBaseCostPerShare() - e.PurchasePricePerShare
+	}
+	return e.CalculateBaseCostPerShare().Percent(e.DiscountPercent)
 }
 
-func (e *EsppOrder) CalculateEffectiveCostPerShare() float64 {
+func (e *EsppOrder) CalculateEffectiveCostPerShare() Money {
+	if e.PurchasePricePerShare > 0 {
+		return e.PurchasePricePerShare
+	}
 	discountAmount := e.CalculateDiscountAmount()
-	return e.CostPerShare - discountAmount
+	return e.CalculateBaseCostPerShare() - discountAmount
 }
 
-func (e *EsppOrder) CalculateProfitOrLoss() float64 {
-	effectiveCostPerShare := e.CalculateEffectiveCostPerShare()
-	var effectiveTransactionCommission float64
-	effectiveTransactionCommission = 0
-	if e.ConsiderTransactionCommission {
-		effectiveTransactionCommission = float64(e.NumberOfTransactions) * e.CommissionPaidPerTransaction
+// CalculateEffectiveCommission returns the commission paid on the sale, if considered.
+func (e *EsppOrder) CalculateEffectiveCommission() Money {
+	if !e.ConsiderTransactionCommission {
+		return 0
 	}
-	return (float64(e.NumberOfSharesSold) * (e.SellingPricePerShare - effectiveCostPerShare)) - (effectiveTransactionCommission)
+	return e.CommissionPaidPerTransaction.MulInt(e.NumberOfTransactions).RoundToCents()
+}
+
+// CalculateProfitOrLoss returns the proceeds less the cost and commission, each total rounded to the cent.
+func (e *EsppOrder) CalculateProfitOrLoss() Money {
+	totalSellingPrice := e.SellingPricePerShare.MulShares(e.NumberOfSharesSold).RoundToCents()
+	totalCost := e.CalculateEffectiveCostPerShare().MulShares(e.NumberOfSharesSold).RoundToCents()
+	return totalSellingPrice - totalCost - e.CalculateEffectiveCommission()
 }
 
-func (e *EsppOrder) CalculateCapitalGainTaxAmount(profit float64) (float64, error) {
+// CalculateCapitalGainTaxAmount returns the tax on the profit, or the tax saved by a loss as a negative amount
+// when ConsiderCapitalLoss is set.
+func (e *EsppOrder) CalculateCapitalGainTaxAmount(profit Money) (Money, error) {
 	if profit < 0 {
-		return 0, fmt.Errorf("profit must be greater than or equal to zero")
+		if !e.ConsiderCapitalLoss {
+			return 0, nil
+		}
+		federalCapitalLossTaxBenefit, stateCapitalLossTaxBenefit, err := e.CalculateCapitalLossTaxBenefit(-profit)
+		if err != nil {
+			return 0, err
+		}
+		return -(federalCapitalLossTaxBenefit + stateCapitalLossTaxBenefit), nil
+	}
+
+	federalCapitalGainTaxAmount, err := e.CalculateFederalCapitalGainTaxAmount(profit)
+	if err != nil {
+		return 0, err
+	}
+	stateCapitalGainTaxAmount, err := e.CalculateStateCapitalGainTaxAmount(profit)
+	if err != nil {
+		return 0, err
+	}
+	return federalCapitalGainTaxAmount + stateCapitalGainTaxAmount, nil
+}
+
+// CalculateCapitalLossTaxBenefit returns the federal and state tax saved by a capital loss (a positive amount).
+func (e *EsppOrder) CalculateCapitalLossTaxBenefit(loss Money) (Money, Money, error) {
+	federalCapitalLossTaxBenefit, err := calculateCapitalLossTaxBenefit(e.TaxProfile.FilingStatus, loss, e.OtherRealizedCapitalGains,
+		e.CapitalLossCarryforward, e.CalculateFederalCapitalGainTaxAmount, func(ordinaryIncome Money) (Money, error) {
+			if e.TaxModel != nil {
+				return calculateOffsetOrdinaryIncomeTax(e.TaxModel, e.TaxProfile, ordinaryIncome)
+			}
+			return ordinaryIncome.Percent(e.OrdinaryIncomeTaxPercent).RoundToCents(), nil
+		})
+	if err != nil {
+		return 0, 0, err
+	}
+	stateCapitalLossTaxBenefit, err := calculateCapitalLossTaxBenefit(e.TaxProfile.FilingStatus, loss, e.OtherRealizedCapitalGains,
+		e.CapitalLossCarryforward, e.CalculateStateCapitalGainTaxAmount, func(ordinaryIncome Money) (Money, error) {
+			if e.StateTaxModel == nil {
+				return 0, nil
+			}
+			return calculateOffsetOrdinaryIncomeTax(e.StateTaxModel, e.TaxProfile, ordinaryIncome)
+		})
+	if err != nil {
+		return 0, 0, err
 	}
+	return federalCapitalLossTaxBenefit, stateCapitalLossTaxBenefit, nil
+}
 
-	return profit * e.CapitalGainTaxPercent / 100, nil
+// CalculateFederalCapitalGainTaxAmount returns the capital gain tax from the TaxModel, or the flat percentages when not set.
+func (e *EsppOrder) CalculateFederalCapitalGainTaxAmount(profit Money) (Money, error) {
+	if e.TaxModel != nil {
+		return calculateTax(e.TaxModel, e.calculateCapitalGainTaxProfile(), e.CalculateCapitalGainIncomeType(), profit)
+	}
+	return profit.Percent(e.CalculateEffectiveCapitalGainTaxPercent()).RoundToCents(), nil
 }
 
-func (e *EsppOrder) CalculateEsppOrderSummary() *EsppOrderSummary {
+// CalculateStateCapitalGainTaxAmount returns the capital gain tax from the StateTaxModel, if set.
+func (e *EsppOrder) CalculateStateCapitalGainTaxAmount(profit Money) (Money, error) {
+	if e.StateTaxModel == nil {
+		return 0, nil
+	}
+	return calculateTax(e.StateTaxModel, e.calculateCapitalGainTaxProfile(), e.CalculateCapitalGainIncomeType(), profit)
+}
+
+// CalculateCapitalGainIncomeType returns how the capital gain is taxed by the TaxModel.
+// Gains are treated as short-term (ordinary income) unless the holding period is considered and long-term.
+func (e *EsppOrder) CalculateCapitalGainIncomeType() tax.IncomeType {
+	if e.ConsiderHoldingPeriod && e.CalculateHoldingPeriod() == LongTerm {
+		return tax.LongTermCapitalGain
+	}
+	return tax.OrdinaryIncome
+}
+
+// calculateCapitalGainTaxProfile stacks the ordinary income of the disposition below the capital gain.
+func (e *EsppOrder) calculateCapitalGainTaxProfile() tax.Profile {
+	profile := e.TaxProfile
+	if e.ConsiderDisposition {
+		profile.OtherIncome += e.CalculateOrdinaryIncomeAmount().Float64()
+	}
+	return profile
+}
+
+// CalculateNetInvestmentIncomeTaxAmount returns the NIIT on the capital gain.
+// The ordinary income of the disposition counts towards the MAGI but is not investment income.
+func (e *EsppOrder) CalculateNetInvestmentIncomeTaxAmount(capitalGain Money) Money {
+	if !e.ConsiderNetInvestmentIncomeTax {
+		return 0
+	}
+	modifiedAdjustedGrossIncome := e.ModifiedAdjustedGrossIncome
+	if e.ConsiderDisposition {
+		modifiedAdjustedGrossIncome += e.CalculateOrdinaryIncomeAmount()
+	}
+	return calculateNetInvestmentIncomeTax(e.TaxProfile.FilingStatus, modifiedAdjustedGrossIncome, capitalGain)
+}
+
+// CalculateHoldingPeriod classifies the sale as short-term or long-term from the purchase and sale dates.
+func (e *EsppOrder) CalculateHoldingPeriod() HoldingPeriod {
+	return CalculateHoldingPeriod(e.PurchaseDate, e.SaleDate)
+}
+
+// CalculateEffectiveCapitalGainTaxPercent returns the capital gain tax rate applicable to the sale.
+func (e *EsppOrder) CalculateEffectiveCapitalGainTaxPercent() float64 {
+	if !e.ConsiderHoldingPeriod {
+		return e.CapitalGainTaxPercent
+	}
+	if e.CalculateHoldingPeriod() == LongTerm {
+		return e.LongTermCapitalGainTaxPercent
+	}
+	return e.ShortTermCapitalGainTaxPercent
+}
+
+// CalculateDispositionType classifies the sale as qualifying when it happens more than 2 years after the
+// offering date and more than 1 year after the purchase date. As for the holding period, the anniversary of
+// Feb 29 is Feb 28.
+func (e *EsppOrder) CalculateDispositionType() DispositionType {
+	if e.SaleDate.After(addMonths(e.OfferingDate, 24)) && !e.SaleDate.Before(CalculateLongTermDate(e.PurchaseDate)) {
+		return Qualifying
+	}
+	return Disqualifying
+}
+
+// CalculateOrdinaryIncomePerShare returns the discount element taxed as ordinary income.
+// For a disqualifying disposition it is the purchase-date market value less the purchase price.
+// For a qualifying disposition it is the lesser of the actual gain and the discount on the offering-date market value.
+func (e *EsppOrder) CalculateOrdinaryIncomePerShare() Money {
 	effectiveCostPerShare := e.CalculateEffectiveCostPerShare()
-	totalSellingPrice := e.SellingPricePerShare * float64(e.NumberOfSharesSold)
-	totalCost := effectiveCostPerShare * float64(e.NumberOfSharesSold)
+	if e.CalculateDispositionType() == Disqualifying {
+		return MaxMoney(e.PurchaseDateMarketValuePerShare-effectiveCostPerShare, 0)
+	}
+	actualGain := MaxMoney(e.SellingPricePerShare-effectiveCostPerShare, 0)
+	offeringDateDiscount := e.OfferingDateMarketValuePerShare.Percent(e.DiscountPercent)
+	return MinMoney(actualGain, offeringDateDiscount)
+}
+
+// CalculateOrdinaryIncomeAmount returns the ordinary income of the shares sold, rounded to the cent.
+func (e *EsppOrder) CalculateOrdinaryIncomeAmount() Money {
+	return e.CalculateOrdinaryIncomePerShare().MulShares(e.NumberOfSharesSold).RoundToCents()
+}
 
-	var effectiveTransactionCommission float64
-	if e.ConsiderTransactionCommission {
-		effectiveTransactionCommission = float64(e.NumberOfTransactions) * e.CommissionPaidPerTransaction
+// CalculateAdjustedCostBasisPerShare returns the capital-gain basis: the purchase price plus the ordinary income.
+func (e *EsppOrder) CalculateAdjustedCostBasisPerShare() Money {
+	if !e.ConsiderDisposition {
+		return e.CalculateEffectiveCostPerShare()
+	}
+	return e.CalculateEffectiveCostPerShare() + e.CalculateOrdinaryIncomePerShare()
+}
+
+func (e *EsppOrder) CalculateOrdinaryIncomeTaxAmount(ordinaryIncome Money) (Money, error) {
+	federalOrdinaryIncomeTaxAmount, err := e.CalculateFederalOrdinaryIncomeTaxAmount(ordinaryIncome)
+	if err != nil {
+		return 0, err
+	}
+	stateOrdinaryIncomeTaxAmount, err := e.CalculateStateOrdinaryIncomeTaxAmount(ordinaryIncome)
+	if err != nil {
+		return 0, err
 	}
+	return federalOrdinaryIncomeTaxAmount + stateOrdinaryIncomeTaxAmount, nil
+}
+
+// CalculateFederalOrdinaryIncomeTaxAmount returns the ordinary income tax from the TaxModel, or OrdinaryIncomeTaxPercent when not set.
+func (e *EsppOrder) CalculateFederalOrdinaryIncomeTaxAmount(ordinaryIncome Money) (Money, error) {
+	if e.TaxModel != nil {
+		return calculateTax(e.TaxModel, e.TaxProfile, tax.OrdinaryIncome, ordinaryIncome)
+	}
+	return ordinaryIncome.Percent(e.OrdinaryIncomeTaxPercent).RoundToCents(), nil
+}
+
+// CalculateStateOrdinaryIncomeTaxAmount returns the ordinary income tax from the StateTaxModel, if set.
+func (e *EsppOrder) CalculateStateOrdinaryIncomeTaxAmount(ordinaryIncome Money) (Money, error) {
+	if e.StateTaxModel == nil {
+		return 0, nil
+	}
+	return calculateTax(e.StateTaxModel, e.TaxProfile, tax.OrdinaryIncome, ordinaryIncome)
+}
+
+func (e *EsppOrder) CalculateEsppOrderSummary() (*EsppOrderSummary, error) {
+	effectiveCostPerShare := e.CalculateEffectiveCostPerShare()
+	totalSellingPrice := e.SellingPricePerShare.MulShares(e.NumberOfSharesSold).RoundToCents()
+	totalCost := effectiveCostPerShare.MulShares(e.NumberOfSharesSold).RoundToCents()
+	effectiveTransactionCommission := e.CalculateEffectiveCommission()
 	netResult := e.CalculateProfitOrLoss()
-	var capitalGainTaxAmount float64
-	if netResult > 0 {
-		capitalGainTaxAmount, _ = e.CalculateCapitalGainTaxAmount(netResult)
+
+	esppOrderSummary := &EsppOrderSummary{
+		EsppOrder:                 e,
+		BaseCostPerShare:          e.CalculateBaseCostPerShare(),
+		EffectiveCostPerShare:     effectiveCostPerShare,
+		TotalSellingPrice:         totalSellingPrice,
+		TotalCost:                 totalCost,
+		EffectiveCommission:       effectiveTransactionCommission,
+		NetResult:                 netResult,
+		AdjustedCostBasisPerShare: effectiveCostPerShare,
+		CapitalGainAmount:         netResult,
+	}
+
+	if e.ConsiderDisposition {
+		esppOrderSummary.DispositionType = e.CalculateDispositionType()
+		esppOrderSummary.OrdinaryIncomePerShare = e.CalculateOrdinaryIncomePerShare()
+		esppOrderSummary.OrdinaryIncomeAmount = e.CalculateOrdinaryIncomeAmount()
+		federalOrdinaryIncomeTaxAmount, err := e.CalculateFederalOrdinaryIncomeTaxAmount(esppOrderSummary.OrdinaryIncomeAmount)
+		if err != nil {
+			return nil, err
+		}
+		stateOrdinaryIncomeTaxAmount, err := e.CalculateStateOrdinaryIncomeTaxAmount(esppOrderSummary.OrdinaryIncomeAmount)
+		if err != nil {
+			return nil, err
+		}
+		esppOrderSummary.FederalOrdinaryIncomeTaxAmount = federalOrdinaryIncomeTaxAmount
+		esppOrderSummary.StateOrdinaryIncomeTaxAmount = stateOrdinaryIncomeTaxAmount
+		esppOrderSummary.OrdinaryIncomeTaxAmount = federalOrdinaryIncomeTaxAmount + stateOrdinaryIncomeTaxAmount
+		esppOrderSummary.AdjustedCostBasisPerShare = e.CalculateAdjustedCostBasisPerShare()
+		esppOrderSummary.CapitalGainAmount = netResult - esppOrderSummary.OrdinaryIncomeAmount
 	}
-	return &EsppOrderSummary{
-		EsppOrder:             e,
-		EffectiveCostPerShare: effectiveCostPerShare,
-		TotalSellingPrice:     totalSellingPrice,
-		TotalCost:             totalCost,
-		EffectiveCommission:   effectiveTransactionCommission,
-		NetResult:             netResult,
-		CapitalGainTaxAmount:  capitalGainTaxAmount,
+
+	esppOrderSummary.EffectiveCapitalGainTaxPercent = e.CalculateEffectiveCapitalGainTaxPercent()
+	if e.ConsiderHoldingPeriod {
+		esppOrderSummary.HoldingPeriod = e.CalculateHoldingPeriod()
+		esppOrderSummary.LongTermDate = CalculateLongTermDate(e.PurchaseDate)
 	}
+
+	if esppOrderSummary.CapitalGainAmount > 0 {
+		federalCapitalGainTaxAmount, err := e.CalculateFederalCapitalGainTaxAmount(esppOrderSummary.CapitalGainAmount)
+		if err != nil {
+			return nil, err
+		}
+		stateCapitalGainTaxAmount, err := e.CalculateStateCapitalGainTaxAmount(esppOrderSummary.CapitalGainAmount)
+		if err != nil {
+			return nil, err
+		}
+		esppOrderSummary.FederalCapitalGainTaxAmount = federalCapitalGainTaxAmount
+		esppOrderSummary.StateCapitalGainTaxAmount = stateCapitalGainTaxAmount
+		esppOrderSummary.CapitalGainTaxAmount = federalCapitalGainTaxAmount + stateCapitalGainTaxAmount
+		if e.TaxModel != nil || e.StateTaxModel != nil {
+			esppOrderSummary.EffectiveCapitalGainTaxPercent = esppOrderSummary.CapitalGainTaxAmount.Ratio(esppOrderSummary.CapitalGainAmount) * 100
+		}
+		esppOrderSummary.NetInvestmentIncomeTaxAmount = e.CalculateNetInvestmentIncomeTaxAmount(esppOrderSummary.CapitalGainAmount)
+	} else if esppOrderSummary.CapitalGainAmount < 0 && e.ConsiderCapitalLoss {
+		capitalLoss := -esppOrderSummary.CapitalGainAmount
+		federalCapitalLossTaxBenefit, stateCapitalLossTaxBenefit, err := e.CalculateCapitalLossTaxBenefit(capitalLoss)
+		if err != nil {
+			return nil, err
+		}
+		esppOrderSummary.FederalCapitalGainTaxAmount = -federalCapitalLossTaxBenefit
+		esppOrderSummary.StateCapitalGainTaxAmount = -stateCapitalLossTaxBenefit
+		esppOrderSummary.CapitalGainTaxAmount = -(federalCapitalLossTaxBenefit + stateCapitalLossTaxBenefit)
+		esppOrderSummary.CapitalLossCarryforwardAmount = applyCapitalLoss(e.TaxProfile.FilingStatus, capitalLoss,
+			e.OtherRealizedCapitalGains, e.CapitalLossCarryforward).Carryforward
+	}
+	return esppOrderSummary, nil
 }
 
 // CalculateBreakEvenSellingPrice calculates the selling price required to break even.
-func (e *EsppOrder) CalculateBreakEvenSellingPrice() float64 {
+func (e *EsppOrder) CalculateBreakEvenSellingPrice() Money {
 	breakEvenSellingPrice, _ := e.CalculateSellingPriceForTargetProfitPercent(0)
 	return breakEvenSellingPrice
 }
 
 // CalculateSellingPriceForTargetProfitPercent calculates the selling price required to achieve a target profit percentage.
-func (e *EsppOrder) CalculateSellingPriceForTargetProfitPercent(targetProfitPercent float64) (float64, error) {
+func (e *EsppOrder) CalculateSellingPriceForTargetProfitPercent(targetProfitPercent float64) (Money, error) {
+	solverResult, err := e.SolveSellingPriceForTargetProfitPercent(targetProfitPercent)
+	if err != nil {
+		return 0, err
+	}
+	return solverResult.Value, nil
+}
+
+// SolveSellingPriceForTargetProfitPercent finds the lowest selling price whose profit/loss margin (the true profit
+// over the cost, commission and taxes) reaches the target, returning a NoSolutionError when no price does.
+func (e *EsppOrder) SolveSellingPriceForTargetProfitPercent(targetProfitPercent float64) (*SolverResult[Money], error) {
 	if targetProfitPercent < 0 {
-		return 0, fmt.Errorf("target profit percent must be greater than or equal to 0")
+		return nil, fmt.Errorf("target profit percent must be greater than or equal to 0")
 	}
+	return e.solveSellingPrice(func(esppOrderSummary *EsppOrderSummary) Money {
+		totalCost := SumMoney(esppOrderSummary.TotalCost, esppOrderSummary.EffectiveCommission, esppOrderSummary.CapitalGainTaxAmount,
+			esppOrderSummary.OrdinaryIncomeTaxAmount, esppOrderSummary.NetInvestmentIncomeTaxAmount)
+		return esppOrderSummary.TrueProfitOrLoss() - totalCost.Percent(targetProfitPercent).RoundToCents()
+	})
+}
 
+// SolveSellingPriceForTarget finds the lowest selling price whose true profit or net cash reaches the target amount,
+// returning a NoSolutionError when no price does.
+func (e *EsppOrder) SolveSellingPriceForTarget(saleTarget SaleTarget, targetAmount Money) (*SolverResult[Money], error) {
+	return e.solveSellingPrice(func(esppOrderSummary *EsppOrderSummary) Money {
+		return esppOrderSummary.SaleOutcome(saleTarget) - targetAmount
+	})
+}
+
+// solveSellingPrice finds the lowest selling price per share for which the residual of the order summary is zero or more.
+func (e *EsppOrder) solveSellingPrice(residual func(*EsppOrderSummary) Money) (*SolverResult[Money], error) {
 	if e.NumberOfSharesSold <= 0 {
-		return 0, fmt.Errorf("number of shares sold must be greater than zero")
+		return nil, fmt.Errorf("number of shares sold must be greater than zero")
 	}
-
-	effectiveCostPerShare := e.CalculateEffectiveCostPerShare()
-	effectiveCost := effectiveCostPerShare * float64(e.NumberOfSharesSold)
-
-	// Initial guess for selling price
-	sellingPrice := effectiveCost + (targetProfitPercent/100)*effectiveCost
-	for i := 0; i < 100; i++ { // Limit iterations to avoid infinite loops
-		profitBeforeTax := (sellingPrice - effectiveCostPerShare) * float64(e.NumberOfSharesSold)
-		if e.ConsiderTransactionCommission {
-			profitBeforeTax -= float64(e.NumberOfTransactions) * e.CommissionPaidPerTransaction
+	esppOrder := e.Clone()
+	return solveBracketed(func(sellingPrice Money) (Money, error) {
+		esppOrder.SellingPricePerShare = sellingPrice
+		esppOrderSummary, err := esppOrder.CalculateEsppOrderSummary()
+		if err != nil {
+			return 0, err
 		}
-		capitalGainsTax := 0.0
-		if e.ConsiderCapitalGainTax {
-			capitalGainsTax = profitBeforeTax * (e.CapitalGainTaxPercent / 100)
-		}
-		profitAfterTax := profitBeforeTax - capitalGainsTax
-
-		// Calculate the target profit after tax
-		targetProfitAfterTax := (targetProfitPercent / 100) * (effectiveCost + capitalGainsTax)
+		return residual(esppOrderSummary), nil
+	}, max(e.CalculateEffectiveCostPerShare(), NewMoney(1)), Money(1), maxSellingPricePerShare)
+}
 
-		if math.Abs(profitAfterTax-targetProfitAfterTax) < 0.01 { // Convergence condition
-			break
-		}
-		sellingPrice += (targetProfitAfterTax - profitAfterTax) / float64(e.NumberOfSharesSold) // Adjust selling price
+// SolveSharesSoldForTarget finds the fewest shares to sell at SellingPricePerShare whose true profit or net cash
+// reaches the target amount, returning a NoSolutionError when no number of shares does.
+// Whole shares are sold unless fractionalShares, and the commission per transaction is paid however few shares are sold.
+func (e *EsppOrder) SolveSharesSoldForTarget(saleTarget SaleTarget, targetAmount Money, fractionalShares bool) (*SolverResult[Shares], error) {
+	if e.SellingPricePerShare <= 0 {
+		return nil, fmt.Errorf("selling price per share must be greater than zero")
 	}
-
-	return sellingPrice, nil
+	if targetAmount <= 0 {
+		return nil, fmt.Errorf("target amount must be greater than zero")
+	}
+	esppOrder := e.Clone()
+	return solveBracketed(func(sharesSold Shares) (Money, error) {
+		esppOrder.NumberOfSharesSold = sharesSold
+		esppOrderSummary, err := esppOrder.CalculateEsppOrderSummary()
+		if err != nil {
+			return 0, err
+		}
+		return esppOrderSummary.SaleOutcome(saleTarget) - targetAmount, nil
+	}, NewShares(targetAmount.Ratio(e.SellingPricePerShare)), shareStep(fractionalShares), maxSharesSold)
 }
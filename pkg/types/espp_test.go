@@ -25,16 +25,18 @@ package types
 
 import (
 	"fmt"
+	"github.com/leogps/lunar/pkg/tax"
 	"testing"
+	"time"
 )
 
 func TestEsppOrder_CalculateSellingPriceForTargetProfitPercent(t *testing.T) {
 	esppOrder := &EsppOrder{
 		DiscountPercent:               15,
-		CostPerShare:                  100,
-		NumberOfSharesSold:            1,
+		CostPerShare:                  NewMoney(100),
+		NumberOfSharesSold:            NewShares(1),
 		ConsiderTransactionCommission: true,
-		CommissionPaidPerTransaction:  5,
+		CommissionPaidPerTransaction:  NewMoney(5),
 		NumberOfTransactions:          1,
 		ConsiderCapitalGainTax:        true,
 		CapitalGainTaxPercent:         24,
@@ -47,10 +49,216 @@ func TestEsppOrder_CalculateSellingPriceForTargetProfitPercent(t *testing.T) {
 
 	esppClone := esppOrder.Clone()
 	esppClone.SellingPricePerShare = breakEvenSellingPrice
-	summary := esppClone.CalculateEsppOrderSummary()
-	fmt.Println(fmt.Sprintf("Break-Even selling price: $%.2f", breakEvenSellingPrice))
+	summary, err := esppClone.CalculateEsppOrderSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(fmt.Sprintf("Break-Even selling price: $%s", breakEvenSellingPrice))
 	fmt.Println(summary.ToString())
-	if summary.CapitalGainTaxAmount != 0 {
+	if summary.CapitalGainTaxAmount != NewMoney(0) {
 		t.Fail()
 	}
 }
+
+func TestEsppOrder_CalculateEffectiveCostPerShareWithLookBack(t *testing.T) {
+	esppOrder := &EsppOrder{
+		DiscountPercent:                 15,
+		ConsiderLookBack:                true,
+		OfferingDateMarketValuePerShare: NewMoney(80),
+		PurchaseDateMarketValuePerShare: NewMoney(120),
+	}
+
+	if esppOrder.CalculateBaseCostPerShare() != NewMoney(80) {
+		t.Errorf("expected base cost per share of $80.00, got $%s", esppOrder.CalculateBaseCostPerShare())
+	}
+	if effectiveCostPerShare := esppOrder.CalculateEffectiveCostPerShare(); effectiveCostPerShare != NewMoney(68) {
+		t.Errorf("expected effective cost per share of $68.00, got $%s", effectiveCostPerShare)
+	}
+
+	esppOrder.PurchaseDateMarketValuePerShare = NewMoney(60)
+	if effectiveCostPerShare := esppOrder.CalculateEffectiveCostPerShare(); effectiveCostPerShare != NewMoney(51) {
+		t.Errorf("expected effective cost per share of $51.00, got $%s", effectiveCostPerShare)
+	}
+}
+
+func TestEsppOrder_CalculateEsppOrderSummaryWithDisposition(t *testing.T) {
+	esppOrder := &EsppOrder{
+		DiscountPercent:                 15,
+		ConsiderLookBack:                true,
+		OfferingDateMarketValuePerShare: NewMoney(100),
+		PurchaseDateMarketValuePerShare: NewMoney(120),
+		SellingPricePerShare:            NewMoney(150),
+		NumberOfSharesSold:              NewShares(10),
+		ConsiderDisposition:             true,
+		OfferingDate:                    time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC),
+		PurchaseDate:                    time.Date(2022, time.June, 30, 0, 0, 0, 0, time.UTC),
+		SaleDate:                        time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC),
+		OrdinaryIncomeTaxPercent:        32,
+		ConsiderCapitalGainTax:          true,
+		CapitalGainTaxPercent:           15,
+	}
+
+	// Disqualifying: ordinary income is purchase-date FMV less purchase price ($120 - $85).
+	summary, err := esppOrder.CalculateEsppOrderSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(summary.ToString())
+	if summary.DispositionType != Disqualifying {
+		t.Errorf("expected disqualifying disposition, got %s", summary.DispositionType)
+	}
+	if summary.OrdinaryIncomeAmount != NewMoney(350) {
+		t.Errorf("expected ordinary income of $350.00, got $%s", summary.OrdinaryIncomeAmount)
+	}
+	if summary.AdjustedCostBasisPerShare != NewMoney(120) {
+		t.Errorf("expected adjusted cost basis of $120.00, got $%s", summary.AdjustedCostBasisPerShare)
+	}
+	if summary.CapitalGainAmount != NewMoney(300) {
+		t.Errorf("expected capital gain of $300.00, got $%s", summary.CapitalGainAmount)
+	}
+
+	// Qualifying: ordinary income is the lesser of the actual gain and the offering-date discount ($15).
+	esppOrder.SaleDate = time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	summary, err = esppOrder.CalculateEsppOrderSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(summary.ToString())
+	if summary.DispositionType != Qualifying {
+		t.Errorf("expected qualifying disposition, got %s", summary.DispositionType)
+	}
+	if summary.OrdinaryIncomeAmount != NewMoney(150) {
+		t.Errorf("expected ordinary income of $150.00, got $%s", summary.OrdinaryIncomeAmount)
+	}
+	if summary.CapitalGainAmount != NewMoney(500) {
+		t.Errorf("expected capital gain of $500.00, got $%s", summary.CapitalGainAmount)
+	}
+}
+
+func TestEsppOrder_CalculateDispositionType_LeapDay(t *testing.T) {
+	esppOrder := &EsppOrder{
+		OfferingDate: time.Date(2022, time.August, 31, 0, 0, 0, 0, time.UTC),
+		PurchaseDate: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+		SaleDate:     time.Date(2025, time.February, 28, 0, 0, 0, 0, time.UTC),
+	}
+	if esppOrder.CalculateDispositionType() != Disqualifying || esppOrder.CalculateHoldingPeriod() != ShortTerm {
+		t.Errorf("expected a disqualifying short-term sale on the anniversary, got %s and %s",
+			esppOrder.CalculateDispositionType(), esppOrder.CalculateHoldingPeriod())
+	}
+
+	// The sale goes long-term and qualifying on the same day
+	esppOrder.SaleDate = time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	if esppOrder.CalculateDispositionType() != Qualifying || esppOrder.CalculateHoldingPeriod() != LongTerm {
+		t.Errorf("expected a qualifying long-term sale on Mar 1, got %s and %s",
+			esppOrder.CalculateDispositionType(), esppOrder.CalculateHoldingPeriod())
+	}
+
+	esppOrder.OfferingDate = time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)
+	esppOrder.PurchaseDate = time.Date(2024, time.August, 30, 0, 0, 0, 0, time.UTC)
+	esppOrder.SaleDate = time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	if esppOrder.CalculateDispositionType() != Qualifying {
+		t.Errorf("expected a qualifying sale on Mar 1 two years after a Feb 29 offering, got %s",
+			esppOrder.CalculateDispositionType())
+	}
+}
+
+func TestEsppOrder_CalculateEsppOrderSummaryWithStateTax(t *testing.T) {
+	esppOrder := &EsppOrder{
+		DiscountPercent:                 15,
+		ConsiderLookBack:                true,
+		OfferingDateMarketValuePerShare: NewMoney(100),
+		PurchaseDateMarketValuePerShare: NewMoney(120),
+		SellingPricePerShare:            NewMoney(150),
+		NumberOfSharesSold:              NewShares(10),
+		ConsiderDisposition:             true,
+		OfferingDate:                    time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC),
+		PurchaseDate:                    time.Date(2023, time.June, 30, 0, 0, 0, 0, time.UTC),
+		SaleDate:                        time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+		OrdinaryIncomeTaxPercent:        32,
+		ConsiderCapitalGainTax:          true,
+		CapitalGainTaxPercent:           15,
+		StateTaxModel:                   tax.California,
+		TaxProfile: tax.Profile{
+			FilingStatus: tax.Single,
+			TaxYear:      2024,
+			OtherIncome:  100000,
+		},
+	}
+
+	summary, err := esppOrder.CalculateEsppOrderSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(summary.ToString())
+
+	// Federal stays on the flat percentages while California taxes both amounts as ordinary income.
+	brackets, _ := tax.CaliforniaBrackets.Brackets(2024, tax.Single)
+	expectedStateOrdinaryIncomeTax := tax.CalculateIncrementalTax(brackets, 100000, 350)
+	expectedStateCapitalGainTax := tax.CalculateIncrementalTax(brackets, 100350, 300)
+	if summary.FederalOrdinaryIncomeTaxAmount != NewMoney(112) {
+		t.Errorf("expected federal ordinary income tax of $112.00, got $%s", summary.FederalOrdinaryIncomeTaxAmount)
+	}
+	if summary.StateOrdinaryIncomeTaxAmount != NewMoney(expectedStateOrdinaryIncomeTax).RoundToCents() {
+		t.Errorf("expected state ordinary income tax of $%.2f, got $%s",
+			expectedStateOrdinaryIncomeTax, summary.StateOrdinaryIncomeTaxAmount)
+	}
+	if summary.FederalCapitalGainTaxAmount != NewMoney(45) {
+		t.Errorf("expected federal capital gain tax of $45.00, got $%s", summary.FederalCapitalGainTaxAmount)
+	}
+	if summary.StateCapitalGainTaxAmount != NewMoney(expectedStateCapitalGainTax).RoundToCents() {
+		t.Errorf("expected state capital gain tax of $%.2f, got $%s",
+			expectedStateCapitalGainTax, summary.StateCapitalGainTaxAmount)
+	}
+	if summary.CapitalGainTaxAmount != NewMoney(45+expectedStateCapitalGainTax).RoundToCents() {
+		t.Errorf("expected capital gain tax to be the sum of federal and state, got $%s", summary.CapitalGainTaxAmount)
+	}
+}
+
+func TestEsppOrder_CalculateEsppOrderSummaryWithCapitalLoss(t *testing.T) {
+	esppOrder := &EsppOrder{
+		DiscountPercent:                 15,
+		ConsiderLookBack:                true,
+		OfferingDateMarketValuePerShare: NewMoney(100),
+		PurchaseDateMarketValuePerShare: NewMoney(120),
+		SellingPricePerShare:            NewMoney(100),
+		NumberOfSharesSold:              NewShares(10),
+		ConsiderDisposition:             true,
+		OfferingDate:                    time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC),
+		PurchaseDate:                    time.Date(2023, time.June, 30, 0, 0, 0, 0, time.UTC),
+		SaleDate:                        time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+		OrdinaryIncomeTaxPercent:        32,
+		ConsiderCapitalGainTax:          true,
+		CapitalGainTaxPercent:           15,
+		StateTaxModel:                   tax.California,
+		TaxProfile: tax.Profile{
+			FilingStatus: tax.Single,
+			TaxYear:      2024,
+			OtherIncome:  100000,
+		},
+		ConsiderCapitalLoss: true,
+	}
+
+	summary, err := esppOrder.CalculateEsppOrderSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(summary.ToString())
+
+	// $350 of the $150 gain is ordinary income, leaving a $200 capital loss against ordinary income.
+	if summary.CapitalGainAmount != NewMoney(-200) {
+		t.Fatalf("expected a $-200.00 capital loss, got $%s", summary.CapitalGainAmount)
+	}
+	brackets, _ := tax.CaliforniaBrackets.Brackets(2024, tax.Single)
+	expectedStateCapitalLossTaxBenefit := tax.CalculateIncrementalTax(brackets, 99800, 200)
+	if summary.FederalCapitalGainTaxAmount != NewMoney(-64) {
+		t.Errorf("expected federal capital gain tax of $-64.00, got $%s", summary.FederalCapitalGainTaxAmount)
+	}
+	if summary.StateCapitalGainTaxAmount != NewMoney(-expectedStateCapitalLossTaxBenefit).RoundToCents() {
+		t.Errorf("expected state capital gain tax of $%.2f, got $%s",
+			-expectedStateCapitalLossTaxBenefit, summary.StateCapitalGainTaxAmount)
+	}
+	capitalGainTaxAmount, err := esppOrder.CalculateCapitalGainTaxAmount(summary.CapitalGainAmount)
+	if err != nil || capitalGainTaxAmount != summary.CapitalGainTaxAmount {
+		t.Errorf("expected CalculateCapitalGainTaxAmount to match the summary, got $%s (%v)", capitalGainTaxAmount, err)
+	}
+}
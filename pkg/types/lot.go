@@ -0,0 +1,432 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package types
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/tax"
+	"strings"
+	"time"
+)
+
+// Lot is a block of shares acquired together from an RSU vest or an ESPP purchase.
+type Lot struct {
+	Source OrderType
+	// AcquisitionDate is the vest date of RSU lots and the purchase date of ESPP lots.
+	AcquisitionDate time.Time
+	Quantity        Shares
+	// BasisPerShare is the market value at vest for RSU lots and the purchase price paid for ESPP lots.
+	BasisPerShare Money
+
+	// ESPP lots classify the disposition from the offering details when the sale considers it.
+	DiscountPercent                 float64
+	OfferingDate                    time.Time
+	OfferingDateMarketValuePerShare Money
+	PurchaseDateMarketValuePerShare Money
+}
+
+// CostPerShare is the price per share before the ESPP discount, e.g. to show the discount on the purchase price
+// paid. It is the basis of RSU lots.
+func (l *Lot) CostPerShare() Money {
+	if l.Source != Espp || l.DiscountPercent <= 0 || l.DiscountPercent >= 100 {
+		return l.BasisPerShare
+	}
+	return l.BasisPerShare.MulFloat(100 / (100 - l.DiscountPercent))
+}
+
+// TaxBasisPerShare is the capital-gain basis per share when the lot is sold on saleDate at sellingPricePerShare: the
+// market value at vest of RSU lots and, for ESPP lots, the purchase price plus the ordinary income of the disposition
+// when it is considered.
+func (l *Lot) TaxBasisPerShare(saleDate time.Time, sellingPricePerShare Money, considerDisposition bool) Money {
+	if l.Source != Espp {
+		return l.BasisPerShare
+	}
+	return l.toEsppOrder(saleDate, sellingPricePerShare, considerDisposition).CalculateAdjustedCostBasisPerShare()
+}
+
+// toEsppOrder builds the EsppOrder of selling shares of an ESPP lot, without the shares sold or the taxes.
+func (l *Lot) toEsppOrder(saleDate time.Time, sellingPricePerShare Money, considerDisposition bool) *EsppOrder {
+	return &EsppOrder{
+		DiscountPercent:                 l.DiscountPercent,
+		CostPerShare:                    l.CostPerShare(),
+		PurchasePricePerShare:           l.BasisPerShare,
+		SellingPricePerShare:            sellingPricePerShare,
+		OfferingDateMarketValuePerShare: l.OfferingDateMarketValuePerShare,
+		PurchaseDateMarketValuePerShare: l.PurchaseDateMarketValuePerShare,
+		OfferingDate:                    l.OfferingDate,
+		PurchaseDate:                    l.AcquisitionDate,
+		SaleDate:                        saleDate,
+		ConsiderDisposition:             considerDisposition,
+	}
+}
+
+// LotSale sells NumberOfSharesSold shares, consuming the Lots in order.
+// The tax settings apply to every lot, as they would to a single EsppOrder or RsuOrder.
+type LotSale struct {
+	SellingPricePerShare Money
+	NumberOfSharesSold   Shares
+	SaleDate             time.Time
+	Lots                 []*Lot
+
+	// The commission is split across the lots by the number of shares sold from each.
+	ConsiderTransactionCommission bool
+	CommissionPaidPerTransaction  Money
+	NumberOfTransactions          int
+
+	ConsiderCapitalGainTax bool
+	CapitalGainTaxPercent  float64
+
+	ConsiderHoldingPeriod          bool
+	ShortTermCapitalGainTaxPercent float64
+	LongTermCapitalGainTaxPercent  float64
+
+	// ConsiderDisposition splits the gain on ESPP lots into ordinary income and capital gain.
+	ConsiderDisposition      bool
+	OrdinaryIncomeTaxPercent float64
+
+	// Lots are stacked on top of each other in the TaxProfile and ModifiedAdjustedGrossIncome,
+	// so progressive brackets and NIIT see the income of the lots sold before them.
+	TaxModel      tax.Model
+	TaxProfile    tax.Profile
+	StateTaxModel tax.Model
+
+	ConsiderNetInvestmentIncomeTax bool
+	ModifiedAdjustedGrossIncome    Money
+
+	// ConsiderWashSale flags losses disallowed by lots acquired within 30 days of the sale that are not sold.
+	ConsiderWashSale bool
+}
+
+// LotSaleResult is the outcome of the shares sold from a single lot.
+// Exactly one of EsppOrderSummary and RsuOrderSummary is set, depending on the lot source.
+type LotSaleResult struct {
+	Lot              *Lot
+	SharesSold       Shares
+	EsppOrderSummary *EsppOrderSummary
+	RsuOrderSummary  *RsuOrderSummary
+
+	Proceeds                     Money
+	CostBasis                    Money
+	Commission                   Money
+	HoldingPeriod                HoldingPeriod
+	OrdinaryIncomeAmount         Money
+	CapitalGainAmount            Money
+	OrdinaryIncomeTaxAmount      Money
+	CapitalGainTaxAmount         Money
+	NetInvestmentIncomeTaxAmount Money
+	WashSaleDisallowedLoss       Money
+}
+
+// TaxAmount is the tax owed on the sale of the lot.
+func (l *LotSaleResult) TaxAmount() Money {
+	return l.OrdinaryIncomeTaxAmount + l.CapitalGainTaxAmount + l.NetInvestmentIncomeTaxAmount
+}
+
+// ProfitOrLoss is the gain over the cost basis after commission and the taxes on the sale.
+func (l *LotSaleResult) ProfitOrLoss() Money {
+	return l.Proceeds - l.Commission - l.CostBasis - l.TaxAmount()
+}
+
+type LotSaleSummary struct {
+	LotSale    *LotSale
+	LotResults []*LotSaleResult
+
+	TotalProceeds                     Money
+	TotalCostBasis                    Money
+	TotalCommission                   Money
+	TotalOrdinaryIncomeAmount         Money
+	TotalCapitalGainAmount            Money
+	TotalOrdinaryIncomeTaxAmount      Money
+	TotalCapitalGainTaxAmount         Money
+	TotalNetInvestmentIncomeTaxAmount Money
+
+	// WashSales carry the disallowed losses into the basis of the replacement lots, and HeldShares are the shares of
+	// the lots not sold at their adjusted basis.
+	WashSales                   []*WashSale
+	HeldShares                  []*HeldShares
+	TotalWashSaleDisallowedLoss Money
+}
+
+func (l *LotSaleSummary) TotalTaxAmount() Money {
+	return l.TotalOrdinaryIncomeTaxAmount + l.TotalCapitalGainTaxAmount + l.TotalNetInvestmentIncomeTaxAmount
+}
+
+// TrueProfitOrLoss is the gain over the cost basis of all lots after commission and taxes.
+func (l *LotSaleSummary) TrueProfitOrLoss() Money {
+	return l.TotalProceeds - l.TotalCommission - l.TotalCostBasis - l.TotalTaxAmount()
+}
+
+// NetProceeds is the cash left from the sale after commission and taxes.
+func (l *LotSaleSummary) NetProceeds() Money {
+	return l.TotalProceeds - l.TotalCommission - l.TotalTaxAmount()
+}
+
+func (l *LotSaleSummary) ToString() string {
+	var sb strings.Builder
+
+	sb.WriteString("Lot Sale Summary:\n")
+	for index, lotResult := range l.LotResults {
+		sb.WriteString(fmt.Sprintf("  Lot %d (%s acquired %s, %s): %s shares\n", index+1, lotResult.Lot.Source,
+			lotResult.Lot.AcquisitionDate.Format(DateLayout), lotResult.HoldingPeriod, lotResult.SharesSold))
+		sb.WriteString(fmt.Sprintf("    Proceeds:                   $%s\n", lotResult.Proceeds))
+		sb.WriteString(fmt.Sprintf("    Cost Basis:                 $%s\n", lotResult.CostBasis))
+		sb.WriteString(fmt.Sprintf("    Commission:                 $%s\n", lotResult.Commission))
+		if lotResult.OrdinaryIncomeAmount != 0 {
+			sb.WriteString(fmt.Sprintf("    Ordinary Income:            $%s\n", lotResult.OrdinaryIncomeAmount))
+			sb.WriteString(fmt.Sprintf("    Ordinary Income Tax:        $%s\n", lotResult.OrdinaryIncomeTaxAmount))
+		}
+		sb.WriteString(fmt.Sprintf("    Capital Gain:               $%s\n", lotResult.CapitalGainAmount))
+		sb.WriteString(fmt.Sprintf("    Capital Gain Tax:           $%s\n", lotResult.CapitalGainTaxAmount))
+		if l.LotSale.ConsiderNetInvestmentIncomeTax {
+			sb.WriteString(fmt.Sprintf("    Net Investment Income Tax:  $%s\n", lotResult.NetInvestmentIncomeTaxAmount))
+		}
+		if lotResult.WashSaleDisallowedLoss != 0 {
+			sb.WriteString(fmt.Sprintf("    Wash Sale Disallowed Loss:  $%s\n", lotResult.WashSaleDisallowedLoss))
+		}
+		sb.WriteString(fmt.Sprintf("    Profit/Loss:                $%s\n", lotResult.ProfitOrLoss()))
+	}
+	for _, washSale := range l.WashSales {
+		sb.WriteString(fmt.Sprintf("  Wash Sale: $%s loss carried into %s shares acquired %s (basis $%s/share)\n",
+			washSale.DisallowedLoss, washSale.ReplacementShares, washSale.ReplacementLot.AcquisitionDate.Format(DateLayout),
+			washSale.AdjustedBasisPerShare.StringPerShare()))
+	}
+	if len(l.WashSales) > 0 {
+		for _, heldShares := range l.HeldShares {
+			sb.WriteString(fmt.Sprintf("  Held: %s shares acquired %s (basis $%s/share)\n", heldShares.Quantity,
+				heldShares.Lot.AcquisitionDate.Format(DateLayout), heldShares.BasisPerShare.StringPerShare()))
+		}
+	}
+	sb.WriteString(fmt.Sprintf("  Total Proceeds:               $%s\n", l.TotalProceeds))
+	sb.WriteString(fmt.Sprintf("  Total Cost Basis:             $%s\n", l.TotalCostBasis))
+	sb.WriteString(fmt.Sprintf("  Total Commission:             $%s\n", l.TotalCommission))
+	sb.WriteString(fmt.Sprintf("  Total Ordinary Income:        $%s\n", l.TotalOrdinaryIncomeAmount))
+	sb.WriteString(fmt.Sprintf("  Total Capital Gain:           $%s\n", l.TotalCapitalGainAmount))
+	if l.LotSale.ConsiderWashSale {
+		sb.WriteString(fmt.Sprintf("  Total Wash Sale Disallowed:   $%s\n", l.TotalWashSaleDisallowedLoss))
+	}
+	sb.WriteString(fmt.Sprintf("  Total Tax:                    $%s\n", l.TotalTaxAmount()))
+	sb.WriteString(fmt.Sprintf("  True Profit/Loss:             $%s\n", l.TrueProfitOrLoss()))
+	return sb.String()
+}
+
+// calculateLotCommission splits the commission across the lots by the number of shares sold from each.
+// Each lot takes the cent-rounded share of the shares sold so far less that of the lots before it,
+// so the lot commissions add up to the total commission to the cent.
+func (l *LotSale) calculateLotCommission(sharesSoldBefore Shares, sharesSold Shares) Money {
+	if !l.ConsiderTransactionCommission || l.NumberOfSharesSold <= 0 {
+		return 0
+	}
+	totalCommission := l.CommissionPaidPerTransaction.MulInt(l.NumberOfTransactions).RoundToCents()
+	commissionThrough := func(shares Shares) Money {
+		return totalCommission.Prorate(shares, l.NumberOfSharesSold).RoundToCents()
+	}
+	return commissionThrough(sharesSoldBefore+sharesSold) - commissionThrough(sharesSoldBefore)
+}
+
+// toEsppOrder builds the EsppOrder for the shares sold from an ESPP lot.
+func (l *LotSale) toEsppOrder(lot *Lot, sharesSold Shares, commission Money, taxProfile tax.Profile, modifiedAdjustedGrossIncome Money) *EsppOrder {
+	esppOrder := lot.toEsppOrder(l.SaleDate, l.SellingPricePerShare, l.ConsiderDisposition)
+	esppOrder.NumberOfSharesSold = sharesSold
+	esppOrder.OrdinaryIncomeTaxPercent = l.OrdinaryIncomeTaxPercent
+	esppOrder.ConsiderTransactionCommission = commission > 0
+	esppOrder.CommissionPaidPerTransaction = commission
+	esppOrder.NumberOfTransactions = 1
+	esppOrder.ConsiderCapitalGainTax = l.ConsiderCapitalGainTax
+	esppOrder.CapitalGainTaxPercent = l.CapitalGainTaxPercent
+	esppOrder.ConsiderHoldingPeriod = l.ConsiderHoldingPeriod
+	esppOrder.ShortTermCapitalGainTaxPercent = l.ShortTermCapitalGainTaxPercent
+	esppOrder.LongTermCapitalGainTaxPercent = l.LongTermCapitalGainTaxPercent
+	esppOrder.TaxModel = l.TaxModel
+	esppOrder.TaxProfile = taxProfile
+	esppOrder.StateTaxModel = l.StateTaxModel
+	esppOrder.ConsiderNetInvestmentIncomeTax = l.ConsiderNetInvestmentIncomeTax
+	esppOrder.ModifiedAdjustedGrossIncome = modifiedAdjustedGrossIncome
+	return esppOrder
+}
+
+// toRsuOrder builds the RsuOrder for the shares sold from an RSU lot.
+func (l *LotSale) toRsuOrder(lot *Lot, sharesSold Shares, commission Money, taxProfile tax.Profile, modifiedAdjustedGrossIncome Money) *RsuOrder {
+	return &RsuOrder{
+		SellingPricePerShare:            l.SellingPricePerShare,
+		NumberOfSharesSold:              sharesSold,
+		ConsiderTransactionCommission:   commission > 0,
+		CommissionPaidPerTransaction:    commission,
+		NumberOfTransactions:            1,
+		ConsiderCapitalGainTax:          l.ConsiderCapitalGainTax,
+		CapitalGainTaxPercent:           l.CapitalGainTaxPercent,
+		ConsiderHoldingPeriod:           l.ConsiderHoldingPeriod,
+		VestDate:                        lot.AcquisitionDate,
+		SaleDate:                        l.SaleDate,
+		ShortTermCapitalGainTaxPercent:  l.ShortTermCapitalGainTaxPercent,
+		LongTermCapitalGainTaxPercent:   l.LongTermCapitalGainTaxPercent,
+		TaxModel:                        l.TaxModel,
+		TaxProfile:                      taxProfile,
+		StateTaxModel:                   l.StateTaxModel,
+		ConsiderNetInvestmentIncomeTax:  l.ConsiderNetInvestmentIncomeTax,
+		ModifiedAdjustedGrossIncome:     modifiedAdjustedGrossIncome,
+		NumberOfStocksVested:            lot.Quantity,
+		MarketValuePerShare:             lot.BasisPerShare,
+		DeductCommissionFromCapitalGain: true,
+	}
+}
+
+// CalculateLotSaleSummary sells the shares lot by lot, computing each lot with its EsppOrder or RsuOrder. The cost
+// basis of a lot comes from the same order that taxes it, and the commission of either kind of lot reduces its
+// capital gain.
+func (l *LotSale) CalculateLotSaleSummary() (*LotSaleSummary, error) {
+	if l.NumberOfSharesSold <= 0 {
+		return nil, fmt.Errorf("number of shares sold must be greater than zero")
+	}
+
+	summary := &LotSaleSummary{LotSale: l}
+	taxProfile := l.TaxProfile
+	modifiedAdjustedGrossIncome := l.ModifiedAdjustedGrossIncome
+	remainingShares := l.NumberOfSharesSold
+	for _, lot := range l.Lots {
+		if remainingShares == 0 {
+			break
+		}
+		if lot.Quantity <= 0 {
+			return nil, fmt.Errorf("lot quantity must be greater than zero")
+		}
+		sharesSold := min(remainingShares, lot.Quantity)
+		remainingShares -= sharesSold
+
+		lotResult := &LotSaleResult{
+			Lot:           lot,
+			SharesSold:    sharesSold,
+			Proceeds:      l.SellingPricePerShare.MulShares(sharesSold).RoundToCents(),
+			Commission:    l.calculateLotCommission(l.NumberOfSharesSold-remainingShares-sharesSold, sharesSold),
+			HoldingPeriod: CalculateHoldingPeriod(lot.AcquisitionDate, l.SaleDate),
+		}
+		switch lot.Source {
+		case Espp:
+			esppOrderSummary, err := l.toEsppOrder(lot, sharesSold, lotResult.Commission, taxProfile, modifiedAdjustedGrossIncome).
+				CalculateEsppOrderSummary()
+			if err != nil {
+				return nil, err
+			}
+			lotResult.EsppOrderSummary = esppOrderSummary
+			lotResult.CostBasis = esppOrderSummary.TotalCost
+			lotResult.CapitalGainAmount = esppOrderSummary.CapitalGainAmount
+			if l.ConsiderDisposition {
+				lotResult.OrdinaryIncomeAmount = esppOrderSummary.OrdinaryIncomeAmount
+				lotResult.OrdinaryIncomeTaxAmount = esppOrderSummary.OrdinaryIncomeTaxAmount
+			}
+			if l.ConsiderCapitalGainTax {
+				lotResult.CapitalGainTaxAmount = esppOrderSummary.CapitalGainTaxAmount
+			}
+			lotResult.NetInvestmentIncomeTaxAmount = esppOrderSummary.NetInvestmentIncomeTaxAmount
+		default:
+			rsuOrderSummary, err := l.toRsuOrder(lot, sharesSold, lotResult.Commission, taxProfile, modifiedAdjustedGrossIncome).
+				CalculateRsuOrderSummary()
+			if err != nil {
+				return nil, err
+			}
+			lotResult.RsuOrderSummary = rsuOrderSummary
+			lotResult.CostBasis = rsuOrderSummary.RsuOrder.CalculateTotalCostBasis()
+			lotResult.CapitalGainAmount = rsuOrderSummary.RsuOrder.CalculateProfitOrLossForCapitalGain()
+			if l.ConsiderCapitalGainTax {
+				lotResult.CapitalGainTaxAmount = rsuOrderSummary.CapitalGainTaxAmount
+			}
+			lotResult.NetInvestmentIncomeTaxAmount = rsuOrderSummary.NetInvestmentIncomeTaxAmount
+		}
+
+		// Stack the income of this lot below the next ones.
+		taxableIncome := lotResult.OrdinaryIncomeAmount + MaxMoney(lotResult.CapitalGainAmount, 0)
+		taxProfile.OtherIncome += taxableIncome.Float64()
+		modifiedAdjustedGrossIncome += taxableIncome
+
+		summary.LotResults = append(summary.LotResults, lotResult)
+		summary.TotalProceeds += lotResult.Proceeds
+		summary.TotalCostBasis += lotResult.CostBasis
+		summary.TotalCommission += lotResult.Commission
+		summary.TotalOrdinaryIncomeAmount += lotResult.OrdinaryIncomeAmount
+		summary.TotalCapitalGainAmount += lotResult.CapitalGainAmount
+		summary.TotalOrdinaryIncomeTaxAmount += lotResult.OrdinaryIncomeTaxAmount
+		summary.TotalCapitalGainTaxAmount += lotResult.CapitalGainTaxAmount
+		summary.TotalNetInvestmentIncomeTaxAmount += lotResult.NetInvestmentIncomeTaxAmount
+	}
+	if remainingShares > 0 {
+		return nil, fmt.Errorf("lots hold %s fewer shares than the %s sold", remainingShares, l.NumberOfSharesSold)
+	}
+	if l.ConsiderWashSale {
+		if err := l.calculateWashSales(summary); err != nil {
+			return nil, err
+		}
+	}
+	return summary, nil
+}
+
+// calculateWashSales replays the sale over the lots, the ones not sold being the candidate replacement shares.
+// The disallowed loss of a lot is removed from its capital loss, and the capital gain tax is calculated again.
+func (l *LotSale) calculateWashSales(summary *LotSaleSummary) error {
+	transactionHistory := &TransactionHistory{
+		Lots: l.Lots,
+		Sales: []*Sale{{
+			Date:          l.SaleDate,
+			Quantity:      l.NumberOfSharesSold,
+			PricePerShare: l.SellingPricePerShare,
+		}},
+		ConsiderDisposition: l.ConsiderDisposition,
+	}
+	washSaleReport, err := transactionHistory.CalculateWashSaleReport()
+	if err != nil {
+		return err
+	}
+	for _, disposition := range washSaleReport.Dispositions {
+		if disposition.DisallowedLoss == 0 {
+			continue
+		}
+		for _, lotResult := range summary.LotResults {
+			if lotResult.Lot != disposition.Lot {
+				continue
+			}
+			lotResult.WashSaleDisallowedLoss += disposition.DisallowedLoss
+			lotResult.CapitalGainAmount += disposition.DisallowedLoss
+			summary.TotalCapitalGainAmount += disposition.DisallowedLoss
+			if !l.ConsiderCapitalGainTax {
+				continue
+			}
+			capitalGainTaxAmount, err := lotResult.calculateCapitalGainTaxAmount()
+			if err != nil {
+				return err
+			}
+			summary.TotalCapitalGainTaxAmount += capitalGainTaxAmount - lotResult.CapitalGainTaxAmount
+			lotResult.CapitalGainTaxAmount = capitalGainTaxAmount
+		}
+	}
+	summary.WashSales = washSaleReport.WashSales
+	summary.HeldShares = washSaleReport.HeldShares
+	summary.TotalWashSaleDisallowedLoss = washSaleReport.TotalDisallowedLoss
+	return nil
+}
+
+// calculateCapitalGainTaxAmount is the capital gain tax on the capital gain of the lot, with the order of the lot
+func (l *LotSaleResult) calculateCapitalGainTaxAmount() (Money, error) {
+	if l.EsppOrderSummary != nil {
+		return l.EsppOrderSummary.EsppOrder.CalculateCapitalGainTaxAmount(l.CapitalGainAmount)
+	}
+	return l.RsuOrderSummary.RsuOrder.CalculateCapitalGainTaxAmount(l.CapitalGainAmount)
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package types
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestLotSale_CalculateLotSaleSummary(t *testing.T) {
+	lotSale := &LotSale{
+		SellingPricePerShare: NewMoney(150),
+		NumberOfSharesSold:   NewShares(120),
+		SaleDate:             time.Date(2024, time.December, 1, 0, 0, 0, 0, time.UTC),
+		Lots: []*Lot{
+			{
+				Source:          Rsu,
+				AcquisitionDate: time.Date(2023, time.January, 15, 0, 0, 0, 0, time.UTC),
+				Quantity:        NewShares(50),
+				BasisPerShare:   NewMoney(100),
+			},
+			{
+				Source:                          Espp,
+				AcquisitionDate:                 time.Date(2024, time.June, 30, 0, 0, 0, 0, time.UTC),
+				Quantity:                        NewShares(100),
+				BasisPerShare:                   NewMoney(85),
+				DiscountPercent:                 15,
+				OfferingDate:                    time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+				OfferingDateMarketValuePerShare: NewMoney(100),
+				PurchaseDateMarketValuePerShare: NewMoney(120),
+			},
+		},
+		ConsiderTransactionCommission:  true,
+		CommissionPaidPerTransaction:   NewMoney(12),
+		NumberOfTransactions:           1,
+		ConsiderCapitalGainTax:         true,
+		ConsiderHoldingPeriod:          true,
+		ShortTermCapitalGainTaxPercent: 32,
+		LongTermCapitalGainTaxPercent:  15,
+		ConsiderDisposition:            true,
+		OrdinaryIncomeTaxPercent:       32,
+	}
+	summary, err := lotSale.CalculateLotSaleSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(summary.ToString())
+
+	if len(summary.LotResults) != 2 || summary.LotResults[1].SharesSold != NewShares(70) {
+		t.Fatalf("expected 50 shares from the RSU lot and 70 from the ESPP lot, got %+v", summary.LotResults)
+	}
+	// RSU lot: long-term gain of $2,500 less $5 commission at 15%.
+	rsuLot := summary.LotResults[0]
+	if rsuLot.HoldingPeriod != LongTerm || rsuLot.CapitalGainAmount != NewMoney(2495) || rsuLot.CapitalGainTaxAmount != NewMoney(374.25) {
+		t.Errorf("expected $374.25 long-term capital gain tax on $2495.00, got %s $%s on $%s", rsuLot.HoldingPeriod,
+			rsuLot.CapitalGainTaxAmount, rsuLot.CapitalGainAmount)
+	}
+	if rsuLot.ProfitOrLoss() != NewMoney(2120.75) {
+		t.Errorf("expected $2120.75 profit on the RSU lot, got $%s", rsuLot.ProfitOrLoss())
+	}
+	// ESPP lot: disqualifying, $35/share ordinary income, the rest ($2,093 after $7 commission) short-term.
+	esppLot := summary.LotResults[1]
+	if esppLot.OrdinaryIncomeAmount != NewMoney(2450) || esppLot.CapitalGainAmount != NewMoney(2093) {
+		t.Errorf("expected $2450.00 ordinary income and $2093.00 capital gain, got $%s and $%s",
+			esppLot.OrdinaryIncomeAmount, esppLot.CapitalGainAmount)
+	}
+	expectedTrueProfit := 2120.75 + (10500 - 7 - 5950 - 784 - 2093*0.32)
+	if summary.TrueProfitOrLoss() != NewMoney(expectedTrueProfit).RoundToCents() {
+		t.Errorf("expected $%.2f true profit, got $%s", expectedTrueProfit, summary.TrueProfitOrLoss())
+	}
+
+	lotSale.NumberOfSharesSold = NewShares(200)
+	if _, err = lotSale.CalculateLotSaleSummary(); err == nil {
+		t.Error("expected error when selling more shares than the lots hold")
+	}
+}
+
+func TestLotSale_CalculateLotSaleSummary_CostBasis(t *testing.T) {
+	lotSale := &LotSale{
+		SellingPricePerShare: NewMoney(110),
+		NumberOfSharesSold:   NewShares(7),
+		SaleDate:             time.Date(2024, time.December, 1, 0, 0, 0, 0, time.UTC),
+		Lots: []*Lot{{
+			Source:          Espp,
+			AcquisitionDate: time.Date(2024, time.June, 30, 0, 0, 0, 0, time.UTC),
+			Quantity:        NewShares(10),
+			BasisPerShare:   NewMoney(85.1237),
+			DiscountPercent: 13.3,
+		}},
+	}
+	summary, err := lotSale.CalculateLotSaleSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The purchase price paid is the basis as is, not rebuilt from the discount
+	esppLot := summary.LotResults[0]
+	if esppLot.EsppOrderSummary.EffectiveCostPerShare != NewMoney(85.1237) {
+		t.Errorf("expected the $85.1237 purchase price as the cost per share, got $%s",
+			esppLot.EsppOrderSummary.EffectiveCostPerShare.StringPerShare())
+	}
+	if esppLot.CostBasis != NewMoney(595.87) || esppLot.CostBasis != esppLot.EsppOrderSummary.TotalCost {
+		t.Errorf("expected the $595.87 cost basis taxed by the order, got $%s and $%s", esppLot.CostBasis,
+			esppLot.EsppOrderSummary.TotalCost)
+	}
+	if esppLot.CapitalGainAmount != esppLot.Proceeds-esppLot.CostBasis {
+		t.Errorf("expected the capital gain over the cost basis, got $%s", esppLot.CapitalGainAmount)
+	}
+}
+
+func TestLotSale_CalculateLotCommission(t *testing.T) {
+	lotSale := &LotSale{
+		NumberOfSharesSold:            NewShares(3),
+		ConsiderTransactionCommission: true,
+		CommissionPaidPerTransaction:  NewMoney(10),
+		NumberOfTransactions:          1,
+	}
+	var totalCommission Money
+	for sharesSoldBefore := 0; sharesSoldBefore < 3; sharesSoldBefore++ {
+		totalCommission += lotSale.calculateLotCommission(WholeShares(sharesSoldBefore), WholeShares(1))
+	}
+	if totalCommission != NewMoney(10) {
+		t.Errorf("expected the lot commissions to add up to $10.00, got $%s", totalCommission)
+	}
+}
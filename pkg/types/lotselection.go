@@ -0,0 +1,220 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LotSelectionStrategy decides which lots the shares of a LotSale are sold from.
+type LotSelectionStrategy int
+
+const (
+	// FirstInFirstOut sells the oldest lots first.
+	FirstInFirstOut LotSelectionStrategy = iota
+	// LastInFirstOut sells the newest lots first.
+	LastInFirstOut
+	// HighestCostFirst sells the lots with the highest tax basis per share first, i.e. including the ordinary income
+	// added to the basis of ESPP lots.
+	HighestCostFirst
+	// LowestTaxFirst sells the lots with the lowest tax per share first.
+	LowestTaxFirst
+	// SpecificIdentification sells the lots picked by the seller, in the order picked.
+	SpecificIdentification
+)
+
+func (l LotSelectionStrategy) String() string {
+	switch l {
+	case FirstInFirstOut:
+		return "FIFO"
+	case LastInFirstOut:
+		return "LIFO"
+	case HighestCostFirst:
+		return "HIFO"
+	case LowestTaxFirst:
+		return "Lowest Tax"
+	case SpecificIdentification:
+		return "Specific ID"
+	default:
+		return "Unknown"
+	}
+}
+
+// LotSelectionStrategies lists the strategies in the order they are compared.
+func LotSelectionStrategies() []LotSelectionStrategy {
+	return []LotSelectionStrategy{FirstInFirstOut, LastInFirstOut, HighestCostFirst, LowestTaxFirst, SpecificIdentification}
+}
+
+func ParseLotSelectionStrategy(value string) (LotSelectionStrategy, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "fifo":
+		return FirstInFirstOut, nil
+	case "lifo":
+		return LastInFirstOut, nil
+	case "hifo", "highest-cost":
+		return HighestCostFirst, nil
+	case "lowest-tax", "tax":
+		return LowestTaxFirst, nil
+	case "specific", "specific-id":
+		return SpecificIdentification, nil
+	default:
+		return 0, fmt.Errorf("invalid lot selection strategy: %s, valid values: fifo, lifo, hifo, lowest-tax, specific", value)
+	}
+}
+
+// ParseLotNumbers parses the 1-based lot numbers picked for specific identification, e.g. "3,1".
+func ParseLotNumbers(value string) ([]int, error) {
+	var lotNumbers []int
+	for _, lotNumberValue := range strings.Split(value, ",") {
+		lotNumberValue = strings.TrimSpace(lotNumberValue)
+		if lotNumberValue == "" {
+			continue
+		}
+		lotNumber, err := strconv.Atoi(lotNumberValue)
+		if err != nil || lotNumber <= 0 {
+			return nil, fmt.Errorf("invalid lot number: %s", lotNumberValue)
+		}
+		lotNumbers = append(lotNumbers, lotNumber)
+	}
+	if len(lotNumbers) == 0 {
+		return nil, fmt.Errorf("at least one lot number is required")
+	}
+	return lotNumbers, nil
+}
+
+// SelectLots orders the lots by the strategy, leaving l.Lots untouched.
+// specificLotNumbers are the 1-based lot numbers used by SpecificIdentification and are ignored otherwise.
+func (l *LotSale) SelectLots(strategy LotSelectionStrategy, specificLotNumbers []int) ([]*Lot, error) {
+	lots := make([]*Lot, len(l.Lots))
+	copy(lots, l.Lots)
+
+	switch strategy {
+	case FirstInFirstOut:
+		sort.SliceStable(lots, func(i, j int) bool {
+			return lots[i].AcquisitionDate.Before(lots[j].AcquisitionDate)
+		})
+	case LastInFirstOut:
+		sort.SliceStable(lots, func(i, j int) bool {
+			return lots[i].AcquisitionDate.After(lots[j].AcquisitionDate)
+		})
+	case HighestCostFirst:
+		taxBasisPerShare := make(map[*Lot]Money, len(lots))
+		for _, lot := range lots {
+			taxBasisPerShare[lot] = lot.TaxBasisPerShare(l.SaleDate, l.SellingPricePerShare, l.ConsiderDisposition)
+		}
+		sort.SliceStable(lots, func(i, j int) bool {
+			return taxBasisPerShare[lots[i]] > taxBasisPerShare[lots[j]]
+		})
+	case LowestTaxFirst:
+		taxPerShare := make(map[*Lot]Money, len(lots))
+		for _, lot := range lots {
+			lotTaxPerShare, err := l.calculateTaxPerShare(lot)
+			if err != nil {
+				return nil, err
+			}
+			taxPerShare[lot] = lotTaxPerShare
+		}
+		sort.SliceStable(lots, func(i, j int) bool {
+			return taxPerShare[lots[i]] < taxPerShare[lots[j]]
+		})
+	case SpecificIdentification:
+		if len(specificLotNumbers) == 0 {
+			return nil, fmt.Errorf("specific identification requires the lot numbers to sell")
+		}
+		lots = lots[:0:0]
+		picked := make(map[int]bool, len(specificLotNumbers))
+		for _, lotNumber := range specificLotNumbers {
+			if lotNumber <= 0 || lotNumber > len(l.Lots) {
+				return nil, fmt.Errorf("lot number %d is out of range (1-%d)", lotNumber, len(l.Lots))
+			}
+			if picked[lotNumber] {
+				return nil, fmt.Errorf("lot number %d is picked more than once", lotNumber)
+			}
+			picked[lotNumber] = true
+			lots = append(lots, l.Lots[lotNumber-1])
+		}
+	default:
+		return nil, fmt.Errorf("invalid lot selection strategy: %d", strategy)
+	}
+	return lots, nil
+}
+
+// calculateTaxPerShare is the tax owed per share when the whole lot is sold on its own.
+func (l *LotSale) calculateTaxPerShare(lot *Lot) (Money, error) {
+	lotSale := *l
+	lotSale.Lots = []*Lot{lot}
+	lotSale.NumberOfSharesSold = lot.Quantity
+	lotSale.ConsiderTransactionCommission = false
+	summary, err := lotSale.CalculateLotSaleSummary()
+	if err != nil {
+		return 0, err
+	}
+	return summary.TotalTaxAmount().DivShares(lot.Quantity), nil
+}
+
+// LotSalePlan is the outcome of selling with one lot selection strategy.
+type LotSalePlan struct {
+	Strategy LotSelectionStrategy
+	Summary  *LotSaleSummary
+}
+
+// CalculateLotSalePlans sells the shares with every strategy so they can be compared side by side.
+// SpecificIdentification is only planned when specificLotNumbers are given.
+func (l *LotSale) CalculateLotSalePlans(specificLotNumbers []int) ([]*LotSalePlan, error) {
+	var lotSalePlans []*LotSalePlan
+	for _, strategy := range LotSelectionStrategies() {
+		if strategy == SpecificIdentification && len(specificLotNumbers) == 0 {
+			continue
+		}
+		lots, err := l.SelectLots(strategy, specificLotNumbers)
+		if err != nil {
+			return nil, err
+		}
+		lotSale := *l
+		lotSale.Lots = lots
+		summary, err := lotSale.CalculateLotSaleSummary()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", strategy, err)
+		}
+		lotSalePlans = append(lotSalePlans, &LotSalePlan{
+			Strategy: strategy,
+			Summary:  summary,
+		})
+	}
+	return lotSalePlans, nil
+}
+
+// BestLotSalePlan is the plan that leaves the most cash after commission and taxes.
+func BestLotSalePlan(lotSalePlans []*LotSalePlan) *LotSalePlan {
+	var best *LotSalePlan
+	for _, lotSalePlan := range lotSalePlans {
+		if best == nil || lotSalePlan.Summary.NetProceeds() > best.Summary.NetProceeds() {
+			best = lotSalePlan
+		}
+	}
+	return best
+}
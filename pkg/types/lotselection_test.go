@@ -0,0 +1,113 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLotSale_CalculateLotSalePlans(t *testing.T) {
+	oldLot := &Lot{Source: Rsu, AcquisitionDate: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC), Quantity: NewShares(50), BasisPerShare: NewMoney(50)}
+	newLot := &Lot{Source: Rsu, AcquisitionDate: time.Date(2024, time.October, 1, 0, 0, 0, 0, time.UTC), Quantity: NewShares(50), BasisPerShare: NewMoney(140)}
+	underwaterLot := &Lot{Source: Rsu, AcquisitionDate: time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC), Quantity: NewShares(50), BasisPerShare: NewMoney(160)}
+	lotSale := &LotSale{
+		SellingPricePerShare:           NewMoney(150),
+		NumberOfSharesSold:             NewShares(50),
+		SaleDate:                       time.Date(2024, time.December, 1, 0, 0, 0, 0, time.UTC),
+		Lots:                           []*Lot{oldLot, newLot, underwaterLot},
+		ConsiderCapitalGainTax:         true,
+		ConsiderHoldingPeriod:          true,
+		ShortTermCapitalGainTaxPercent: 32,
+		LongTermCapitalGainTaxPercent:  15,
+	}
+
+	lotSalePlans, err := lotSale.CalculateLotSalePlans([]int{2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[LotSelectionStrategy]struct {
+		lot       *Lot
+		taxAmount float64
+	}{
+		FirstInFirstOut:        {oldLot, 750},
+		LastInFirstOut:         {newLot, 160},
+		HighestCostFirst:       {underwaterLot, 0},
+		LowestTaxFirst:         {underwaterLot, 0},
+		SpecificIdentification: {newLot, 160},
+	}
+	if len(lotSalePlans) != len(expected) {
+		t.Fatalf("expected %d plans, got %d", len(expected), len(lotSalePlans))
+	}
+	for _, lotSalePlan := range lotSalePlans {
+		want := expected[lotSalePlan.Strategy]
+		lotResults := lotSalePlan.Summary.LotResults
+		if len(lotResults) != 1 || lotResults[0].Lot != want.lot {
+			t.Errorf("%s: expected a single lot acquired %s", lotSalePlan.Strategy, want.lot.AcquisitionDate.Format(DateLayout))
+		}
+		if lotSalePlan.Summary.TotalTaxAmount() != NewMoney(want.taxAmount).RoundToCents() {
+			t.Errorf("%s: expected $%.2f tax, got $%s", lotSalePlan.Strategy, want.taxAmount, lotSalePlan.Summary.TotalTaxAmount())
+		}
+	}
+	if best := BestLotSalePlan(lotSalePlans); best.Strategy != HighestCostFirst {
+		t.Errorf("expected HIFO to be the best plan, got %s", best.Strategy)
+	}
+	if lotSale.Lots[0] != oldLot || lotSale.Lots[2] != underwaterLot {
+		t.Error("expected the lots of the sale to be left in their original order")
+	}
+
+	if _, err = lotSale.SelectLots(SpecificIdentification, []int{4}); err == nil {
+		t.Error("expected error for an out of range lot number")
+	}
+	if _, err = ParseLotNumbers("2,x"); err == nil {
+		t.Error("expected error for an invalid lot number")
+	}
+}
+
+func TestLotSale_SelectLots_HighestCostFirstTaxBasis(t *testing.T) {
+	// The ESPP lot was bought at $85, but its tax basis is the $120 market value at purchase.
+	esppLot := &Lot{Source: Espp, AcquisitionDate: time.Date(2024, time.June, 30, 0, 0, 0, 0, time.UTC), Quantity: NewShares(10),
+		BasisPerShare: NewMoney(85), DiscountPercent: 15, OfferingDate: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		OfferingDateMarketValuePerShare: NewMoney(100), PurchaseDateMarketValuePerShare: NewMoney(120)}
+	rsuLot := &Lot{Source: Rsu, AcquisitionDate: time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC), Quantity: NewShares(10), BasisPerShare: NewMoney(110)}
+	lotSale := &LotSale{
+		SellingPricePerShare: NewMoney(130),
+		NumberOfSharesSold:   NewShares(10),
+		SaleDate:             time.Date(2024, time.December, 1, 0, 0, 0, 0, time.UTC),
+		Lots:                 []*Lot{rsuLot, esppLot},
+		ConsiderDisposition:  true,
+	}
+	lots, err := lotSale.SelectLots(HighestCostFirst, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lots[0] != esppLot {
+		t.Errorf("expected the ESPP lot at its $120.00 tax basis first, got the lot acquired %s", lots[0].AcquisitionDate.Format(DateLayout))
+	}
+
+	lotSale.ConsiderDisposition = false
+	if lots, _ = lotSale.SelectLots(HighestCostFirst, nil); lots[0] != rsuLot {
+		t.Error("expected the RSU lot first when the ESPP basis is the purchase price")
+	}
+}
@@ -0,0 +1,277 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package types
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/tax"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// Money is a fixed-point dollar amount in ten-thousandths of a dollar.
+// Per-share values keep 4 decimal places while totals are rounded to cents with RoundToCents,
+// so totals reconcile with broker statements to the cent.
+type Money int64
+
+const (
+	// PerShareDecimalPlaces is the precision Money keeps, used for per-share values.
+	PerShareDecimalPlaces = 4
+	// TotalDecimalPlaces is the precision totals are rounded to.
+	TotalDecimalPlaces = 2
+
+	moneyScale = 10000
+)
+
+// NewMoney converts a float64 dollar amount, rounding half away from zero to 4 decimal places.
+func NewMoney(value float64) Money {
+	return Money(math.Round(value * moneyScale))
+}
+
+// ParseMoney parses a decimal dollar amount such as "1,234.5678" or "$-12.50" without going through float64.
+// Digits beyond 4 decimal places are rounded half away from zero.
+func ParseMoney(value string) (Money, error) {
+	normalized := strings.ReplaceAll(strings.TrimSpace(value), ",", "")
+	negative := strings.HasPrefix(normalized, "-")
+	normalized = strings.TrimPrefix(strings.TrimPrefix(normalized, "-"), "$")
+	if negative {
+		normalized = "-" + normalized
+	}
+	money, err := parseFixedPoint(normalized, PerShareDecimalPlaces)
+	if err != nil {
+		return 0, fmt.Errorf("invalid dollar amount: %s", value)
+	}
+	return Money(money), nil
+}
+
+// parseFixedPoint parses a decimal number into units of 10^-decimalPlaces, rounding the digits beyond
+// decimalPlaces half away from zero.
+func parseFixedPoint(value string, decimalPlaces int) (int64, error) {
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+
+	wholePart, fractionPart, _ := strings.Cut(value, ".")
+	if wholePart == "" && fractionPart == "" {
+		return 0, fmt.Errorf("invalid number: %s", value)
+	}
+	if wholePart == "" {
+		wholePart = "0"
+	}
+	for _, digits := range []string{wholePart, fractionPart} {
+		if strings.Trim(digits, "0123456789") != "" {
+			return 0, fmt.Errorf("invalid number: %s", value)
+		}
+	}
+	scale := int64(math.Pow10(decimalPlaces))
+	whole, err := strconv.ParseInt(wholePart, 10, 64)
+	if err != nil || whole > math.MaxInt64/scale-1 {
+		return 0, fmt.Errorf("invalid number: %s", value)
+	}
+
+	roundUp := len(fractionPart) > decimalPlaces && fractionPart[decimalPlaces] >= '5'
+	fractionPart = (fractionPart + strings.Repeat("0", decimalPlaces))[:decimalPlaces]
+	fraction, _ := strconv.ParseInt(fractionPart, 10, 64)
+
+	number := whole*scale + fraction
+	if roundUp {
+		number++
+	}
+	if negative {
+		number = -number
+	}
+	return number, nil
+}
+
+// MinMoney returns the smaller of a and b.
+func MinMoney(a Money, b Money) Money {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// MaxMoney returns the larger of a and b.
+func MaxMoney(a Money, b Money) Money {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// SumMoney adds up the amounts.
+func SumMoney(amounts ...Money) Money {
+	var sum Money
+	for _, amount := range amounts {
+		sum += amount
+	}
+	return sum
+}
+
+func (m Money) Float64() float64 {
+	return float64(m) / moneyScale
+}
+
+// Round rounds half away from zero to the decimal places (0-4).
+func (m Money) Round(decimalPlaces int) Money {
+	if decimalPlaces >= PerShareDecimalPlaces {
+		return m
+	}
+	unit := Money(math.Pow10(PerShareDecimalPlaces - decimalPlaces))
+	half := unit / 2
+	if m < 0 {
+		return -((-m + half) / unit * unit)
+	}
+	return (m + half) / unit * unit
+}
+
+// RoundToCents rounds a total half away from zero to the cent.
+func (m Money) RoundToCents() Money {
+	return m.Round(TotalDecimalPlaces)
+}
+
+// MulInt multiplies by a count, e.g. the commission per transaction by the number of transactions; the result is exact.
+func (m Money) MulInt(count int) Money {
+	return m * Money(count)
+}
+
+// MulFloat multiplies by a factor, rounding half away from zero to 4 decimal places.
+// Panics when the product does not fit in a Money.
+func (m Money) MulFloat(factor float64) Money {
+	product := math.Round(float64(m) * factor)
+	// float64(math.MaxInt64) rounds up to 2^63, the first value out of range
+	if math.IsNaN(product) || product >= float64(math.MaxInt64) || product < float64(math.MinInt64) {
+		panic(fmt.Sprintf("types: $%s * %g overflows a dollar amount", m, factor))
+	}
+	return Money(product)
+}
+
+// Percent returns percent of the amount, rounding half away from zero to 4 decimal places.
+func (m Money) Percent(percent float64) Money {
+	return m.MulFloat(percent / 100)
+}
+
+// MulShares multiplies a per-share value by a number of shares, rounding half away from zero to 4 decimal places.
+func (m Money) MulShares(shares Shares) Money {
+	return Money(mulDiv(int64(m), int64(shares), shareScale))
+}
+
+// DivShares divides a total by a number of shares, rounding half away from zero to 4 decimal places.
+func (m Money) DivShares(shares Shares) Money {
+	if shares == 0 {
+		return 0
+	}
+	return Money(mulDiv(int64(m), shareScale, int64(shares)))
+}
+
+// Prorate returns the part of the amount that falls on part of the whole shares, e.g. the income tax on the
+// shares sold out of those vested. Only the result is rounded, not an intermediate per-share value.
+func (m Money) Prorate(part Shares, whole Shares) Money {
+	if whole == 0 {
+		return 0
+	}
+	return Money(mulDiv(int64(m), int64(part), int64(whole)))
+}
+
+// Ratio returns m / other, e.g. to derive a rate from two amounts. It is 0 when other is zero.
+func (m Money) Ratio(other Money) float64 {
+	if other == 0 {
+		return 0
+	}
+	return float64(m) / float64(other)
+}
+
+func (m Money) Abs() Money {
+	if m < 0 {
+		return -m
+	}
+	return m
+}
+
+// String formats the amount rounded to the cent, e.g. "1234.56" or "-0.50".
+func (m Money) String() string {
+	cents := int64(m.RoundToCents()) / (moneyScale / 100)
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// StringPerShare formats the amount with the 4 decimal places kept for per-share values.
+func (m Money) StringPerShare() string {
+	sign := ""
+	value := int64(m)
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+	return fmt.Sprintf("%s%d.%04d", sign, value/moneyScale, value%moneyScale)
+}
+
+// mulDiv returns a * b / c rounded half away from zero, keeping the 128-bit intermediate product exact.
+// Panics when the result does not fit in an int64, e.g. a dollar amount times a huge number of shares.
+func mulDiv(a int64, b int64, c int64) int64 {
+	negative := (a < 0) != (b < 0) != (c < 0)
+	hi, lo := bits.Mul64(absInt64(a), absInt64(b))
+	divisor := absInt64(c)
+	if hi >= divisor {
+		panic(fmt.Sprintf("types: %d * %d / %d overflows 64 bits", a, b, c))
+	}
+	quotient, remainder := bits.Div64(hi, lo, divisor)
+	if remainder >= divisor-remainder {
+		quotient++
+	}
+	if quotient > math.MaxInt64 && !(negative && quotient == 1<<63) {
+		panic(fmt.Sprintf("types: %d * %d / %d overflows 64 bits", a, b, c))
+	}
+	if negative {
+		return -int64(quotient)
+	}
+	return int64(quotient)
+}
+
+func absInt64(value int64) uint64 {
+	if value < 0 {
+		return uint64(-value)
+	}
+	return uint64(value)
+}
+
+// calculateTax applies the tax model to a Money amount, rounding the tax to the cent.
+func calculateTax(model tax.Model, profile tax.Profile, incomeType tax.IncomeType, income Money) (Money, error) {
+	taxAmount, err := model.CalculateTax(profile, incomeType, income.Float64())
+	if err != nil {
+		return 0, err
+	}
+	return NewMoney(taxAmount).RoundToCents(), nil
+}
+
+// calculateNetInvestmentIncomeTax applies tax.CalculateNetInvestmentIncomeTax to Money amounts, rounding the tax to the cent.
+func calculateNetInvestmentIncomeTax(filingStatus tax.FilingStatus, modifiedAdjustedGrossIncome Money, investmentIncome Money) Money {
+	return NewMoney(tax.CalculateNetInvestmentIncomeTax(filingStatus, modifiedAdjustedGrossIncome.Float64(),
+		investmentIncome.Float64())).RoundToCents()
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package types
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Money
+	}{
+		{"1234.56", 12345600},
+		{"$1,234.5678", 12345678},
+		{"-12.5", -125000},
+		{"$-0.00005", -1},
+		{"0.00004", 0},
+		{".25", 2500},
+		{"100", 1000000},
+	}
+	for _, test := range tests {
+		actual, err := ParseMoney(test.input)
+		if err != nil {
+			t.Errorf("%s: %v", test.input, err)
+			continue
+		}
+		if actual != test.expected {
+			t.Errorf("%s: expected %d, got %d", test.input, test.expected, actual)
+		}
+	}
+	for _, input := range []string{"", "abc", "1.2.3", "12a", "-"} {
+		if _, err := ParseMoney(input); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
+
+func TestMoney_Rounding(t *testing.T) {
+	// $1,000.01 of income tax over 3 shares keeps 4 decimal places per share.
+	perShare := NewMoney(1000.01).DivShares(NewShares(3))
+	if perShare.StringPerShare() != "333.3367" {
+		t.Errorf("expected 333.3367 per share, got %s", perShare.StringPerShare())
+	}
+	if total := perShare.MulShares(NewShares(3)).RoundToCents(); total.String() != "1000.01" {
+		t.Errorf("expected the total to round back to 1000.01, got %s", total)
+	}
+	// $10,000 over 3,000 shares is $3.3333 per share, which would come to $4,999.95 for half of them.
+	if prorated := NewMoney(10000).Prorate(NewShares(1500), NewShares(3000)); prorated != NewMoney(5000) {
+		t.Errorf("expected $5000.00 prorated, got $%s", prorated)
+	}
+	if NewMoney(0.125).RoundToCents().String() != "0.13" || NewMoney(-0.125).RoundToCents().String() != "-0.13" {
+		t.Error("expected half-cents to round away from zero")
+	}
+
+	// Sums of cents do not drift the way float64 does (0.1 + 0.2 != 0.3).
+	if SumMoney(NewMoney(0.1), NewMoney(0.2)) != NewMoney(0.3) {
+		t.Error("expected 0.10 + 0.20 to equal 0.30")
+	}
+	if NewMoney(19.99).Percent(15).StringPerShare() != "2.9985" {
+		t.Errorf("expected 15%% of 19.99 to be 2.9985, got %s", NewMoney(19.99).Percent(15).StringPerShare())
+	}
+}
+
+func TestMoney_Overflow(t *testing.T) {
+	// The largest amount still multiplies by one share exactly
+	if product := Money(math.MaxInt64).MulShares(NewShares(1)); product != Money(math.MaxInt64) {
+		t.Errorf("expected the largest amount, got %d", product)
+	}
+	if product := Money(math.MinInt64).MulShares(NewShares(1)); product != Money(math.MinInt64) {
+		t.Errorf("expected the smallest amount, got %d", product)
+	}
+	if product := Money(1 << 62).MulFloat(1.5); product != Money(3<<61) {
+		t.Errorf("expected 1.5 times the amount, got %d", product)
+	}
+
+	for name, overflow := range map[string]func(){
+		"quotient over 64 bits": func() { Money(math.MaxInt64).MulShares(Shares(math.MaxInt64)) },
+		"quotient over int64":   func() { Money(math.MaxInt64).MulShares(NewShares(1.000001)) },
+		"float product":         func() { Money(1 << 62).MulFloat(2) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected a panic", name)
+				}
+			}()
+			overflow()
+		}()
+	}
+}
@@ -25,49 +25,95 @@ package types
 
 import (
 	"fmt"
-	"math"
+	"github.com/leogps/lunar/pkg/tax"
 	"strings"
+	"time"
 )
 
 type RsuOrder struct {
-	SellingPricePerShare float64
-	NumberOfSharesSold   int
+	SellingPricePerShare Money
+	NumberOfSharesSold   Shares
 
 	ConsiderTransactionCommission bool
-	CommissionPaidPerTransaction  float64
+	CommissionPaidPerTransaction  Money
 	NumberOfTransactions          int
 
 	ConsiderCapitalGainTax bool
 	CapitalGainTaxPercent  float64
 
+	// ConsiderHoldingPeriod picks the short-term or long-term rate from the vest and sale dates
+	// instead of CapitalGainTaxPercent.
+	ConsiderHoldingPeriod          bool
+	VestDate                       time.Time
+	SaleDate                       time.Time
+	ShortTermCapitalGainTaxPercent float64
+	LongTermCapitalGainTaxPercent  float64
+
+	// TaxModel, when set, computes the capital gain tax progressively for the TaxProfile
+	// instead of using the flat percentages.
+	TaxModel   tax.Model
+	TaxProfile tax.Profile
+	// StateTaxModel, when set, adds state tax on the capital gain for the TaxProfile.
+	StateTaxModel tax.Model
+
+	// ConsiderNetInvestmentIncomeTax adds the 3.8% NIIT on the capital gain above the MAGI threshold
+	// for the filing status of the TaxProfile. ModifiedAdjustedGrossIncome excludes this sale.
+	ConsiderNetInvestmentIncomeTax bool
+	ModifiedAdjustedGrossIncome    Money
+
+	// ConsiderCapitalLoss values a capital loss by the tax it saves, reported as a negative capital gain tax.
+	// The loss offsets OtherRealizedCapitalGains of the tax year first, then up to $3,000 of ordinary income
+	// (taxed at OrdinaryIncomeTaxPercent when TaxModel is not set), and the rest is carried forward along with
+	// the CapitalLossCarryforward from prior years.
+	ConsiderCapitalLoss       bool
+	OtherRealizedCapitalGains Money
+	CapitalLossCarryforward   Money
+	OrdinaryIncomeTaxPercent  float64
+
 	ConsiderIncomeTaxOnVestedStock   bool
-	IncomeTaxIncurredWhenStockVested float64
-	NumberOfStocksVested             int
-	MarketValuePerShare              float64
+	IncomeTaxIncurredWhenStockVested Money
+	NumberOfStocksVested             Shares
+	MarketValuePerShare              Money
+
+	// DeductCommissionFromCapitalGain takes the commission out of the capital gain, as ESPP orders do.
+	DeductCommissionFromCapitalGain bool
 }
 
 type RsuOrderSummary struct {
 	RsuOrder               *RsuOrder
-	TotalSellingPrice      float64
-	EffectiveCommission    float64
-	NetResult              float64
-	CapitalGainTaxAmount   float64
-	TotalIncomeTaxIncurred float64
+	TotalSellingPrice      Money
+	EffectiveCommission    Money
+	NetResult              Money
+	CapitalGainTaxAmount   Money
+	TotalIncomeTaxIncurred Money
+
+	HoldingPeriod                  HoldingPeriod
+	LongTermDate                   time.Time
+	EffectiveCapitalGainTaxPercent float64
+
+	FederalCapitalGainTaxAmount Money
+	StateCapitalGainTaxAmount   Money
+
+	NetInvestmentIncomeTaxAmount Money
+
+	// CapitalLossCarryforwardAmount is the capital loss left over for the next tax year.
+	CapitalLossCarryforwardAmount Money
 }
 
-func (r *RsuOrderSummary) ProfitOrLossAfterIncomeTax() float64 {
+func (r *RsuOrderSummary) ProfitOrLossAfterIncomeTax() Money {
 	return r.NetResult - r.TotalIncomeTaxIncurred
 }
 
-func (r *RsuOrderSummary) ProfitOrLossAfterCapitalGainsTax() float64 {
-	return r.NetResult - r.CapitalGainTaxAmount
+// ProfitOrLossAfterCapitalGainsTax deducts the capital gain tax and the NIIT on the capital gain.
+func (r *RsuOrderSummary) ProfitOrLossAfterCapitalGainsTax() Money {
+	return r.NetResult - r.CapitalGainTaxAmount - r.NetInvestmentIncomeTaxAmount
 }
 
 func (r *RsuOrderSummary) IsProfitable() bool {
 	return r.TrueProfitOrLoss() > 0
 }
 
-func (r *RsuOrderSummary) TrueProfitOrLoss() float64 {
+func (r *RsuOrderSummary) TrueProfitOrLoss() Money {
 	trueProfitOrLoss := r.NetResult
 	if r.RsuOrder.ConsiderCapitalGainTax {
 		trueProfitOrLoss -= r.CapitalGainTaxAmount
@@ -75,27 +121,59 @@ func (r *RsuOrderSummary) TrueProfitOrLoss() float64 {
 	if r.RsuOrder.ConsiderIncomeTaxOnVestedStock {
 		trueProfitOrLoss -= r.TotalIncomeTaxIncurred
 	}
+	if r.RsuOrder.ConsiderNetInvestmentIncomeTax {
+		trueProfitOrLoss -= r.NetInvestmentIncomeTaxAmount
+	}
 	return trueProfitOrLoss
 }
 
+// NetProceeds is the cash left from the sale after commission and taxes. The income tax was withheld at vest, so
+// only the capital gain tax and NIIT come out of the proceeds.
+func (r *RsuOrderSummary) NetProceeds() Money {
+	return r.TrueProfitOrLoss() + r.TotalIncomeTaxIncurred
+}
+
+// SaleOutcome returns the outcome of the sale a SaleTarget aims for.
+func (r *RsuOrderSummary) SaleOutcome(saleTarget SaleTarget) Money {
+	if saleTarget == NetProceedsTarget {
+		return r.NetProceeds()
+	}
+	return r.TrueProfitOrLoss()
+}
+
 func (r *RsuOrderSummary) ProfitOrLossMargin() float64 {
-	return (r.TrueProfitOrLoss() / r.TotalIncomeTaxIncurred) * 100
+	return r.TrueProfitOrLoss().Float64() / r.TotalIncomeTaxIncurred.Float64() * 100
 }
 
 func (r *RsuOrderSummary) ToString() string {
 	var sb strings.Builder
 
 	sb.WriteString("RSU Order Summary:\n")
-	sb.WriteString(fmt.Sprintf("  Total Selling Price:          		$%.2f\n", r.TotalSellingPrice))
-	sb.WriteString(fmt.Sprintf("  Effective Commission:         		$%.2f\n", r.EffectiveCommission))
-	sb.WriteString(fmt.Sprintf("  Capital Gain Tax Amount:      		$%.2f\n", r.CapitalGainTaxAmount))
-	sb.WriteString(fmt.Sprintf("  Total Income Tax Incurred:    		$%.2f\n", r.TotalIncomeTaxIncurred))
-	sb.WriteString(fmt.Sprintf("  Net Result:                   		$%.2f\n", r.NetResult))
-	sb.WriteString(fmt.Sprintf("  Is Profitable:                		%t\n", r.IsProfitable()))
-	sb.WriteString(fmt.Sprintf("  Profit After Capital Gains Tax: 	$%.2f\n", r.ProfitOrLossAfterCapitalGainsTax()))
-	sb.WriteString(fmt.Sprintf("  Profit/Loss After Income Tax: 		$%.2f\n", r.ProfitOrLossAfterIncomeTax()))
-	sb.WriteString(fmt.Sprintf("  True Profit/Loss: 					$%.2f\n", r.TrueProfitOrLoss()))
-	sb.WriteString(fmt.Sprintf("  Profit/Loss Margin: 		   	   	%.2f%%\n", r.ProfitOrLossMargin()))
+	sb.WriteString(fmt.Sprintf("  Total Selling Price:            $%s\n", r.TotalSellingPrice))
+	sb.WriteString(fmt.Sprintf("  Effective Commission:           $%s\n", r.EffectiveCommission))
+	if r.RsuOrder.ConsiderHoldingPeriod {
+		sb.WriteString(fmt.Sprintf("  Holding Period:                 %s\n", r.HoldingPeriod))
+		sb.WriteString(fmt.Sprintf("  Long-Term From:                 %s\n", r.LongTermDate.Format(DateLayout)))
+	}
+	sb.WriteString(fmt.Sprintf("  Capital Gain Tax Percent:       %.2f%%\n", r.EffectiveCapitalGainTaxPercent))
+	sb.WriteString(fmt.Sprintf("  Capital Gain Tax Amount:        $%s\n", r.CapitalGainTaxAmount))
+	if r.RsuOrder.StateTaxModel != nil {
+		sb.WriteString(fmt.Sprintf("    Federal:                      $%s\n", r.FederalCapitalGainTaxAmount))
+		sb.WriteString(fmt.Sprintf("    State:                        $%s\n", r.StateCapitalGainTaxAmount))
+	}
+	if r.RsuOrder.ConsiderNetInvestmentIncomeTax {
+		sb.WriteString(fmt.Sprintf("  Net Investment Income Tax:      $%s\n", r.NetInvestmentIncomeTaxAmount))
+	}
+	if r.RsuOrder.ConsiderCapitalLoss {
+		sb.WriteString(fmt.Sprintf("  Capital Loss Carryforward:      $%s\n", r.CapitalLossCarryforwardAmount))
+	}
+	sb.WriteString(fmt.Sprintf("  Total Income Tax Incurred:      $%s\n", r.TotalIncomeTaxIncurred))
+	sb.WriteString(fmt.Sprintf("  Net Result:                     $%s\n", r.NetResult))
+	sb.WriteString(fmt.Sprintf("  Is Profitable:                  %t\n", r.IsProfitable()))
+	sb.WriteString(fmt.Sprintf("  Profit After Capital Gains Tax: $%s\n", r.ProfitOrLossAfterCapitalGainsTax()))
+	sb.WriteString(fmt.Sprintf("  Profit/Loss After Income Tax:   $%s\n", r.ProfitOrLossAfterIncomeTax()))
+	sb.WriteString(fmt.Sprintf("  True Profit/Loss:               $%s\n", r.TrueProfitOrLoss()))
+	sb.WriteString(fmt.Sprintf("  Profit/Loss Margin:             %.2f%%\n", r.ProfitOrLossMargin()))
 	return sb.String()
 }
 
@@ -109,135 +187,303 @@ func (r *RsuOrder) Clone() *RsuOrder {
 		NumberOfTransactions:             r.NumberOfTransactions,
 		ConsiderCapitalGainTax:           r.ConsiderCapitalGainTax,
 		CapitalGainTaxPercent:            r.CapitalGainTaxPercent,
+		ConsiderHoldingPeriod:            r.ConsiderHoldingPeriod,
+		VestDate:                         r.VestDate,
+		SaleDate:                         r.SaleDate,
+		ShortTermCapitalGainTaxPercent:   r.ShortTermCapitalGainTaxPercent,
+		LongTermCapitalGainTaxPercent:    r.LongTermCapitalGainTaxPercent,
+		TaxModel:                         r.TaxModel,
+		TaxProfile:                       r.TaxProfile,
+		StateTaxModel:                    r.StateTaxModel,
+		ConsiderNetInvestmentIncomeTax:   r.ConsiderNetInvestmentIncomeTax,
+		ModifiedAdjustedGrossIncome:      r.ModifiedAdjustedGrossIncome,
+		ConsiderCapitalLoss:              r.ConsiderCapitalLoss,
+		OtherRealizedCapitalGains:        r.OtherRealizedCapitalGains,
+		CapitalLossCarryforward:          r.CapitalLossCarryforward,
+		OrdinaryIncomeTaxPercent:         r.OrdinaryIncomeTaxPercent,
 		ConsiderIncomeTaxOnVestedStock:   r.ConsiderIncomeTaxOnVestedStock,
 		IncomeTaxIncurredWhenStockVested: r.IncomeTaxIncurredWhenStockVested,
 		NumberOfStocksVested:             r.NumberOfStocksVested,
 		MarketValuePerShare:              r.MarketValuePerShare,
+		DeductCommissionFromCapitalGain:  r.DeductCommissionFromCapitalGain,
 	}
 }
 
-func (r *RsuOrder) CalculateEffectiveProfitOrLoss() float64 {
-	var effectiveTransactionCommission float64
-	effectiveTransactionCommission = 0
-	if r.ConsiderTransactionCommission {
-		effectiveTransactionCommission = float64(r.NumberOfTransactions) * r.CommissionPaidPerTransaction
+// CalculateEffectiveCommission returns the commission paid on the sale, if considered.
+func (r *RsuOrder) CalculateEffectiveCommission() Money {
+	if !r.ConsiderTransactionCommission {
+		return 0
 	}
-	return (float64(r.NumberOfSharesSold) * (r.SellingPricePerShare)) - (effectiveTransactionCommission)
+	return r.CommissionPaidPerTransaction.MulInt(r.NumberOfTransactions).RoundToCents()
+}
+
+func (r *RsuOrder) CalculateEffectiveProfitOrLoss() Money {
+	return r.SellingPricePerShare.MulShares(r.NumberOfSharesSold).RoundToCents() - r.CalculateEffectiveCommission()
 }
 
-func (r *RsuOrder) CalculateCapitalGainTaxAmount(profit float64) (float64, error) {
+// CalculateCapitalGainTaxAmount returns the tax on the profit, or the tax saved by a loss as a negative amount
+// when ConsiderCapitalLoss is set.
+func (r *RsuOrder) CalculateCapitalGainTaxAmount(profit Money) (Money, error) {
 	if profit < 0 {
-		return 0, fmt.Errorf("profit must be greater than or equal to zero")
+		if !r.ConsiderCapitalLoss {
+			return 0, nil
+		}
+		federalCapitalLossTaxBenefit, stateCapitalLossTaxBenefit, err := r.CalculateCapitalLossTaxBenefit(-profit)
+		if err != nil {
+			return 0, err
+		}
+		return -(federalCapitalLossTaxBenefit + stateCapitalLossTaxBenefit), nil
+	}
+
+	federalCapitalGainTaxAmount, err := r.CalculateFederalCapitalGainTaxAmount(profit)
+	if err != nil {
+		return 0, err
+	}
+	stateCapitalGainTaxAmount, err := r.CalculateStateCapitalGainTaxAmount(profit)
+	if err != nil {
+		return 0, err
+	}
+	return federalCapitalGainTaxAmount + stateCapitalGainTaxAmount, nil
+}
+
+// CalculateCapitalLossTaxBenefit returns the federal and state tax saved by a capital loss (a positive amount).
+func (r *RsuOrder) CalculateCapitalLossTaxBenefit(loss Money) (Money, Money, error) {
+	federalCapitalLossTaxBenefit, err := calculateCapitalLossTaxBenefit(r.TaxProfile.FilingStatus, loss, r.OtherRealizedCapitalGains,
+		r.CapitalLossCarryforward, r.CalculateFederalCapitalGainTaxAmount, func(ordinaryIncome Money) (Money, error) {
+			if r.TaxModel != nil {
+				return calculateOffsetOrdinaryIncomeTax(r.TaxModel, r.TaxProfile, ordinaryIncome)
+			}
+			return ordinaryIncome.Percent(r.OrdinaryIncomeTaxPercent).RoundToCents(), nil
+		})
+	if err != nil {
+		return 0, 0, err
+	}
+	stateCapitalLossTaxBenefit, err := calculateCapitalLossTaxBenefit(r.TaxProfile.FilingStatus, loss, r.OtherRealizedCapitalGains,
+		r.CapitalLossCarryforward, r.CalculateStateCapitalGainTaxAmount, func(ordinaryIncome Money) (Money, error) {
+			if r.StateTaxModel == nil {
+				return 0, nil
+			}
+			return calculateOffsetOrdinaryIncomeTax(r.StateTaxModel, r.TaxProfile, ordinaryIncome)
+		})
+	if err != nil {
+		return 0, 0, err
+	}
+	return federalCapitalLossTaxBenefit, stateCapitalLossTaxBenefit, nil
+}
+
+// CalculateFederalCapitalGainTaxAmount returns the capital gain tax from the TaxModel, or the flat percentages when not set.
+func (r *RsuOrder) CalculateFederalCapitalGainTaxAmount(profit Money) (Money, error) {
+	if r.TaxModel != nil {
+		return calculateTax(r.TaxModel, r.TaxProfile, r.CalculateCapitalGainIncomeType(), profit)
+	}
+	return profit.Percent(r.CalculateEffectiveCapitalGainTaxPercent()).RoundToCents(), nil
+}
+
+// CalculateStateCapitalGainTaxAmount returns the capital gain tax from the StateTaxModel, if set.
+func (r *RsuOrder) CalculateStateCapitalGainTaxAmount(profit Money) (Money, error) {
+	if r.StateTaxModel == nil {
+		return 0, nil
+	}
+	return calculateTax(r.StateTaxModel, r.TaxProfile, r.CalculateCapitalGainIncomeType(), profit)
+}
+
+// CalculateCapitalGainIncomeType returns how the capital gain is taxed by the TaxModel.
+// Gains are treated as short-term (ordinary income) unless the holding period is considered and long-term.
+func (r *RsuOrder) CalculateCapitalGainIncomeType() tax.IncomeType {
+	if r.ConsiderHoldingPeriod && r.CalculateHoldingPeriod() == LongTerm {
+		return tax.LongTermCapitalGain
+	}
+	return tax.OrdinaryIncome
+}
+
+// CalculateNetInvestmentIncomeTaxAmount returns the NIIT on the capital gain.
+func (r *RsuOrder) CalculateNetInvestmentIncomeTaxAmount(capitalGain Money) Money {
+	if !r.ConsiderNetInvestmentIncomeTax {
+		return 0
 	}
+	return calculateNetInvestmentIncomeTax(r.TaxProfile.FilingStatus, r.ModifiedAdjustedGrossIncome, capitalGain)
+}
 
-	return profit * r.CapitalGainTaxPercent / 100, nil
+// CalculateHoldingPeriod classifies the sale as short-term or long-term from the vest and sale dates.
+func (r *RsuOrder) CalculateHoldingPeriod() HoldingPeriod {
+	return CalculateHoldingPeriod(r.VestDate, r.SaleDate)
 }
 
-func (r *RsuOrder) CalculateIncomeTaxPerShare() (float64, error) {
+// CalculateEffectiveCapitalGainTaxPercent returns the capital gain tax rate applicable to the sale.
+func (r *RsuOrder) CalculateEffectiveCapitalGainTaxPercent() float64 {
+	if !r.ConsiderHoldingPeriod {
+		return r.CapitalGainTaxPercent
+	}
+	if r.CalculateHoldingPeriod() == LongTerm {
+		return r.LongTermCapitalGainTaxPercent
+	}
+	return r.ShortTermCapitalGainTaxPercent
+}
+
+func (r *RsuOrder) CalculateIncomeTaxPerShare() (Money, error) {
 	if r.NumberOfStocksVested <= 0 {
 		return 0, fmt.Errorf("number of stocks vested must be greater than zero")
 	}
-	return r.IncomeTaxIncurredWhenStockVested / float64(r.NumberOfStocksVested), nil
+	return r.IncomeTaxIncurredWhenStockVested.DivShares(r.NumberOfStocksVested), nil
 }
 
-func (r *RsuOrder) CalculateTotalIncomeTaxAmount() (float64, error) {
-	incomeTaxPerShare, err := r.CalculateIncomeTaxPerShare()
-	if err != nil {
-		return 0, err
+// CalculateTotalIncomeTaxAmount returns the income tax incurred at vest on the shares sold, rounded to the cent.
+// Selling every vested share returns the income tax incurred exactly.
+func (r *RsuOrder) CalculateTotalIncomeTaxAmount() (Money, error) {
+	if r.NumberOfStocksVested <= 0 {
+		return 0, fmt.Errorf("number of stocks vested must be greater than zero")
+	}
+	if r.NumberOfSharesSold == r.NumberOfStocksVested {
+		return r.IncomeTaxIncurredWhenStockVested, nil
 	}
-	return incomeTaxPerShare * float64(r.NumberOfSharesSold), nil
+	return r.IncomeTaxIncurredWhenStockVested.Prorate(r.NumberOfSharesSold, r.NumberOfStocksVested).RoundToCents(), nil
 }
 
 func (r *RsuOrder) CalculateRsuOrderSummary() (*RsuOrderSummary, error) {
 
-	totalSellingPrice := r.SellingPricePerShare * float64(r.NumberOfSharesSold)
-	effectiveTransactionCommission := float64(r.NumberOfTransactions) * r.CommissionPaidPerTransaction
+	totalSellingPrice := r.SellingPricePerShare.MulShares(r.NumberOfSharesSold).RoundToCents()
+	effectiveTransactionCommission := r.CalculateEffectiveCommission()
 	netResult := r.CalculateEffectiveProfitOrLoss()
 
-	var capitalGainTaxAmount float64
+	var federalCapitalGainTaxAmount, stateCapitalGainTaxAmount, capitalLossCarryforwardAmount Money
 
 	profitOrLossForCapitalGain := r.CalculateProfitOrLossForCapitalGain()
 	if profitOrLossForCapitalGain > 0 {
-		capitalGainTaxAmount, _ = r.CalculateCapitalGainTaxAmount(profitOrLossForCapitalGain)
+		var err error
+		federalCapitalGainTaxAmount, err = r.CalculateFederalCapitalGainTaxAmount(profitOrLossForCapitalGain)
+		if err != nil {
+			return nil, err
+		}
+		stateCapitalGainTaxAmount, err = r.CalculateStateCapitalGainTaxAmount(profitOrLossForCapitalGain)
+		if err != nil {
+			return nil, err
+		}
+	} else if profitOrLossForCapitalGain < 0 && r.ConsiderCapitalLoss {
+		capitalLoss := -profitOrLossForCapitalGain
+		federalCapitalLossTaxBenefit, stateCapitalLossTaxBenefit, err := r.CalculateCapitalLossTaxBenefit(capitalLoss)
+		if err != nil {
+			return nil, err
+		}
+		federalCapitalGainTaxAmount = -federalCapitalLossTaxBenefit
+		stateCapitalGainTaxAmount = -stateCapitalLossTaxBenefit
+		capitalLossCarryforwardAmount = applyCapitalLoss(r.TaxProfile.FilingStatus, capitalLoss,
+			r.OtherRealizedCapitalGains, r.CapitalLossCarryforward).Carryforward
 	}
+	capitalGainTaxAmount := federalCapitalGainTaxAmount + stateCapitalGainTaxAmount
 
-	totalIncomeTaxIncurred, err := r.CalculateTotalIncomeTaxAmount()
-	if err != nil {
-		return nil, err
+	var totalIncomeTaxIncurred Money
+	if r.ConsiderIncomeTaxOnVestedStock {
+		var err error
+		if totalIncomeTaxIncurred, err = r.CalculateTotalIncomeTaxAmount(); err != nil {
+			return nil, err
+		}
 	}
-	return &RsuOrderSummary{
-		RsuOrder:               r,
-		TotalSellingPrice:      totalSellingPrice,
-		EffectiveCommission:    effectiveTransactionCommission,
-		NetResult:              netResult,
-		CapitalGainTaxAmount:   capitalGainTaxAmount,
-		TotalIncomeTaxIncurred: totalIncomeTaxIncurred,
-	}, nil
+	rsuOrderSummary := &RsuOrderSummary{
+		RsuOrder:                       r,
+		TotalSellingPrice:              totalSellingPrice,
+		EffectiveCommission:            effectiveTransactionCommission,
+		NetResult:                      netResult,
+		CapitalGainTaxAmount:           capitalGainTaxAmount,
+		TotalIncomeTaxIncurred:         totalIncomeTaxIncurred,
+		EffectiveCapitalGainTaxPercent: r.CalculateEffectiveCapitalGainTaxPercent(),
+		FederalCapitalGainTaxAmount:    federalCapitalGainTaxAmount,
+		StateCapitalGainTaxAmount:      stateCapitalGainTaxAmount,
+		NetInvestmentIncomeTaxAmount:   r.CalculateNetInvestmentIncomeTaxAmount(profitOrLossForCapitalGain),
+		CapitalLossCarryforwardAmount:  capitalLossCarryforwardAmount,
+	}
+	if (r.TaxModel != nil || r.StateTaxModel != nil) && profitOrLossForCapitalGain > 0 {
+		rsuOrderSummary.EffectiveCapitalGainTaxPercent = capitalGainTaxAmount.Ratio(profitOrLossForCapitalGain) * 100
+	}
+	if r.ConsiderHoldingPeriod {
+		rsuOrderSummary.HoldingPeriod = r.CalculateHoldingPeriod()
+		rsuOrderSummary.LongTermDate = CalculateLongTermDate(r.VestDate)
+	}
+	return rsuOrderSummary, nil
 }
 
 // CalculateSellingPriceForTargetProfitPercent calculates the selling price required to achieve a target profit percentage.
-func (r *RsuOrder) CalculateSellingPriceForTargetProfitPercent(targetProfitPercent float64) (float64, error) {
+func (r *RsuOrder) CalculateSellingPriceForTargetProfitPercent(targetProfitPercent float64) (Money, error) {
+	solverResult, err := r.SolveSellingPriceForTargetProfitPercent(targetProfitPercent)
+	if err != nil {
+		return 0, err
+	}
+	return solverResult.Value, nil
+}
+
+// SolveSellingPriceForTargetProfitPercent finds the lowest selling price whose true profit reaches the target percentage
+// of the income tax incurred on the shares sold, returning a NoSolutionError when no price does.
+// Without income tax there is no cost to earn a percentage on, so every target is the break-even price.
+func (r *RsuOrder) SolveSellingPriceForTargetProfitPercent(targetProfitPercent float64) (*SolverResult[Money], error) {
 	if targetProfitPercent < 0 {
-		return 0, fmt.Errorf("target profit percent must be greater than or equal to 0")
+		return nil, fmt.Errorf("target profit percent must be greater than or equal to 0")
 	}
+	return r.solveSellingPrice(func(rsuOrderSummary *RsuOrderSummary) Money {
+		return rsuOrderSummary.TrueProfitOrLoss() - rsuOrderSummary.TotalIncomeTaxIncurred.Percent(targetProfitPercent).RoundToCents()
+	})
+}
+
+// SolveSellingPriceForTarget finds the lowest selling price whose true profit or net cash reaches the target amount,
+// returning a NoSolutionError when no price does.
+func (r *RsuOrder) SolveSellingPriceForTarget(saleTarget SaleTarget, targetAmount Money) (*SolverResult[Money], error) {
+	return r.solveSellingPrice(func(rsuOrderSummary *RsuOrderSummary) Money {
+		return rsuOrderSummary.SaleOutcome(saleTarget) - targetAmount
+	})
+}
+
+// solveSellingPrice finds the lowest selling price per share for which the residual of the order summary is zero or more.
+func (r *RsuOrder) solveSellingPrice(residual func(*RsuOrderSummary) Money) (*SolverResult[Money], error) {
 	if r.NumberOfSharesSold <= 0 {
-		return 0, fmt.Errorf("number of shares sold must be greater than zero")
+		return nil, fmt.Errorf("number of shares sold must be greater than zero")
 	}
-
-	// Calculate income tax per share incurred at vesting, if applicable
-	incomeTaxPerShare := 0.0
-	var err error
+	initialGuess := r.MarketValuePerShare
 	if r.ConsiderIncomeTaxOnVestedStock {
-		incomeTaxPerShare, err = r.CalculateIncomeTaxPerShare()
+		incomeTaxPerShare, err := r.CalculateIncomeTaxPerShare()
 		if err != nil {
-			return 0, err
+			return nil, err
 		}
+		initialGuess = max(initialGuess, incomeTaxPerShare)
 	}
-
-	// Initial effective cost per share is only the income tax per share
-	effectiveCostPerShare := incomeTaxPerShare
-	totalEffectiveCost := effectiveCostPerShare * float64(r.NumberOfSharesSold)
-
-	// Initial guess for selling price per share to achieve the target profit
-	estimatedSellingPrice := totalEffectiveCost / float64(r.NumberOfSharesSold) * (1 + targetProfitPercent/100)
-
-	// Iteratively adjust the selling price to achieve the target profit percent
-	for i := 0; i < 1000; i++ { // Limit iterations for safety
-		// Calculate total selling price and profit before tax
-		totalSellingPrice := estimatedSellingPrice * float64(r.NumberOfSharesSold)
-		profitBeforeTax := totalSellingPrice - totalEffectiveCost
-
-		// Subtract transaction commission, if applicable
-		totalTransactionCommission := 0.0
-		if r.ConsiderTransactionCommission {
-			totalTransactionCommission = float64(r.NumberOfTransactions) * r.CommissionPaidPerTransaction
-			profitBeforeTax -= totalTransactionCommission
-		}
-
-		// Calculate capital gains tax, only if selling price exceeds market value at vesting
-		capitalGainsTax := 0.0
-		if r.ConsiderCapitalGainTax && estimatedSellingPrice > r.MarketValuePerShare {
-			capitalGainPerShare := estimatedSellingPrice - r.MarketValuePerShare
-			totalCapitalGains := capitalGainPerShare * float64(r.NumberOfSharesSold)
-			capitalGainsTax = totalCapitalGains * (r.CapitalGainTaxPercent / 100)
-			profitBeforeTax -= capitalGainsTax
+	rsuOrder := r.Clone()
+	return solveBracketed(func(sellingPrice Money) (Money, error) {
+		rsuOrder.SellingPricePerShare = sellingPrice
+		rsuOrderSummary, err := rsuOrder.CalculateRsuOrderSummary()
+		if err != nil {
+			return 0, err
 		}
+		return residual(rsuOrderSummary), nil
+	}, max(initialGuess, NewMoney(1)), Money(1), maxSellingPricePerShare)
+}
 
-		// Calculate net profit percentage after all deductions
-		actualProfitPercent := (profitBeforeTax / totalEffectiveCost) * 100
-
-		// Check if the achieved profit percentage matches the target profit percentage
-		if math.Abs(actualProfitPercent-targetProfitPercent) < 0.01 { // Convergence condition
-			break
+// SolveSharesSoldForTarget finds the fewest shares to sell at SellingPricePerShare whose true profit or net cash
+// reaches the target amount, returning a NoSolutionError when no number of shares does.
+// Whole shares are sold unless fractionalShares, and the commission per transaction is paid however few shares are sold.
+func (r *RsuOrder) SolveSharesSoldForTarget(saleTarget SaleTarget, targetAmount Money, fractionalShares bool) (*SolverResult[Shares], error) {
+	if r.SellingPricePerShare <= 0 {
+		return nil, fmt.Errorf("selling price per share must be greater than zero")
+	}
+	if targetAmount <= 0 {
+		return nil, fmt.Errorf("target amount must be greater than zero")
+	}
+	rsuOrder := r.Clone()
+	return solveBracketed(func(sharesSold Shares) (Money, error) {
+		rsuOrder.NumberOfSharesSold = sharesSold
+		rsuOrderSummary, err := rsuOrder.CalculateRsuOrderSummary()
+		if err != nil {
+			return 0, err
 		}
+		return rsuOrderSummary.SaleOutcome(saleTarget) - targetAmount, nil
+	}, NewShares(targetAmount.Ratio(r.SellingPricePerShare)), shareStep(fractionalShares), maxSharesSold)
+}
 
-		// Adjust selling price per share based on the difference between actual and target profit percentages
-		estimatedSellingPrice += (targetProfitPercent - actualProfitPercent) / 100 * effectiveCostPerShare
+func (r *RsuOrder) CalculateProfitOrLossForCapitalGain() Money {
+	profitOrLoss := r.SellingPricePerShare.MulShares(r.NumberOfSharesSold).RoundToCents() - r.CalculateTotalCostBasis()
+	if r.DeductCommissionFromCapitalGain {
+		profitOrLoss -= r.CalculateEffectiveCommission()
 	}
-
-	return estimatedSellingPrice, nil
+	return profitOrLoss
 }
 
-func (r *RsuOrder) CalculateProfitOrLossForCapitalGain() float64 {
-	return (float64(r.NumberOfSharesSold) * (r.SellingPricePerShare)) - (r.MarketValuePerShare * float64(r.NumberOfSharesSold))
+// CalculateTotalCostBasis returns the market value at vest of the shares sold, rounded to the cent.
+func (r *RsuOrder) CalculateTotalCostBasis() Money {
+	return r.MarketValuePerShare.MulShares(r.NumberOfSharesSold).RoundToCents()
 }
@@ -25,22 +25,24 @@ package types
 
 import (
 	"fmt"
+	"github.com/leogps/lunar/pkg/tax"
 	"testing"
+	"time"
 )
 
 func TestRsuOrder_CalculateRsuOrderSummary(t *testing.T) {
 	rsuOrder := &RsuOrder{
-		SellingPricePerShare:             200.00,
-		NumberOfSharesSold:               1,
+		SellingPricePerShare:             NewMoney(200.00),
+		NumberOfSharesSold:               NewShares(1),
 		ConsiderTransactionCommission:    true,
-		CommissionPaidPerTransaction:     5.00,
+		CommissionPaidPerTransaction:     NewMoney(5.00),
 		NumberOfTransactions:             1,
 		ConsiderCapitalGainTax:           true,
 		CapitalGainTaxPercent:            24,
 		ConsiderIncomeTaxOnVestedStock:   true,
-		IncomeTaxIncurredWhenStockVested: 2166.12,
-		NumberOfStocksVested:             33,
-		MarketValuePerShare:              120.34,
+		IncomeTaxIncurredWhenStockVested: NewMoney(2166.12),
+		NumberOfStocksVested:             NewShares(33),
+		MarketValuePerShare:              NewMoney(120.34),
 	}
 
 	summary, err := rsuOrder.CalculateRsuOrderSummary()
@@ -50,25 +52,46 @@ func TestRsuOrder_CalculateRsuOrderSummary(t *testing.T) {
 	fmt.Println(summary.ToString())
 }
 
+func TestRsuOrder_CalculateRsuOrderSummaryWithoutCommission(t *testing.T) {
+	rsuOrder := &RsuOrder{
+		SellingPricePerShare:          NewMoney(200.00),
+		NumberOfSharesSold:            NewShares(1),
+		ConsiderTransactionCommission: false,
+		CommissionPaidPerTransaction:  NewMoney(5.00),
+		NumberOfTransactions:          1,
+		NumberOfStocksVested:          NewShares(1),
+		MarketValuePerShare:           NewMoney(120.34),
+	}
+
+	summary, err := rsuOrder.CalculateRsuOrderSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.EffectiveCommission != 0 || summary.NetResult != summary.TotalSellingPrice {
+		t.Errorf("expected no commission when it is not considered, got $%s with a $%s net result",
+			summary.EffectiveCommission, summary.NetResult)
+	}
+}
+
 func TestRsuOrder_CalculateSellingPriceForTargetProfitPercent(t *testing.T) {
 	rsuOrder := &RsuOrder{
-		NumberOfSharesSold:               1,
+		NumberOfSharesSold:               NewShares(1),
 		ConsiderTransactionCommission:    true,
-		CommissionPaidPerTransaction:     5.00,
+		CommissionPaidPerTransaction:     NewMoney(5.00),
 		NumberOfTransactions:             1,
 		ConsiderCapitalGainTax:           true,
 		CapitalGainTaxPercent:            24,
 		ConsiderIncomeTaxOnVestedStock:   true,
-		IncomeTaxIncurredWhenStockVested: 2166.12,
-		NumberOfStocksVested:             33,
-		MarketValuePerShare:              120.34,
+		IncomeTaxIncurredWhenStockVested: NewMoney(2166.12),
+		NumberOfStocksVested:             NewShares(33),
+		MarketValuePerShare:              NewMoney(120.34),
 	}
 
 	sellingPrice, err := rsuOrder.CalculateSellingPriceForTargetProfitPercent(0)
 	if err != nil {
 		t.Error(err)
 	}
-	fmt.Println(fmt.Sprintf("Selling Price: $%.2f", sellingPrice))
+	fmt.Println(fmt.Sprintf("Selling Price: $%s", sellingPrice))
 
 	rsuClone := rsuOrder.Clone()
 	rsuClone.SellingPricePerShare = sellingPrice
@@ -81,16 +104,16 @@ func TestRsuOrder_CalculateSellingPriceForTargetProfitPercent(t *testing.T) {
 
 func TestRsuOrder_CalculateSellingPriceFor5PercentProfit(t *testing.T) {
 	rsuOrder := &RsuOrder{
-		NumberOfSharesSold:               1,
+		NumberOfSharesSold:               NewShares(1),
 		ConsiderTransactionCommission:    true,
-		CommissionPaidPerTransaction:     5.00,
+		CommissionPaidPerTransaction:     NewMoney(5.00),
 		NumberOfTransactions:             1,
 		ConsiderCapitalGainTax:           true,
 		CapitalGainTaxPercent:            24,
 		ConsiderIncomeTaxOnVestedStock:   true,
-		IncomeTaxIncurredWhenStockVested: 2166.12,
-		NumberOfStocksVested:             33,
-		MarketValuePerShare:              120.34,
+		IncomeTaxIncurredWhenStockVested: NewMoney(2166.12),
+		NumberOfStocksVested:             NewShares(33),
+		MarketValuePerShare:              NewMoney(120.34),
 	}
 
 	sellingPrice, err := rsuOrder.CalculateSellingPriceForTargetProfitPercent(5)
@@ -110,16 +133,16 @@ func TestRsuOrder_CalculateSellingPriceFor5PercentProfit(t *testing.T) {
 
 func TestRsuOrder_CalculateSellingPriceFor290PercentProfit(t *testing.T) {
 	rsuOrder := &RsuOrder{
-		NumberOfSharesSold:               1,
+		NumberOfSharesSold:               NewShares(1),
 		ConsiderTransactionCommission:    true,
-		CommissionPaidPerTransaction:     5.00,
+		CommissionPaidPerTransaction:     NewMoney(5.00),
 		NumberOfTransactions:             1,
 		ConsiderCapitalGainTax:           true,
 		CapitalGainTaxPercent:            24,
 		ConsiderIncomeTaxOnVestedStock:   true,
-		IncomeTaxIncurredWhenStockVested: 2166.12,
-		NumberOfStocksVested:             33,
-		MarketValuePerShare:              190.39,
+		IncomeTaxIncurredWhenStockVested: NewMoney(2166.12),
+		NumberOfStocksVested:             NewShares(33),
+		MarketValuePerShare:              NewMoney(190.39),
 	}
 
 	sellingPrice, err := rsuOrder.CalculateSellingPriceForTargetProfitPercent(280)
@@ -136,3 +159,163 @@ func TestRsuOrder_CalculateSellingPriceFor290PercentProfit(t *testing.T) {
 	}
 	fmt.Println(summary.ToString())
 }
+
+func TestRsuOrder_CalculateRsuOrderSummaryWithHoldingPeriod(t *testing.T) {
+	rsuOrder := &RsuOrder{
+		SellingPricePerShare:           NewMoney(200.00),
+		NumberOfSharesSold:             NewShares(10),
+		ConsiderCapitalGainTax:         true,
+		ConsiderHoldingPeriod:          true,
+		VestDate:                       time.Date(2023, time.May, 20, 0, 0, 0, 0, time.UTC),
+		SaleDate:                       time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC),
+		ShortTermCapitalGainTaxPercent: 32,
+		LongTermCapitalGainTaxPercent:  15,
+		NumberOfStocksVested:           NewShares(33),
+		MarketValuePerShare:            NewMoney(150.00),
+	}
+
+	summary, err := rsuOrder.CalculateRsuOrderSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(summary.ToString())
+	if summary.HoldingPeriod != ShortTerm || summary.CapitalGainTaxAmount != NewMoney(160) {
+		t.Errorf("expected short-term tax of $160.00, got %s tax of $%s", summary.HoldingPeriod, summary.CapitalGainTaxAmount)
+	}
+	if summary.LongTermDate.Format(DateLayout) != "2024-05-21" {
+		t.Errorf("expected long-term date of 2024-05-21, got %s", summary.LongTermDate.Format(DateLayout))
+	}
+
+	rsuOrder.SaleDate = time.Date(2024, time.June, 3, 0, 0, 0, 0, time.UTC)
+	summary, err = rsuOrder.CalculateRsuOrderSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.HoldingPeriod != LongTerm || summary.CapitalGainTaxAmount != NewMoney(75) {
+		t.Errorf("expected long-term tax of $75.00, got %s tax of $%s", summary.HoldingPeriod, summary.CapitalGainTaxAmount)
+	}
+}
+
+func TestRsuOrder_CalculateRsuOrderSummaryWithTaxModel(t *testing.T) {
+	rsuOrder := &RsuOrder{
+		SellingPricePerShare:   NewMoney(300.00),
+		NumberOfSharesSold:     NewShares(100),
+		ConsiderCapitalGainTax: true,
+		NumberOfStocksVested:   NewShares(100),
+		MarketValuePerShare:    NewMoney(100.00),
+		TaxModel:               tax.Federal,
+		TaxProfile: tax.Profile{
+			FilingStatus: tax.Single,
+			TaxYear:      2024,
+			OtherIncome:  180000,
+		},
+	}
+
+	// $20,000 short-term gain spans the 24% ($11,950) and 32% ($8,050) brackets.
+	summary, err := rsuOrder.CalculateRsuOrderSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(summary.ToString())
+	expectedCapitalGainTax := 11950*0.24 + 8050*0.32
+	if summary.CapitalGainTaxAmount != NewMoney(expectedCapitalGainTax).RoundToCents() {
+		t.Errorf("expected capital gain tax of $%.2f, got $%s", expectedCapitalGainTax, summary.CapitalGainTaxAmount)
+	}
+
+	rsuOrder.TaxProfile.TaxYear = 1999
+	if _, err = rsuOrder.CalculateRsuOrderSummary(); err == nil {
+		t.Error("expected error for unsupported tax year")
+	}
+}
+
+func TestRsuOrder_CalculateRsuOrderSummaryWithNetInvestmentIncomeTax(t *testing.T) {
+	rsuOrder := &RsuOrder{
+		SellingPricePerShare:           NewMoney(300.00),
+		NumberOfSharesSold:             NewShares(100),
+		ConsiderCapitalGainTax:         true,
+		CapitalGainTaxPercent:          15,
+		NumberOfStocksVested:           NewShares(100),
+		MarketValuePerShare:            NewMoney(100.00),
+		ConsiderNetInvestmentIncomeTax: true,
+		ModifiedAdjustedGrossIncome:    NewMoney(190000),
+		TaxProfile:                     tax.Profile{FilingStatus: tax.Single},
+	}
+
+	// Only $10,000 of the $20,000 gain is above the $200,000 single threshold.
+	summary, err := rsuOrder.CalculateRsuOrderSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(summary.ToString())
+	if summary.NetInvestmentIncomeTaxAmount != NewMoney(380) {
+		t.Errorf("expected NIIT of $380.00, got $%s", summary.NetInvestmentIncomeTaxAmount)
+	}
+	expectedTrueProfit := 30000 - 3000 - 380.0
+	if summary.TrueProfitOrLoss() != NewMoney(expectedTrueProfit).RoundToCents() {
+		t.Errorf("expected true profit of $%.2f, got $%s", expectedTrueProfit, summary.TrueProfitOrLoss())
+	}
+}
+
+func TestRsuOrder_CalculateRsuOrderSummaryWithCapitalLoss(t *testing.T) {
+	rsuOrder := &RsuOrder{
+		SellingPricePerShare:      NewMoney(80.00),
+		NumberOfSharesSold:        NewShares(100),
+		ConsiderCapitalGainTax:    true,
+		CapitalGainTaxPercent:     24,
+		NumberOfStocksVested:      NewShares(100),
+		MarketValuePerShare:       NewMoney(100.00),
+		ConsiderCapitalLoss:       true,
+		OtherRealizedCapitalGains: NewMoney(500),
+		OrdinaryIncomeTaxPercent:  30,
+	}
+
+	// The $2,000 loss offsets the $500 gain at 24% and $1,500 of ordinary income at 30%.
+	summary, err := rsuOrder.CalculateRsuOrderSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(summary.ToString())
+	if summary.CapitalGainTaxAmount != NewMoney(-570) || summary.CapitalLossCarryforwardAmount != 0 {
+		t.Errorf("expected $-570.00 capital gain tax and no carryforward, got $%s and $%s",
+			summary.CapitalGainTaxAmount, summary.CapitalLossCarryforwardAmount)
+	}
+	if summary.ProfitOrLossAfterCapitalGainsTax() != NewMoney(8000+570).RoundToCents() {
+		t.Errorf("expected the tax benefit to be added to the $8000.00 proceeds, got $%s", summary.ProfitOrLossAfterCapitalGainsTax())
+	}
+
+	// A $2,000 carryforward already uses up the gain and half of the ordinary income offset,
+	// leaving only $1,500 of ordinary income for this loss and $500 to carry forward.
+	rsuOrder.CapitalLossCarryforward = NewMoney(2000)
+	summary, err = rsuOrder.CalculateRsuOrderSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.CapitalGainTaxAmount != NewMoney(-450) || summary.CapitalLossCarryforwardAmount != NewMoney(500) {
+		t.Errorf("expected $-450.00 capital gain tax and $500.00 carryforward, got $%s and $%s",
+			summary.CapitalGainTaxAmount, summary.CapitalLossCarryforwardAmount)
+	}
+
+	rsuOrder.ConsiderCapitalLoss = false
+	capitalGainTaxAmount, err := rsuOrder.CalculateCapitalGainTaxAmount(NewMoney(-2000))
+	if err != nil || capitalGainTaxAmount != 0 {
+		t.Errorf("expected no tax benefit when capital losses are not considered, got $%s (%v)", capitalGainTaxAmount, err)
+	}
+}
+
+func TestRsuOrder_CalculateTotalIncomeTaxAmountWithFractionalShares(t *testing.T) {
+	rsuOrder := &RsuOrder{
+		NumberOfSharesSold:               NewShares(3.25),
+		NumberOfStocksVested:             NewShares(10.5),
+		ConsiderIncomeTaxOnVestedStock:   true,
+		IncomeTaxIncurredWhenStockVested: NewMoney(1000),
+	}
+
+	// $1,000 * 3.25 / 10.5 = $309.5238, rounded once rather than going through $95.2381 per share.
+	incomeTax, err := rsuOrder.CalculateTotalIncomeTaxAmount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if incomeTax != NewMoney(309.52) {
+		t.Errorf("expected $309.52 of income tax, got $%s", incomeTax)
+	}
+}
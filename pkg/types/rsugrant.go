@@ -0,0 +1,229 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package types
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VestCadence is how often an RSU grant vests after the cliff.
+type VestCadence int
+
+const (
+	Monthly VestCadence = iota
+	Quarterly
+	// Custom vests according to the grant's tranches
+	Custom
+)
+
+var vestCadenceNames = map[VestCadence]string{
+	Monthly:   "monthly",
+	Quarterly: "quarterly",
+	Custom:    "custom",
+}
+
+func (v VestCadence) String() string {
+	return vestCadenceNames[v]
+}
+
+// ParseVestCadence parses monthly, quarterly or custom.
+func ParseVestCadence(value string) (VestCadence, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for cadence, name := range vestCadenceNames {
+		if name == value {
+			return cadence, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown vest cadence: %s (expected monthly, quarterly or custom)", value)
+}
+
+// VestTranche is a custom vest of Percent of the grant MonthsFromGrant months after the grant date.
+type VestTranche struct {
+	MonthsFromGrant int
+	Percent         float64
+}
+
+// ParseVestTranches parses comma-separated months:percent pairs, e.g. "12:25,24:25,36:25,48:25".
+func ParseVestTranches(value string) ([]VestTranche, error) {
+	var tranches []VestTranche
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(pair), ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid tranche %q (expected months:percent)", pair)
+		}
+		monthsFromGrant, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid tranche months %q", parts[0])
+		}
+		percent, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tranche percent %q", parts[1])
+		}
+		tranches = append(tranches, VestTranche{MonthsFromGrant: monthsFromGrant, Percent: percent})
+	}
+	return tranches, nil
+}
+
+// RsuGrant describes an RSU grant and how it vests.
+type RsuGrant struct {
+	GrantDate  time.Time
+	TotalUnits int
+
+	// VestingMonths is the total vesting period for Monthly and Quarterly cadences.
+	VestingMonths int
+	// CliffMonths defers every vest before it to the cliff date.
+	CliffMonths int
+	Cadence     VestCadence
+	// Tranches are used instead of VestingMonths when the cadence is Custom.
+	Tranches []VestTranche
+}
+
+// VestEvent is a single dated vest of an RSU grant.
+type VestEvent struct {
+	Date                  time.Time
+	Units                 int
+	CumulativeVestedUnits int
+	UnvestedUnits         int
+}
+
+// CalculateGrossValue returns the projected value of the units vesting in this event at pricePerShare.
+func (v VestEvent) CalculateGrossValue(pricePerShare Money) Money {
+	return pricePerShare.MulInt(v.Units).RoundToCents()
+}
+
+// vestPoint is the cumulative fraction of the grant vested a number of months after the grant date.
+type vestPoint struct {
+	monthsFromGrant    int
+	cumulativeFraction float64
+}
+
+func (g *RsuGrant) calculateVestPoints() ([]vestPoint, error) {
+	if g.Cadence == Custom {
+		if len(g.Tranches) == 0 {
+			return nil, fmt.Errorf("custom cadence requires at least one tranche")
+		}
+		var points []vestPoint
+		var cumulativePercent float64
+		previousMonths := 0
+		for _, tranche := range g.Tranches {
+			if tranche.MonthsFromGrant <= previousMonths {
+				return nil, fmt.Errorf("tranche months must be positive and increasing")
+			}
+			if tranche.Percent <= 0 {
+				return nil, fmt.Errorf("tranche percent must be greater than zero")
+			}
+			cumulativePercent += tranche.Percent
+			points = append(points, vestPoint{monthsFromGrant: tranche.MonthsFromGrant, cumulativeFraction: cumulativePercent / 100})
+			previousMonths = tranche.MonthsFromGrant
+		}
+		if math.Abs(cumulativePercent-100) > 0.0001 {
+			return nil, fmt.Errorf("tranche percents must add up to 100, got %.2f", cumulativePercent)
+		}
+		return points, nil
+	}
+
+	monthsPerVest := 1
+	if g.Cadence == Quarterly {
+		monthsPerVest = 3
+	}
+	if g.VestingMonths <= 0 || g.VestingMonths%monthsPerVest != 0 {
+		return nil, fmt.Errorf("vesting months must be a positive multiple of %d for %s vesting", monthsPerVest, g.Cadence)
+	}
+	var points []vestPoint
+	for months := monthsPerVest; months <= g.VestingMonths; months += monthsPerVest {
+		points = append(points, vestPoint{monthsFromGrant: months, cumulativeFraction: float64(months) / float64(g.VestingMonths)})
+	}
+	return points, nil
+}
+
+// CalculateVestSchedule generates the dated vest events of the grant.
+// Units are whole shares: each event vests the floor of the cumulative units less what has vested already,
+// so rounding is carried forward and the last event vests the remainder.
+func (g *RsuGrant) CalculateVestSchedule() ([]VestEvent, error) {
+	if g.TotalUnits <= 0 {
+		return nil, fmt.Errorf("total units must be greater than zero")
+	}
+	if g.CliffMonths < 0 {
+		return nil, fmt.Errorf("cliff months must not be negative")
+	}
+	points, err := g.calculateVestPoints()
+	if err != nil {
+		return nil, err
+	}
+	if g.CliffMonths > points[len(points)-1].monthsFromGrant {
+		return nil, fmt.Errorf("cliff of %d months is after the last vest", g.CliffMonths)
+	}
+
+	cumulativeUnitsAt := func(index int) int {
+		if index == len(points)-1 {
+			return g.TotalUnits
+		}
+		return int(math.Floor(float64(g.TotalUnits)*points[index].cumulativeFraction + 1e-9))
+	}
+
+	var events []VestEvent
+	vestedUnits := 0
+	addEvent := func(monthsFromGrant int, cumulativeUnits int) {
+		if cumulativeUnits == vestedUnits {
+			return
+		}
+		events = append(events, VestEvent{
+			Date:                  addMonths(g.GrantDate, monthsFromGrant),
+			Units:                 cumulativeUnits - vestedUnits,
+			CumulativeVestedUnits: cumulativeUnits,
+			UnvestedUnits:         g.TotalUnits - cumulativeUnits,
+		})
+		vestedUnits = cumulativeUnits
+	}
+
+	deferredIndex := -1
+	for index, point := range points {
+		// Vests before the cliff are deferred to the cliff date.
+		if point.monthsFromGrant < g.CliffMonths {
+			deferredIndex = index
+			continue
+		}
+		if deferredIndex >= 0 && vestedUnits == 0 && point.monthsFromGrant > g.CliffMonths {
+			addEvent(g.CliffMonths, cumulativeUnitsAt(deferredIndex))
+		}
+		addEvent(point.monthsFromGrant, cumulativeUnitsAt(index))
+	}
+	return events, nil
+}
+
+// addMonths adds months to date, clamping to the last day of the month instead of overflowing (Jan 31 + 1 = Feb 28).
+func addMonths(date time.Time, months int) time.Time {
+	firstOfMonth := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location()).AddDate(0, months, 0)
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	day := date.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(firstOfMonth.Year(), firstOfMonth.Month(), day,
+		date.Hour(), date.Minute(), date.Second(), date.Nanosecond(), date.Location())
+}
@@ -0,0 +1,121 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package types
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRsuGrant_CalculateVestScheduleMonthlyWithCliff(t *testing.T) {
+	rsuGrant := &RsuGrant{
+		GrantDate:     time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC),
+		TotalUnits:    1000,
+		VestingMonths: 48,
+		CliffMonths:   12,
+		Cadence:       Monthly,
+	}
+	vestEvents, err := rsuGrant.CalculateVestSchedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, vestEvent := range vestEvents {
+		fmt.Printf("%s %d %d %d\n", vestEvent.Date.Format(DateLayout), vestEvent.Units,
+			vestEvent.CumulativeVestedUnits, vestEvent.UnvestedUnits)
+	}
+	if len(vestEvents) != 37 {
+		t.Fatalf("expected 37 vest events, got %d", len(vestEvents))
+	}
+	if vestEvents[0].Units != 250 || !vestEvents[0].Date.Equal(time.Date(2025, time.January, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected 250 units at the cliff on 2025-01-31, got %d on %s", vestEvents[0].Units, vestEvents[0].Date.Format(DateLayout))
+	}
+	// Month-end grants vest on the last day of shorter months.
+	if !vestEvents[1].Date.Equal(time.Date(2025, time.February, 28, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected second vest on 2025-02-28, got %s", vestEvents[1].Date.Format(DateLayout))
+	}
+	last := vestEvents[len(vestEvents)-1]
+	if last.CumulativeVestedUnits != 1000 || last.UnvestedUnits != 0 {
+		t.Errorf("expected the grant to be fully vested, got %d vested and %d unvested", last.CumulativeVestedUnits, last.UnvestedUnits)
+	}
+}
+
+func TestRsuGrant_CalculateVestScheduleQuarterlyWithOffCycleCliff(t *testing.T) {
+	rsuGrant := &RsuGrant{
+		GrantDate:     time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC),
+		TotalUnits:    100,
+		VestingMonths: 48,
+		CliffMonths:   13,
+		Cadence:       Quarterly,
+	}
+	vestEvents, err := rsuGrant.CalculateVestSchedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first year (25 units) vests at the 13 month cliff, then quarterly from month 15.
+	if vestEvents[0].Units != 25 || !vestEvents[0].Date.Equal(time.Date(2025, time.April, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected 25 units on 2025-04-15, got %d on %s", vestEvents[0].Units, vestEvents[0].Date.Format(DateLayout))
+	}
+	if !vestEvents[1].Date.Equal(time.Date(2025, time.June, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected second vest on 2025-06-15, got %s", vestEvents[1].Date.Format(DateLayout))
+	}
+	totalUnits := 0
+	for _, vestEvent := range vestEvents {
+		totalUnits += vestEvent.Units
+	}
+	if totalUnits != 100 {
+		t.Errorf("expected 100 units to vest, got %d", totalUnits)
+	}
+}
+
+func TestRsuGrant_CalculateVestScheduleCustomTranches(t *testing.T) {
+	tranches, err := ParseVestTranches("12:10, 24:20, 36:30, 48:40")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsuGrant := &RsuGrant{
+		GrantDate:  time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC),
+		TotalUnits: 333,
+		Cadence:    Custom,
+		Tranches:   tranches,
+	}
+	vestEvents, err := rsuGrant.CalculateVestSchedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedUnits := []int{33, 66, 100, 134}
+	if len(vestEvents) != len(expectedUnits) {
+		t.Fatalf("expected %d vest events, got %d", len(expectedUnits), len(vestEvents))
+	}
+	for index, vestEvent := range vestEvents {
+		if vestEvent.Units != expectedUnits[index] {
+			t.Errorf("tranche %d: expected %d units, got %d", index, expectedUnits[index], vestEvent.Units)
+		}
+	}
+
+	rsuGrant.Tranches = []VestTranche{{MonthsFromGrant: 12, Percent: 50}}
+	if _, err = rsuGrant.CalculateVestSchedule(); err == nil {
+		t.Error("expected error when tranche percents do not add up to 100")
+	}
+}
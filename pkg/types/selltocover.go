@@ -0,0 +1,150 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SellToCover describes the shares a broker withholds or sells at vest to cover the tax withholding.
+type SellToCover struct {
+	SharesVested        Shares
+	MarketValuePerShare Money
+	// WithholdingRatePercents are added up, e.g. federal supplemental, Social Security, Medicare and state.
+	WithholdingRatePercents []float64
+	// SalePricePerShare is the price the covering shares are sold at; the market value at vest is used when zero.
+	SalePricePerShare Money
+	// FractionalShares sells just the fraction of a share needed when the broker supports it; otherwise whole
+	// shares are sold.
+	FractionalShares bool
+}
+
+type SellToCoverSummary struct {
+	SellToCover        *SellToCover
+	VestValue          Money
+	WithholdingPercent float64
+	TaxWithheld        Money
+	SharesSold         Shares
+	SaleProceeds       Money
+	CashRefunded       Money
+	NetSharesDeposited Shares
+}
+
+func (s *SellToCoverSummary) ToString() string {
+	var sb strings.Builder
+
+	sb.WriteString("Sell-To-Cover Summary:\n")
+	sb.WriteString(fmt.Sprintf("  Vest Value:           $%s\n", s.VestValue))
+	sb.WriteString(fmt.Sprintf("  Withholding Percent:  %.2f%%\n", s.WithholdingPercent))
+	sb.WriteString(fmt.Sprintf("  Tax Withheld:         $%s\n", s.TaxWithheld))
+	sb.WriteString(fmt.Sprintf("  Shares Sold:          %s\n", s.SharesSold))
+	sb.WriteString(fmt.Sprintf("  Sale Proceeds:        $%s\n", s.SaleProceeds))
+	sb.WriteString(fmt.Sprintf("  Cash Refunded:        $%s\n", s.CashRefunded))
+	sb.WriteString(fmt.Sprintf("  Net Shares Deposited: %s\n", s.NetSharesDeposited))
+	return sb.String()
+}
+
+// ApplyTo feeds the withheld tax and the vested shares into the RSU order.
+func (s *SellToCoverSummary) ApplyTo(rsuOrder *RsuOrder) {
+	rsuOrder.ConsiderIncomeTaxOnVestedStock = true
+	rsuOrder.IncomeTaxIncurredWhenStockVested = s.TaxWithheld
+	rsuOrder.NumberOfStocksVested = s.SellToCover.SharesVested
+}
+
+// ParseWithholdingRatePercents parses comma-separated percents, e.g. "22,6.2,1.45".
+func ParseWithholdingRatePercents(value string) ([]float64, error) {
+	var percents []float64
+	for _, percentValue := range strings.Split(value, ",") {
+		percent, err := strconv.ParseFloat(strings.TrimSpace(percentValue), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid withholding percent %q", percentValue)
+		}
+		percents = append(percents, percent)
+	}
+	return percents, nil
+}
+
+func (s *SellToCover) CalculateWithholdingPercent() float64 {
+	var withholdingPercent float64
+	for _, percent := range s.WithholdingRatePercents {
+		withholdingPercent += percent
+	}
+	return withholdingPercent
+}
+
+func (s *SellToCover) calculateSalePricePerShare() Money {
+	if s.SalePricePerShare > 0 {
+		return s.SalePricePerShare
+	}
+	return s.MarketValuePerShare
+}
+
+// calculateSharesSold returns the fewest shares whose proceeds cover the withheld tax.
+func (s *SellToCover) calculateSharesSold(taxWithheld Money, salePricePerShare Money) Shares {
+	if !s.FractionalShares {
+		return WholeShares(int((taxWithheld + salePricePerShare - 1) / salePricePerShare))
+	}
+	sharesSold := Shares(mulDiv(int64(taxWithheld), shareScale, int64(salePricePerShare)))
+	for salePricePerShare.MulShares(sharesSold).RoundToCents() < taxWithheld {
+		sharesSold++
+	}
+	return sharesSold
+}
+
+// CalculateSellToCoverSummary computes the shares sold to cover the withholding on the vest value.
+// Whole shares are rounded up unless FractionalShares is set, so the proceeds above the withheld tax are refunded as cash.
+func (s *SellToCover) CalculateSellToCoverSummary() (*SellToCoverSummary, error) {
+	if s.SharesVested <= 0 {
+		return nil, fmt.Errorf("number of shares vested must be greater than zero")
+	}
+	if s.MarketValuePerShare <= 0 {
+		return nil, fmt.Errorf("market value per share at vest must be greater than zero")
+	}
+	withholdingPercent := s.CalculateWithholdingPercent()
+	if withholdingPercent < 0 || withholdingPercent >= 100 {
+		return nil, fmt.Errorf("withholding percent must be between 0 and 100, got %.2f", withholdingPercent)
+	}
+
+	vestValue := s.MarketValuePerShare.MulShares(s.SharesVested).RoundToCents()
+	taxWithheld := vestValue.Percent(withholdingPercent).RoundToCents()
+	salePricePerShare := s.calculateSalePricePerShare()
+	sharesSold := s.calculateSharesSold(taxWithheld, salePricePerShare)
+	if sharesSold > s.SharesVested {
+		return nil, fmt.Errorf("%s shares needed to cover the withholding but only %s vested", sharesSold, s.SharesVested)
+	}
+	saleProceeds := salePricePerShare.MulShares(sharesSold).RoundToCents()
+
+	return &SellToCoverSummary{
+		SellToCover:        s,
+		VestValue:          vestValue,
+		WithholdingPercent: withholdingPercent,
+		TaxWithheld:        taxWithheld,
+		SharesSold:         sharesSold,
+		SaleProceeds:       saleProceeds,
+		CashRefunded:       saleProceeds - taxWithheld,
+		NetSharesDeposited: s.SharesVested - sharesSold,
+	}, nil
+}
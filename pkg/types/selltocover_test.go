@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package types
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/tax"
+	"testing"
+)
+
+func TestSellToCover_CalculateSellToCoverSummary(t *testing.T) {
+	sellToCover := &SellToCover{
+		SharesVested:            NewShares(100),
+		MarketValuePerShare:     NewMoney(150),
+		WithholdingRatePercents: tax.DefaultSupplementalWithholdingPercents(),
+	}
+	summary, err := sellToCover.CalculateSellToCoverSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(summary.ToString())
+
+	// $15,000 * 29.65% = $4,447.50 withheld, covered by 30 shares ($4,500).
+	if summary.TaxWithheld != NewMoney(4447.5) {
+		t.Errorf("expected $4447.50 withheld, got $%s", summary.TaxWithheld)
+	}
+	if summary.SharesSold != NewShares(30) || summary.NetSharesDeposited != NewShares(70) {
+		t.Errorf("expected 30 shares sold and 70 deposited, got %s and %s", summary.SharesSold, summary.NetSharesDeposited)
+	}
+	if summary.CashRefunded != NewMoney(52.5) {
+		t.Errorf("expected $52.50 refunded, got $%s", summary.CashRefunded)
+	}
+
+	rsuOrder := &RsuOrder{NumberOfSharesSold: NewShares(70)}
+	summary.ApplyTo(rsuOrder)
+	if !rsuOrder.ConsiderIncomeTaxOnVestedStock || rsuOrder.NumberOfStocksVested != NewShares(100) ||
+		rsuOrder.IncomeTaxIncurredWhenStockVested != NewMoney(4447.5) {
+		t.Errorf("expected the sell-to-cover results to be applied to the RSU order, got %+v", rsuOrder)
+	}
+
+	sellToCover.WithholdingRatePercents = []float64{60, 50}
+	if _, err = sellToCover.CalculateSellToCoverSummary(); err == nil {
+		t.Error("expected error for withholding above 100%")
+	}
+}
+
+func TestSellToCover_CalculateSellToCoverSummaryWithFractionalShares(t *testing.T) {
+	sellToCover := &SellToCover{
+		SharesVested:            NewShares(100.5),
+		MarketValuePerShare:     NewMoney(150),
+		WithholdingRatePercents: []float64{22},
+		FractionalShares:        true,
+	}
+	summary, err := sellToCover.CalculateSellToCoverSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(summary.ToString())
+
+	// $15,075 * 22% = $3,316.50 withheld, covered by exactly 22.11 shares with nothing refunded.
+	if summary.SharesSold != NewShares(22.11) || summary.NetSharesDeposited != NewShares(78.39) {
+		t.Errorf("expected 22.11 shares sold and 78.39 deposited, got %s and %s", summary.SharesSold, summary.NetSharesDeposited)
+	}
+	if summary.CashRefunded != 0 {
+		t.Errorf("expected nothing refunded, got $%s", summary.CashRefunded)
+	}
+
+	sellToCover.FractionalShares = false
+	summary, err = sellToCover.CalculateSellToCoverSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.SharesSold != NewShares(23) || summary.CashRefunded != NewMoney(133.5) {
+		t.Errorf("expected 23 whole shares sold and $133.50 refunded, got %s and $%s", summary.SharesSold, summary.CashRefunded)
+	}
+}
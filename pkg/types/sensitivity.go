@@ -0,0 +1,219 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package types
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// maxSensitivitySteps bounds the number of rows or columns in a sensitivity grid.
+const maxSensitivitySteps = 100
+
+// SensitivityVariable is what varies across the columns of a sensitivity grid; the rows are always selling prices.
+type SensitivityVariable int
+
+const (
+	// CapitalGainTaxRateVariable varies the flat federal capital gains tax percent.
+	CapitalGainTaxRateVariable SensitivityVariable = iota
+	// SharesSoldVariable varies the number of shares sold.
+	SharesSoldVariable
+	// MarketValueAtVestVariable varies the (FMV) market value per share at vest, for RSU orders only.
+	MarketValueAtVestVariable
+)
+
+func (s SensitivityVariable) String() string {
+	switch s {
+	case CapitalGainTaxRateVariable:
+		return "Capital Gain Tax %"
+	case SharesSoldVariable:
+		return "Shares Sold"
+	case MarketValueAtVestVariable:
+		return "FMV at Vest"
+	default:
+		return "Unknown"
+	}
+}
+
+// Format formats a column value with the unit of the variable.
+func (s SensitivityVariable) Format(value float64) string {
+	switch s {
+	case CapitalGainTaxRateVariable:
+		return fmt.Sprintf("%s%%", strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", value), "0"), "."))
+	case SharesSoldVariable:
+		return NewShares(value).String()
+	case MarketValueAtVestVariable:
+		return fmt.Sprintf("$%s", NewMoney(value))
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// SensitivityVariables lists the variables in the order they are offered.
+func SensitivityVariables() []SensitivityVariable {
+	return []SensitivityVariable{CapitalGainTaxRateVariable, SharesSoldVariable, MarketValueAtVestVariable}
+}
+
+func ParseSensitivityVariable(value string) (SensitivityVariable, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "rate", "tax-rate":
+		return CapitalGainTaxRateVariable, nil
+	case "shares", "quantity":
+		return SharesSoldVariable, nil
+	case "fmv", "vest-price":
+		return MarketValueAtVestVariable, nil
+	default:
+		return 0, fmt.Errorf("invalid sensitivity variable: %s, valid values: rate, shares, fmv", value)
+	}
+}
+
+// SensitivityRange lists the values from start to end (inclusive) in steps, e.g. for the rows or columns of a grid.
+func SensitivityRange[T Money | float64](start T, end T, step T) ([]T, error) {
+	if step <= 0 {
+		return nil, fmt.Errorf("step must be greater than zero")
+	}
+	if end < start {
+		return nil, fmt.Errorf("end must be greater than or equal to start")
+	}
+	// The tolerance keeps the end of a float64 range such as 0.1 steps from being lost to rounding
+	steps := int(math.Floor(float64(end-start)/float64(step)+1e-9)) + 1
+	if steps > maxSensitivitySteps {
+		return nil, fmt.Errorf("range has %d steps, at most %d are supported", steps, maxSensitivitySteps)
+	}
+	values := make([]T, steps)
+	for index := range values {
+		values[index] = start + T(index)*step
+	}
+	return values, nil
+}
+
+// SensitivityGrid is the true profit or loss of an order for every combination of a selling price and a value
+// of the variable.
+type SensitivityGrid struct {
+	Variable      SensitivityVariable
+	SellingPrices []Money
+	Values        []float64
+	// TrueProfitOrLoss holds a row per selling price and a column per value.
+	TrueProfitOrLoss [][]Money
+}
+
+// calculateSensitivityGrid evaluates the true profit or loss for each cell of the grid.
+// Varying the capital gain tax rate applies it as a flat rate whatever the holding period, so it cannot be combined
+// with progressive tax brackets. Varying the FMV at vest of an RSU order moves both the basis and the income tax
+// paid at vest, which keeps the rate the recorded tax bears to the vest income.
+func calculateSensitivityGrid(variable SensitivityVariable, sellingPrices []Money, values []float64,
+	trueProfitOrLoss func(sellingPrice Money, value float64) (Money, error)) (*SensitivityGrid, error) {
+	if len(sellingPrices) == 0 || len(values) == 0 {
+		return nil, fmt.Errorf("at least one selling price and one %s value are required", variable)
+	}
+	sensitivityGrid := &SensitivityGrid{
+		Variable:         variable,
+		SellingPrices:    sellingPrices,
+		Values:           values,
+		TrueProfitOrLoss: make([][]Money, len(sellingPrices)),
+	}
+	for row, sellingPrice := range sellingPrices {
+		sensitivityGrid.TrueProfitOrLoss[row] = make([]Money, len(values))
+		for column, value := range values {
+			cell, err := trueProfitOrLoss(sellingPrice, value)
+			if err != nil {
+				return nil, fmt.Errorf("selling price $%s, %s %s: %w", sellingPrice.StringPerShare(), variable,
+					variable.Format(value), err)
+			}
+			sensitivityGrid.TrueProfitOrLoss[row][column] = cell
+		}
+	}
+	return sensitivityGrid, nil
+}
+
+// CalculateSensitivityGrid evaluates the true profit or loss of the order at each selling price and value of the variable.
+func (e *EsppOrder) CalculateSensitivityGrid(variable SensitivityVariable, sellingPrices []Money, values []float64) (*SensitivityGrid, error) {
+	switch variable {
+	case CapitalGainTaxRateVariable:
+		if e.TaxModel != nil {
+			return nil, fmt.Errorf("the capital gain tax rate cannot vary with progressive tax brackets")
+		}
+	case SharesSoldVariable:
+	default:
+		return nil, fmt.Errorf("%s does not apply to ESPP orders", variable)
+	}
+	esppOrder := e.Clone()
+	return calculateSensitivityGrid(variable, sellingPrices, values, func(sellingPrice Money, value float64) (Money, error) {
+		esppOrder.SellingPricePerShare = sellingPrice
+		if variable == CapitalGainTaxRateVariable {
+			esppOrder.ConsiderCapitalGainTax = true
+			esppOrder.CapitalGainTaxPercent = value
+			esppOrder.ShortTermCapitalGainTaxPercent = value
+			esppOrder.LongTermCapitalGainTaxPercent = value
+		} else {
+			esppOrder.NumberOfSharesSold = NewShares(value)
+		}
+		esppOrderSummary, err := esppOrder.CalculateEsppOrderSummary()
+		if err != nil {
+			return 0, err
+		}
+		return esppOrderSummary.TrueProfitOrLoss(), nil
+	})
+}
+
+// CalculateSensitivityGrid evaluates the true profit or loss of the order at each selling price and value of the variable.
+func (r *RsuOrder) CalculateSensitivityGrid(variable SensitivityVariable, sellingPrices []Money, values []float64) (*SensitivityGrid, error) {
+	if variable == CapitalGainTaxRateVariable && r.TaxModel != nil {
+		return nil, fmt.Errorf("the capital gain tax rate cannot vary with progressive tax brackets")
+	}
+	rsuOrder := r.Clone()
+	// The income tax is scaled from the one recorded at the FMV of the order, or estimated at the ordinary income
+	// rate without an FMV to scale from
+	incomeTaxAt := func(marketValuePerShare Money) Money {
+		if r.MarketValuePerShare > 0 {
+			return r.IncomeTaxIncurredWhenStockVested.MulFloat(marketValuePerShare.Ratio(r.MarketValuePerShare)).RoundToCents()
+		}
+		return marketValuePerShare.MulShares(r.NumberOfStocksVested).Percent(r.OrdinaryIncomeTaxPercent).RoundToCents()
+	}
+	return calculateSensitivityGrid(variable, sellingPrices, values, func(sellingPrice Money, value float64) (Money, error) {
+		rsuOrder.SellingPricePerShare = sellingPrice
+		switch variable {
+		case CapitalGainTaxRateVariable:
+			rsuOrder.ConsiderCapitalGainTax = true
+			rsuOrder.CapitalGainTaxPercent = value
+			rsuOrder.ShortTermCapitalGainTaxPercent = value
+			rsuOrder.LongTermCapitalGainTaxPercent = value
+		case SharesSoldVariable:
+			rsuOrder.NumberOfSharesSold = NewShares(value)
+		case MarketValueAtVestVariable:
+			rsuOrder.MarketValuePerShare = NewMoney(value)
+			if rsuOrder.ConsiderIncomeTaxOnVestedStock {
+				rsuOrder.IncomeTaxIncurredWhenStockVested = incomeTaxAt(rsuOrder.MarketValuePerShare)
+			}
+		default:
+			return 0, fmt.Errorf("invalid sensitivity variable: %d", variable)
+		}
+		rsuOrderSummary, err := rsuOrder.CalculateRsuOrderSummary()
+		if err != nil {
+			return 0, err
+		}
+		return rsuOrderSummary.TrueProfitOrLoss(), nil
+	})
+}
@@ -0,0 +1,150 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package types
+
+import (
+	"github.com/leogps/lunar/pkg/tax"
+	"testing"
+)
+
+func TestSensitivityRange(t *testing.T) {
+	rates, err := SensitivityRange(0, 0.3, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 4 {
+		t.Errorf("expected 4 values from 0 to 0.3 in 0.1 steps, got %v", rates)
+	}
+
+	sellingPrices, err := SensitivityRange(NewMoney(100), NewMoney(150), NewMoney(20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sellingPrices) != 3 || sellingPrices[2] != NewMoney(140) {
+		t.Errorf("expected $100.00, $120.00 and $140.00, got %v", sellingPrices)
+	}
+
+	for _, invalid := range [][3]float64{{0, 10, 0}, {10, 0, 1}, {0, 1000, 1}} {
+		if _, err := SensitivityRange(invalid[0], invalid[1], invalid[2]); err == nil {
+			t.Errorf("expected an error for %v", invalid)
+		}
+	}
+}
+
+func TestEsppOrder_CalculateSensitivityGrid(t *testing.T) {
+	esppOrder := &EsppOrder{
+		DiscountPercent:    15,
+		CostPerShare:       NewMoney(100),
+		NumberOfSharesSold: NewShares(10),
+	}
+	sellingPrices := []Money{NewMoney(80), NewMoney(150)}
+
+	grid, err := esppOrder.CalculateSensitivityGrid(CapitalGainTaxRateVariable, sellingPrices, []float64{0, 24})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A $650 gain keeps $494 after a 24% tax; the $50 loss at $80 is not taxed without the capital loss benefit.
+	expected := [][]Money{{NewMoney(-50), NewMoney(-50)}, {NewMoney(650), NewMoney(494)}}
+	for row := range expected {
+		for column := range expected[row] {
+			if grid.TrueProfitOrLoss[row][column] != expected[row][column] {
+				t.Errorf("expected $%s at row %d, column %d, got $%s", expected[row][column], row, column,
+					grid.TrueProfitOrLoss[row][column])
+			}
+		}
+	}
+
+	grid, err = esppOrder.CalculateSensitivityGrid(SharesSoldVariable, sellingPrices, []float64{2.5, 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if grid.TrueProfitOrLoss[1][0] != NewMoney(162.5) || grid.TrueProfitOrLoss[1][1] != NewMoney(1300) {
+		t.Errorf("expected $162.50 and $1300.00, got $%s and $%s", grid.TrueProfitOrLoss[1][0], grid.TrueProfitOrLoss[1][1])
+	}
+
+	if _, err = esppOrder.CalculateSensitivityGrid(MarketValueAtVestVariable, sellingPrices, []float64{100}); err == nil {
+		t.Error("expected an error for FMV at vest on an ESPP order")
+	}
+	esppOrder.TaxModel = tax.Federal
+	if _, err = esppOrder.CalculateSensitivityGrid(CapitalGainTaxRateVariable, sellingPrices, []float64{24}); err == nil {
+		t.Error("expected an error for varying the capital gain tax rate with progressive tax brackets")
+	}
+}
+
+func TestRsuOrder_CalculateSensitivityGrid(t *testing.T) {
+	rsuOrder := &RsuOrder{
+		SellingPricePerShare:   NewMoney(120),
+		NumberOfSharesSold:     NewShares(10),
+		ConsiderCapitalGainTax: true,
+		CapitalGainTaxPercent:  20,
+	}
+
+	grid, err := rsuOrder.CalculateSensitivityGrid(MarketValueAtVestVariable, []Money{NewMoney(120), NewMoney(150)}, []float64{100, 110})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Without income tax the true profit is the sale less 20% of the gain over the FMV at vest.
+	expected := [][]Money{{NewMoney(1160), NewMoney(1180)}, {NewMoney(1400), NewMoney(1420)}}
+	for row := range expected {
+		for column := range expected[row] {
+			if grid.TrueProfitOrLoss[row][column] != expected[row][column] {
+				t.Errorf("expected $%s at row %d, column %d, got $%s", expected[row][column], row, column,
+					grid.TrueProfitOrLoss[row][column])
+			}
+		}
+	}
+	if rsuOrder.MarketValuePerShare != 0 || rsuOrder.SellingPricePerShare != NewMoney(120) {
+		t.Error("expected the order to be left untouched")
+	}
+}
+
+func TestRsuOrder_CalculateSensitivityGrid_IncomeTax(t *testing.T) {
+	rsuOrder := &RsuOrder{
+		SellingPricePerShare:             NewMoney(120),
+		NumberOfSharesSold:               NewShares(10),
+		ConsiderIncomeTaxOnVestedStock:   true,
+		IncomeTaxIncurredWhenStockVested: NewMoney(400),
+		NumberOfStocksVested:             NewShares(10),
+		MarketValuePerShare:              NewMoney(100),
+	}
+
+	grid, err := rsuOrder.CalculateSensitivityGrid(MarketValueAtVestVariable, []Money{NewMoney(120)}, []float64{100, 110})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The 40% income tax at vest rises with the FMV: $400 at $100, $440 at $110
+	if grid.TrueProfitOrLoss[0][0] != NewMoney(800) || grid.TrueProfitOrLoss[0][1] != NewMoney(760) {
+		t.Errorf("expected $800.00 and $760.00, got $%s and $%s", grid.TrueProfitOrLoss[0][0], grid.TrueProfitOrLoss[0][1])
+	}
+
+	// Without an FMV to scale from, the income tax is estimated at the ordinary income rate
+	rsuOrder.MarketValuePerShare = 0
+	rsuOrder.OrdinaryIncomeTaxPercent = 30
+	if grid, err = rsuOrder.CalculateSensitivityGrid(MarketValueAtVestVariable, []Money{NewMoney(120)}, []float64{110}); err != nil {
+		t.Fatal(err)
+	}
+	if grid.TrueProfitOrLoss[0][0] != NewMoney(870) {
+		t.Errorf("expected $870.00, got $%s", grid.TrueProfitOrLoss[0][0])
+	}
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package types
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Shares is a fixed-point number of shares in millionths of a share, so fractional quantities from dividend
+// reinvestment or fractional-share brokers stay exact.
+type Shares int64
+
+// ShareDecimalPlaces is the number of decimal places a quantity of shares keeps.
+const ShareDecimalPlaces = 6
+
+const shareScale = 1000000
+
+// NewShares converts a float64 number of shares, rounding half away from zero to 6 decimal places.
+func NewShares(value float64) Shares {
+	return Shares(math.Round(value * shareScale))
+}
+
+// WholeShares converts a whole number of shares.
+func WholeShares(quantity int) Shares {
+	return Shares(quantity) * shareScale
+}
+
+// ParseShares parses a decimal number of shares such as "12.5" or "1,000" without going through float64.
+// Digits beyond 6 decimal places are rounded half away from zero.
+func ParseShares(value string) (Shares, error) {
+	shares, err := parseFixedPoint(strings.ReplaceAll(strings.TrimSpace(value), ",", ""), ShareDecimalPlaces)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number of shares: %s", value)
+	}
+	return Shares(shares), nil
+}
+
+// Float64 returns the number of shares as a float64, for ratios and display only.
+func (s Shares) Float64() float64 {
+	return float64(s) / shareScale
+}
+
+// IsWhole reports whether the quantity is a whole number of shares.
+func (s Shares) IsWhole() bool {
+	return s%shareScale == 0
+}
+
+// Floor rounds the quantity down to a whole number of shares.
+func (s Shares) Floor() Shares {
+	whole := s / shareScale * shareScale
+	if whole > s {
+		whole -= shareScale
+	}
+	return whole
+}
+
+// Ceil rounds the quantity up to a whole number of shares.
+func (s Shares) Ceil() Shares {
+	whole := s / shareScale * shareScale
+	if whole < s {
+		whole += shareScale
+	}
+	return whole
+}
+
+// String formats the quantity with up to 6 decimal places, without trailing zeros.
+func (s Shares) String() string {
+	sign := ""
+	if s < 0 {
+		sign = "-"
+	}
+	whole, fraction := uint64(absInt64(int64(s)))/shareScale, uint64(absInt64(int64(s)))%shareScale
+	if fraction == 0 {
+		return fmt.Sprintf("%s%d", sign, whole)
+	}
+	return strings.TrimRight(fmt.Sprintf("%s%d.%06d", sign, whole, fraction), "0")
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package types
+
+import "testing"
+
+func TestParseShares(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Shares
+	}{
+		{"100", WholeShares(100)},
+		{"1,000", WholeShares(1000)},
+		{"12.5", NewShares(12.5)},
+		{".123456", NewShares(0.123456)},
+		{"0.0000005", 1},
+		{"-2.25", NewShares(-2.25)},
+	}
+	for _, test := range tests {
+		actual, err := ParseShares(test.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.input, test.expected, actual)
+		}
+	}
+	for _, input := range []string{"", "abc", "1.2.3", "$5", "-"} {
+		if _, err := ParseShares(input); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
+
+func TestShares_Rounding(t *testing.T) {
+	if NewShares(12.5).String() != "12.5" || WholeShares(10).String() != "10" || NewShares(-0.25).String() != "-0.25" {
+		t.Error("expected shares to be formatted without trailing zeros")
+	}
+	if NewShares(12.5).Ceil() != WholeShares(13) || NewShares(12.5).Floor() != WholeShares(12) ||
+		NewShares(-12.5).Floor() != WholeShares(-13) {
+		t.Error("expected shares to round to whole shares")
+	}
+	if !WholeShares(3).IsWhole() || NewShares(3.000001).IsWhole() {
+		t.Error("expected only whole quantities to be whole")
+	}
+}
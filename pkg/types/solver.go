@@ -0,0 +1,169 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SaleTarget is the outcome of a sale a solver aims for.
+type SaleTarget int
+
+const (
+	// ProfitTarget aims for the true profit, after commission and taxes.
+	ProfitTarget SaleTarget = iota
+	// NetProceedsTarget aims for the cash left from the sale after commission and taxes.
+	NetProceedsTarget
+)
+
+func (s SaleTarget) String() string {
+	switch s {
+	case ProfitTarget:
+		return "After-Tax Profit"
+	case NetProceedsTarget:
+		return "Net Cash"
+	default:
+		return "Unknown"
+	}
+}
+
+// SaleTargets lists the targets in the order they are offered.
+func SaleTargets() []SaleTarget {
+	return []SaleTarget{ProfitTarget, NetProceedsTarget}
+}
+
+func ParseSaleTarget(value string) (SaleTarget, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "profit":
+		return ProfitTarget, nil
+	case "cash", "net-cash", "proceeds":
+		return NetProceedsTarget, nil
+	default:
+		return 0, fmt.Errorf("invalid sale target: %s, valid values: profit, cash", value)
+	}
+}
+
+// maxSellingPricePerShare bounds the search for a selling price, well above any listed share price.
+const maxSellingPricePerShare = Money(10000000 * moneyScale)
+
+// maxSharesSold bounds the search for a number of shares, well above any single position.
+const maxSharesSold = Shares(1000000000 * shareScale)
+
+// SolverResult is the value found by a solver along with how it converged.
+// Value is a selling price (Money) or a number of shares sold (Shares).
+type SolverResult[T Money | Shares] struct {
+	Value T
+	// Iterations is the number of times the order was evaluated.
+	Iterations int
+	// Residual is how far the outcome at Value is over the target; never negative.
+	Residual Money
+}
+
+// NoSolutionError is returned when no value up to UpperBound meets the target.
+type NoSolutionError struct {
+	// UpperBound is the largest value tried, formatted with its unit, e.g. "$10000000.0000" or "1000000000 shares".
+	UpperBound string
+	Iterations int
+	// Shortfall is how far the outcome at UpperBound falls short of the target.
+	Shortfall Money
+}
+
+func (n *NoSolutionError) Error() string {
+	return fmt.Sprintf("no solution up to %s: the target is still $%s away after %d iterations",
+		n.UpperBound, n.Shortfall, n.Iterations)
+}
+
+// solveBracketed finds the smallest value from zero up to upperBound whose residual (the outcome less the target)
+// is zero or more. The upper end of the bracket starts at initialGuess and doubles until the target is met, then the
+// bracket is bisected down to a single step, so the result is the smallest multiple of step that meets the target.
+// The residual must be non-decreasing in the value for the result to be the only solution.
+func solveBracketed[T Money | Shares](residual func(T) (Money, error), initialGuess T, step T, upperBound T) (*SolverResult[T], error) {
+	iterations := 0
+	evaluate := func(value T) (Money, error) {
+		iterations++
+		return residual(value)
+	}
+
+	lower := T(0)
+	lowerResidual, err := evaluate(lower)
+	if err != nil {
+		return nil, err
+	}
+	if lowerResidual >= 0 {
+		return &SolverResult[T]{Value: lower, Iterations: iterations, Residual: lowerResidual}, nil
+	}
+
+	upper := (max(initialGuess, step) + step - 1) / step * step
+	var upperResidual Money
+	for {
+		upper = min(upper, upperBound)
+		if upperResidual, err = evaluate(upper); err != nil {
+			return nil, err
+		}
+		if upperResidual >= 0 {
+			break
+		}
+		if upper == upperBound {
+			return nil, &NoSolutionError{UpperBound: formatSolverValue(upperBound), Iterations: iterations, Shortfall: -upperResidual}
+		}
+		lower = upper
+		upper *= 2
+	}
+
+	for upper-lower > step {
+		middle := lower + (upper-lower)/step/2*step
+		middleResidual, err := evaluate(middle)
+		if err != nil {
+			return nil, err
+		}
+		if middleResidual >= 0 {
+			upper, upperResidual = middle, middleResidual
+		} else {
+			lower = middle
+		}
+	}
+	return &SolverResult[T]{Value: upper, Iterations: iterations, Residual: upperResidual}, nil
+}
+
+// formatSolverValue formats a selling price or a number of shares with its unit.
+func formatSolverValue[T Money | Shares](value T) string {
+	switch value := any(value).(type) {
+	case Money:
+		return "$" + value.StringPerShare()
+	case Shares:
+		return value.String() + " shares"
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// shareStep is the smallest number of shares a solver can sell: one share, or one millionth of a share when the
+// broker sells fractional shares.
+func shareStep(fractionalShares bool) Shares {
+	if fractionalShares {
+		return 1
+	}
+	return WholeShares(1)
+}
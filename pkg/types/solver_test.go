@@ -0,0 +1,307 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package types
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSolveBracketed(t *testing.T) {
+	// The outcome is $3 per unit of value, so $100 is first met at $33.3334.
+	solverResult, err := solveBracketed(func(value Money) (Money, error) {
+		return value.MulInt(3) - NewMoney(100), nil
+	}, NewMoney(1), Money(1), maxSellingPricePerShare)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if solverResult.Value != NewMoney(33.3334) || solverResult.Residual != NewMoney(0.0002) {
+		t.Errorf("expected $33.3334 with a $0.0002 residual, got $%s with $%s",
+			solverResult.Value.StringPerShare(), solverResult.Residual.StringPerShare())
+	}
+
+	_, err = solveBracketed(func(value Money) (Money, error) {
+		return -NewMoney(1), nil
+	}, NewMoney(1), Money(1), NewMoney(1000))
+	var noSolutionError *NoSolutionError
+	if !errors.As(err, &noSolutionError) || noSolutionError.Shortfall != NewMoney(1) {
+		t.Errorf("expected a NoSolutionError $1.00 short, got %v", err)
+	}
+}
+
+func TestEsppOrder_SolveSellingPriceForTargetProfitPercent(t *testing.T) {
+	for _, considerCommission := range []bool{false, true} {
+		for _, considerCapitalGainTax := range []bool{false, true} {
+			for _, considerDisposition := range []bool{false, true} {
+				esppOrder := &EsppOrder{
+					DiscountPercent:                 15,
+					CostPerShare:                    NewMoney(100),
+					NumberOfSharesSold:              NewShares(12.5),
+					ConsiderTransactionCommission:   considerCommission,
+					CommissionPaidPerTransaction:    NewMoney(5),
+					NumberOfTransactions:            1,
+					ConsiderCapitalGainTax:          considerCapitalGainTax,
+					CapitalGainTaxPercent:           24,
+					ConsiderDisposition:             considerDisposition,
+					PurchaseDate:                    time.Date(2024, time.June, 30, 0, 0, 0, 0, time.UTC),
+					PurchaseDateMarketValuePerShare: NewMoney(110),
+					SaleDate:                        time.Date(2024, time.September, 1, 0, 0, 0, 0, time.UTC),
+					OrdinaryIncomeTaxPercent:        32,
+				}
+				for _, targetProfitPercent := range []float64{0, 20} {
+					solverResult, err := esppOrder.SolveSellingPriceForTargetProfitPercent(targetProfitPercent)
+					if err != nil {
+						t.Fatal(err)
+					}
+					assertEsppTargetProfitPercent(t, esppOrder, solverResult.Value, targetProfitPercent, true)
+					assertEsppTargetProfitPercent(t, esppOrder, solverResult.Value-1, targetProfitPercent, false)
+				}
+			}
+		}
+	}
+
+	// At 1000% the target grows faster than the profit after the 24% tax, so no price reaches it.
+	esppOrder := &EsppOrder{DiscountPercent: 15, CostPerShare: NewMoney(100), NumberOfSharesSold: NewShares(10),
+		ConsiderCapitalGainTax: true, CapitalGainTaxPercent: 24}
+	_, err := esppOrder.SolveSellingPriceForTargetProfitPercent(1000)
+	var noSolutionError *NoSolutionError
+	if !errors.As(err, &noSolutionError) {
+		t.Errorf("expected a NoSolutionError, got %v", err)
+	}
+}
+
+func assertEsppTargetProfitPercent(t *testing.T, esppOrder *EsppOrder, sellingPrice Money, targetProfitPercent float64, expectMet bool) {
+	esppOrderClone := esppOrder.Clone()
+	esppOrderClone.SellingPricePerShare = sellingPrice
+	summary, err := esppOrderClone.CalculateEsppOrderSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	totalCost := SumMoney(summary.TotalCost, summary.EffectiveCommission, summary.CapitalGainTaxAmount,
+		summary.OrdinaryIncomeTaxAmount, summary.NetInvestmentIncomeTaxAmount)
+	targetProfit := totalCost.Percent(targetProfitPercent).RoundToCents()
+	if met := summary.TrueProfitOrLoss() >= targetProfit; met != expectMet {
+		t.Errorf("at $%s expected the %.0f%% target met to be %t, got $%s profit over $%s (%.4f%%)",
+			sellingPrice.StringPerShare(), targetProfitPercent, expectMet, summary.TrueProfitOrLoss(), totalCost,
+			summary.ProfitOrLossMargin())
+	}
+}
+
+func TestRsuOrder_SolveSellingPriceForTargetProfitPercent(t *testing.T) {
+	for _, considerCommission := range []bool{false, true} {
+		for _, considerCapitalGainTax := range []bool{false, true} {
+			for _, considerIncomeTax := range []bool{false, true} {
+				rsuOrder := &RsuOrder{
+					NumberOfSharesSold:               NewShares(10),
+					ConsiderTransactionCommission:    considerCommission,
+					CommissionPaidPerTransaction:     NewMoney(5),
+					NumberOfTransactions:             1,
+					ConsiderCapitalGainTax:           considerCapitalGainTax,
+					CapitalGainTaxPercent:            24,
+					ConsiderIncomeTaxOnVestedStock:   considerIncomeTax,
+					IncomeTaxIncurredWhenStockVested: NewMoney(2166.12),
+					NumberOfStocksVested:             NewShares(33),
+					MarketValuePerShare:              NewMoney(120.34),
+				}
+				solverResult, err := rsuOrder.SolveSellingPriceForTargetProfitPercent(50)
+				if err != nil {
+					t.Fatal(err)
+				}
+				rsuOrderClone := rsuOrder.Clone()
+				rsuOrderClone.SellingPricePerShare = solverResult.Value
+				summary, err := rsuOrderClone.CalculateRsuOrderSummary()
+				if err != nil {
+					t.Fatal(err)
+				}
+				targetProfit := summary.TotalIncomeTaxIncurred.Percent(50).RoundToCents()
+				if residual := summary.TrueProfitOrLoss() - targetProfit; residual != solverResult.Residual || residual < 0 {
+					t.Errorf("expected a $%s profit at $%s, got $%s (residual $%s)", targetProfit,
+						solverResult.Value.StringPerShare(), summary.TrueProfitOrLoss(), solverResult.Residual)
+				}
+				if !considerIncomeTax && !considerCommission && solverResult.Value != 0 {
+					t.Errorf("expected nothing to recover without income tax or commission, got $%s", solverResult.Value)
+				}
+			}
+		}
+	}
+}
+
+func TestEsppOrder_SolveSellingPriceForTarget(t *testing.T) {
+	esppOrder := &EsppOrder{
+		DiscountPercent:        15,
+		CostPerShare:           NewMoney(100),
+		NumberOfSharesSold:     NewShares(100),
+		ConsiderCapitalGainTax: true,
+		CapitalGainTaxPercent:  24,
+	}
+
+	// $40,000 after a 24% tax is a $52,631.58 gain over the $85 effective cost.
+	solverResult, err := esppOrder.SolveSellingPriceForTarget(ProfitTarget, NewMoney(40000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if solverResult.Value != NewMoney(611.3158) || solverResult.Residual != 0 {
+		t.Errorf("expected $611.3158 with no residual, got $%s with $%s", solverResult.Value.StringPerShare(), solverResult.Residual)
+	}
+
+	solverResult, err = esppOrder.SolveSellingPriceForTarget(NetProceedsTarget, NewMoney(40000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	esppOrderClone := esppOrder.Clone()
+	esppOrderClone.SellingPricePerShare = solverResult.Value
+	summary, err := esppOrderClone.CalculateEsppOrderSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.NetProceeds()-NewMoney(40000) != solverResult.Residual || solverResult.Residual < 0 {
+		t.Errorf("expected $40000.00 net cash at $%s, got $%s", solverResult.Value.StringPerShare(), summary.NetProceeds())
+	}
+}
+
+func TestRsuOrder_SolveSellingPriceForTarget(t *testing.T) {
+	rsuOrder := &RsuOrder{
+		NumberOfSharesSold:               NewShares(100),
+		ConsiderTransactionCommission:    true,
+		CommissionPaidPerTransaction:     NewMoney(5),
+		NumberOfTransactions:             1,
+		ConsiderCapitalGainTax:           true,
+		CapitalGainTaxPercent:            24,
+		ConsiderIncomeTaxOnVestedStock:   true,
+		IncomeTaxIncurredWhenStockVested: NewMoney(2200),
+		NumberOfStocksVested:             NewShares(100),
+		MarketValuePerShare:              NewMoney(100),
+	}
+
+	for _, saleTarget := range SaleTargets() {
+		solverResult, err := rsuOrder.SolveSellingPriceForTarget(saleTarget, NewMoney(20000))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, sellingPrice := range []Money{solverResult.Value, solverResult.Value - 1} {
+			rsuOrderClone := rsuOrder.Clone()
+			rsuOrderClone.SellingPricePerShare = sellingPrice
+			summary, err := rsuOrderClone.CalculateRsuOrderSummary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if met := summary.SaleOutcome(saleTarget) >= NewMoney(20000); met != (sellingPrice == solverResult.Value) {
+				t.Errorf("%s: expected $%s to be the lowest price reaching $20000.00, got $%s at $%s",
+					saleTarget, solverResult.Value.StringPerShare(), summary.SaleOutcome(saleTarget), sellingPrice.StringPerShare())
+			}
+		}
+	}
+}
+
+func TestEsppOrder_SolveSharesSoldForTarget(t *testing.T) {
+	esppOrder := &EsppOrder{
+		DiscountPercent:               15,
+		CostPerShare:                  NewMoney(100),
+		SellingPricePerShare:          NewMoney(150),
+		ConsiderTransactionCommission: true,
+		CommissionPaidPerTransaction:  NewMoney(5),
+		NumberOfTransactions:          2,
+		ConsiderCapitalGainTax:        true,
+		CapitalGainTaxPercent:         24,
+	}
+
+	// Each share nets $150 less 24% of the $65 gain, $134.40, and the $10 commission nets $7.60 after it is deducted from
+	// the gain, so $10,000 takes about 74.46 shares.
+	var fractionalSharesSold Shares
+	for _, fractionalShares := range []bool{true, false} {
+		solverResult, err := esppOrder.SolveSharesSoldForTarget(NetProceedsTarget, NewMoney(10000), fractionalShares)
+		if err != nil {
+			t.Fatal(err)
+		}
+		step := shareStep(fractionalShares)
+		for _, sharesSold := range []Shares{solverResult.Value, solverResult.Value - step} {
+			esppOrderClone := esppOrder.Clone()
+			esppOrderClone.NumberOfSharesSold = sharesSold
+			summary, err := esppOrderClone.CalculateEsppOrderSummary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if met := summary.NetProceeds() >= NewMoney(10000); met != (sharesSold == solverResult.Value) {
+				t.Errorf("expected %s to be the fewest shares netting $10000.00, got $%s for %s",
+					solverResult.Value, summary.NetProceeds(), sharesSold)
+			}
+		}
+		if fractionalShares {
+			fractionalSharesSold = solverResult.Value
+		} else if solverResult.Value != fractionalSharesSold.Ceil() {
+			t.Errorf("expected %s whole shares, got %s", fractionalSharesSold.Ceil(), solverResult.Value)
+		}
+	}
+	if fractionalSharesSold != NewShares(74.4613) {
+		t.Errorf("expected 74.4613 fractional shares, got %s", fractionalSharesSold)
+	}
+
+	// Selling below the effective cost only adds to the loss.
+	esppOrder.SellingPricePerShare = NewMoney(80)
+	_, err := esppOrder.SolveSharesSoldForTarget(ProfitTarget, NewMoney(1000), true)
+	var noSolutionError *NoSolutionError
+	if !errors.As(err, &noSolutionError) {
+		t.Errorf("expected a NoSolutionError, got %v", err)
+	}
+}
+
+func TestRsuOrder_SolveSharesSoldForTarget(t *testing.T) {
+	rsuOrder := &RsuOrder{
+		SellingPricePerShare:             NewMoney(120),
+		ConsiderTransactionCommission:    true,
+		CommissionPaidPerTransaction:     NewMoney(5),
+		NumberOfTransactions:             1,
+		ConsiderCapitalGainTax:           true,
+		CapitalGainTaxPercent:            24,
+		ConsiderIncomeTaxOnVestedStock:   true,
+		IncomeTaxIncurredWhenStockVested: NewMoney(2200),
+		NumberOfStocksVested:             NewShares(100),
+		MarketValuePerShare:              NewMoney(100),
+	}
+
+	for _, saleTarget := range SaleTargets() {
+		for _, fractionalShares := range []bool{true, false} {
+			solverResult, err := rsuOrder.SolveSharesSoldForTarget(saleTarget, NewMoney(5000), fractionalShares)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !fractionalShares && !solverResult.Value.IsWhole() {
+				t.Errorf("%s: expected whole shares, got %s", saleTarget, solverResult.Value)
+			}
+			for _, sharesSold := range []Shares{solverResult.Value, solverResult.Value - shareStep(fractionalShares)} {
+				rsuOrderClone := rsuOrder.Clone()
+				rsuOrderClone.NumberOfSharesSold = sharesSold
+				summary, err := rsuOrderClone.CalculateRsuOrderSummary()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if met := summary.SaleOutcome(saleTarget) >= NewMoney(5000); met != (sharesSold == solverResult.Value) {
+					t.Errorf("%s: expected %s to be the fewest shares reaching $5000.00, got $%s for %s",
+						saleTarget, solverResult.Value, summary.SaleOutcome(saleTarget), sharesSold)
+				}
+			}
+		}
+	}
+}
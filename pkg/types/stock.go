@@ -23,6 +23,15 @@
 
 package types
 
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DateLayout is the layout used to format and parse dates.
+const DateLayout = "2006-01-02"
+
 // OrderType Define a new type for the enum
 type OrderType int
 
@@ -31,3 +40,50 @@ const (
 	Espp OrderType = iota
 	Rsu
 )
+
+func (o OrderType) String() string {
+	if o == Rsu {
+		return "RSU"
+	}
+	return "ESPP"
+}
+
+// ParseOrderType parses espp or rsu, case-insensitively.
+func ParseOrderType(value string) (OrderType, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "espp":
+		return Espp, nil
+	case "rsu":
+		return Rsu, nil
+	}
+	return 0, fmt.Errorf("unknown order type: %s (expected espp or rsu)", value)
+}
+
+// HoldingPeriod classifies a capital gain by how long the shares were held
+type HoldingPeriod int
+
+const (
+	ShortTerm HoldingPeriod = iota
+	LongTerm
+)
+
+func (h HoldingPeriod) String() string {
+	if h == LongTerm {
+		return "Long-Term"
+	}
+	return "Short-Term"
+}
+
+// CalculateLongTermDate returns the first sale date on which shares acquired on acquisitionDate are held long-term,
+// i.e. the day after the one-year anniversary. The anniversary of Feb 29 is Feb 28 of the following year.
+func CalculateLongTermDate(acquisitionDate time.Time) time.Time {
+	return addMonths(acquisitionDate, 12).AddDate(0, 0, 1)
+}
+
+// CalculateHoldingPeriod classifies a sale as long-term when the shares were held for more than one year.
+func CalculateHoldingPeriod(acquisitionDate time.Time, saleDate time.Time) HoldingPeriod {
+	if saleDate.Before(CalculateLongTermDate(acquisitionDate)) {
+		return ShortTerm
+	}
+	return LongTerm
+}
@@ -0,0 +1,57 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalculateHoldingPeriod(t *testing.T) {
+	acquisitionDate := time.Date(2023, time.March, 15, 0, 0, 0, 0, time.UTC)
+
+	if holdingPeriod := CalculateHoldingPeriod(acquisitionDate, time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC)); holdingPeriod != ShortTerm {
+		t.Errorf("expected sale on the one-year anniversary to be short-term, got %s", holdingPeriod)
+	}
+	if holdingPeriod := CalculateHoldingPeriod(acquisitionDate, time.Date(2024, time.March, 16, 0, 0, 0, 0, time.UTC)); holdingPeriod != LongTerm {
+		t.Errorf("expected sale after the one-year anniversary to be long-term, got %s", holdingPeriod)
+	}
+	if longTermDate := CalculateLongTermDate(acquisitionDate).Format(DateLayout); longTermDate != "2024-03-16" {
+		t.Errorf("expected long-term date of 2024-03-16, got %s", longTermDate)
+	}
+}
+
+func TestCalculateLongTermDate_LeapDay(t *testing.T) {
+	acquisitionDate := time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)
+
+	if longTermDate := CalculateLongTermDate(acquisitionDate).Format(DateLayout); longTermDate != "2025-03-01" {
+		t.Errorf("expected long-term date of 2025-03-01, got %s", longTermDate)
+	}
+	if holdingPeriod := CalculateHoldingPeriod(acquisitionDate, time.Date(2025, time.February, 28, 0, 0, 0, 0, time.UTC)); holdingPeriod != ShortTerm {
+		t.Errorf("expected sale on the one-year anniversary to be short-term, got %s", holdingPeriod)
+	}
+	if holdingPeriod := CalculateHoldingPeriod(acquisitionDate, time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)); holdingPeriod != LongTerm {
+		t.Errorf("expected sale the day after the one-year anniversary to be long-term, got %s", holdingPeriod)
+	}
+}
@@ -0,0 +1,250 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WashSaleWindowDays is how many days before and after a sale at a loss a replacement acquisition triggers the wash sale rule.
+const WashSaleWindowDays = 30
+
+// Sale is a sale of shares in a TransactionHistory.
+type Sale struct {
+	Date          time.Time
+	Quantity      Shares
+	PricePerShare Money
+}
+
+// TransactionHistory is the dated record of the lots acquired (RSU vests and ESPP purchases) and the sales made from them.
+// Each sale consumes the lots acquired on or before its date in the order they are listed.
+type TransactionHistory struct {
+	Lots  []*Lot
+	Sales []*Sale
+	// ConsiderDisposition adds the ordinary income of the disposition to the basis of the ESPP shares sold.
+	ConsiderDisposition bool
+}
+
+// Disposition is the part of a sale taken from a single lot. The gain or loss is before commission.
+type Disposition struct {
+	Sale       *Sale
+	Lot        *Lot
+	SharesSold Shares
+	// BasisPerShare is the tax basis of the shares sold, i.e. including the ordinary income of an ESPP disposition and
+	// the disallowed losses carried into the lot by earlier wash sales.
+	BasisPerShare  Money
+	GainOrLoss     Money
+	DisallowedLoss Money
+}
+
+// AllowedGainOrLoss is the gain or loss recognized on the disposition once the disallowed loss is removed.
+func (d *Disposition) AllowedGainOrLoss() Money {
+	return d.GainOrLoss + d.DisallowedLoss
+}
+
+// WashSale is a loss disallowed because replacement shares were acquired within the wash sale window.
+type WashSale struct {
+	Disposition       *Disposition
+	ReplacementLot    *Lot
+	ReplacementShares Shares
+	DisallowedLoss    Money
+	// AdjustedBasisPerShare is the basis of the replacement shares after the disallowed loss is added to it.
+	AdjustedBasisPerShare Money
+}
+
+// HeldShares are shares of a lot still held at the end of the history, at their adjusted basis. The basis of ESPP shares
+// is the purchase price, as the ordinary income of their disposition is only known once they are sold.
+type HeldShares struct {
+	Lot           *Lot
+	Quantity      Shares
+	BasisPerShare Money
+}
+
+type WashSaleReport struct {
+	Dispositions []*Disposition
+	WashSales    []*WashSale
+	HeldShares   []*HeldShares
+
+	TotalGainOrLoss     Money
+	TotalDisallowedLoss Money
+}
+
+// AllowedGainOrLoss is the total gain or loss recognized once the disallowed losses are removed.
+func (w *WashSaleReport) AllowedGainOrLoss() Money {
+	return w.TotalGainOrLoss + w.TotalDisallowedLoss
+}
+
+func (w *WashSaleReport) ToString() string {
+	var sb strings.Builder
+
+	sb.WriteString("Wash Sale Report:\n")
+	for _, disposition := range w.Dispositions {
+		sb.WriteString(fmt.Sprintf("  Sold %s shares on %s from the lot acquired %s: $%s gain/loss, $%s disallowed\n",
+			disposition.SharesSold, disposition.Sale.Date.Format(DateLayout), disposition.Lot.AcquisitionDate.Format(DateLayout),
+			disposition.GainOrLoss, disposition.DisallowedLoss))
+	}
+	for _, washSale := range w.WashSales {
+		sb.WriteString(fmt.Sprintf("  Wash sale: $%s loss carried into %s shares acquired %s (basis $%s/share)\n",
+			washSale.DisallowedLoss, washSale.ReplacementShares, washSale.ReplacementLot.AcquisitionDate.Format(DateLayout),
+			washSale.AdjustedBasisPerShare.StringPerShare()))
+	}
+	sb.WriteString(fmt.Sprintf("  Total Gain/Loss:              $%s\n", w.TotalGainOrLoss))
+	sb.WriteString(fmt.Sprintf("  Total Disallowed Loss:        $%s\n", w.TotalDisallowedLoss))
+	sb.WriteString(fmt.Sprintf("  Allowed Gain/Loss:            $%s\n", w.AllowedGainOrLoss()))
+	return sb.String()
+}
+
+// position is a block of shares of a lot sharing the same adjusted basis.
+// Lots are split into positions as parts of them become replacement shares.
+type position struct {
+	lot           *Lot
+	quantity      Shares
+	basisPerShare Money
+	// replacement marks shares whose basis already absorbed a disallowed loss; they cannot replace another sale.
+	replacement bool
+}
+
+// IsWithinWashSaleWindow reports whether the acquisition falls within 30 days before or after the sale.
+func IsWithinWashSaleWindow(acquisitionDate time.Time, saleDate time.Time) bool {
+	return !acquisitionDate.Before(saleDate.AddDate(0, 0, -WashSaleWindowDays)) &&
+		!acquisitionDate.After(saleDate.AddDate(0, 0, WashSaleWindowDays))
+}
+
+// CalculateWashSaleReport replays the sales in date order, flagging losses with replacement shares acquired
+// within the wash sale window and carrying the disallowed loss into the basis of the replacement shares.
+func (t *TransactionHistory) CalculateWashSaleReport() (*WashSaleReport, error) {
+	var positions []*position
+	for _, lot := range t.Lots {
+		if lot.Quantity <= 0 {
+			return nil, fmt.Errorf("lot quantity must be greater than zero")
+		}
+		positions = append(positions, &position{
+			lot:           lot,
+			quantity:      lot.Quantity,
+			basisPerShare: lot.BasisPerShare,
+		})
+	}
+	sales := make([]*Sale, len(t.Sales))
+	copy(sales, t.Sales)
+	sort.SliceStable(sales, func(i, j int) bool {
+		return sales[i].Date.Before(sales[j].Date)
+	})
+
+	report := &WashSaleReport{}
+	for _, sale := range sales {
+		if sale.Quantity <= 0 {
+			return nil, fmt.Errorf("number of shares sold must be greater than zero")
+		}
+		remainingShares := sale.Quantity
+		var saleDispositions []*Disposition
+		for _, soldPosition := range positions {
+			if remainingShares == 0 {
+				break
+			}
+			if soldPosition.quantity == 0 || soldPosition.lot.AcquisitionDate.After(sale.Date) {
+				continue
+			}
+			sharesSold := min(remainingShares, soldPosition.quantity)
+			remainingShares -= sharesSold
+			soldPosition.quantity -= sharesSold
+
+			// The ordinary income reported on an ESPP disposition adds to the basis of the shares sold
+			lot := soldPosition.lot
+			basisPerShare := soldPosition.basisPerShare + lot.TaxBasisPerShare(sale.Date, sale.PricePerShare, t.ConsiderDisposition) -
+				lot.BasisPerShare
+			saleDispositions = append(saleDispositions, &Disposition{
+				Sale:          sale,
+				Lot:           lot,
+				SharesSold:    sharesSold,
+				BasisPerShare: basisPerShare,
+				GainOrLoss:    (sale.PricePerShare - basisPerShare).MulShares(sharesSold).RoundToCents(),
+			})
+		}
+		if remainingShares > 0 {
+			return nil, fmt.Errorf("lots acquired by %s hold %s fewer shares than the %s sold",
+				sale.Date.Format(DateLayout), remainingShares, sale.Quantity)
+		}
+
+		for _, disposition := range saleDispositions {
+			if disposition.GainOrLoss < 0 {
+				positions = washLoss(positions, disposition, report)
+			}
+			report.Dispositions = append(report.Dispositions, disposition)
+			report.TotalGainOrLoss += disposition.GainOrLoss
+			report.TotalDisallowedLoss += disposition.DisallowedLoss
+		}
+	}
+
+	for _, heldPosition := range positions {
+		if heldPosition.quantity == 0 {
+			continue
+		}
+		report.HeldShares = append(report.HeldShares, &HeldShares{
+			Lot:           heldPosition.lot,
+			Quantity:      heldPosition.quantity,
+			BasisPerShare: heldPosition.basisPerShare,
+		})
+	}
+	return report, nil
+}
+
+// washLoss matches the loss shares of the disposition with replacement shares, splitting the positions
+// so only the matched shares carry the disallowed loss. Returns the updated positions.
+func washLoss(positions []*position, disposition *Disposition, report *WashSaleReport) []*position {
+	lossPerShare := disposition.BasisPerShare - disposition.Sale.PricePerShare
+	unmatchedShares := disposition.SharesSold
+	for index := 0; index < len(positions) && unmatchedShares > 0; index++ {
+		replacementPosition := positions[index]
+		// Shares bought together with the ones sold are not replacement shares.
+		if replacementPosition.lot == disposition.Lot || replacementPosition.replacement || replacementPosition.quantity == 0 ||
+			!IsWithinWashSaleWindow(replacementPosition.lot.AcquisitionDate, disposition.Sale.Date) {
+			continue
+		}
+		replacementShares := min(unmatchedShares, replacementPosition.quantity)
+		unmatchedShares -= replacementShares
+
+		if replacementShares < replacementPosition.quantity {
+			remainder := *replacementPosition
+			remainder.quantity -= replacementShares
+			replacementPosition.quantity = replacementShares
+			positions = append(positions[:index+1], append([]*position{&remainder}, positions[index+1:]...)...)
+		}
+		replacementPosition.replacement = true
+		replacementPosition.basisPerShare += lossPerShare
+
+		disallowedLoss := lossPerShare.MulShares(replacementShares).RoundToCents()
+		disposition.DisallowedLoss += disallowedLoss
+		report.WashSales = append(report.WashSales, &WashSale{
+			Disposition:           disposition,
+			ReplacementLot:        replacementPosition.lot,
+			ReplacementShares:     replacementShares,
+			DisallowedLoss:        disallowedLoss,
+			AdjustedBasisPerShare: replacementPosition.basisPerShare,
+		})
+	}
+	return positions
+}
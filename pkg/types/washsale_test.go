@@ -0,0 +1,166 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTransactionHistory_CalculateWashSaleReport(t *testing.T) {
+	januaryVest := &Lot{Source: Rsu, AcquisitionDate: time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC), Quantity: NewShares(100), BasisPerShare: NewMoney(150)}
+	esppPurchase := &Lot{Source: Espp, AcquisitionDate: time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC), Quantity: NewShares(40), BasisPerShare: NewMoney(90)}
+	julyVest := &Lot{Source: Rsu, AcquisitionDate: time.Date(2024, time.July, 15, 0, 0, 0, 0, time.UTC), Quantity: NewShares(100), BasisPerShare: NewMoney(100)}
+	transactionHistory := &TransactionHistory{
+		Lots: []*Lot{januaryVest, esppPurchase, julyVest},
+		Sales: []*Sale{
+			// The ESPP purchase replaces 40 of the 100 shares sold at a $40/share loss.
+			{Date: time.Date(2024, time.April, 20, 0, 0, 0, 0, time.UTC), Quantity: NewShares(100), PricePerShare: NewMoney(110)},
+			// The replacement shares are sold at their adjusted basis: $130 - ($90 + $40) = $0.
+			{Date: time.Date(2024, time.September, 1, 0, 0, 0, 0, time.UTC), Quantity: NewShares(40), PricePerShare: NewMoney(130)},
+		},
+	}
+
+	washSaleReport, err := transactionHistory.CalculateWashSaleReport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(washSaleReport.ToString())
+
+	if len(washSaleReport.WashSales) != 1 {
+		t.Fatalf("expected a single wash sale, got %d", len(washSaleReport.WashSales))
+	}
+	washSale := washSaleReport.WashSales[0]
+	if washSale.ReplacementLot != esppPurchase || washSale.ReplacementShares != NewShares(40) ||
+		washSale.DisallowedLoss != NewMoney(1600) || washSale.AdjustedBasisPerShare != NewMoney(130) {
+		t.Errorf("expected $1600.00 carried into 40 ESPP shares at $130.00/share, got $%s into %s shares at $%s/share",
+			washSale.DisallowedLoss, washSale.ReplacementShares, washSale.AdjustedBasisPerShare)
+	}
+	secondSale := washSaleReport.Dispositions[1]
+	if secondSale.Lot != esppPurchase || secondSale.GainOrLoss != NewMoney(0) {
+		t.Errorf("expected the ESPP shares to be sold at their adjusted basis, got $%s", secondSale.GainOrLoss)
+	}
+	if washSaleReport.AllowedGainOrLoss() != NewMoney(-2400) {
+		t.Errorf("expected $-2400.00 allowed loss, got $%s", washSaleReport.AllowedGainOrLoss())
+	}
+	if len(washSaleReport.HeldShares) != 1 || washSaleReport.HeldShares[0].Lot != julyVest {
+		t.Errorf("expected only the July vest to be held, got %+v", washSaleReport.HeldShares)
+	}
+
+	transactionHistory.Sales[0].Quantity = NewShares(300)
+	if _, err = transactionHistory.CalculateWashSaleReport(); err == nil {
+		t.Error("expected error when selling more shares than acquired by the sale date")
+	}
+}
+
+func TestLotSale_CalculateLotSaleSummary_WashSale(t *testing.T) {
+	lotSale := &LotSale{
+		SellingPricePerShare: NewMoney(110),
+		NumberOfSharesSold:   NewShares(50),
+		SaleDate:             time.Date(2024, time.April, 20, 0, 0, 0, 0, time.UTC),
+		Lots: []*Lot{
+			{Source: Rsu, AcquisitionDate: time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC), Quantity: NewShares(50), BasisPerShare: NewMoney(150)},
+			{Source: Rsu, AcquisitionDate: time.Date(2024, time.April, 15, 0, 0, 0, 0, time.UTC), Quantity: NewShares(100), BasisPerShare: NewMoney(120)},
+		},
+		ConsiderWashSale: true,
+	}
+	summary, err := lotSale.CalculateLotSaleSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.TotalWashSaleDisallowedLoss != NewMoney(2000) ||
+		summary.LotResults[0].WashSaleDisallowedLoss != NewMoney(2000) {
+		t.Errorf("expected $2000.00 disallowed loss, got $%s", summary.TotalWashSaleDisallowedLoss)
+	}
+}
+
+func TestLotSale_CalculateLotSaleSummary_EsppWashSale(t *testing.T) {
+	// Bought at $85 with a $120 market value, the disqualifying disposition at $100 reports $35/share of ordinary
+	// income and a $20/share capital loss against the $120 tax basis.
+	esppLot := &Lot{Source: Espp, AcquisitionDate: time.Date(2024, time.June, 28, 0, 0, 0, 0, time.UTC), Quantity: NewShares(20),
+		BasisPerShare: NewMoney(85), DiscountPercent: 15, OfferingDate: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		OfferingDateMarketValuePerShare: NewMoney(100), PurchaseDateMarketValuePerShare: NewMoney(120)}
+	rsuVest := &Lot{Source: Rsu, AcquisitionDate: time.Date(2024, time.August, 20, 0, 0, 0, 0, time.UTC), Quantity: NewShares(10), BasisPerShare: NewMoney(100)}
+	lotSale := &LotSale{
+		SellingPricePerShare:   NewMoney(100),
+		NumberOfSharesSold:     NewShares(20),
+		SaleDate:               time.Date(2024, time.August, 15, 0, 0, 0, 0, time.UTC),
+		Lots:                   []*Lot{esppLot, rsuVest},
+		ConsiderDisposition:    true,
+		ConsiderCapitalGainTax: true,
+		CapitalGainTaxPercent:  20,
+		ConsiderWashSale:       true,
+	}
+	summary, err := lotSale.CalculateLotSaleSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(summary.ToString())
+
+	lotResult := summary.LotResults[0]
+	if lotResult.OrdinaryIncomeAmount != NewMoney(700) || lotResult.WashSaleDisallowedLoss != NewMoney(200) {
+		t.Errorf("expected $700.00 ordinary income and $200.00 disallowed loss, got $%s and $%s",
+			lotResult.OrdinaryIncomeAmount, lotResult.WashSaleDisallowedLoss)
+	}
+	if lotResult.CapitalGainAmount != NewMoney(-200) || summary.TotalCapitalGainAmount != NewMoney(-200) ||
+		lotResult.CapitalGainTaxAmount != 0 {
+		t.Errorf("expected the $-200.00 allowed capital loss with no tax, got $%s and $%s tax",
+			lotResult.CapitalGainAmount, lotResult.CapitalGainTaxAmount)
+	}
+	if len(summary.HeldShares) != 1 || summary.HeldShares[0].Lot != rsuVest || summary.HeldShares[0].BasisPerShare != NewMoney(120) {
+		t.Errorf("expected the RSU vest to be held at a $120.00 adjusted basis, got %+v", summary.HeldShares)
+	}
+
+	// Without the disposition, the ESPP shares are sold at a gain over the purchase price
+	lotSale.ConsiderDisposition = false
+	if summary, err = lotSale.CalculateLotSaleSummary(); err != nil || summary.TotalWashSaleDisallowedLoss != 0 {
+		t.Errorf("expected no wash sale over the purchase price, got %v", err)
+	}
+}
+
+func TestLotSale_CalculateLotSaleSummary_WashSaleCapitalGainTax(t *testing.T) {
+	// The loss of the January 2024 lot is disallowed by the April vest, so it no longer offsets the gain of the
+	// 2023 lot.
+	lotSale := &LotSale{
+		SellingPricePerShare: NewMoney(110),
+		NumberOfSharesSold:   NewShares(100),
+		SaleDate:             time.Date(2024, time.April, 20, 0, 0, 0, 0, time.UTC),
+		Lots: []*Lot{
+			{Source: Rsu, AcquisitionDate: time.Date(2023, time.January, 15, 0, 0, 0, 0, time.UTC), Quantity: NewShares(50), BasisPerShare: NewMoney(100)},
+			{Source: Rsu, AcquisitionDate: time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC), Quantity: NewShares(50), BasisPerShare: NewMoney(150)},
+			{Source: Rsu, AcquisitionDate: time.Date(2024, time.April, 15, 0, 0, 0, 0, time.UTC), Quantity: NewShares(100), BasisPerShare: NewMoney(120)},
+		},
+		ConsiderCapitalGainTax: true,
+		CapitalGainTaxPercent:  20,
+		ConsiderWashSale:       true,
+	}
+	summary, err := lotSale.CalculateLotSaleSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.TotalCapitalGainAmount != NewMoney(500) || summary.TotalCapitalGainTaxAmount != NewMoney(100) {
+		t.Errorf("expected the $500.00 gain with the loss disallowed and $100.00 tax, got $%s and $%s",
+			summary.TotalCapitalGainAmount, summary.TotalCapitalGainTaxAmount)
+	}
+}
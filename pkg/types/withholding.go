@@ -0,0 +1,185 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package types
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/tax"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WithholdingEstimate compares the federal tax withheld on supplemental income against the tax owed on it.
+type WithholdingEstimate struct {
+	// Salary is the taxable salary for the year, assumed to be withheld correctly through the W-4.
+	Salary Money
+	// RsuVests are the vests of the year: NumberOfStocksVested shares at MarketValuePerShare on VestDate, withheld
+	// IncomeTaxIncurredWhenStockVested when ConsiderIncomeTaxOnVestedStock is set.
+	RsuVests []*RsuOrder
+	// EsppDisqualifyingIncome is the ordinary income from disqualifying ESPP sales, which is usually not withheld on.
+	EsppDisqualifyingIncome Money
+
+	// TaxModel computes the tax owed for the TaxProfile, stacking the supplemental income above the salary.
+	// MarginalTaxPercent is used instead when it is not set.
+	TaxModel           tax.Model
+	TaxProfile         tax.Profile
+	MarginalTaxPercent float64
+}
+
+// VestWithholding is the supplemental withholding on a single RSU vest.
+type VestWithholding struct {
+	VestDate    time.Time
+	Income      Money
+	TaxWithheld Money
+	// Estimated is set when the vest did not record the tax withheld, so it is estimated at the supplemental rates.
+	Estimated bool
+}
+
+type WithholdingEstimateSummary struct {
+	WithholdingEstimate     *WithholdingEstimate
+	VestWithholdings        []VestWithholding
+	RsuIncome               Money
+	EsppDisqualifyingIncome Money
+	SupplementalIncome      Money
+	TaxWithheld             Money
+	TaxOwed                 Money
+	MarginalTaxPercent      float64
+}
+
+// Shortfall is the tax owed beyond what was withheld; it is negative when too much was withheld.
+func (w *WithholdingEstimateSummary) Shortfall() Money {
+	return w.TaxOwed - w.TaxWithheld
+}
+
+func (w *WithholdingEstimateSummary) EffectiveWithholdingPercent() float64 {
+	return w.TaxWithheld.Ratio(w.SupplementalIncome) * 100
+}
+
+func (w *WithholdingEstimateSummary) ToString() string {
+	var sb strings.Builder
+
+	sb.WriteString("Withholding Estimate Summary:\n")
+	for _, vestWithholding := range w.VestWithholdings {
+		estimated := ""
+		if vestWithholding.Estimated {
+			estimated = " (estimated)"
+		}
+		sb.WriteString(fmt.Sprintf("  Vest %s:              $%s withheld on $%s%s\n",
+			vestWithholding.VestDate.Format(DateLayout), vestWithholding.TaxWithheld, vestWithholding.Income, estimated))
+	}
+	sb.WriteString(fmt.Sprintf("  RSU Income:                   $%s\n", w.RsuIncome))
+	sb.WriteString(fmt.Sprintf("  ESPP Disqualifying Income:    $%s\n", w.EsppDisqualifyingIncome))
+	sb.WriteString(fmt.Sprintf("  Supplemental Income:          $%s\n", w.SupplementalIncome))
+	sb.WriteString(fmt.Sprintf("  Tax Withheld:                 $%s (%.2f%%)\n", w.TaxWithheld, w.EffectiveWithholdingPercent()))
+	sb.WriteString(fmt.Sprintf("  Tax Owed:                     $%s\n", w.TaxOwed))
+	sb.WriteString(fmt.Sprintf("  Marginal Tax Percent:         %.2f%%\n", w.MarginalTaxPercent))
+	sb.WriteString(fmt.Sprintf("  Projected Shortfall:          $%s\n", w.Shortfall()))
+	return sb.String()
+}
+
+// CalculateRsuVestIncome returns the ordinary income recognized on the vest.
+func (r *RsuOrder) CalculateRsuVestIncome() Money {
+	return r.MarketValuePerShare.MulShares(r.NumberOfStocksVested).RoundToCents()
+}
+
+// CalculateTaxOwed returns the tax owed on the supplemental income on top of the salary.
+func (w *WithholdingEstimate) CalculateTaxOwed(supplementalIncome Money) (Money, error) {
+	if w.TaxModel == nil {
+		return supplementalIncome.Percent(w.MarginalTaxPercent).RoundToCents(), nil
+	}
+	return calculateTax(w.TaxModel, w.salaryProfile(), tax.OrdinaryIncome, supplementalIncome)
+}
+
+// salaryProfile is the TaxProfile with the salary as the other income.
+func (w *WithholdingEstimate) salaryProfile() tax.Profile {
+	profile := w.TaxProfile
+	profile.OtherIncome = w.Salary.Float64()
+	return profile
+}
+
+// CalculateMarginalTaxPercent returns the rate on the last dollar of supplemental income.
+// The tax is not rounded to the cent here, so the rate is not distorted by the rounding.
+func (w *WithholdingEstimate) CalculateMarginalTaxPercent(supplementalIncome Money) (float64, error) {
+	if w.TaxModel == nil {
+		return w.MarginalTaxPercent, nil
+	}
+	taxOwed, err := w.TaxModel.CalculateTax(w.salaryProfile(), tax.OrdinaryIncome, supplementalIncome.Float64())
+	if err != nil {
+		return 0, err
+	}
+	taxOwedOnNextDollar, err := w.TaxModel.CalculateTax(w.salaryProfile(), tax.OrdinaryIncome, supplementalIncome.Float64()+1)
+	if err != nil {
+		return 0, err
+	}
+	return (taxOwedOnNextDollar - taxOwed) * 100, nil
+}
+
+// CalculateWithholdingEstimateSummary takes the tax withheld on each vest from the income tax it recorded, otherwise
+// estimates it at the federal supplemental rates in vest date order, and compares the total against the tax owed
+// on the RSU and ESPP income.
+func (w *WithholdingEstimate) CalculateWithholdingEstimateSummary() (*WithholdingEstimateSummary, error) {
+	rsuVests := make([]*RsuOrder, len(w.RsuVests))
+	copy(rsuVests, w.RsuVests)
+	sort.SliceStable(rsuVests, func(i, j int) bool {
+		return rsuVests[i].VestDate.Before(rsuVests[j].VestDate)
+	})
+
+	summary := &WithholdingEstimateSummary{
+		WithholdingEstimate:     w,
+		EsppDisqualifyingIncome: w.EsppDisqualifyingIncome,
+	}
+	for _, rsuVest := range rsuVests {
+		income := rsuVest.CalculateRsuVestIncome()
+		if income < 0 {
+			return nil, fmt.Errorf("vest income must not be negative")
+		}
+		vestWithholding := VestWithholding{
+			VestDate:    rsuVest.VestDate,
+			Income:      income,
+			TaxWithheld: rsuVest.IncomeTaxIncurredWhenStockVested,
+		}
+		if !rsuVest.ConsiderIncomeTaxOnVestedStock {
+			// The supplemental rate steps up past $1M of the year's supplemental income, so it is estimated on top
+			// of the vests before, whether or not they recorded the tax withheld
+			vestWithholding.TaxWithheld = NewMoney(tax.CalculateFederalSupplementalWithholding(summary.RsuIncome.Float64(), income.Float64())).RoundToCents()
+			vestWithholding.Estimated = true
+		} else if vestWithholding.TaxWithheld < 0 {
+			return nil, fmt.Errorf("income tax withheld on the vest must not be negative")
+		}
+		summary.VestWithholdings = append(summary.VestWithholdings, vestWithholding)
+		summary.RsuIncome += income
+		summary.TaxWithheld += vestWithholding.TaxWithheld
+	}
+	summary.SupplementalIncome = summary.RsuIncome + summary.EsppDisqualifyingIncome
+
+	var err error
+	if summary.TaxOwed, err = w.CalculateTaxOwed(summary.SupplementalIncome); err != nil {
+		return nil, err
+	}
+	if summary.MarginalTaxPercent, err = w.CalculateMarginalTaxPercent(summary.SupplementalIncome); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package types
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/tax"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestWithholdingEstimate_CalculateWithholdingEstimateSummary(t *testing.T) {
+	withholdingEstimate := &WithholdingEstimate{
+		Salary: NewMoney(200000),
+		RsuVests: []*RsuOrder{
+			{VestDate: time.Date(2024, time.August, 15, 0, 0, 0, 0, time.UTC), NumberOfStocksVested: NewShares(100), MarketValuePerShare: NewMoney(250)},
+			{VestDate: time.Date(2024, time.February, 15, 0, 0, 0, 0, time.UTC), NumberOfStocksVested: NewShares(100), MarketValuePerShare: NewMoney(250)},
+		},
+		EsppDisqualifyingIncome: NewMoney(5000),
+		TaxModel:                tax.Federal,
+		TaxProfile:              tax.Profile{FilingStatus: tax.Single, TaxYear: 2024},
+	}
+	summary, err := withholdingEstimate.CalculateWithholdingEstimateSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(summary.ToString())
+
+	// $50,000 of vests withheld at 22%; the $55,000 of income on top of the salary spans the 32% and 35% brackets.
+	if summary.TaxWithheld != NewMoney(11000) {
+		t.Errorf("expected $11000.00 withheld, got $%s", summary.TaxWithheld)
+	}
+	expectedTaxOwed := 43725*0.32 + 11275*0.35
+	if summary.TaxOwed != NewMoney(expectedTaxOwed).RoundToCents() {
+		t.Errorf("expected $%.2f owed, got $%s", expectedTaxOwed, summary.TaxOwed)
+	}
+	if summary.Shortfall() != NewMoney(expectedTaxOwed-11000).RoundToCents() {
+		t.Errorf("expected $%.2f shortfall, got $%s", expectedTaxOwed-11000, summary.Shortfall())
+	}
+	if math.Abs(summary.MarginalTaxPercent-35) > 0.0001 {
+		t.Errorf("expected 35%% marginal rate, got %.2f%%", summary.MarginalTaxPercent)
+	}
+	if !summary.VestWithholdings[0].VestDate.Before(summary.VestWithholdings[1].VestDate) {
+		t.Error("expected vests to be withheld in vest date order")
+	}
+}
+
+func TestWithholdingEstimate_CalculateWithholdingEstimateSummary_RecordedTax(t *testing.T) {
+	withholdingEstimate := &WithholdingEstimate{
+		Salary: NewMoney(200000),
+		RsuVests: []*RsuOrder{
+			{VestDate: time.Date(2024, time.February, 15, 0, 0, 0, 0, time.UTC), NumberOfStocksVested: NewShares(100), MarketValuePerShare: NewMoney(250),
+				ConsiderIncomeTaxOnVestedStock: true, IncomeTaxIncurredWhenStockVested: NewMoney(8000)},
+			{VestDate: time.Date(2024, time.August, 15, 0, 0, 0, 0, time.UTC), NumberOfStocksVested: NewShares(100), MarketValuePerShare: NewMoney(250)},
+		},
+		MarginalTaxPercent: 35,
+	}
+	summary, err := withholdingEstimate.CalculateWithholdingEstimateSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The employer withheld 32% on the first vest; the second is estimated at 22%
+	if summary.VestWithholdings[0].TaxWithheld != NewMoney(8000) || summary.VestWithholdings[0].Estimated {
+		t.Errorf("expected the recorded $8000.00 withheld, got $%s", summary.VestWithholdings[0].TaxWithheld)
+	}
+	if summary.VestWithholdings[1].TaxWithheld != NewMoney(5500) || !summary.VestWithholdings[1].Estimated {
+		t.Errorf("expected an estimated $5500.00 withheld, got $%s", summary.VestWithholdings[1].TaxWithheld)
+	}
+	if summary.Shortfall() != NewMoney(17500-13500) {
+		t.Errorf("expected $4000.00 shortfall, got $%s", summary.Shortfall())
+	}
+}
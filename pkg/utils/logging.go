@@ -26,12 +26,21 @@ package utils
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 )
 
 var logger *slog.Logger
 
+// logOutput is where the log entries are written, stdout unless the results are written there instead.
+var logOutput io.Writer = os.Stdout
+
+// SetLogOutput writes the log entries to w, e.g. os.Stderr to keep stdout for machine-readable results.
+func SetLogOutput(w io.Writer) {
+	logOutput = w
+}
+
 // YeetLogHandler formats log entries according to your desired format
 type YeetLogHandler struct {
 	*slog.TextHandler
@@ -50,7 +59,7 @@ func (h *YeetLogHandler) Handle(_ context.Context, r slog.Record) error {
 
 	// Print the log in the desired format
 	//_, err := fmt.Fprintf(os.Stdout, "%s %s - %s\n", timestamp, level, msg)
-	_, err := fmt.Fprintf(os.Stdout, "%s\n", msg)
+	_, err := fmt.Fprintf(logOutput, "%s\n", msg)
 	if err != nil {
 		return err
 	}
@@ -76,7 +85,7 @@ func LogInfo(message string, args ...interface{}) {
 		logger.Info(fmt.Sprintf(message, args...))
 		return
 	}
-	fmt.Printf(fmt.Sprintf(message+"\n", args...))
+	fmt.Fprintf(logOutput, fmt.Sprintf(message+"\n", args...))
 }
 
 func LogDebug(message string, args ...interface{}) {
@@ -84,7 +93,7 @@ func LogDebug(message string, args ...interface{}) {
 		logger.Debug(fmt.Sprintf(message, args...))
 		return
 	}
-	fmt.Printf(fmt.Sprintf(message+"\n", args...))
+	fmt.Fprintf(logOutput, fmt.Sprintf(message+"\n", args...))
 }
 
 func LogWarn(message string, args ...interface{}) {
@@ -92,7 +101,7 @@ func LogWarn(message string, args ...interface{}) {
 		logger.Warn(fmt.Sprintf(message, args...))
 		return
 	}
-	fmt.Printf(fmt.Sprintf(message+"\n", args...))
+	fmt.Fprintf(logOutput, fmt.Sprintf(message+"\n", args...))
 }
 
 func LogError(message string, err error, args ...interface{}) {
@@ -105,5 +114,5 @@ func LogError(message string, err error, args ...interface{}) {
 		logger.Error(effectiveMessage)
 		return
 	}
-	fmt.Printf(fmt.Sprintf(effectiveMessage+"\n", args...))
+	fmt.Fprintf(logOutput, fmt.Sprintf(effectiveMessage+"\n", args...))
 }
@@ -24,8 +24,10 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
 	"github.com/gdamore/tcell/v2"
+	"github.com/leogps/lunar/pkg/tax"
 	"github.com/leogps/lunar/pkg/types"
 	"github.com/rivo/tview"
 	"strconv"
@@ -43,28 +45,62 @@ func loadEspp(app *tview.Application) *tview.Flex {
 	costPerShare := tview.NewInputField().
 		SetLabel("Cost price per share ($)").
 		SetFieldWidth(20).
-		SetAcceptanceFunc(acceptFloat64InputValue)
+		SetAcceptanceFunc(acceptMoneyInputValue)
 
 	discountPercent := tview.NewInputField().
 		SetLabel("Discounted (buying) price percent per share (%)").
 		SetFieldWidth(20).
 		SetAcceptanceFunc(acceptFloat64InputValue)
 
+	// Look-back Group
+	offeringDateMarketValueField := tview.NewInputField().
+		SetLabel("Market price per share on offering date ($): ").
+		SetFieldWidth(20).
+		SetAcceptanceFunc(acceptMoneyInputValue)
+	offeringDateMarketValueField.SetDisabled(true)
+
+	purchaseDateMarketValueField := tview.NewInputField().
+		SetLabel("Market price per share on purchase date ($): ").
+		SetFieldWidth(20).
+		SetAcceptanceFunc(acceptMoneyInputValue)
+	purchaseDateMarketValueField.SetDisabled(true)
+
+	var dispositionCheckbox *tview.Checkbox
+	lookBackCheckbox := tview.NewCheckbox().
+		SetLabel("Apply look-back (lower of offering/purchase date price) (hit Enter/Space to toggle): ").
+		SetChangedFunc(func(checked bool) {
+			// Market values are still needed by the disposition group when look-back is off.
+			needsMarketValues := checked || dispositionCheckbox.IsChecked()
+			if !needsMarketValues {
+				offeringDateMarketValueField.SetText("")
+				purchaseDateMarketValueField.SetText("")
+			}
+			if checked {
+				costPerShare.SetText("")
+			}
+			costPerShare.SetDisabled(checked)
+			offeringDateMarketValueField.SetDisabled(!needsMarketValues)
+			purchaseDateMarketValueField.SetDisabled(!needsMarketValues)
+		})
+
 	form := tview.NewForm()
 
 	form.AddFormItem(costPerShare).
-		AddFormItem(discountPercent)
+		AddFormItem(discountPercent).
+		AddFormItem(lookBackCheckbox).
+		AddFormItem(offeringDateMarketValueField).
+		AddFormItem(purchaseDateMarketValueField)
 
 	// Selling Group
 	sellingPricePerShare := tview.NewInputField().
 		SetLabel("Selling price per share ($)").
 		SetFieldWidth(20).
-		SetAcceptanceFunc(acceptFloat64InputValue)
+		SetAcceptanceFunc(acceptMoneyInputValue)
 
 	shareQty := tview.NewInputField().
 		SetLabel("Number of shares sold").
 		SetFieldWidth(20).
-		SetAcceptanceFunc(acceptIntInputValue)
+		SetAcceptanceFunc(acceptSharesInputValue)
 
 	form.AddFormItem(sellingPricePerShare).
 		AddFormItem(shareQty)
@@ -73,7 +109,7 @@ func loadEspp(app *tview.Application) *tview.Flex {
 	commissionAmountField := tview.NewInputField().
 		SetLabel("Commission Fee Amount per Transaction ($): ").
 		SetFieldWidth(20).
-		SetAcceptanceFunc(acceptFloat64InputValue)
+		SetAcceptanceFunc(acceptMoneyInputValue)
 	commissionAmountField.SetDisabled(true)
 
 	numTransactionsField := tview.NewInputField().
@@ -97,6 +133,26 @@ func loadEspp(app *tview.Application) *tview.Flex {
 		AddFormItem(commissionAmountField).
 		AddFormItem(numTransactionsField)
 
+	// Dates Group
+	offeringDateField := tview.NewInputField().
+		SetLabel("Offering date (YYYY-MM-DD): ").
+		SetFieldWidth(20).
+		SetAcceptanceFunc(acceptDateInputValue)
+
+	purchaseDateField := tview.NewInputField().
+		SetLabel("Purchase date (YYYY-MM-DD): ").
+		SetFieldWidth(20).
+		SetAcceptanceFunc(acceptDateInputValue)
+
+	saleDateField := tview.NewInputField().
+		SetLabel("Sale date (YYYY-MM-DD): ").
+		SetFieldWidth(20).
+		SetAcceptanceFunc(acceptDateInputValue)
+
+	form.AddFormItem(offeringDateField).
+		AddFormItem(purchaseDateField).
+		AddFormItem(saleDateField)
+
 	// Tax Group
 	capitalGainTaxField := tview.NewInputField().
 		SetLabel("Capital Gain Tax Percent percent (Short-Term: 10%-35%) (Long-Term: 0%-20%): ").
@@ -104,64 +160,250 @@ func loadEspp(app *tview.Application) *tview.Flex {
 		SetAcceptanceFunc(acceptFloat64InputValue)
 	capitalGainTaxField.SetDisabled(true)
 
-	taxCheckbox := tview.NewCheckbox().SetLabel("Calculate Capital Gain Tax (hit Enter/Space					 to toggle): ").SetChangedFunc(func(checked bool) {
-		if !checked {
+	shortTermCapitalGainTaxField := tview.NewInputField().
+		SetLabel("Short-Term Capital Gain Tax Percent (10%-37%): ").
+		SetFieldWidth(20).
+		SetAcceptanceFunc(acceptFloat64InputValue)
+	shortTermCapitalGainTaxField.SetDisabled(true)
+
+	longTermCapitalGainTaxField := tview.NewInputField().
+		SetLabel("Long-Term Capital Gain Tax Percent (0%-20%): ").
+		SetFieldWidth(20).
+		SetAcceptanceFunc(acceptFloat64InputValue)
+	longTermCapitalGainTaxField.SetDisabled(true)
+
+	holdingPeriodCheckbox := tview.NewCheckbox().
+		SetLabel("Determine Short-Term/Long-Term from purchase and sale dates (hit Enter/Space to toggle): ")
+	holdingPeriodCheckbox.SetDisabled(true)
+
+	var taxCheckbox *tview.Checkbox
+	updateCapitalGainTaxFields := func() {
+		considerCapitalGainTax := taxCheckbox.IsChecked()
+		considerHoldingPeriod := considerCapitalGainTax && holdingPeriodCheckbox.IsChecked()
+		if !considerCapitalGainTax || considerHoldingPeriod {
 			capitalGainTaxField.SetText("")
 		}
-		capitalGainTaxField.SetDisabled(!checked)
+		if !considerHoldingPeriod {
+			shortTermCapitalGainTaxField.SetText("")
+			longTermCapitalGainTaxField.SetText("")
+		}
+		capitalGainTaxField.SetDisabled(!considerCapitalGainTax || considerHoldingPeriod)
+		shortTermCapitalGainTaxField.SetDisabled(!considerHoldingPeriod)
+		longTermCapitalGainTaxField.SetDisabled(!considerHoldingPeriod)
+	}
+	holdingPeriodCheckbox.SetChangedFunc(func(_ bool) {
+		updateCapitalGainTaxFields()
+	})
+
+	taxCheckbox = tview.NewCheckbox().SetLabel("Calculate Capital Gain Tax (hit Enter/Space					 to toggle): ").SetChangedFunc(func(checked bool) {
+		if !checked {
+			holdingPeriodCheckbox.SetChecked(false)
+		}
+		holdingPeriodCheckbox.SetDisabled(!checked)
+		updateCapitalGainTaxFields()
 	})
 
 	form.AddFormItem(taxCheckbox).
-		AddFormItem(capitalGainTaxField)
+		AddFormItem(capitalGainTaxField).
+		AddFormItem(holdingPeriodCheckbox).
+		AddFormItem(shortTermCapitalGainTaxField).
+		AddFormItem(longTermCapitalGainTaxField)
+
+	taxProfile := newTaxProfileFields()
+	taxProfile.addTo(form)
+
+	// Disposition Group
+	ordinaryIncomeTaxField := tview.NewInputField().
+		SetLabel("Ordinary Income Tax Percent: ").
+		SetFieldWidth(20).
+		SetAcceptanceFunc(acceptFloat64InputValue)
+	ordinaryIncomeTaxField.SetDisabled(true)
+
+	dispositionCheckbox = tview.NewCheckbox().
+		SetLabel("Classify qualifying/disqualifying disposition from dates (hit Enter/Space to toggle): ").
+		SetChangedFunc(func(checked bool) {
+			if !checked {
+				ordinaryIncomeTaxField.SetText("")
+			}
+			ordinaryIncomeTaxField.SetDisabled(!checked)
+			// Market values on offering/purchase date are needed to compute the ordinary income.
+			needsMarketValues := checked || lookBackCheckbox.IsChecked()
+			if !needsMarketValues {
+				offeringDateMarketValueField.SetText("")
+				purchaseDateMarketValueField.SetText("")
+			}
+			offeringDateMarketValueField.SetDisabled(!needsMarketValues)
+			purchaseDateMarketValueField.SetDisabled(!needsMarketValues)
+		})
+
+	form.AddFormItem(dispositionCheckbox).
+		AddFormItem(ordinaryIncomeTaxField)
+
+	// Config profile defaults
+	prefillInputField(discountPercent, "discount")
+	if profileEnabled("look-back") {
+		checkCheckbox(lookBackCheckbox)
+	}
+	prefillCommission(commissionCheckbox, commissionAmountField, numTransactionsField)
+	prefillCapitalGainTax(taxCheckbox, capitalGainTaxField, holdingPeriodCheckbox, shortTermCapitalGainTaxField,
+		longTermCapitalGainTaxField)
+	taxProfile.prefill()
+	prefillInputField(ordinaryIncomeTaxField, "ordinary-income-percent")
+
+	saleTarget := newSaleTargetFields()
+	saleTarget.addTo(form)
+
+	sensitivity := newSensitivityFields([]types.SensitivityVariable{types.CapitalGainTaxRateVariable, types.SharesSoldVariable})
+	sensitivity.addTo(form)
+
+	readEsppOrder := func() (*types.EsppOrder, error) {
+		esppOrder := types.EsppOrder{}
+		// Retrieve values
+		esppOrder.CostPerShare, _ = types.ParseMoney(costPerShare.GetText())
+		esppOrder.DiscountPercent, _ = strconv.ParseFloat(discountPercent.GetText(), 64)
+		esppOrder.OfferingDateMarketValuePerShare, _ = types.ParseMoney(offeringDateMarketValueField.GetText())
+		esppOrder.PurchaseDateMarketValuePerShare, _ = types.ParseMoney(purchaseDateMarketValueField.GetText())
+		esppOrder.ConsiderLookBack = lookBackCheckbox.IsChecked()
+		esppOrder.SellingPricePerShare, _ = types.ParseMoney(sellingPricePerShare.GetText())
+		esppOrder.NumberOfSharesSold, _ = types.ParseShares(shareQty.GetText())
+
+		if commissionCheckbox.IsChecked() {
+			esppOrder.ConsiderTransactionCommission = true
+			esppOrder.CommissionPaidPerTransaction, _ = types.ParseMoney(commissionAmountField.GetText())
+			esppOrder.NumberOfTransactions, _ = strconv.Atoi(numTransactionsField.GetText())
+		}
+
+		if taxCheckbox.IsChecked() {
+			esppOrder.ConsiderCapitalGainTax = true
+			esppOrder.CapitalGainTaxPercent, _ = strconv.ParseFloat(capitalGainTaxField.GetText(), 64)
+			if holdingPeriodCheckbox.IsChecked() {
+				esppOrder.ConsiderHoldingPeriod = true
+				esppOrder.ShortTermCapitalGainTaxPercent, _ = strconv.ParseFloat(shortTermCapitalGainTaxField.GetText(), 64)
+				esppOrder.LongTermCapitalGainTaxPercent, _ = strconv.ParseFloat(longTermCapitalGainTaxField.GetText(), 64)
+			}
+		}
+
+		if dispositionCheckbox.IsChecked() {
+			esppOrder.ConsiderDisposition = true
+			esppOrder.OrdinaryIncomeTaxPercent, _ = strconv.ParseFloat(ordinaryIncomeTaxField.GetText(), 64)
+		}
+
+		var err error
+		if esppOrder.ConsiderDisposition {
+			if esppOrder.OfferingDate, err = parseDateInputValue("offering date", offeringDateField.GetText()); err != nil {
+				return nil, err
+			}
+		}
+		if esppOrder.ConsiderDisposition || esppOrder.ConsiderHoldingPeriod {
+			if esppOrder.PurchaseDate, err = parseDateInputValue("purchase date", purchaseDateField.GetText()); err != nil {
+				return nil, err
+			}
+			if esppOrder.SaleDate, err = parseDateInputValue("sale date", saleDateField.GetText()); err != nil {
+				return nil, err
+			}
+		}
+		taxSelection, err := taxProfile.read()
+		if err != nil {
+			return nil, err
+		}
+		esppOrder.TaxModel = taxSelection.TaxModel
+		esppOrder.StateTaxModel = taxSelection.StateTaxModel
+		esppOrder.TaxProfile = taxSelection.Profile
+		esppOrder.ConsiderNetInvestmentIncomeTax = taxSelection.ConsiderNetInvestmentIncomeTax
+		esppOrder.ModifiedAdjustedGrossIncome = taxSelection.ModifiedAdjustedGrossIncome
+		return &esppOrder, nil
+	}
 
 	// Create a Submit Button
 	form.AddButton("Submit", func() {
-		costPerShareValue, _ := strconv.ParseFloat(costPerShare.GetText(), 64)
-		discountPercentValue, _ := strconv.ParseFloat(discountPercent.GetText(), 64)
-		sellingPricePerShareValue, _ := strconv.ParseFloat(sellingPricePerShare.GetText(), 64)
-		shareQtyValue, _ := strconv.Atoi(shareQty.GetText())
-		considerCommission := commissionCheckbox.IsChecked()
-		commissionAmount, _ := strconv.ParseFloat(commissionAmountField.GetText(), 64)
-		numOfTransactions, _ := strconv.Atoi(numTransactionsField.GetText())
-		considerCapitalGainTax := taxCheckbox.IsChecked()
-		capitalGainTax, _ := strconv.ParseFloat(capitalGainTaxField.GetText(), 64)
-
-		esppOrder := buildEsppOrder(costPerShareValue,
-			discountPercentValue,
-			sellingPricePerShareValue,
-			shareQtyValue,
-			considerCommission,
-			commissionAmount,
-			numOfTransactions,
-			considerCapitalGainTax,
-			capitalGainTax)
-
+		esppOrder, err := readEsppOrder()
+		if err != nil {
+			showEsppError(err, status, summary)
+			return
+		}
 		calculateEspp(esppOrder, status, summary)
 	})
 
 	form.AddButton("Target Profits", func() {
-		costPerShareValue, _ := strconv.ParseFloat(costPerShare.GetText(), 64)
-		discountPercentValue, _ := strconv.ParseFloat(discountPercent.GetText(), 64)
-		sellingPricePerShareValue, _ := strconv.ParseFloat(sellingPricePerShare.GetText(), 64)
-		shareQtyValue, _ := strconv.Atoi(shareQty.GetText())
-		considerCommission := commissionCheckbox.IsChecked()
-		commissionAmount, _ := strconv.ParseFloat(commissionAmountField.GetText(), 64)
-		numOfTransactions, _ := strconv.Atoi(numTransactionsField.GetText())
-		considerCapitalGainTax := taxCheckbox.IsChecked()
-		capitalGainTax, _ := strconv.ParseFloat(capitalGainTaxField.GetText(), 64)
-
-		esppOrder := buildEsppOrder(costPerShareValue,
-			discountPercentValue,
-			sellingPricePerShareValue,
-			shareQtyValue,
-			considerCommission,
-			commissionAmount,
-			numOfTransactions,
-			considerCapitalGainTax,
-			capitalGainTax)
+		esppOrder, err := readEsppOrder()
+		if err != nil {
+			showEsppError(err, status, summary)
+			return
+		}
 		calculateEsppTargetProfits(esppOrder, status, summary, form, app)
 	})
 
+	form.AddButton("Solve Target", func() {
+		esppOrder, err := readEsppOrder()
+		if err != nil {
+			showEsppError(err, status, summary)
+			return
+		}
+		target, targetAmount, err := saleTarget.read()
+		if err != nil {
+			showEsppError(err, status, summary)
+			return
+		}
+		solverResult, err := esppOrder.SolveSellingPriceForTarget(target, targetAmount)
+		if err != nil {
+			clearFlexItems(summary)
+			status.SetText(unsolvedStatus(err))
+			currentDataView = EsppError
+			return
+		}
+		sellingPricePerShare.SetText(solverResult.Value.StringPerShare())
+		esppOrder.SellingPricePerShare = solverResult.Value
+		calculateEspp(esppOrder, status, summary)
+		if currentDataView == EsppOrderSummary {
+			status.SetText(solvedStatus(target, targetAmount, solverResult))
+		}
+	})
+
+	form.AddButton("Shares Needed", func() {
+		esppOrder, err := readEsppOrder()
+		if err != nil {
+			showEsppError(err, status, summary)
+			return
+		}
+		target, targetAmount, err := saleTarget.read()
+		if err != nil {
+			showEsppError(err, status, summary)
+			return
+		}
+		solverResult, err := esppOrder.SolveSharesSoldForTarget(target, targetAmount, saleTarget.fractionalSharesCheckbox.IsChecked())
+		if err != nil {
+			clearFlexItems(summary)
+			status.SetText(unsolvedStatus(err))
+			currentDataView = EsppError
+			return
+		}
+		shareQty.SetText(solverResult.Value.String())
+		esppOrder.NumberOfSharesSold = solverResult.Value
+		calculateEspp(esppOrder, status, summary)
+		if currentDataView == EsppOrderSummary {
+			status.SetText(sharesNeededStatus(target, targetAmount, solverResult))
+		}
+	})
+
+	form.AddButton("Sensitivity", func() {
+		esppOrder, err := readEsppOrder()
+		if err != nil {
+			showEsppError(err, status, summary)
+			return
+		}
+		variable, sellingPrices, values, err := sensitivity.read()
+		if err != nil {
+			showEsppError(err, status, summary)
+			return
+		}
+		sensitivityGrid, err := esppOrder.CalculateSensitivityGrid(variable, sellingPrices, values)
+		if err != nil {
+			showEsppError(err, status, summary)
+			return
+		}
+		showSensitivityGrid(sensitivityGrid, EsppSensitivity, status, summary, form, app)
+	})
+
 	// Create a Exit Button
 	form.AddButton("Exit", func() {
 		app.Stop() // Close the app without submission
@@ -220,6 +462,7 @@ func calculateEsppTargetProfits(esppOrder *types.EsppOrder,
 		"Effective Commission ($)",
 		"Profit Before Tax ($)",
 		"Capital Gain Tax ($)",
+		"NIIT ($)",
 		"Profit After Tax ($)",
 	} {
 		table.SetCell(0, index, tview.NewTableCell(header).
@@ -233,9 +476,6 @@ func calculateEsppTargetProfits(esppOrder *types.EsppOrder,
 	for percent := 0.0; percent <= 100.0; percent += 5 {
 		// Calculate the selling price for the current percentage
 		sellingPrice, err := esppOrder.CalculateSellingPriceForTargetProfitPercent(percent)
-		if err != nil {
-			sellingPrice = -1 // Handle error case by setting to 0 or any fallback
-		}
 
 		// Set profit percentage and calculated selling price in the table
 		var targetProfitHeader = fmt.Sprintf("%.0f%%", percent)
@@ -246,29 +486,49 @@ func calculateEsppTargetProfits(esppOrder *types.EsppOrder,
 		table.SetCell(row, col, tview.NewTableCell(targetProfitHeader).
 			SetAlign(tview.AlignCenter))
 		col++
-		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%.2f", sellingPrice)).
+		var noSolutionError *types.NoSolutionError
+		if errors.As(err, &noSolutionError) {
+			// Higher targets are out of reach as well
+			table.SetCell(row, col, tview.NewTableCell("No solution").
+				SetAlign(tview.AlignCenter))
+			break
+		}
+		if err != nil {
+			status.SetText(fmt.Sprintf("Error occurred: %v", err))
+			currentDataView = EsppError
+			return
+		}
+		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%s", sellingPrice)).
 			SetAlign(tview.AlignCenter))
 		col++
 
 		esppOrderClone := esppOrder.Clone()
 		esppOrderClone.SellingPricePerShare = sellingPrice
-		esppOrderSummary := esppOrderClone.CalculateEsppOrderSummary()
-		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%.2f", esppOrderSummary.TotalSellingPrice)).
+		esppOrderSummary, err := esppOrderClone.CalculateEsppOrderSummary()
+		if err != nil {
+			status.SetText(fmt.Sprintf("Error occurred: %v", err))
+			currentDataView = EsppError
+			return
+		}
+		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%s", esppOrderSummary.TotalSellingPrice)).
 			SetAlign(tview.AlignCenter))
 		col++
-		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%.2f", esppOrderSummary.TotalCost)).
+		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%s", esppOrderSummary.TotalCost)).
 			SetAlign(tview.AlignCenter))
 		col++
-		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%.2f", esppOrderSummary.EffectiveCommission)).
+		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%s", esppOrderSummary.EffectiveCommission)).
 			SetAlign(tview.AlignCenter))
 		col++
-		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%.2f", esppOrderSummary.NetResult)).
+		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%s", esppOrderSummary.NetResult)).
 			SetAlign(tview.AlignCenter))
 		col++
-		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%.2f", esppOrderSummary.CapitalGainTaxAmount)).
+		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%s", esppOrderSummary.CapitalGainTaxAmount)).
 			SetAlign(tview.AlignCenter))
 		col++
-		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%.2f", esppOrderSummary.ProfitOrLossAfterCapitalGainsTax())).
+		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%s", esppOrderSummary.NetInvestmentIncomeTaxAmount)).
+			SetAlign(tview.AlignCenter))
+		col++
+		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%s", esppOrderSummary.ProfitOrLossAfterCapitalGainsTax())).
 			SetAlign(tview.AlignCenter))
 		col++
 
@@ -303,33 +563,12 @@ func calculateEsppTargetProfits(esppOrder *types.EsppOrder,
 	currentDataView = EsppTargetProfits
 }
 
-func buildEsppOrder(costPerShare float64,
-	discountPercent float64,
-	sellingPricePerShare float64,
-	shareQty int,
-	considerCommission bool,
-	commissionAmount float64,
-	numOfTransactions int,
-	considerCapitalGainTax bool,
-	capitalGainTax float64) *types.EsppOrder {
-	esppOrder := types.EsppOrder{}
-	// Retrieve values
-	esppOrder.CostPerShare = costPerShare
-	esppOrder.DiscountPercent = discountPercent
-	esppOrder.SellingPricePerShare = sellingPricePerShare
-	esppOrder.NumberOfSharesSold = shareQty
-
-	if considerCommission {
-		esppOrder.ConsiderTransactionCommission = true
-		esppOrder.CommissionPaidPerTransaction = commissionAmount
-		esppOrder.NumberOfTransactions = numOfTransactions
-	}
-
-	if considerCapitalGainTax {
-		esppOrder.ConsiderCapitalGainTax = true
-		esppOrder.CapitalGainTaxPercent = capitalGainTax
-	}
-	return &esppOrder
+func showEsppError(err error,
+	status *tview.TextView,
+	summary *tview.Flex) {
+	clearFlexItems(summary)
+	status.SetText(fmt.Sprintf("Error occurred: %v", err))
+	currentDataView = EsppError
 }
 
 func calculateEspp(esppOrder *types.EsppOrder,
@@ -338,9 +577,22 @@ func calculateEspp(esppOrder *types.EsppOrder,
 	status.SetText("Calculating...")
 	clearFlexItems(summary)
 
-	esppOrderSummary := esppOrder.CalculateEsppOrderSummary()
+	esppOrderSummary, err := esppOrder.CalculateEsppOrderSummary()
+	if err != nil {
+		status.SetText(fmt.Sprintf("Error occurred: %v", err))
+		currentDataView = EsppError
+		return
+	}
+	if esppOrder.ConsiderLookBack {
+		lookBackField := tview.NewTextView().
+			SetLabel(fmt.Sprintf("Look-back: lower of offering ($%s) and purchase ($%s) date price",
+				esppOrder.OfferingDateMarketValuePerShare, esppOrder.PurchaseDateMarketValuePerShare)).
+			SetTextAlign(tview.AlignLeft)
+		summary.AddItem(lookBackField, 1, 1, false)
+	}
+
 	costField := tview.NewTextView().
-		SetLabel(fmt.Sprintf("Cost: $%.2f", esppOrder.CostPerShare)).
+		SetLabel(fmt.Sprintf("Cost: $%s", esppOrderSummary.BaseCostPerShare)).
 		SetTextAlign(tview.AlignLeft)
 	summary.AddItem(costField, 1, 1, false)
 
@@ -351,30 +603,30 @@ func calculateEspp(esppOrder *types.EsppOrder,
 
 	effectiveCostPerShare := esppOrderSummary.EffectiveCostPerShare
 	effectiveCostPerShareField := tview.NewTextView().
-		SetLabel(fmt.Sprintf("True cost per share: $%.2f", effectiveCostPerShare)).
+		SetLabel(fmt.Sprintf("True cost per share: $%s", effectiveCostPerShare)).
 		SetTextAlign(tview.AlignLeft)
 	summary.AddItem(effectiveCostPerShareField, 1, 1, false)
 
 	sellingPricePerShareField := tview.NewTextView().
-		SetLabel(fmt.Sprintf("Selling price per share: $%.2f", esppOrder.SellingPricePerShare)).
+		SetLabel(fmt.Sprintf("Selling price per share: $%s", esppOrder.SellingPricePerShare)).
 		SetTextAlign(tview.AlignLeft)
 	summary.AddItem(sellingPricePerShareField, 1, 1, false)
 
 	numberOfSharesSoldField := tview.NewTextView().
-		SetLabel(fmt.Sprintf("Number of shares sold: %d", esppOrder.NumberOfSharesSold)).
+		SetLabel(fmt.Sprintf("Number of shares sold: %s", esppOrder.NumberOfSharesSold)).
 		SetTextAlign(tview.AlignLeft)
 	summary.AddItem(numberOfSharesSoldField, 1, 1, false)
 
 	totalSellingPrice := esppOrderSummary.TotalSellingPrice
 	totalSellingPriceField := tview.NewTextView().
-		SetLabel(fmt.Sprintf("Total selling price (%d * $%.2f): $%.2f",
+		SetLabel(fmt.Sprintf("Total selling price (%s * $%s): $%s",
 			esppOrder.NumberOfSharesSold, esppOrder.SellingPricePerShare, totalSellingPrice)).
 		SetTextAlign(tview.AlignLeft)
 	summary.AddItem(totalSellingPriceField, 1, 1, false)
 
 	totalCost := esppOrderSummary.TotalCost
 	totalCostField := tview.NewTextView().
-		SetLabel(fmt.Sprintf("Total cost (%d * $%.2f): $%.2f",
+		SetLabel(fmt.Sprintf("Total cost (%s * $%s): $%s",
 			esppOrder.NumberOfSharesSold, esppOrderSummary.EffectiveCostPerShare, totalCost)).
 		SetTextAlign(tview.AlignLeft)
 	summary.AddItem(totalCostField, 1, 1, false)
@@ -382,44 +634,110 @@ func calculateEspp(esppOrder *types.EsppOrder,
 	if esppOrder.ConsiderTransactionCommission {
 		effectiveTransactionCommission := esppOrderSummary.EffectiveCommission
 		effectiveTransactionCommissionField := tview.NewTextView().
-			SetLabel(fmt.Sprintf("Effective commission fee (%d * $%.2f): $%.2f",
+			SetLabel(fmt.Sprintf("Effective commission fee (%d * $%s): $%s",
 				esppOrder.NumberOfTransactions, esppOrder.CommissionPaidPerTransaction, effectiveTransactionCommission)).
 			SetTextAlign(tview.AlignLeft)
 		summary.AddItem(effectiveTransactionCommissionField, 1, 1, false)
 	}
 
+	if esppOrder.ConsiderDisposition {
+		dispositionField := tview.NewTextView().
+			SetLabel(fmt.Sprintf("Disposition: %s", esppOrderSummary.DispositionType)).
+			SetTextAlign(tview.AlignLeft)
+		summary.AddItem(dispositionField, 1, 1, false)
+
+		ordinaryIncomeField := tview.NewTextView().
+			SetLabel(fmt.Sprintf("Ordinary income (%s * $%s): $%s",
+				esppOrder.NumberOfSharesSold, esppOrderSummary.OrdinaryIncomePerShare, esppOrderSummary.OrdinaryIncomeAmount)).
+			SetTextAlign(tview.AlignLeft)
+		summary.AddItem(ordinaryIncomeField, 1, 1, false)
+
+		ordinaryIncomeTaxField := tview.NewTextView().
+			SetLabel(fmt.Sprintf("Ordinary income tax amount: $%s", esppOrderSummary.OrdinaryIncomeTaxAmount)).
+			SetTextAlign(tview.AlignLeft)
+		summary.AddItem(ordinaryIncomeTaxField, 1, 1, false)
+		if esppOrder.StateTaxModel != nil {
+			ordinaryIncomeTaxBreakdownField := tview.NewTextView().
+				SetLabel(fmt.Sprintf("  Federal: $%s, State: $%s",
+					esppOrderSummary.FederalOrdinaryIncomeTaxAmount, esppOrderSummary.StateOrdinaryIncomeTaxAmount)).
+				SetTextAlign(tview.AlignLeft)
+			summary.AddItem(ordinaryIncomeTaxBreakdownField, 1, 1, false)
+		}
+
+		adjustedCostBasisField := tview.NewTextView().
+			SetLabel(fmt.Sprintf("Adjusted cost basis per share: $%s", esppOrderSummary.AdjustedCostBasisPerShare)).
+			SetTextAlign(tview.AlignLeft)
+		summary.AddItem(adjustedCostBasisField, 1, 1, false)
+
+		capitalGainField := tview.NewTextView().
+			SetLabel(fmt.Sprintf("Capital gain/loss: $%s", esppOrderSummary.CapitalGainAmount)).
+			SetTextAlign(tview.AlignLeft)
+		summary.AddItem(capitalGainField, 1, 1, false)
+	}
+
 	profitOrLoss := esppOrderSummary.NetResult
 	if esppOrderSummary.NetResult > 0 {
 		profitOrLossField := tview.NewTextView().
-			SetLabel(fmt.Sprintf("Profit (before capital gains tax): $%.2f", profitOrLoss)).
+			SetLabel(fmt.Sprintf("Profit (before capital gains tax): $%s", profitOrLoss)).
 			SetTextAlign(tview.AlignLeft)
 		summary.AddItem(profitOrLossField, 1, 1, false)
 
+		if esppOrder.ConsiderHoldingPeriod {
+			holdingPeriodField := tview.NewTextView().
+				SetLabel(fmt.Sprintf("Holding period: %s (long-term from %s)",
+					esppOrderSummary.HoldingPeriod, esppOrderSummary.LongTermDate.Format(types.DateLayout))).
+				SetTextAlign(tview.AlignLeft)
+			summary.AddItem(holdingPeriodField, 1, 1, false)
+		}
+
+		if esppOrder.ConsiderNetInvestmentIncomeTax {
+			netInvestmentIncomeTaxField := tview.NewTextView().
+				SetLabel(fmt.Sprintf("Net investment income tax (%.1f%%): $%s",
+					tax.NetInvestmentIncomeTaxPercent, esppOrderSummary.NetInvestmentIncomeTaxAmount)).
+				SetTextAlign(tview.AlignLeft)
+			summary.AddItem(netInvestmentIncomeTaxField, 1, 1, false)
+		}
+
 		if esppOrder.ConsiderCapitalGainTax {
 			capitalGainTaxAmount := esppOrderSummary.CapitalGainTaxAmount
 			capitalGainTaxAmountField := tview.NewTextView().
-				SetLabel(fmt.Sprintf("Captial gain tax amount: $%.2f", capitalGainTaxAmount)).
+				SetLabel(fmt.Sprintf("Captial gain tax amount (%.2f%%): $%s",
+					esppOrderSummary.EffectiveCapitalGainTaxPercent, capitalGainTaxAmount)).
 				SetTextAlign(tview.AlignLeft)
 			summary.AddItem(capitalGainTaxAmountField, 1, 1, false)
+			if esppOrder.StateTaxModel != nil {
+				capitalGainTaxBreakdownField := tview.NewTextView().
+					SetLabel(fmt.Sprintf("  Federal: $%s, State: $%s",
+						esppOrderSummary.FederalCapitalGainTaxAmount, esppOrderSummary.StateCapitalGainTaxAmount)).
+					SetTextAlign(tview.AlignLeft)
+				summary.AddItem(capitalGainTaxBreakdownField, 1, 1, false)
+			}
 
 			effectiveProfit := esppOrderSummary.ProfitOrLossAfterCapitalGainsTax()
 			effectiveProfitField := tview.NewTextView().
-				SetLabel(fmt.Sprintf("Profit (after capital gain tax): $%.2f", effectiveProfit)).
+				SetLabel(fmt.Sprintf("Profit (after capital gain tax): $%s", effectiveProfit)).
 				SetTextAlign(tview.AlignLeft)
 			summary.AddItem(effectiveProfitField, 1, 1, false)
 		}
 	} else if profitOrLoss < 0 {
 		profitOrLossField := tview.NewTextView().
-			SetLabel(fmt.Sprintf("Loss: $%.2f", profitOrLoss)).
+			SetLabel(fmt.Sprintf("Loss: $%s", profitOrLoss)).
 			SetTextAlign(tview.AlignLeft)
 		summary.AddItem(profitOrLossField, 1, 1, false)
 	} else {
 		profitOrLossField := tview.NewTextView().
-			SetLabel(fmt.Sprintf("Break even: $%.2f", profitOrLoss)).
+			SetLabel(fmt.Sprintf("Break even: $%s", profitOrLoss)).
 			SetTextAlign(tview.AlignLeft)
 		summary.AddItem(profitOrLossField, 1, 1, false)
 	}
 
+	if esppOrder.ConsiderDisposition || esppOrder.ConsiderNetInvestmentIncomeTax {
+		trueProfitOrLossField := tview.NewTextView().
+			SetLabel(fmt.Sprintf("True Profit/Loss: $%s", esppOrderSummary.TrueProfitOrLoss())).
+			SetTextAlign(tview.AlignLeft)
+		summary.AddItem(trueProfitOrLossField, 1, 1, false)
+	}
+
 	gainOrLossMargin := esppOrderSummary.ProfitOrLossMargin()
 	gainOrLossMarginField := tview.NewTextView().
 		SetLabel(fmt.Sprintf("Gain/Loss Margin: %.2f%%", gainOrLossMargin)).
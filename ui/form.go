@@ -24,9 +24,14 @@
 package ui
 
 import (
+	"fmt"
 	"github.com/gdamore/tcell/v2"
+	"github.com/leogps/lunar/pkg/config"
+	"github.com/leogps/lunar/pkg/types"
 	"github.com/rivo/tview"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type DataView int
@@ -34,23 +39,32 @@ type DataView int
 const (
 	EsppOrderSummary DataView = iota
 	EsppTargetProfits
+	EsppSensitivity
 	EsppError
 	RsuOrderSummary
 	RsuTargetProfits
+	RsuSensitivity
 	RsuError
+	WithholdingSummary
+	WithholdingError
 )
 
 var currentDataView DataView
 
-func StartApp() error {
+// StartApp runs the terminal UI with the forms prefilled from the config profile
+func StartApp(profile config.Profile) error {
+	profileDefaults = profile
 	app := tview.NewApplication()
 
 	// Function to show the main form
 	showMainForm := func(orderType string) {
 		var root *tview.Flex
-		if orderType == "ESPP" {
+		switch orderType {
+		case "ESPP":
 			root = loadEspp(app)
-		} else {
+		case "Withholding":
+			root = loadWithholding(app)
+		default:
 			root = loadRsu(app)
 		}
 		app.SetRoot(root, true) // Set the root to the new form layout
@@ -59,7 +73,7 @@ func StartApp() error {
 	// Create a dropdown for selecting ESPP or RSU
 	selectBox := tview.NewDropDown().
 		SetLabel("Select Order Type (hit Enter/Space to choose): ").
-		SetOptions([]string{"ESPP", "RSU"}, func(option string, index int) {
+		SetOptions([]string{"ESPP", "RSU", "Withholding"}, func(option string, index int) {
 			// Show the corresponding form when an option is selected
 			showMainForm(option)
 		})
@@ -96,6 +110,35 @@ func acceptFloat64InputValue(text string, _ rune) bool {
 	return err == nil || text == ""
 }
 
+// acceptSharesInputValue validates the input to only allow whole or fractional numbers of shares
+func acceptSharesInputValue(text string, _ rune) bool {
+	_, err := types.ParseShares(text)
+	return err == nil || text == ""
+}
+
+// acceptMoneyInputValue validates the input to only allow dollar amounts
+func acceptMoneyInputValue(text string, _ rune) bool {
+	_, err := types.ParseMoney(text)
+	return err == nil || text == ""
+}
+
+// acceptDateInputValue validates the input to only allow partial or complete YYYY-MM-DD values
+func acceptDateInputValue(text string, _ rune) bool {
+	if len(text) > len(types.DateLayout) {
+		return false
+	}
+	return strings.Trim(text, "0123456789-") == ""
+}
+
+// parseDateInputValue parses a YYYY-MM-DD value, naming the field on error
+func parseDateInputValue(name string, text string) (time.Time, error) {
+	value, err := time.Parse(types.DateLayout, text)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be in YYYY-MM-DD format", name)
+	}
+	return value, nil
+}
+
 func enableTableScroll(table *tview.Table) {
 	totalRowCount := table.GetRowCount()
 	// Manage selection
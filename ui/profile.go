@@ -0,0 +1,135 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+package ui
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/leogps/lunar/pkg/config"
+	"github.com/leogps/lunar/pkg/tax"
+	"github.com/rivo/tview"
+	"strings"
+)
+
+// profileDefaults are the values of the config profile the forms are prefilled with
+var profileDefaults = config.Profile{}
+
+// profileValue returns the value the config profile sets for the key
+func profileValue(key string) (string, bool) {
+	value, ok := profileDefaults[key]
+	return value, ok && value != ""
+}
+
+// profileEnabled reports whether the config profile sets the boolean key to true
+func profileEnabled(key string) bool {
+	value, _ := profileValue(key)
+	switch strings.ToLower(value) {
+	case "true", "yes", "y":
+		return true
+	}
+	return false
+}
+
+// prefillInputField sets the text of the field to the value of the config profile, if any
+func prefillInputField(field *tview.InputField, key string) {
+	if value, ok := profileValue(key); ok {
+		field.SetText(value)
+	}
+}
+
+// checkCheckbox checks the checkbox as if toggled by the user, so its changed func updates the dependent fields
+func checkCheckbox(checkbox *tview.Checkbox) {
+	if checkbox.IsChecked() {
+		return
+	}
+	checkbox.InputHandler()(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), func(tview.Primitive) {})
+}
+
+// prefillCommission checks the commission checkbox and fills in the commission of the config profile, if any
+func prefillCommission(commissionCheckbox *tview.Checkbox, commissionAmountField *tview.InputField,
+	numTransactionsField *tview.InputField) {
+	if _, ok := profileValue("commission"); !ok {
+		return
+	}
+	checkCheckbox(commissionCheckbox)
+	prefillInputField(commissionAmountField, "commission")
+	numTransactionsField.SetText("1")
+	prefillInputField(numTransactionsField, "transactions")
+}
+
+// prefillCapitalGainTax checks the capital gain tax and holding period checkboxes and fills in the rates of the config
+// profile, if any. Like the flags, any of the rates or the tax brackets imply the capital gain tax.
+func prefillCapitalGainTax(taxCheckbox *tview.Checkbox, capitalGainTaxField *tview.InputField,
+	holdingPeriodCheckbox *tview.Checkbox, shortTermCapitalGainTaxField *tview.InputField,
+	longTermCapitalGainTaxField *tview.InputField) {
+	_, hasCapitalGainTaxPercent := profileValue("cgt-percent")
+	_, hasShortTermPercent := profileValue("short-term-percent")
+	_, hasLongTermPercent := profileValue("long-term-percent")
+	considerHoldingPeriod := profileEnabled("holding-period") || hasShortTermPercent || hasLongTermPercent
+	if !hasCapitalGainTaxPercent && !considerHoldingPeriod && !profileEnabled("tax-brackets") {
+		return
+	}
+	checkCheckbox(taxCheckbox)
+	if considerHoldingPeriod {
+		checkCheckbox(holdingPeriodCheckbox)
+		prefillInputField(shortTermCapitalGainTaxField, "short-term-percent")
+		prefillInputField(longTermCapitalGainTaxField, "long-term-percent")
+		return
+	}
+	prefillInputField(capitalGainTaxField, "cgt-percent")
+}
+
+// prefill selects the tax brackets, state, filing status, tax year, other income and NIIT of the config profile
+func (t *taxProfileFields) prefill() {
+	if profileEnabled("tax-brackets") {
+		checkCheckbox(t.checkbox)
+	}
+	if stateCode, ok := profileValue("state"); ok {
+		// The first option is noStateOption
+		for index, code := range tax.StateCodes() {
+			if strings.EqualFold(code, strings.TrimSpace(stateCode)) {
+				t.state.SetCurrentOption(index + 1)
+			}
+		}
+	}
+	prefillFilingStatus(t.filingStatus)
+	prefillInputField(t.taxYearField, "tax-year")
+	prefillInputField(t.otherIncomeField, "other-income")
+	if _, ok := profileValue("magi"); ok {
+		checkCheckbox(t.netInvestmentIncomeTaxCheckbox)
+		prefillInputField(t.magiField, "magi")
+	}
+}
+
+// prefillFilingStatus selects the filing status of the config profile, if any
+func prefillFilingStatus(filingStatusDropDown *tview.DropDown) {
+	filingStatusValue, _ := profileValue("filing-status")
+	selected, err := tax.ParseFilingStatus(filingStatusValue)
+	if err != nil {
+		return
+	}
+	for index, filingStatus := range tax.FilingStatuses() {
+		if filingStatus == selected {
+			filingStatusDropDown.SetCurrentOption(index)
+		}
+	}
+}
@@ -24,8 +24,10 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
 	"github.com/gdamore/tcell/v2"
+	"github.com/leogps/lunar/pkg/tax"
 	"github.com/leogps/lunar/pkg/types"
 	"github.com/rivo/tview"
 	"strconv"
@@ -45,12 +47,12 @@ func loadRsu(app *tview.Application) *tview.Flex {
 	sellingPricePerShare := tview.NewInputField().
 		SetLabel("Selling price per share ($)").
 		SetFieldWidth(20).
-		SetAcceptanceFunc(acceptFloat64InputValue)
+		SetAcceptanceFunc(acceptMoneyInputValue)
 
 	shareQty := tview.NewInputField().
 		SetLabel("Number of shares sold").
 		SetFieldWidth(20).
-		SetAcceptanceFunc(acceptIntInputValue)
+		SetAcceptanceFunc(acceptSharesInputValue)
 
 	form.AddFormItem(sellingPricePerShare).
 		AddFormItem(shareQty)
@@ -59,7 +61,7 @@ func loadRsu(app *tview.Application) *tview.Flex {
 	commissionAmountField := tview.NewInputField().
 		SetLabel("Commission Fee Amount per Transaction ($): ").
 		SetFieldWidth(20).
-		SetAcceptanceFunc(acceptFloat64InputValue)
+		SetAcceptanceFunc(acceptMoneyInputValue)
 	commissionAmountField.SetDisabled(true)
 
 	numTransactionsField := tview.NewInputField().
@@ -83,6 +85,20 @@ func loadRsu(app *tview.Application) *tview.Flex {
 		AddFormItem(commissionAmountField).
 		AddFormItem(numTransactionsField)
 
+	// Dates Group
+	vestDateField := tview.NewInputField().
+		SetLabel("Vest date (YYYY-MM-DD): ").
+		SetFieldWidth(20).
+		SetAcceptanceFunc(acceptDateInputValue)
+
+	saleDateField := tview.NewInputField().
+		SetLabel("Sale date (YYYY-MM-DD): ").
+		SetFieldWidth(20).
+		SetAcceptanceFunc(acceptDateInputValue)
+
+	form.AddFormItem(vestDateField).
+		AddFormItem(saleDateField)
+
 	// Tax Group
 	capitalGainTaxField := tview.NewInputField().
 		SetLabel("Capital Gain Tax Percent percent (Short-Term: 10%-35%) (Long-Term: 0%-20%): ").
@@ -90,106 +106,309 @@ func loadRsu(app *tview.Application) *tview.Flex {
 		SetAcceptanceFunc(acceptFloat64InputValue)
 	capitalGainTaxField.SetDisabled(true)
 
-	taxCheckbox := tview.NewCheckbox().SetLabel("Calculate Capital Gain Tax (hit Enter/Space to toggle): ").SetChangedFunc(func(checked bool) {
-		if !checked {
+	shortTermCapitalGainTaxField := tview.NewInputField().
+		SetLabel("Short-Term Capital Gain Tax Percent (10%-37%): ").
+		SetFieldWidth(20).
+		SetAcceptanceFunc(acceptFloat64InputValue)
+	shortTermCapitalGainTaxField.SetDisabled(true)
+
+	longTermCapitalGainTaxField := tview.NewInputField().
+		SetLabel("Long-Term Capital Gain Tax Percent (0%-20%): ").
+		SetFieldWidth(20).
+		SetAcceptanceFunc(acceptFloat64InputValue)
+	longTermCapitalGainTaxField.SetDisabled(true)
+
+	holdingPeriodCheckbox := tview.NewCheckbox().
+		SetLabel("Determine Short-Term/Long-Term from vest and sale dates (hit Enter/Space to toggle): ")
+	holdingPeriodCheckbox.SetDisabled(true)
+
+	var taxCheckbox *tview.Checkbox
+	updateCapitalGainTaxFields := func() {
+		considerCapitalGainTax := taxCheckbox.IsChecked()
+		considerHoldingPeriod := considerCapitalGainTax && holdingPeriodCheckbox.IsChecked()
+		if !considerCapitalGainTax || considerHoldingPeriod {
 			capitalGainTaxField.SetText("")
 		}
-		capitalGainTaxField.SetDisabled(!checked)
+		if !considerHoldingPeriod {
+			shortTermCapitalGainTaxField.SetText("")
+			longTermCapitalGainTaxField.SetText("")
+		}
+		capitalGainTaxField.SetDisabled(!considerCapitalGainTax || considerHoldingPeriod)
+		shortTermCapitalGainTaxField.SetDisabled(!considerHoldingPeriod)
+		longTermCapitalGainTaxField.SetDisabled(!considerHoldingPeriod)
+	}
+	holdingPeriodCheckbox.SetChangedFunc(func(_ bool) {
+		updateCapitalGainTaxFields()
+	})
+
+	taxCheckbox = tview.NewCheckbox().SetLabel("Calculate Capital Gain Tax (hit Enter/Space to toggle): ").SetChangedFunc(func(checked bool) {
+		if !checked {
+			holdingPeriodCheckbox.SetChecked(false)
+		}
+		holdingPeriodCheckbox.SetDisabled(!checked)
+		updateCapitalGainTaxFields()
 	})
 
 	form.AddFormItem(taxCheckbox).
-		AddFormItem(capitalGainTaxField)
+		AddFormItem(capitalGainTaxField).
+		AddFormItem(holdingPeriodCheckbox).
+		AddFormItem(shortTermCapitalGainTaxField).
+		AddFormItem(longTermCapitalGainTaxField)
+
+	taxProfile := newTaxProfileFields()
+	taxProfile.addTo(form)
 
 	// Income tax Group
 	incomeTaxField := tview.NewInputField().
 		SetLabel("Income Tax incurred (no. of shares traded * FMV to cover for taxes): ").
 		SetFieldWidth(20).
-		SetAcceptanceFunc(acceptFloat64InputValue)
+		SetAcceptanceFunc(acceptMoneyInputValue)
 	incomeTaxField.SetDisabled(true)
 
 	noOfStocksVestedField := tview.NewInputField().
 		SetLabel("Number of stocks vested: ").
 		SetFieldWidth(20).
-		SetAcceptanceFunc(acceptFloat64InputValue)
+		SetAcceptanceFunc(acceptSharesInputValue)
 	noOfStocksVestedField.SetDisabled(true)
 
-	incomeTaxCheckbox := tview.NewCheckbox().SetLabel("Include Income Tax (hit Enter/Space to toggle): ").SetChangedFunc(func(checked bool) {
-		if !checked {
+	withholdingRatesField := tview.NewInputField().
+		SetLabel("Withholding rates (comma-separated %, blank for 22,6.2,1.45): ").
+		SetFieldWidth(20)
+	withholdingRatesField.SetDisabled(true)
+
+	sellToCoverPriceField := tview.NewInputField().
+		SetLabel("Sell-to-cover price per share (blank for market price on vest) ($): ").
+		SetFieldWidth(20).
+		SetAcceptanceFunc(acceptMoneyInputValue)
+	sellToCoverPriceField.SetDisabled(true)
+
+	fractionalSharesCheckbox := tview.NewCheckbox().
+		SetLabel("Broker sells fractional shares to cover (hit Enter/Space to toggle): ")
+	fractionalSharesCheckbox.SetDisabled(true)
+
+	sellToCoverCheckbox := tview.NewCheckbox().
+		SetLabel("Simulate sell-to-cover instead of entering the income tax (hit Enter/Space to toggle): ")
+	sellToCoverCheckbox.SetDisabled(true)
+
+	var incomeTaxCheckbox *tview.Checkbox
+	updateIncomeTaxFields := func() {
+		considerIncomeTax := incomeTaxCheckbox.IsChecked()
+		simulateSellToCover := considerIncomeTax && sellToCoverCheckbox.IsChecked()
+		if !considerIncomeTax || simulateSellToCover {
 			incomeTaxField.SetText("")
+		}
+		if !considerIncomeTax {
 			noOfStocksVestedField.SetText("")
 		}
-		incomeTaxField.SetDisabled(!checked)
-		noOfStocksVestedField.SetDisabled(!checked)
+		if !simulateSellToCover {
+			withholdingRatesField.SetText("")
+			sellToCoverPriceField.SetText("")
+			fractionalSharesCheckbox.SetChecked(false)
+		}
+		incomeTaxField.SetDisabled(!considerIncomeTax || simulateSellToCover)
+		noOfStocksVestedField.SetDisabled(!considerIncomeTax)
+		withholdingRatesField.SetDisabled(!simulateSellToCover)
+		sellToCoverPriceField.SetDisabled(!simulateSellToCover)
+		fractionalSharesCheckbox.SetDisabled(!simulateSellToCover)
+	}
+	sellToCoverCheckbox.SetChangedFunc(func(_ bool) {
+		updateIncomeTaxFields()
+	})
+
+	incomeTaxCheckbox = tview.NewCheckbox().SetLabel("Include Income Tax (hit Enter/Space to toggle): ").SetChangedFunc(func(checked bool) {
+		if !checked {
+			sellToCoverCheckbox.SetChecked(false)
+		}
+		sellToCoverCheckbox.SetDisabled(!checked)
+		updateIncomeTaxFields()
 	})
 
 	form.AddFormItem(incomeTaxCheckbox).
 		AddFormItem(incomeTaxField).
-		AddFormItem(noOfStocksVestedField)
+		AddFormItem(noOfStocksVestedField).
+		AddFormItem(sellToCoverCheckbox).
+		AddFormItem(withholdingRatesField).
+		AddFormItem(sellToCoverPriceField).
+		AddFormItem(fractionalSharesCheckbox)
 
 	marketPriceOnVestedStockPerShareField := tview.NewInputField().
 		SetLabel("Market Price on vested stock per share ($): ").
 		SetFieldWidth(20).
-		SetAcceptanceFunc(acceptFloat64InputValue)
+		SetAcceptanceFunc(acceptMoneyInputValue)
 	form.
 		AddFormItem(marketPriceOnVestedStockPerShareField)
 
+	// Config profile defaults
+	prefillCommission(commissionCheckbox, commissionAmountField, numTransactionsField)
+	prefillCapitalGainTax(taxCheckbox, capitalGainTaxField, holdingPeriodCheckbox, shortTermCapitalGainTaxField,
+		longTermCapitalGainTaxField)
+	taxProfile.prefill()
+
+	saleTarget := newSaleTargetFields()
+	saleTarget.addTo(form)
+
+	sensitivity := newSensitivityFields(types.SensitivityVariables())
+	sensitivity.addTo(form)
+
+	// readRsuOrder also returns the sell-to-cover summary when it is simulated
+	readRsuOrder := func() (*types.RsuOrder, *types.SellToCoverSummary, error) {
+		rsuOrder := types.RsuOrder{}
+		// Retrieve values
+		rsuOrder.SellingPricePerShare, _ = types.ParseMoney(sellingPricePerShare.GetText())
+		rsuOrder.NumberOfSharesSold, _ = types.ParseShares(shareQty.GetText())
+
+		if commissionCheckbox.IsChecked() {
+			rsuOrder.ConsiderTransactionCommission = true
+			rsuOrder.CommissionPaidPerTransaction, _ = types.ParseMoney(commissionAmountField.GetText())
+			rsuOrder.NumberOfTransactions, _ = strconv.Atoi(numTransactionsField.GetText())
+		}
+
+		if taxCheckbox.IsChecked() {
+			rsuOrder.ConsiderCapitalGainTax = true
+			rsuOrder.CapitalGainTaxPercent, _ = strconv.ParseFloat(capitalGainTaxField.GetText(), 64)
+			if holdingPeriodCheckbox.IsChecked() {
+				var err error
+				rsuOrder.ConsiderHoldingPeriod = true
+				rsuOrder.ShortTermCapitalGainTaxPercent, _ = strconv.ParseFloat(shortTermCapitalGainTaxField.GetText(), 64)
+				rsuOrder.LongTermCapitalGainTaxPercent, _ = strconv.ParseFloat(longTermCapitalGainTaxField.GetText(), 64)
+				if rsuOrder.VestDate, err = parseDateInputValue("vest date", vestDateField.GetText()); err != nil {
+					return nil, nil, err
+				}
+				if rsuOrder.SaleDate, err = parseDateInputValue("sale date", saleDateField.GetText()); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+
+		rsuOrder.MarketValuePerShare, _ = types.ParseMoney(marketPriceOnVestedStockPerShareField.GetText())
+		var sellToCoverSummary *types.SellToCoverSummary
+		if incomeTaxCheckbox.IsChecked() {
+			rsuOrder.ConsiderIncomeTaxOnVestedStock = true
+			rsuOrder.IncomeTaxIncurredWhenStockVested, _ = types.ParseMoney(incomeTaxField.GetText())
+			rsuOrder.NumberOfStocksVested, _ = types.ParseShares(noOfStocksVestedField.GetText())
+			if sellToCoverCheckbox.IsChecked() {
+				sellToCover := &types.SellToCover{
+					SharesVested:            rsuOrder.NumberOfStocksVested,
+					MarketValuePerShare:     rsuOrder.MarketValuePerShare,
+					WithholdingRatePercents: tax.DefaultSupplementalWithholdingPercents(),
+					FractionalShares:        fractionalSharesCheckbox.IsChecked(),
+				}
+				if withholdingRates := withholdingRatesField.GetText(); withholdingRates != "" {
+					var err error
+					if sellToCover.WithholdingRatePercents, err = types.ParseWithholdingRatePercents(withholdingRates); err != nil {
+						return nil, nil, err
+					}
+				}
+				sellToCover.SalePricePerShare, _ = types.ParseMoney(sellToCoverPriceField.GetText())
+				var err error
+				if sellToCoverSummary, err = sellToCover.CalculateSellToCoverSummary(); err != nil {
+					return nil, nil, err
+				}
+				sellToCoverSummary.ApplyTo(&rsuOrder)
+			}
+		}
+		taxSelection, err := taxProfile.read()
+		if err != nil {
+			return nil, nil, err
+		}
+		rsuOrder.TaxModel = taxSelection.TaxModel
+		rsuOrder.StateTaxModel = taxSelection.StateTaxModel
+		rsuOrder.TaxProfile = taxSelection.Profile
+		rsuOrder.ConsiderNetInvestmentIncomeTax = taxSelection.ConsiderNetInvestmentIncomeTax
+		rsuOrder.ModifiedAdjustedGrossIncome = taxSelection.ModifiedAdjustedGrossIncome
+		return &rsuOrder, sellToCoverSummary, nil
+	}
+
 	// Create a Submit Button
 	form.AddButton("Submit", func() {
-		sellingPricePerShareValue, _ := strconv.ParseFloat(sellingPricePerShare.GetText(), 64)
-		shareQtyValue, _ := strconv.Atoi(shareQty.GetText())
-		considerCommission := commissionCheckbox.IsChecked()
-		commissionAmount, _ := strconv.ParseFloat(commissionAmountField.GetText(), 64)
-		numOfTransactions, _ := strconv.Atoi(numTransactionsField.GetText())
-		considerCapitalGainTax := taxCheckbox.IsChecked()
-		capitalGainTax, _ := strconv.ParseFloat(capitalGainTaxField.GetText(), 64)
-
-		considerIncomeTax := incomeTaxCheckbox.IsChecked()
-		incomeTaxIncurred, _ := strconv.ParseFloat(incomeTaxField.GetText(), 64)
-		noOfStocksVested, _ := strconv.Atoi(noOfStocksVestedField.GetText())
-		marketPriceOnVestedStockPerShare, _ := strconv.ParseFloat(marketPriceOnVestedStockPerShareField.GetText(), 64)
-
-		rsuOrder := buildRsuOrder(sellingPricePerShareValue,
-			shareQtyValue,
-			considerCommission,
-			commissionAmount,
-			numOfTransactions,
-			considerCapitalGainTax,
-			capitalGainTax,
-			considerIncomeTax,
-			incomeTaxIncurred,
-			noOfStocksVested,
-			marketPriceOnVestedStockPerShare)
-		calculateRsu(rsuOrder, status, summary)
+		rsuOrder, sellToCoverSummary, err := readRsuOrder()
+		if err != nil {
+			showRsuError(err, status, summary)
+			return
+		}
+		calculateRsu(rsuOrder, sellToCoverSummary, status, summary)
 	})
 
 	form.AddButton("Target Profits", func() {
-		sellingPricePerShareValue, _ := strconv.ParseFloat(sellingPricePerShare.GetText(), 64)
-		shareQtyValue, _ := strconv.Atoi(shareQty.GetText())
-		considerCommission := commissionCheckbox.IsChecked()
-		commissionAmount, _ := strconv.ParseFloat(commissionAmountField.GetText(), 64)
-		numOfTransactions, _ := strconv.Atoi(numTransactionsField.GetText())
-		considerCapitalGainTax := taxCheckbox.IsChecked()
-		capitalGainTax, _ := strconv.ParseFloat(capitalGainTaxField.GetText(), 64)
-
-		considerIncomeTax := incomeTaxCheckbox.IsChecked()
-		incomeTaxIncurred, _ := strconv.ParseFloat(incomeTaxField.GetText(), 64)
-		noOfStocksVested, _ := strconv.Atoi(noOfStocksVestedField.GetText())
-		marketPriceOnVestedStockPerShare, _ := strconv.ParseFloat(marketPriceOnVestedStockPerShareField.GetText(), 64)
-
-		rsuOrder := buildRsuOrder(sellingPricePerShareValue,
-			shareQtyValue,
-			considerCommission,
-			commissionAmount,
-			numOfTransactions,
-			considerCapitalGainTax,
-			capitalGainTax,
-			considerIncomeTax,
-			incomeTaxIncurred,
-			noOfStocksVested,
-			marketPriceOnVestedStockPerShare)
+		rsuOrder, _, err := readRsuOrder()
+		if err != nil {
+			showRsuError(err, status, summary)
+			return
+		}
 		calculateRsuTargetProfits(rsuOrder, status, summary, form, app)
 	})
 
+	form.AddButton("Solve Target", func() {
+		rsuOrder, sellToCoverSummary, err := readRsuOrder()
+		if err != nil {
+			showRsuError(err, status, summary)
+			return
+		}
+		target, targetAmount, err := saleTarget.read()
+		if err != nil {
+			showRsuError(err, status, summary)
+			return
+		}
+		solverResult, err := rsuOrder.SolveSellingPriceForTarget(target, targetAmount)
+		if err != nil {
+			clearFlexItems(summary)
+			status.SetText(unsolvedStatus(err))
+			currentDataView = RsuError
+			return
+		}
+		sellingPricePerShare.SetText(solverResult.Value.StringPerShare())
+		rsuOrder.SellingPricePerShare = solverResult.Value
+		calculateRsu(rsuOrder, sellToCoverSummary, status, summary)
+		if currentDataView == RsuOrderSummary {
+			status.SetText(solvedStatus(target, targetAmount, solverResult))
+		}
+	})
+
+	form.AddButton("Shares Needed", func() {
+		rsuOrder, sellToCoverSummary, err := readRsuOrder()
+		if err != nil {
+			showRsuError(err, status, summary)
+			return
+		}
+		target, targetAmount, err := saleTarget.read()
+		if err != nil {
+			showRsuError(err, status, summary)
+			return
+		}
+		solverResult, err := rsuOrder.SolveSharesSoldForTarget(target, targetAmount, saleTarget.fractionalSharesCheckbox.IsChecked())
+		if err != nil {
+			clearFlexItems(summary)
+			status.SetText(unsolvedStatus(err))
+			currentDataView = RsuError
+			return
+		}
+		shareQty.SetText(solverResult.Value.String())
+		rsuOrder.NumberOfSharesSold = solverResult.Value
+		calculateRsu(rsuOrder, sellToCoverSummary, status, summary)
+		if currentDataView == RsuOrderSummary {
+			status.SetText(sharesNeededStatus(target, targetAmount, solverResult))
+		}
+	})
+
+	form.AddButton("Sensitivity", func() {
+		rsuOrder, _, err := readRsuOrder()
+		if err != nil {
+			showRsuError(err, status, summary)
+			return
+		}
+		variable, sellingPrices, values, err := sensitivity.read()
+		if err != nil {
+			showRsuError(err, status, summary)
+			return
+		}
+		sensitivityGrid, err := rsuOrder.CalculateSensitivityGrid(variable, sellingPrices, values)
+		if err != nil {
+			showRsuError(err, status, summary)
+			return
+		}
+		showSensitivityGrid(sensitivityGrid, RsuSensitivity, status, summary, form, app)
+	})
+
 	// Create a Exit Button
 	form.AddButton("Exit", func() {
 		app.Stop() // Close the app without submission
@@ -252,6 +471,7 @@ func calculateRsuTargetProfits(rsuOrder *types.RsuOrder,
 		"Effective Commission ($)",
 		"Profit Before Tax ($)",
 		"Capital Gain Tax ($)",
+		"NIIT ($)",
 		"Profit After C.G Tax ($)",
 		"Income Tax ($)",
 		"Profit/Loss After Income Tax ($)",
@@ -268,9 +488,6 @@ func calculateRsuTargetProfits(rsuOrder *types.RsuOrder,
 	for percent := 0.0; percent <= 300.0; percent += 5 {
 		// Calculate the selling price for the current percentage
 		sellingPrice, err := rsuOrder.CalculateSellingPriceForTargetProfitPercent(percent)
-		if err != nil {
-			sellingPrice = -1 // Handle error case by setting to 0 or any fallback
-		}
 
 		// Set profit percentage and calculated selling price in the table
 		var targetProfitHeader = fmt.Sprintf("%.0f%%", percent)
@@ -281,7 +498,19 @@ func calculateRsuTargetProfits(rsuOrder *types.RsuOrder,
 		table.SetCell(row, col, tview.NewTableCell(targetProfitHeader).
 			SetAlign(tview.AlignCenter))
 		col++
-		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%.2f", sellingPrice)).
+		var noSolutionError *types.NoSolutionError
+		if errors.As(err, &noSolutionError) {
+			// Higher targets are out of reach as well
+			table.SetCell(row, col, tview.NewTableCell("No solution").
+				SetAlign(tview.AlignCenter))
+			break
+		}
+		if err != nil {
+			status.SetText(fmt.Sprintf("Error occurred: %v", err))
+			currentDataView = RsuError
+			return
+		}
+		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%s", sellingPrice)).
 			SetAlign(tview.AlignCenter))
 		col++
 
@@ -293,28 +522,31 @@ func calculateRsuTargetProfits(rsuOrder *types.RsuOrder,
 			currentDataView = RsuError
 			return
 		}
-		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%.2f", rsuOrderSummary.TotalSellingPrice)).
+		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%s", rsuOrderSummary.TotalSellingPrice)).
 			SetAlign(tview.AlignCenter))
 		col++
-		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%.2f", rsuOrderSummary.EffectiveCommission)).
+		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%s", rsuOrderSummary.EffectiveCommission)).
 			SetAlign(tview.AlignCenter))
 		col++
-		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%.2f", rsuOrderSummary.NetResult)).
+		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%s", rsuOrderSummary.NetResult)).
 			SetAlign(tview.AlignCenter))
 		col++
-		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%.2f", rsuOrderSummary.CapitalGainTaxAmount)).
+		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%s", rsuOrderSummary.CapitalGainTaxAmount)).
 			SetAlign(tview.AlignCenter))
 		col++
-		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%.2f", rsuOrderSummary.ProfitOrLossAfterCapitalGainsTax())).
+		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%s", rsuOrderSummary.NetInvestmentIncomeTaxAmount)).
 			SetAlign(tview.AlignCenter))
 		col++
-		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%.2f", rsuOrderSummary.TotalIncomeTaxIncurred)).
+		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%s", rsuOrderSummary.ProfitOrLossAfterCapitalGainsTax())).
 			SetAlign(tview.AlignCenter))
 		col++
-		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%.2f", rsuOrderSummary.ProfitOrLossAfterIncomeTax())).
+		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%s", rsuOrderSummary.TotalIncomeTaxIncurred)).
 			SetAlign(tview.AlignCenter))
 		col++
-		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%.2f", rsuOrderSummary.TrueProfitOrLoss())).
+		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%s", rsuOrderSummary.ProfitOrLossAfterIncomeTax())).
+			SetAlign(tview.AlignCenter))
+		col++
+		table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("$%s", rsuOrderSummary.TrueProfitOrLoss())).
 			SetAlign(tview.AlignCenter))
 		col++
 
@@ -349,43 +581,16 @@ func calculateRsuTargetProfits(rsuOrder *types.RsuOrder,
 	currentDataView = RsuTargetProfits
 }
 
-func buildRsuOrder(sellingPricePerShare float64,
-	shareQty int,
-	considerCommission bool,
-	commissionAmount float64,
-	numOfTransactions int,
-	considerCapitalGainTax bool,
-	capitalGainTax float64,
-	considerIncomeTax bool,
-	incomeTaxIncurred float64,
-	noOfStocksVested int,
-	marketPriceOnVestedStockPerShare float64) *types.RsuOrder {
-	rsuOrder := types.RsuOrder{}
-	// Retrieve values
-	rsuOrder.SellingPricePerShare = sellingPricePerShare
-	rsuOrder.NumberOfSharesSold = shareQty
-
-	if considerCommission {
-		rsuOrder.ConsiderTransactionCommission = true
-		rsuOrder.CommissionPaidPerTransaction = commissionAmount
-		rsuOrder.NumberOfTransactions = numOfTransactions
-	}
-
-	if considerCapitalGainTax {
-		rsuOrder.ConsiderCapitalGainTax = true
-		rsuOrder.CapitalGainTaxPercent = capitalGainTax
-	}
-
-	if considerIncomeTax {
-		rsuOrder.ConsiderIncomeTaxOnVestedStock = true
-		rsuOrder.IncomeTaxIncurredWhenStockVested = incomeTaxIncurred
-		rsuOrder.NumberOfStocksVested = noOfStocksVested
-	}
-	rsuOrder.MarketValuePerShare = marketPriceOnVestedStockPerShare
-	return &rsuOrder
+func showRsuError(err error,
+	status *tview.TextView,
+	summary *tview.Flex) {
+	clearFlexItems(summary)
+	status.SetText(fmt.Sprintf("Error occurred: %v", err))
+	currentDataView = RsuError
 }
 
 func calculateRsu(rsuOrder *types.RsuOrder,
+	sellToCoverSummary *types.SellToCoverSummary,
 	status *tview.TextView,
 	summary *tview.Flex) {
 	status.SetText("Calculating...")
@@ -399,18 +604,18 @@ func calculateRsu(rsuOrder *types.RsuOrder,
 	}
 
 	sellingPricePerShareField := tview.NewTextView().
-		SetLabel(fmt.Sprintf("Selling price per share: $%.2f", rsuOrder.SellingPricePerShare)).
+		SetLabel(fmt.Sprintf("Selling price per share: $%s", rsuOrder.SellingPricePerShare)).
 		SetTextAlign(tview.AlignLeft)
 	summary.AddItem(sellingPricePerShareField, 1, 1, false)
 
 	numberOfSharesSoldField := tview.NewTextView().
-		SetLabel(fmt.Sprintf("Number of shares sold: %d", rsuOrder.NumberOfSharesSold)).
+		SetLabel(fmt.Sprintf("Number of shares sold: %s", rsuOrder.NumberOfSharesSold)).
 		SetTextAlign(tview.AlignLeft)
 	summary.AddItem(numberOfSharesSoldField, 1, 1, false)
 
 	totalSellingPrice := rsuOrderSummary.TotalSellingPrice
 	totalSellingPriceField := tview.NewTextView().
-		SetLabel(fmt.Sprintf("Total selling price (%d * $%.2f): $%.2f",
+		SetLabel(fmt.Sprintf("Total selling price (%s * $%s): $%s",
 			rsuOrder.NumberOfSharesSold, rsuOrder.SellingPricePerShare, totalSellingPrice)).
 		SetTextAlign(tview.AlignLeft)
 	summary.AddItem(totalSellingPriceField, 1, 1, false)
@@ -418,7 +623,7 @@ func calculateRsu(rsuOrder *types.RsuOrder,
 	if rsuOrder.ConsiderTransactionCommission {
 		effectiveTransactionCommission := rsuOrderSummary.EffectiveCommission
 		effectiveTransactionCommissionField := tview.NewTextView().
-			SetLabel(fmt.Sprintf("Effective commission fee (%d * $%.2f): $%.2f",
+			SetLabel(fmt.Sprintf("Effective commission fee (%d * $%s): $%s",
 				rsuOrder.NumberOfTransactions, rsuOrder.CommissionPaidPerTransaction, effectiveTransactionCommission)).
 			SetTextAlign(tview.AlignLeft)
 		summary.AddItem(effectiveTransactionCommissionField, 1, 1, false)
@@ -427,48 +632,81 @@ func calculateRsu(rsuOrder *types.RsuOrder,
 	profitOrLoss := rsuOrderSummary.NetResult
 	if profitOrLoss > 0 {
 		profitOrLossField := tview.NewTextView().
-			SetLabel(fmt.Sprintf("Profit or Loss (before taxes): $%.2f", profitOrLoss)).
+			SetLabel(fmt.Sprintf("Profit or Loss (before taxes): $%s", profitOrLoss)).
 			SetTextAlign(tview.AlignLeft)
 		summary.AddItem(profitOrLossField, 1, 1, false)
 
+		if rsuOrder.ConsiderHoldingPeriod {
+			holdingPeriodField := tview.NewTextView().
+				SetLabel(fmt.Sprintf("Holding period: %s (long-term from %s)",
+					rsuOrderSummary.HoldingPeriod, rsuOrderSummary.LongTermDate.Format(types.DateLayout))).
+				SetTextAlign(tview.AlignLeft)
+			summary.AddItem(holdingPeriodField, 1, 1, false)
+		}
+
 		if rsuOrder.ConsiderCapitalGainTax {
 			capitalGainTaxAmount := rsuOrderSummary.CapitalGainTaxAmount
 			capitalGainTaxAmountField := tview.NewTextView().
-				SetLabel(fmt.Sprintf("Captial gain tax amount: $%.2f", capitalGainTaxAmount)).
+				SetLabel(fmt.Sprintf("Captial gain tax amount (%.2f%%): $%s",
+					rsuOrderSummary.EffectiveCapitalGainTaxPercent, capitalGainTaxAmount)).
 				SetTextAlign(tview.AlignLeft)
 			summary.AddItem(capitalGainTaxAmountField, 1, 1, false)
+			if rsuOrder.StateTaxModel != nil {
+				capitalGainTaxBreakdownField := tview.NewTextView().
+					SetLabel(fmt.Sprintf("  Federal: $%s, State: $%s",
+						rsuOrderSummary.FederalCapitalGainTaxAmount, rsuOrderSummary.StateCapitalGainTaxAmount)).
+					SetTextAlign(tview.AlignLeft)
+				summary.AddItem(capitalGainTaxBreakdownField, 1, 1, false)
+			}
+		}
+
+		if rsuOrder.ConsiderNetInvestmentIncomeTax {
+			netInvestmentIncomeTaxField := tview.NewTextView().
+				SetLabel(fmt.Sprintf("Net investment income tax (%.1f%%): $%s",
+					tax.NetInvestmentIncomeTaxPercent, rsuOrderSummary.NetInvestmentIncomeTaxAmount)).
+				SetTextAlign(tview.AlignLeft)
+			summary.AddItem(netInvestmentIncomeTaxField, 1, 1, false)
 		}
 	} else if profitOrLoss < 0 {
 		profitOrLossField := tview.NewTextView().
-			SetLabel(fmt.Sprintf("Loss: $%.2f", profitOrLoss)).
+			SetLabel(fmt.Sprintf("Loss: $%s", profitOrLoss)).
 			SetTextAlign(tview.AlignLeft)
 		summary.AddItem(profitOrLossField, 1, 1, false)
 	} else {
 		profitOrLossField := tview.NewTextView().
-			SetLabel(fmt.Sprintf("Break even: $%.2f", profitOrLoss)).
+			SetLabel(fmt.Sprintf("Break even: $%s", profitOrLoss)).
 			SetTextAlign(tview.AlignLeft)
 		summary.AddItem(profitOrLossField, 1, 1, false)
 	}
 
+	if sellToCoverSummary != nil {
+		sellToCoverField := tview.NewTextView().
+			SetLabel(fmt.Sprintf("Sell-to-cover: %s shares sold, $%s withheld (%.2f%%), $%s refunded, %s shares deposited",
+				sellToCoverSummary.SharesSold, sellToCoverSummary.TaxWithheld, sellToCoverSummary.WithholdingPercent,
+				sellToCoverSummary.CashRefunded, sellToCoverSummary.NetSharesDeposited)).
+			SetTextAlign(tview.AlignLeft)
+		summary.AddItem(sellToCoverField, 1, 1, false)
+	}
+
 	if rsuOrder.ConsiderIncomeTaxOnVestedStock {
 		incomeTaxPerShare, err := rsuOrder.CalculateIncomeTaxPerShare()
 		if err == nil {
 			incomeTaxPerShareField := tview.NewTextView().
-				SetLabel(fmt.Sprintf("Income tax per share: $%.2f", incomeTaxPerShare)).
+				SetLabel(fmt.Sprintf("Income tax per share: $%s", incomeTaxPerShare)).
 				SetTextAlign(tview.AlignLeft)
 			summary.AddItem(incomeTaxPerShareField, 1, 1, false)
 		}
 
 		totalIncomeTaxIncurred := rsuOrderSummary.TotalIncomeTaxIncurred
 		totalIncomeTaxIncurredField := tview.NewTextView().
-			SetLabel(fmt.Sprintf("Total income tax amount: $%.2f", totalIncomeTaxIncurred)).
+			SetLabel(fmt.Sprintf("Total income tax amount: $%s", totalIncomeTaxIncurred)).
 			SetTextAlign(tview.AlignLeft)
 		summary.AddItem(totalIncomeTaxIncurredField, 1, 1, false)
 	}
 
 	trueProfitOrLoss := rsuOrderSummary.TrueProfitOrLoss()
 	trueProfitOrLossField := tview.NewTextView().
-		SetLabel(fmt.Sprintf("True Profit/Loss: $%.2f", trueProfitOrLoss)).
+		SetLabel(fmt.Sprintf("True Profit/Loss: $%s", trueProfitOrLoss)).
 		SetTextAlign(tview.AlignLeft)
 	summary.AddItem(trueProfitOrLossField, 1, 1, false)
 
@@ -0,0 +1,96 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"github.com/leogps/lunar/pkg/types"
+	"github.com/rivo/tview"
+)
+
+// saleTargetFields groups the form items used to solve for the selling price or the number of shares that reaches
+// a dollar target
+type saleTargetFields struct {
+	saleTarget               *tview.DropDown
+	targetAmount             *tview.InputField
+	fractionalSharesCheckbox *tview.Checkbox
+}
+
+func newSaleTargetFields() *saleTargetFields {
+	var saleTargetOptions []string
+	for _, saleTarget := range types.SaleTargets() {
+		saleTargetOptions = append(saleTargetOptions, saleTarget.String())
+	}
+	return &saleTargetFields{
+		saleTarget: tview.NewDropDown().
+			SetLabel("Solve for: ").
+			SetOptions(saleTargetOptions, nil).
+			SetCurrentOption(0),
+		targetAmount: tview.NewInputField().
+			SetLabel("Target amount ($): ").
+			SetFieldWidth(20).
+			SetAcceptanceFunc(acceptMoneyInputValue),
+		fractionalSharesCheckbox: tview.NewCheckbox().
+			SetLabel("Sell fractional shares for Shares Needed (hit Enter/Space to toggle): "),
+	}
+}
+
+func (s *saleTargetFields) addTo(form *tview.Form) {
+	form.AddFormItem(s.saleTarget).
+		AddFormItem(s.targetAmount).
+		AddFormItem(s.fractionalSharesCheckbox)
+}
+
+// read returns the selected target and the amount to reach
+func (s *saleTargetFields) read() (types.SaleTarget, types.Money, error) {
+	selected, _ := s.saleTarget.GetCurrentOption()
+	saleTarget := types.SaleTargets()[selected]
+	targetAmount, err := types.ParseMoney(s.targetAmount.GetText())
+	if err != nil {
+		return saleTarget, 0, fmt.Errorf("target amount is required")
+	}
+	return saleTarget, targetAmount, nil
+}
+
+// solvedStatus describes the selling price found for the target and how the solver converged
+func solvedStatus(saleTarget types.SaleTarget, targetAmount types.Money, solverResult *types.SolverResult[types.Money]) string {
+	return fmt.Sprintf("Solved: $%s per share for $%s %s (%d iterations, $%s over the target)",
+		solverResult.Value.StringPerShare(), targetAmount, saleTarget, solverResult.Iterations, solverResult.Residual)
+}
+
+// sharesNeededStatus describes the number of shares to sell found for the target and how the solver converged
+func sharesNeededStatus(saleTarget types.SaleTarget, targetAmount types.Money, solverResult *types.SolverResult[types.Shares]) string {
+	return fmt.Sprintf("Solved: sell %s shares for $%s %s (%d iterations, $%s over the target)",
+		solverResult.Value, targetAmount, saleTarget, solverResult.Iterations, solverResult.Residual)
+}
+
+// unsolvedStatus describes why the target could not be solved
+func unsolvedStatus(err error) string {
+	var noSolutionError *types.NoSolutionError
+	if errors.As(err, &noSolutionError) {
+		return fmt.Sprintf("No solution: %v", err)
+	}
+	return fmt.Sprintf("Error occurred: %v", err)
+}
@@ -0,0 +1,175 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package ui
+
+import (
+	"fmt"
+	"github.com/gdamore/tcell/v2"
+	"github.com/leogps/lunar/pkg/types"
+	"github.com/rivo/tview"
+	"strconv"
+)
+
+// sensitivityFields groups the form items used to tabulate the true profit/loss by selling price and a second variable
+type sensitivityFields struct {
+	variables []types.SensitivityVariable
+
+	priceStartField *tview.InputField
+	priceEndField   *tview.InputField
+	priceStepField  *tview.InputField
+	variable        *tview.DropDown
+	valueStartField *tview.InputField
+	valueEndField   *tview.InputField
+	valueStepField  *tview.InputField
+}
+
+func newSensitivityFields(variables []types.SensitivityVariable) *sensitivityFields {
+	var variableOptions []string
+	for _, variable := range variables {
+		variableOptions = append(variableOptions, variable.String())
+	}
+	newRangeField := func(label string, acceptanceFunc func(string, rune) bool) *tview.InputField {
+		return tview.NewInputField().
+			SetLabel(label).
+			SetFieldWidth(20).
+			SetAcceptanceFunc(acceptanceFunc)
+	}
+	return &sensitivityFields{
+		variables:       variables,
+		priceStartField: newRangeField("Sensitivity: lowest selling price ($): ", acceptMoneyInputValue),
+		priceEndField:   newRangeField("Sensitivity: highest selling price ($): ", acceptMoneyInputValue),
+		priceStepField:  newRangeField("Sensitivity: selling price step ($): ", acceptMoneyInputValue),
+		variable: tview.NewDropDown().
+			SetLabel("Sensitivity: vary across columns: ").
+			SetOptions(variableOptions, nil).
+			SetCurrentOption(0),
+		valueStartField: newRangeField("Sensitivity: lowest column value: ", acceptFloat64InputValue),
+		valueEndField:   newRangeField("Sensitivity: highest column value: ", acceptFloat64InputValue),
+		valueStepField:  newRangeField("Sensitivity: column value step: ", acceptFloat64InputValue),
+	}
+}
+
+func (s *sensitivityFields) addTo(form *tview.Form) {
+	form.AddFormItem(s.priceStartField).
+		AddFormItem(s.priceEndField).
+		AddFormItem(s.priceStepField).
+		AddFormItem(s.variable).
+		AddFormItem(s.valueStartField).
+		AddFormItem(s.valueEndField).
+		AddFormItem(s.valueStepField)
+}
+
+// read returns the variable along with the selling prices on the rows and the values on the columns
+func (s *sensitivityFields) read() (types.SensitivityVariable, []types.Money, []float64, error) {
+	selected, _ := s.variable.GetCurrentOption()
+	variable := s.variables[selected]
+
+	priceStart, _ := types.ParseMoney(s.priceStartField.GetText())
+	priceEnd, _ := types.ParseMoney(s.priceEndField.GetText())
+	priceStep, _ := types.ParseMoney(s.priceStepField.GetText())
+	sellingPrices, err := types.SensitivityRange(priceStart, priceEnd, priceStep)
+	if err != nil {
+		return variable, nil, nil, fmt.Errorf("selling price range: %w", err)
+	}
+
+	valueStart, _ := strconv.ParseFloat(s.valueStartField.GetText(), 64)
+	valueEnd, _ := strconv.ParseFloat(s.valueEndField.GetText(), 64)
+	valueStep, _ := strconv.ParseFloat(s.valueStepField.GetText(), 64)
+	values, err := types.SensitivityRange(valueStart, valueEnd, valueStep)
+	if err != nil {
+		return variable, nil, nil, fmt.Errorf("%s range: %w", variable, err)
+	}
+	return variable, sellingPrices, values, nil
+}
+
+// showSensitivityGrid shows the grid as a scrollable table with profits in green and losses in red
+func showSensitivityGrid(sensitivityGrid *types.SensitivityGrid,
+	dataView DataView,
+	status *tview.TextView,
+	summary *tview.Flex,
+	form *tview.Form,
+	app *tview.Application) {
+	clearFlexItems(summary)
+
+	table := tview.NewTable().
+		SetBorders(true).
+		SetFixed(1, 1)
+
+	table.SetCell(0, 0, tview.NewTableCell(fmt.Sprintf("Price \\ %s", sensitivityGrid.Variable)).
+		SetTextColor(tcell.ColorYellow).
+		SetAlign(tview.AlignCenter).
+		SetSelectable(false))
+	for column, value := range sensitivityGrid.Values {
+		table.SetCell(0, column+1, tview.NewTableCell(sensitivityGrid.Variable.Format(value)).
+			SetTextColor(tcell.ColorYellow).
+			SetAlign(tview.AlignCenter).
+			SetSelectable(false))
+	}
+	for row, sellingPrice := range sensitivityGrid.SellingPrices {
+		table.SetCell(row+1, 0, tview.NewTableCell(fmt.Sprintf("$%s", sellingPrice)).
+			SetTextColor(tcell.ColorYellow).
+			SetAlign(tview.AlignCenter).
+			SetSelectable(false))
+		for column, trueProfitOrLoss := range sensitivityGrid.TrueProfitOrLoss[row] {
+			color := tview.Styles.PrimaryTextColor
+			if trueProfitOrLoss > 0 {
+				color = tcell.ColorGreen
+			} else if trueProfitOrLoss < 0 {
+				color = tcell.ColorRed
+			}
+			table.SetCell(row+1, column+1, tview.NewTableCell(fmt.Sprintf("$%s", trueProfitOrLoss)).
+				SetTextColor(color).
+				SetAlign(tview.AlignCenter))
+		}
+	}
+
+	// Cells are selected one at a time so the table scrolls both ways
+	table.SetSelectable(true, true)
+	table.Select(1, 1)
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyCtrlI:
+			app.SetFocus(form)
+		}
+		return event
+	})
+	form.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyCtrlD:
+			if currentDataView == dataView {
+				app.SetFocus(table)
+			}
+		}
+		return event
+	})
+
+	summary.
+		SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true)
+
+	status.SetText(fmt.Sprintf("True Profit/Loss by Selling Price and %s: [ <ctrl+i> to switch focus to input form | <ctrl+d> to switch focus to Data View ]",
+		sensitivityGrid.Variable))
+	app.SetFocus(table)
+	currentDataView = dataView
+}
@@ -0,0 +1,192 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package ui
+
+import (
+	"fmt"
+	"github.com/leogps/lunar/pkg/tax"
+	"github.com/leogps/lunar/pkg/types"
+	"github.com/rivo/tview"
+	"strconv"
+	"time"
+)
+
+// noStateOption is the state dropdown option that disables state tax
+const noStateOption = "None"
+
+// taxProfileFields groups the form items used to compute taxes with progressive brackets
+type taxProfileFields struct {
+	checkbox         *tview.Checkbox
+	state            *tview.DropDown
+	filingStatus     *tview.DropDown
+	taxYearField     *tview.InputField
+	otherIncomeField *tview.InputField
+
+	netInvestmentIncomeTaxCheckbox *tview.Checkbox
+	magiField                      *tview.InputField
+}
+
+// taxSelection is what the tax profile fields resolve to
+type taxSelection struct {
+	// TaxModel is nil when flat percentages are used; StateTaxModel is nil when there is no state tax
+	TaxModel      tax.Model
+	StateTaxModel tax.Model
+	Profile       tax.Profile
+
+	ConsiderNetInvestmentIncomeTax bool
+	ModifiedAdjustedGrossIncome    types.Money
+}
+
+func newTaxProfileFields() *taxProfileFields {
+	var filingStatusOptions []string
+	for _, filingStatus := range tax.FilingStatuses() {
+		filingStatusOptions = append(filingStatusOptions, filingStatus.String())
+	}
+	filingStatus := tview.NewDropDown().
+		SetLabel("Filing status: ").
+		SetOptions(filingStatusOptions, nil).
+		SetCurrentOption(0)
+	filingStatus.SetDisabled(true)
+
+	taxYearField := tview.NewInputField().
+		SetLabel("Tax year: ").
+		SetFieldWidth(20).
+		SetText(strconv.Itoa(time.Now().Year())).
+		SetAcceptanceFunc(acceptIntInputValue)
+	taxYearField.SetDisabled(true)
+
+	otherIncomeField := tview.NewInputField().
+		SetLabel("Other taxable income (salary etc. after deductions) ($): ").
+		SetFieldWidth(20).
+		SetAcceptanceFunc(acceptFloat64InputValue)
+	otherIncomeField.SetDisabled(true)
+
+	magiField := tview.NewInputField().
+		SetLabel("Estimated MAGI excluding this sale ($): ").
+		SetFieldWidth(20).
+		SetAcceptanceFunc(acceptMoneyInputValue)
+	magiField.SetDisabled(true)
+
+	fields := &taxProfileFields{
+		filingStatus:     filingStatus,
+		taxYearField:     taxYearField,
+		otherIncomeField: otherIncomeField,
+		magiField:        magiField,
+	}
+	fields.checkbox = tview.NewCheckbox().
+		SetLabel("Use progressive federal tax brackets instead of flat percentages (hit Enter/Space to toggle): ").
+		SetChangedFunc(func(_ bool) {
+			fields.updateProfileFields()
+		})
+	fields.state = tview.NewDropDown().
+		SetLabel("State: ").
+		SetOptions(append([]string{noStateOption}, tax.StateCodes()...), func(_ string, _ int) {
+			fields.updateProfileFields()
+		}).
+		SetCurrentOption(0)
+	fields.netInvestmentIncomeTaxCheckbox = tview.NewCheckbox().
+		SetLabel("Apply the 3.8% Net Investment Income Tax (NIIT) (hit Enter/Space to toggle): ").
+		SetChangedFunc(func(checked bool) {
+			magiField.SetDisabled(!checked)
+			fields.updateProfileFields()
+		})
+	return fields
+}
+
+// updateProfileFields enables the profile fields when either federal brackets or a state is selected
+func (t *taxProfileFields) updateProfileFields() {
+	if t.checkbox == nil || t.state == nil {
+		return
+	}
+	disabled := !t.checkbox.IsChecked() && t.selectedStateCode() == ""
+	considerNetInvestmentIncomeTax := t.netInvestmentIncomeTaxCheckbox != nil && t.netInvestmentIncomeTaxCheckbox.IsChecked()
+	t.filingStatus.SetDisabled(disabled && !considerNetInvestmentIncomeTax)
+	t.taxYearField.SetDisabled(disabled)
+	t.otherIncomeField.SetDisabled(disabled)
+}
+
+func (t *taxProfileFields) selectedStateCode() string {
+	_, stateCode := t.state.GetCurrentOption()
+	if stateCode == noStateOption {
+		return ""
+	}
+	return stateCode
+}
+
+func (t *taxProfileFields) addTo(form *tview.Form) {
+	form.AddFormItem(t.checkbox).
+		AddFormItem(t.state).
+		AddFormItem(t.filingStatus).
+		AddFormItem(t.taxYearField).
+		AddFormItem(t.otherIncomeField).
+		AddFormItem(t.netInvestmentIncomeTaxCheckbox).
+		AddFormItem(t.magiField)
+}
+
+// read resolves the selected tax models, profile and NIIT inputs
+func (t *taxProfileFields) read() (taxSelection, error) {
+	var selection taxSelection
+	if t.checkbox.IsChecked() {
+		selection.TaxModel = tax.Federal
+	}
+	if stateCode := t.selectedStateCode(); stateCode != "" {
+		stateTaxModel, err := tax.LookupState(stateCode)
+		if err != nil {
+			return taxSelection{}, err
+		}
+		selection.StateTaxModel = stateTaxModel
+	}
+	filingStatusIndex, _ := t.filingStatus.GetCurrentOption()
+	if t.netInvestmentIncomeTaxCheckbox.IsChecked() {
+		selection.ConsiderNetInvestmentIncomeTax = true
+		selection.ModifiedAdjustedGrossIncome, _ = types.ParseMoney(t.magiField.GetText())
+		selection.Profile.FilingStatus = tax.FilingStatuses()[filingStatusIndex]
+	}
+	if selection.TaxModel == nil && selection.StateTaxModel == nil {
+		return selection, nil
+	}
+
+	taxYear, err := strconv.Atoi(t.taxYearField.GetText())
+	if err != nil {
+		return taxSelection{}, fmt.Errorf("tax year is required")
+	}
+	otherIncome, _ := strconv.ParseFloat(t.otherIncomeField.GetText(), 64)
+	selection.Profile = tax.Profile{
+		FilingStatus: tax.FilingStatuses()[filingStatusIndex],
+		TaxYear:      taxYear,
+		OtherIncome:  otherIncome,
+	}
+	for _, model := range []tax.Model{selection.TaxModel, selection.StateTaxModel} {
+		if model == nil {
+			continue
+		}
+		if _, err = model.CalculateTax(selection.Profile, tax.OrdinaryIncome, 0); err != nil {
+			return taxSelection{}, err
+		}
+		if _, err = model.CalculateTax(selection.Profile, tax.LongTermCapitalGain, 0); err != nil {
+			return taxSelection{}, err
+		}
+	}
+	return selection, nil
+}
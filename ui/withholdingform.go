@@ -0,0 +1,277 @@
+/*
+ * Copyright (c) 2024, Paul Gundarapu.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ */
+
+package ui
+
+import (
+	"fmt"
+	"github.com/gdamore/tcell/v2"
+	"github.com/leogps/lunar/pkg/tax"
+	"github.com/leogps/lunar/pkg/types"
+	"github.com/rivo/tview"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func loadWithholding(app *tview.Application) *tview.Flex {
+	// Create a TextView for displaying results
+	status := tview.NewTextView().SetTextAlign(tview.AlignLeft).
+		SetText("Please enter data into fields...").SetTextColor(tview.Styles.PrimaryTextColor)
+	summary := tview.NewFlex().
+		SetDirection(tview.FlexRow)
+
+	form := tview.NewForm()
+
+	// Vests Group
+	vestsField := tview.NewInputField().
+		SetLabel("RSU vests (YYYY-MM-DD:shares:FMV per share[:tax withheld], comma-separated): ").
+		SetFieldWidth(60)
+
+	esppDisqualifyingIncomeField := tview.NewInputField().
+		SetLabel("Ordinary income from disqualifying ESPP sales ($): ").
+		SetFieldWidth(20).
+		SetAcceptanceFunc(acceptMoneyInputValue)
+
+	form.AddFormItem(vestsField).
+		AddFormItem(esppDisqualifyingIncomeField)
+
+	// Tax Group
+	marginalTaxField := tview.NewInputField().
+		SetLabel("Marginal federal tax percent (10%-37%): ").
+		SetFieldWidth(20).
+		SetAcceptanceFunc(acceptFloat64InputValue)
+
+	var filingStatusOptions []string
+	for _, filingStatus := range tax.FilingStatuses() {
+		filingStatusOptions = append(filingStatusOptions, filingStatus.String())
+	}
+	filingStatusField := tview.NewDropDown().
+		SetLabel("Filing status: ").
+		SetOptions(filingStatusOptions, nil).
+		SetCurrentOption(0)
+	filingStatusField.SetDisabled(true)
+
+	taxYearField := tview.NewInputField().
+		SetLabel("Tax year: ").
+		SetFieldWidth(20).
+		SetText(strconv.Itoa(time.Now().Year())).
+		SetAcceptanceFunc(acceptIntInputValue)
+	taxYearField.SetDisabled(true)
+
+	salaryField := tview.NewInputField().
+		SetLabel("Taxable salary (after deductions) ($): ").
+		SetFieldWidth(20).
+		SetAcceptanceFunc(acceptMoneyInputValue)
+	salaryField.SetDisabled(true)
+
+	taxBracketsCheckbox := tview.NewCheckbox().
+		SetLabel("Use progressive federal tax brackets instead of a flat marginal rate (hit Enter/Space to toggle): ").
+		SetChangedFunc(func(checked bool) {
+			if checked {
+				marginalTaxField.SetText("")
+			}
+			marginalTaxField.SetDisabled(checked)
+			filingStatusField.SetDisabled(!checked)
+			taxYearField.SetDisabled(!checked)
+			salaryField.SetDisabled(!checked)
+		})
+
+	form.AddFormItem(taxBracketsCheckbox).
+		AddFormItem(marginalTaxField).
+		AddFormItem(filingStatusField).
+		AddFormItem(taxYearField).
+		AddFormItem(salaryField)
+
+	// Config profile defaults
+	if profileEnabled("tax-brackets") {
+		checkCheckbox(taxBracketsCheckbox)
+	}
+	prefillFilingStatus(filingStatusField)
+	prefillInputField(taxYearField, "tax-year")
+
+	readWithholdingEstimate := func() (*types.WithholdingEstimate, error) {
+		withholdingEstimate := types.WithholdingEstimate{}
+		var err error
+		if withholdingEstimate.RsuVests, err = parseRsuVestsInputValue(vestsField.GetText()); err != nil {
+			return nil, err
+		}
+		withholdingEstimate.EsppDisqualifyingIncome, _ = types.ParseMoney(esppDisqualifyingIncomeField.GetText())
+
+		if !taxBracketsCheckbox.IsChecked() {
+			withholdingEstimate.MarginalTaxPercent, _ = strconv.ParseFloat(marginalTaxField.GetText(), 64)
+			return &withholdingEstimate, nil
+		}
+		filingStatusIndex, _ := filingStatusField.GetCurrentOption()
+		taxYear, err := strconv.Atoi(taxYearField.GetText())
+		if err != nil {
+			return nil, fmt.Errorf("tax year is required")
+		}
+		withholdingEstimate.Salary, _ = types.ParseMoney(salaryField.GetText())
+		withholdingEstimate.TaxModel = tax.Federal
+		withholdingEstimate.TaxProfile = tax.Profile{
+			FilingStatus: tax.FilingStatuses()[filingStatusIndex],
+			TaxYear:      taxYear,
+		}
+		return &withholdingEstimate, nil
+	}
+
+	// Create a Submit Button
+	form.AddButton("Submit", func() {
+		withholdingEstimate, err := readWithholdingEstimate()
+		if err != nil {
+			clearFlexItems(summary)
+			status.SetText(fmt.Sprintf("Error occurred: %v", err))
+			currentDataView = WithholdingError
+			return
+		}
+		calculateWithholding(withholdingEstimate, status, summary)
+	})
+
+	// Create a Exit Button
+	form.AddButton("Exit", func() {
+		app.Stop() // Close the app without submission
+	})
+
+	// Set up a Flex layout to arrange the form and the result TextView
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(form, 0, 1, true).
+		AddItem(status, 1, 1, false).
+		AddItem(summary, 0, 1, false)
+	flex.
+		SetBorder(true).
+		SetTitle("** Withholding Shortfall **").
+		SetTitleAlign(tview.AlignCenter)
+
+	return flex
+}
+
+// parseRsuVestsInputValue parses comma-separated YYYY-MM-DD:shares:FMV vests, optionally followed by the tax withheld
+// on the vest, which is otherwise estimated at the supplemental rate
+func parseRsuVestsInputValue(text string) ([]*types.RsuOrder, error) {
+	var rsuVests []*types.RsuOrder
+	if strings.TrimSpace(text) == "" {
+		return rsuVests, nil
+	}
+	for _, vestValue := range strings.Split(text, ",") {
+		parts := strings.Split(strings.TrimSpace(vestValue), ":")
+		if len(parts) != 3 && len(parts) != 4 {
+			return nil, fmt.Errorf("invalid vest %q (expected YYYY-MM-DD:shares:FMV per share[:tax withheld])", vestValue)
+		}
+		vestDate, err := parseDateInputValue("vest date", parts[0])
+		if err != nil {
+			return nil, err
+		}
+		noOfStocksVested, err := types.ParseShares(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid number of stocks vested %q", parts[1])
+		}
+		marketValuePerShare, err := types.ParseMoney(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid market price per share %q", parts[2])
+		}
+		rsuVest := &types.RsuOrder{
+			VestDate:             vestDate,
+			NumberOfStocksVested: noOfStocksVested,
+			MarketValuePerShare:  marketValuePerShare,
+		}
+		if len(parts) == 4 {
+			if rsuVest.IncomeTaxIncurredWhenStockVested, err = types.ParseMoney(parts[3]); err != nil {
+				return nil, fmt.Errorf("invalid tax withheld %q", parts[3])
+			}
+			rsuVest.ConsiderIncomeTaxOnVestedStock = true
+		}
+		rsuVests = append(rsuVests, rsuVest)
+	}
+	return rsuVests, nil
+}
+
+func calculateWithholding(withholdingEstimate *types.WithholdingEstimate,
+	status *tview.TextView,
+	summary *tview.Flex) {
+	status.SetText("Calculating...")
+	clearFlexItems(summary)
+
+	withholdingEstimateSummary, err := withholdingEstimate.CalculateWithholdingEstimateSummary()
+	if err != nil {
+		status.SetText(fmt.Sprintf("Error occurred: %v", err))
+		currentDataView = WithholdingError
+		return
+	}
+
+	table := tview.NewTable().
+		SetBorders(true).
+		SetFixed(1, 1)
+	for index, header := range []string{"Vest Date", "Vest Income ($)", "Withheld ($)"} {
+		table.SetCell(0, index, tview.NewTableCell(header).
+			SetTextColor(tcell.ColorYellow).
+			SetAlign(tview.AlignCenter).
+			SetSelectable(false))
+	}
+	for index, vestWithholding := range withholdingEstimateSummary.VestWithholdings {
+		table.SetCell(index+1, 0, tview.NewTableCell(vestWithholding.VestDate.Format(types.DateLayout)).
+			SetAlign(tview.AlignCenter))
+		table.SetCell(index+1, 1, tview.NewTableCell(fmt.Sprintf("$%s", vestWithholding.Income)).
+			SetAlign(tview.AlignCenter))
+		taxWithheld := fmt.Sprintf("$%s", vestWithholding.TaxWithheld)
+		if vestWithholding.Estimated {
+			taxWithheld += " (estimated)"
+		}
+		table.SetCell(index+1, 2, tview.NewTableCell(taxWithheld).
+			SetAlign(tview.AlignCenter))
+	}
+	summary.AddItem(table, 0, 1, false)
+
+	supplementalIncomeField := tview.NewTextView().
+		SetLabel(fmt.Sprintf("Supplemental income (RSU $%s + ESPP $%s): $%s",
+			withholdingEstimateSummary.RsuIncome, withholdingEstimateSummary.EsppDisqualifyingIncome,
+			withholdingEstimateSummary.SupplementalIncome)).
+		SetTextAlign(tview.AlignLeft)
+	summary.AddItem(supplementalIncomeField, 1, 1, false)
+
+	taxWithheldField := tview.NewTextView().
+		SetLabel(fmt.Sprintf("Federal tax withheld: $%s (%.2f%%)",
+			withholdingEstimateSummary.TaxWithheld, withholdingEstimateSummary.EffectiveWithholdingPercent())).
+		SetTextAlign(tview.AlignLeft)
+	summary.AddItem(taxWithheldField, 1, 1, false)
+
+	taxOwedField := tview.NewTextView().
+		SetLabel(fmt.Sprintf("Federal tax owed: $%s (marginal rate %.2f%%)",
+			withholdingEstimateSummary.TaxOwed, withholdingEstimateSummary.MarginalTaxPercent)).
+		SetTextAlign(tview.AlignLeft)
+	summary.AddItem(taxOwedField, 1, 1, false)
+
+	shortfall := withholdingEstimateSummary.Shortfall()
+	shortfallLabel := fmt.Sprintf("Projected shortfall: $%s", shortfall)
+	if shortfall <= 0 {
+		shortfallLabel = fmt.Sprintf("Projected over-withholding: $%s", -shortfall)
+	}
+	shortfallField := tview.NewTextView().
+		SetLabel(shortfallLabel).
+		SetTextAlign(tview.AlignLeft)
+	summary.AddItem(shortfallField, 1, 1, false)
+
+	status.SetText("Summary: ")
+	currentDataView = WithholdingSummary
+}